@@ -12,6 +12,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.Migrate(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
 	srv, err := app.Bootstrap()
 	if err != nil {
 		log.Fatalf("bootstrap failed: %v", err)
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client is a thin wrapper over the RBAC HTTP API.
+type client struct {
+	base string
+	// actor is sent as X-Actor-ID so changes are attributed in the audit log.
+	actor string
+	// accountID and accountSecret authenticate as a service account when set.
+	accountID     string
+	accountSecret string
+	http          *http.Client
+}
+
+func newClient(addr, actor string) *client {
+	return &client{
+		base:  strings.TrimRight(addr, "/"),
+		actor: actor,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiError is the decoded error envelope returned by the service.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// rawBody is sent verbatim with its content type instead of being JSON encoded.
+type rawBody struct {
+	data        []byte
+	contentType string
+}
+
+// do sends a request and returns the response body; 204 responses yield nil.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (json.RawMessage, error) {
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case rawBody:
+		reader = bytes.NewReader(b.data)
+		contentType = b.contentType
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.actor != "" {
+		req.Header.Set("X-Actor-ID", c.actor)
+	}
+	if c.accountID != "" {
+		req.SetBasicAuth(c.accountID, c.accountSecret)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, decodeError(resp.StatusCode, data)
+	}
+	if resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	return json.RawMessage(data), nil
+}
+
+func decodeError(status int, data []byte) error {
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.Error.Message != "" {
+		return &apiError{Status: status, Code: envelope.Error.Code, Message: envelope.Error.Message}
+	}
+	message := strings.TrimSpace(string(data))
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &apiError{Status: status, Message: message}
+}
@@ -0,0 +1,1805 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/policy"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	adminPrefix = "/admin/v1"
+	apiPrefix   = "/api/v1"
+)
+
+func serviceCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "service", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("service list")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/service-list", page.query(), nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("ID", "ID"), col("KEY", "Key"), col("TITLE", "Title"))
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "service get", "/service/", args)
+		},
+		"create": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("service create")
+			key := fs.String("key", "", "service key (required)")
+			title := fs.String("title", "", "service title")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("key", *key); err != nil {
+				return err
+			}
+			// The service still registers create endpoints under the SET verb.
+			raw, err := c.api.do(ctx, "SET", adminPrefix+"/service", nil, map[string]string{"key": *key, "title": *title})
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw)
+		},
+		"update": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("service update")
+			title := fs.String("title", "", "new service title")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if _, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/service/"+url.PathEscape(pos[0]), nil, map[string]string{"title": *title}); err != nil {
+				return err
+			}
+			return c.out.done("service " + pos[0] + " updated")
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "service delete", "/service/", args)
+		},
+		"bind-role": func(ctx context.Context, c *cli, args []string) error {
+			return c.serviceRoleBinding(ctx, "service bind-role", http.MethodPost, args)
+		},
+		"unbind-role": func(ctx context.Context, c *cli, args []string) error {
+			return c.serviceRoleBinding(ctx, "service unbind-role", http.MethodDelete, args)
+		},
+		"bind-permission": func(ctx context.Context, c *cli, args []string) error {
+			return c.servicePermissionBinding(ctx, "service bind-permission", http.MethodPost, args)
+		},
+		"unbind-permission": func(ctx context.Context, c *cli, args []string) error {
+			return c.servicePermissionBinding(ctx, "service unbind-permission", http.MethodDelete, args)
+		},
+	})
+}
+
+func (c *cli) serviceRoleBinding(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	service := fs.String("service", "", "service id (required)")
+	role := fs.String("role", "", "role key (required)")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("service", *service, "role", *role); err != nil {
+		return err
+	}
+	body := map[string]string{"service_id": *service, "role_key": *role}
+	if _, err := c.api.do(ctx, method, adminPrefix+"/service-role", nil, body); err != nil {
+		return err
+	}
+	return c.out.done(fmt.Sprintf("role %s %s service %s", *role, bindVerb(method), *service))
+}
+
+func (c *cli) servicePermissionBinding(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	service := fs.String("service", "", "service id (required)")
+	permission := fs.String("permission", "", "permission id (required)")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("service", *service, "permission", *permission); err != nil {
+		return err
+	}
+	body := map[string]string{"service_id": *service, "permission_id": *permission}
+	if _, err := c.api.do(ctx, method, adminPrefix+"/service-permission", nil, body); err != nil {
+		return err
+	}
+	return c.out.done(fmt.Sprintf("permission %s %s service %s", *permission, bindVerb(method), *service))
+}
+
+func bindVerb(method string) string {
+	if method == http.MethodDelete {
+		return "unbound from"
+	}
+	return "bound to"
+}
+
+func roleCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "role", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role list")
+			page := pageFlags(fs)
+			service := fs.String("service", "", "only roles bound to this service id")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "service_id", *service)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/role-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("ID", "ID"), col("KEY", "Key"), col("TITLE", "Title"))
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "role get", "/role/", args)
+		},
+		"create": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role create")
+			key := fs.String("key", "", "role key (required)")
+			title := fs.String("title", "", "role title")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("key", *key); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, "SET", adminPrefix+"/role", nil, map[string]string{"key": *key, "title": *title})
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw)
+		},
+		"update": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role update")
+			title := fs.String("title", "", "new role title")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if _, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/role/"+url.PathEscape(pos[0]), nil, map[string]string{"title": *title}); err != nil {
+				return err
+			}
+			return c.out.done("role " + pos[0] + " updated")
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "role delete", "/role/", args)
+		},
+		"members": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role members")
+			page := pageFlags(fs)
+			kind := fs.String("principal-kind", "", "only principals of this kind")
+			scope := newScopeFlags(fs)
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "principal_kind", *kind)
+			setIf(query, "tenant_id", *scope.tenant)
+			setIf(query, "service_id", *scope.service)
+			setIf(query, "resource_kind", *scope.resourceKind)
+			setIf(query, "resource_id", *scope.resourceID)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/role/"+url.PathEscape(pos[0])+"/principals", query, nil)
+			if err != nil {
+				return err
+			}
+			columns := append([]column{col("PRINCIPAL_ID", "principal_id"), col("KIND", "principal_kind")}, scopeColumns...)
+			return c.out.print(raw, append(columns, validityColumns...)...)
+		},
+		"remove-members": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role remove-members")
+			principals := fs.String("principals", "", "comma-separated principal ids; empty removes every match of the scope flags")
+			kind := fs.String("principal-kind", "", "principal kind of --principals, and a filter otherwise")
+			scope := newScopeFlags(fs)
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			body := map[string]any{
+				"principal_kind": *kind,
+				"tenant_id":      *scope.tenant,
+				"service_id":     *scope.service,
+				"resource_kind":  *scope.resourceKind,
+				"resource_id":    *scope.resourceID,
+			}
+			if *principals != "" {
+				members := make([]map[string]string, 0)
+				for _, id := range strings.Split(*principals, ",") {
+					members = append(members, map[string]string{"principal_id": strings.TrimSpace(id), "principal_kind": *kind})
+				}
+				body["principals"] = members
+			}
+			raw, err := c.api.do(ctx, http.MethodDelete, adminPrefix+"/role/"+url.PathEscape(pos[0])+"/principals", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw)
+		},
+	})
+}
+
+func permissionCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "permission", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("permission list")
+			page := pageFlags(fs)
+			service := fs.String("service", "", "only permissions bound to this service id")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "service_id", *service)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/permission-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("ID", "ID"), col("ACTION", "Action"), col("RESOURCE_KIND", "ResourceKind"))
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "permission get", "/permission/", args)
+		},
+		"create": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("permission create")
+			action := fs.String("action", "", "action (required)")
+			kind := fs.String("resource-kind", "", "resource kind (required)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("action", *action, "resource-kind", *kind); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, "SET", adminPrefix+"/permission", nil, map[string]string{"action": *action, "resource_kind": *kind})
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw)
+		},
+		"update": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("permission update")
+			action := fs.String("action", "", "new action")
+			kind := fs.String("resource-kind", "", "new resource kind")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			body := map[string]string{}
+			fs.Visit(func(f *flag.Flag) {
+				switch f.Name {
+				case "action":
+					body["action"] = *action
+				case "resource-kind":
+					body["resource_kind"] = *kind
+				}
+			})
+			if len(body) == 0 {
+				return fmt.Errorf("%w: nothing to update, pass --action and/or --resource-kind", errUsage)
+			}
+			if _, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/permission/"+url.PathEscape(pos[0]), nil, body); err != nil {
+				return err
+			}
+			return c.out.done("permission " + pos[0] + " updated")
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "permission delete", "/permission/", args)
+		},
+	})
+}
+
+func grantCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "grant", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("grant list")
+			role := fs.String("role", "", "role key (required)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("role", *role); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/role-permission", url.Values{"role_key": {*role}}, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("PERMISSION_ID", "ID"), col("ACTION", "Action"), col("RESOURCE_KIND", "ResourceKind"), col("RESOURCE_ID", "ResourceID"),
+				col("CONDITION", "Condition"))
+		},
+		"add": func(ctx context.Context, c *cli, args []string) error {
+			return c.grant(ctx, "grant add", http.MethodPost, args)
+		},
+		"remove": func(ctx context.Context, c *cli, args []string) error {
+			return c.grant(ctx, "grant remove", http.MethodDelete, args)
+		},
+	})
+}
+
+func (c *cli) grant(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	role := fs.String("role", "", "role key (required)")
+	permission := fs.String("permission", "", "permission id (required)")
+	resourceID := fs.String("resource-id", "", "narrow the grant to one resource instance")
+	condition := fs.String("condition", "", `condition the request must satisfy, e.g. 'resource.owner == principal.id'`)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("role", *role, "permission", *permission); err != nil {
+		return err
+	}
+	body := map[string]string{"role_key": *role, "permission_id": *permission, "resource_id": *resourceID}
+	body["condition"] = *condition
+	if _, err := c.api.do(ctx, method, adminPrefix+"/role-permission", nil, body); err != nil {
+		return err
+	}
+	if method == http.MethodDelete {
+		return c.out.done(fmt.Sprintf("permission %s revoked from role %s", *permission, *role))
+	}
+	return c.out.done(fmt.Sprintf("permission %s granted to role %s", *permission, *role))
+}
+
+// scopeFlags are the tenant/service/resource columns shared by assignments and overrides.
+type scopeFlags struct {
+	tenant       *string
+	service      *string
+	resourceKind *string
+	resourceID   *string
+}
+
+func newScopeFlags(fs *flag.FlagSet) scopeFlags {
+	return scopeFlags{
+		tenant:       fs.String("tenant", "", "tenant id"),
+		service:      fs.String("service", "", "service id"),
+		resourceKind: fs.String("resource-kind", "", "resource kind"),
+		resourceID:   fs.String("resource-id", "", "resource id"),
+	}
+}
+
+func (s scopeFlags) apply(body map[string]string) {
+	body["tenant_id"] = *s.tenant
+	body["service_id"] = *s.service
+	body["resource_kind"] = *s.resourceKind
+	body["resource_id"] = *s.resourceID
+}
+
+// validityFlags bound a grant in time; both take RFC 3339 timestamps.
+type validityFlags struct {
+	from  *string
+	until *string
+}
+
+func newValidityFlags(fs *flag.FlagSet) validityFlags {
+	return validityFlags{
+		from:  fs.String("valid-from", "", "RFC 3339 time the grant takes effect"),
+		until: fs.String("valid-until", "", "RFC 3339 time the grant expires"),
+	}
+}
+
+func (v validityFlags) apply(body map[string]string) {
+	if *v.from != "" {
+		body["valid_from"] = *v.from
+	}
+	if *v.until != "" {
+		body["valid_until"] = *v.until
+	}
+}
+
+var validityColumns = []column{col("VALID_FROM", "valid_from"), col("VALID_UNTIL", "valid_until")}
+
+var scopeColumns = []column{
+	col("TENANT", "tenant_id"),
+	col("SERVICE", "service_id"),
+	col("RESOURCE_KIND", "resource_kind"),
+	col("RESOURCE_ID", "resource_id"),
+}
+
+func assignmentCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "assignment", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("assignment list")
+			principal := fs.String("principal", "", "principal id (required)")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("principal", *principal); err != nil {
+				return err
+			}
+			query := url.Values{"principal_id": {*principal}, "principal_kind": {*kind}}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/principal-role-list", query, nil)
+			if err != nil {
+				return err
+			}
+			columns := append([]column{col("ROLE", "role_key")}, scopeColumns...)
+			return c.out.print(raw, append(columns, validityColumns...)...)
+		},
+		"add": func(ctx context.Context, c *cli, args []string) error {
+			return c.assignment(ctx, "assignment add", http.MethodPost, args)
+		},
+		"remove": func(ctx context.Context, c *cli, args []string) error {
+			return c.assignment(ctx, "assignment remove", http.MethodDelete, args)
+		},
+	})
+}
+
+func (c *cli) assignment(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	principal := fs.String("principal", "", "principal id (required)")
+	kind := fs.String("principal-kind", "user", "principal kind")
+	role := fs.String("role", "", "role key (required)")
+	scope := newScopeFlags(fs)
+	validity := newValidityFlags(fs)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("principal", *principal, "role", *role); err != nil {
+		return err
+	}
+	body := map[string]string{"principal_id": *principal, "principal_kind": *kind, "role_key": *role}
+	scope.apply(body)
+	validity.apply(body)
+	if _, err := c.api.do(ctx, method, adminPrefix+"/principal-role", nil, body); err != nil {
+		return err
+	}
+	if method == http.MethodDelete {
+		return c.out.done(fmt.Sprintf("role %s revoked from %s %s", *role, *kind, *principal))
+	}
+	return c.out.done(fmt.Sprintf("role %s assigned to %s %s", *role, *kind, *principal))
+}
+
+func overrideCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "override", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("override list")
+			principal := fs.String("principal", "", "principal id (required)")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("principal", *principal); err != nil {
+				return err
+			}
+			query := url.Values{"principal_id": {*principal}, "principal_kind": {*kind}}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/principal-override-list", query, nil)
+			if err != nil {
+				return err
+			}
+			columns := append([]column{col("PERMISSION_ID", "permission_id"), col("EFFECT", "effect")}, scopeColumns...)
+			columns = append(columns, validityColumns...)
+			return c.out.print(raw, append(columns, col("CONDITION", "condition"))...)
+		},
+		"set": func(ctx context.Context, c *cli, args []string) error {
+			return c.override(ctx, "override set", http.MethodPost, args)
+		},
+		"remove": func(ctx context.Context, c *cli, args []string) error {
+			return c.override(ctx, "override remove", http.MethodDelete, args)
+		},
+	})
+}
+
+func (c *cli) override(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	principal := fs.String("principal", "", "principal id (required)")
+	kind := fs.String("principal-kind", "user", "principal kind")
+	permission := fs.String("permission", "", "permission id (required)")
+	effect := fs.String("effect", "", "allow or deny (required for set)")
+	scope := newScopeFlags(fs)
+	validity := newValidityFlags(fs)
+	condition := fs.String("condition", "", "condition the request must satisfy for the override to apply")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("principal", *principal, "permission", *permission); err != nil {
+		return err
+	}
+	if method == http.MethodPost {
+		if err := required("effect", *effect); err != nil {
+			return err
+		}
+	}
+	body := map[string]string{"principal_id": *principal, "principal_kind": *kind, "permission_id": *permission, "effect": *effect}
+	scope.apply(body)
+	validity.apply(body)
+	body["condition"] = *condition
+	if _, err := c.api.do(ctx, method, adminPrefix+"/principal-override", nil, body); err != nil {
+		return err
+	}
+	if method == http.MethodDelete {
+		return c.out.done(fmt.Sprintf("override on permission %s removed from %s %s", *permission, *kind, *principal))
+	}
+	return c.out.done(fmt.Sprintf("%s override on permission %s set for %s %s", *effect, *permission, *kind, *principal))
+}
+
+func checkCmd(ctx context.Context, c *cli, args []string) error {
+	return c.decision(ctx, "check", "/check", args)
+}
+
+func explainCmd(ctx context.Context, c *cli, args []string) error {
+	return c.decision(ctx, "explain", "/explain", args)
+}
+
+// whoCanCmd lists the principals allowed a request. Tables show one row per access path.
+func whoCanCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("who-can")
+	action := fs.String("action", "", "action (required)")
+	resourceKind := fs.String("resource-kind", "", "resource kind (required)")
+	resourceID := fs.String("resource-id", "", "resource id")
+	tenant := fs.String("tenant", "", "tenant id")
+	service := fs.String("service", "", "service id")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("action", *action, "resource-kind", *resourceKind); err != nil {
+		return err
+	}
+	query := url.Values{"action": {*action}, "resource_kind": {*resourceKind}}
+	setIf(query, "resource_id", *resourceID)
+	setIf(query, "tenant_id", *tenant)
+	setIf(query, "service_id", *service)
+	raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/access-list", query, nil)
+	if err != nil {
+		return err
+	}
+	if c.out.format == outputJSON {
+		return c.out.print(raw)
+	}
+	var items []struct {
+		PrincipalID   string              `json:"principal_id"`
+		PrincipalKind string              `json:"principal_kind"`
+		Paths         []map[string]string `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return err
+	}
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		for _, path := range item.Paths {
+			path["principal_id"] = item.PrincipalID
+			path["principal_kind"] = item.PrincipalKind
+			rows = append(rows, path)
+		}
+	}
+	flat, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	return c.out.print(flat, col("PRINCIPAL", "principal_id"), col("KIND", "principal_kind"), col("PATH", "kind"),
+		col("ROLE", "role_key"), col("GROUP", "group_id"), col("PERMISSION", "permission_id"))
+}
+
+func permissionsCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("permissions")
+	principal := fs.String("principal", "", "principal id (required)")
+	kind := fs.String("principal-kind", "user", "principal kind")
+	tenant := fs.String("tenant", "", "only permissions that can apply in this tenant")
+	service := fs.String("service", "", "only permissions that can apply in this service")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("principal", *principal); err != nil {
+		return err
+	}
+	query := url.Values{"user_id": {*principal}, "principal_kind": {*kind}}
+	setIf(query, "tenant_id", *tenant)
+	setIf(query, "service_id", *service)
+	raw, err := c.api.do(ctx, http.MethodGet, apiPrefix+"/principal-permission/effective", query, nil)
+	if err != nil {
+		return err
+	}
+	if c.out.format == outputJSON {
+		return c.out.print(raw)
+	}
+	var result struct {
+		Superadmin  bool            `json:"superadmin"`
+		Permissions json.RawMessage `json:"permissions"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return err
+	}
+	if result.Superadmin {
+		fmt.Fprintf(c.out.w, "%s %s is a superadmin and is allowed every request\n", *kind, *principal)
+	}
+	return c.out.print(result.Permissions, col("ACTION", "action"), col("RESOURCE_KIND", "resource_kind"), col("EFFECT", "effect"),
+		col("TENANT", "scope.tenant_id"), col("SERVICE", "scope.service_id"), col("SCOPE_KIND", "scope.resource_kind"),
+		col("RESOURCE_ID", "scope.resource_id"), col("SOURCE", "source.kind"), col("ROLE", "source.role_key"),
+		col("GRANTED_BY", "source.granted_by"), col("GROUP", "source.group_id"))
+}
+
+func (c *cli) decision(ctx context.Context, name, path string, args []string) error {
+	fs := c.flags(name)
+	principal := fs.String("principal", "", "principal id (required)")
+	kind := fs.String("principal-kind", "user", "principal kind")
+	action := fs.String("action", "", "action (required)")
+	resourceKind := fs.String("resource-kind", "", "resource kind (required)")
+	resourceID := fs.String("resource-id", "", "resource id")
+	tenant := fs.String("tenant", "", "tenant id")
+	service := fs.String("service", "", "service id")
+	correlation := fs.String("correlation-id", "", "correlation id echoed in the result")
+	attributes := fs.String("attributes", "", `JSON attributes for conditions, e.g. '{"resource":{"owner":"..."}}'`)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("principal", *principal, "action", *action, "resource-kind", *resourceKind); err != nil {
+		return err
+	}
+	body := map[string]any{
+		"principal_id":   *principal,
+		"principal_kind": *kind,
+		"action":         *action,
+		"resource_kind":  *resourceKind,
+		"resource_id":    *resourceID,
+		"tenant_id":      *tenant,
+		"service_id":     *service,
+		"correlation_id": *correlation,
+	}
+	if *attributes != "" {
+		var parsed map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(*attributes), &parsed); err != nil {
+			return fmt.Errorf("%w: --attributes must be a JSON object: %v", errUsage, err)
+		}
+		body["attributes"] = parsed
+	}
+	raw, err := c.api.do(ctx, http.MethodPost, apiPrefix+path, nil, body)
+	if err != nil {
+		return err
+	}
+	return c.out.print(raw)
+}
+
+func policyCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "policy", args, map[string]command{
+		"export": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("policy export")
+			file := fs.String("file", "", "write the document to this path (.yaml/.yml for YAML, JSON otherwise)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/policy", nil, nil)
+			if err != nil {
+				return err
+			}
+			if *file == "" {
+				if c.out.format == outputJSON {
+					return c.out.json(raw)
+				}
+				data, err := policyYAML(raw)
+				if err != nil {
+					return err
+				}
+				_, err = c.out.w.Write(data)
+				return err
+			}
+			data := []byte(raw)
+			if policyFormat(*file) == policy.FormatYAML {
+				if data, err = policyYAML(raw); err != nil {
+					return err
+				}
+			}
+			if err := os.WriteFile(*file, data, 0o644); err != nil {
+				return err
+			}
+			return c.out.done("policy written to " + *file)
+		},
+		"plan": func(ctx context.Context, c *cli, args []string) error {
+			return c.policySync(ctx, "policy plan", "/policy/plan", args)
+		},
+		"apply": func(ctx context.Context, c *cli, args []string) error {
+			return c.policySync(ctx, "policy apply", "/policy/apply", args)
+		},
+	})
+}
+
+func (c *cli) policySync(ctx context.Context, name, path string, args []string) error {
+	fs := c.flags(name)
+	prune := fs.Bool("prune", false, "delete objects that are not in the document")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(pos[0])
+	if err != nil {
+		return err
+	}
+	contentType := "application/json"
+	if policyFormat(pos[0]) == policy.FormatYAML {
+		contentType = "application/yaml"
+	}
+	query := url.Values{}
+	if *prune {
+		query.Set("prune", "true")
+	}
+	raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+path, query, rawBody{data: data, contentType: contentType})
+	if err != nil {
+		return err
+	}
+	if c.out.format == outputJSON {
+		return c.out.json(raw)
+	}
+	var plan policy.Plan
+	if err := json.Unmarshal(raw, &plan); err != nil {
+		return err
+	}
+	if len(plan.Changes) == 0 {
+		return c.out.done("no changes")
+	}
+	changes, err := json.Marshal(plan.Changes)
+	if err != nil {
+		return err
+	}
+	if err := c.out.print(changes,
+		col("OP", "op"), col("KIND", "kind"), col("ROLE", "role"), col("PARENT", "parent"), col("SERVICE", "service"),
+		col("ACTION", "action"), col("RESOURCE_KIND", "resource_kind"), col("RESOURCE_ID", "resource_id"), col("TITLE", "title"),
+	); err != nil {
+		return err
+	}
+	if plan.Applied {
+		return c.out.done(fmt.Sprintf("%d change(s) applied", len(plan.Changes)))
+	}
+	return c.out.done(fmt.Sprintf("%d change(s) planned, run apply to execute", len(plan.Changes)))
+}
+
+func policyFormat(path string) policy.Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return policy.FormatYAML
+	default:
+		return policy.FormatJSON
+	}
+}
+
+func policyYAML(raw json.RawMessage) ([]byte, error) {
+	var doc policy.Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+func backupCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "backup", args, map[string]command{
+		"export": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("backup export")
+			file := fs.String("file", "", "write the backup to this path instead of stdout")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/backup/export", nil, nil)
+			if err != nil {
+				return err
+			}
+			if *file == "" {
+				return c.out.json(raw)
+			}
+			if err := os.WriteFile(*file, raw, 0o600); err != nil {
+				return err
+			}
+			return c.out.done("backup written to " + *file)
+		},
+		"import": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("backup import")
+			excludePrincipals := fs.Bool("exclude-principals", false, "keep the target's assignments, overrides, superadmins and groups")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(pos[0])
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			if *excludePrincipals {
+				query.Set("exclude_principals", "true")
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/backup/import", query, rawBody{data: data, contentType: "application/json"})
+			if err != nil {
+				return err
+			}
+			if c.out.format == outputJSON {
+				return c.out.json(raw)
+			}
+			var report struct {
+				Restored map[string]int `json:"restored"`
+			}
+			if err := json.Unmarshal(raw, &report); err != nil {
+				return err
+			}
+			rows := make([]map[string]interface{}, 0, len(report.Restored))
+			for _, table := range sortedKeys(report.Restored) {
+				rows = append(rows, map[string]interface{}{"table": table, "rows": report.Restored[table]})
+			}
+			data, err = json.Marshal(rows)
+			if err != nil {
+				return err
+			}
+			return c.out.print(data, col("TABLE", "table"), col("ROWS", "rows"))
+		},
+	})
+}
+
+func superadminCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "superadmin", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			if _, err := parseArgs(c.flags("superadmin list"), args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/superadmin-list", nil, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("PRINCIPAL_ID", "PrincipalID"), col("KIND", "PrincipalKind"))
+		},
+		"grant": func(ctx context.Context, c *cli, args []string) error {
+			return c.superadmin(ctx, "superadmin grant", http.MethodPost, args)
+		},
+		"revoke": func(ctx context.Context, c *cli, args []string) error {
+			return c.superadmin(ctx, "superadmin revoke", http.MethodDelete, args)
+		},
+	})
+}
+
+func (c *cli) superadmin(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	principal := fs.String("principal", "", "principal id (required)")
+	kind := fs.String("principal-kind", "user", "principal kind")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := required("principal", *principal); err != nil {
+		return err
+	}
+	body := map[string]string{"principal_id": *principal, "principal_kind": *kind}
+	if _, err := c.api.do(ctx, method, adminPrefix+"/superadmin", nil, body); err != nil {
+		return err
+	}
+	if method == http.MethodDelete {
+		return c.out.done("superadmin revoked")
+	}
+	return c.out.done("superadmin granted")
+}
+
+func auditCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "audit", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("audit list")
+			actor := fs.String("actor", "", "filter by actor id")
+			source := fs.String("source", "", "filter by source: http, nats or system")
+			correlation := fs.String("correlation-id", "", "filter by correlation id")
+			action := fs.String("action", "", "filter by action")
+			entity := fs.String("entity", "", "filter by entity")
+			entityID := fs.String("entity-id", "", "filter by entity id")
+			from := fs.String("from", "", "only entries at or after this RFC 3339 time")
+			to := fs.String("to", "", "only entries before this RFC 3339 time")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "actor_id", *actor)
+			setIf(query, "source", *source)
+			setIf(query, "correlation_id", *correlation)
+			setIf(query, "action", *action)
+			setIf(query, "entity", *entity)
+			setIf(query, "entity_id", *entityID)
+			setIf(query, "from", *from)
+			setIf(query, "to", *to)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/audit-log", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw,
+				col("ID", "id"), col("AT", "occurred_at"), col("ACTOR", "actor_id"), col("SOURCE", "source"),
+				col("ACTION", "action"), col("ENTITY", "entity"), col("ENTITY_ID", "entity_id"), col("CORRELATION_ID", "correlation_id"))
+		},
+	})
+}
+
+var elevationColumns = []column{
+	col("ID", "id"), col("PRINCIPAL", "principal_id"), col("ROLE", "role_key"), col("STATUS", "status"),
+	col("DURATION_S", "duration_seconds"), col("REQUESTED_AT", "requested_at"), col("GRANTED_UNTIL", "granted_until"),
+}
+
+func elevationCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "elevation", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("elevation list")
+			status := fs.String("status", "", "filter by status: pending, approved, rejected or expired")
+			principal := fs.String("principal", "", "filter by principal id")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "status", *status)
+			setIf(query, "principal_id", *principal)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/elevation-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, elevationColumns...)
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "elevation get", "/elevation/", args)
+		},
+		"request": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("elevation request")
+			principal := fs.String("principal", "", "principal id (required)")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			role := fs.String("role", "", "role key (required)")
+			duration := fs.Duration("for", time.Hour, "how long the role is needed, e.g. 2h")
+			justification := fs.String("justification", "", "why the role is needed (required)")
+			scope := newScopeFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("principal", *principal, "role", *role, "justification", *justification); err != nil {
+				return err
+			}
+			body := map[string]interface{}{
+				"principal_id":     *principal,
+				"principal_kind":   *kind,
+				"role_key":         *role,
+				"justification":    *justification,
+				"duration_seconds": int(duration.Seconds()),
+				"tenant_id":        *scope.tenant,
+				"service_id":       *scope.service,
+				"resource_kind":    *scope.resourceKind,
+				"resource_id":      *scope.resourceID,
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/elevation", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, elevationColumns...)
+		},
+		"approve": func(ctx context.Context, c *cli, args []string) error {
+			return c.elevationDecision(ctx, "elevation approve", "/elevation/approve", args)
+		},
+		"reject": func(ctx context.Context, c *cli, args []string) error {
+			return c.elevationDecision(ctx, "elevation reject", "/elevation/reject", args)
+		},
+	})
+}
+
+func (c *cli) elevationDecision(ctx context.Context, name, path string, args []string) error {
+	fs := c.flags(name)
+	note := fs.String("note", "", "reason recorded with the decision")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	// The server decides in the name of --actor.
+	body := map[string]string{"id": pos[0], "note": *note}
+	raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+path, nil, body)
+	if err != nil {
+		return err
+	}
+	return c.out.print(raw, elevationColumns...)
+}
+
+var serviceAccountColumns = []column{
+	col("ID", "id"), col("KEY", "key"), col("TITLE", "title"), col("CREATED_AT", "created_at"),
+	col("ROTATED_AT", "secret_rotated_at"), col("DISABLED_AT", "disabled_at"),
+}
+
+func serviceAccountCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "service-account", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("service-account list")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/service-account-list", page.query(), nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, serviceAccountColumns...)
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "service-account get", "/service-account/", args)
+		},
+		"create": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("service-account create")
+			key := fs.String("key", "", "service account key (required)")
+			title := fs.String("title", "", "service account title")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("key", *key); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/service-account", nil, map[string]string{"key": *key, "title": *title})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.stderr, "store the secret now; it cannot be shown again")
+			return c.out.print(raw, col("ID", "id"), col("KEY", "key"), col("SECRET", "secret"))
+		},
+		"rotate": func(ctx context.Context, c *cli, args []string) error {
+			raw, err := c.serviceAccountAction(ctx, "service-account rotate", "/service-account/rotate", args)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.stderr, "store the secret now; it cannot be shown again")
+			return c.out.print(raw, col("ID", "id"), col("KEY", "key"), col("SECRET", "secret"))
+		},
+		"disable": func(ctx context.Context, c *cli, args []string) error {
+			raw, err := c.serviceAccountAction(ctx, "service-account disable", "/service-account/disable", args)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, serviceAccountColumns...)
+		},
+		"enable": func(ctx context.Context, c *cli, args []string) error {
+			raw, err := c.serviceAccountAction(ctx, "service-account enable", "/service-account/enable", args)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, serviceAccountColumns...)
+		},
+	})
+}
+
+func (c *cli) serviceAccountAction(ctx context.Context, name, path string, args []string) (json.RawMessage, error) {
+	fs := c.flags(name)
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return c.api.do(ctx, http.MethodPost, adminPrefix+path, nil, map[string]string{"id": pos[0]})
+}
+
+var groupColumns = []column{col("ID", "id"), col("KEY", "key"), col("TITLE", "title"), col("CREATED_AT", "created_at")}
+
+func groupCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "group", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("group list")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/group-list", page.query(), nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, groupColumns...)
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "group get", "/group/", args)
+		},
+		"create": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("group create")
+			key := fs.String("key", "", "group key (required)")
+			title := fs.String("title", "", "group title")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("key", *key); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/group", nil, map[string]string{"key": *key, "title": *title})
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, groupColumns...)
+		},
+		"update": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("group update")
+			title := fs.String("title", "", "new group title")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if _, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/group/"+url.PathEscape(pos[0]), nil, map[string]string{"title": *title}); err != nil {
+				return err
+			}
+			return c.out.done("group " + pos[0] + " updated")
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "group delete", "/group/", args)
+		},
+		"add-member": func(ctx context.Context, c *cli, args []string) error {
+			return c.groupMember(ctx, "group add-member", http.MethodPost, args)
+		},
+		"remove-member": func(ctx context.Context, c *cli, args []string) error {
+			return c.groupMember(ctx, "group remove-member", http.MethodDelete, args)
+		},
+		"members": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("group members")
+			page := pageFlags(fs)
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			query := page.query()
+			query.Set("group_id", pos[0])
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/group-member-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("MEMBER", "member_id"), col("KIND", "member_kind"), col("ADDED_AT", "added_at"))
+		},
+		"of": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("group of")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			query := url.Values{"principal_id": {pos[0]}, "principal_kind": {*kind}}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/principal-group-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, groupColumns...)
+		},
+	})
+}
+
+func (c *cli) groupMember(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	member := fs.String("member", "", "member principal id (required)")
+	kind := fs.String("member-kind", "user", "member kind: user, service_account or group")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := required("member", *member); err != nil {
+		return err
+	}
+	body := map[string]string{"group_id": pos[0], "member_id": *member, "member_kind": *kind}
+	if _, err := c.api.do(ctx, method, adminPrefix+"/group-member", nil, body); err != nil {
+		return err
+	}
+	if method == http.MethodDelete {
+		return c.out.done("member removed from group " + pos[0])
+	}
+	return c.out.done("member added to group " + pos[0])
+}
+
+var resourceColumns = []column{col("ID", "id"), col("KIND", "kind"), col("PARENT", "parent_id"), col("CREATED_AT", "created_at")}
+
+func resourceCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "resource", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("resource list")
+			kind := fs.String("kind", "", "only resources of this kind")
+			parent := fs.String("parent", "", "only direct children of this resource")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "kind", *kind)
+			setIf(query, "parent_id", *parent)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/resource-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, resourceColumns...)
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "resource get", "/resource/", args)
+		},
+		"register": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("resource register")
+			kind := fs.String("kind", "", "resource kind (required)")
+			parent := fs.String("parent", "", "parent resource id")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if err := required("kind", *kind); err != nil {
+				return err
+			}
+			body := map[string]string{"id": pos[0], "kind": *kind, "parent_id": *parent}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/resource", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, resourceColumns...)
+		},
+		"update": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("resource update")
+			kind := fs.String("kind", "", "resource kind (required)")
+			parent := fs.String("parent", "", "new parent resource id, empty for a root")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if err := required("kind", *kind); err != nil {
+				return err
+			}
+			body := map[string]string{"kind": *kind, "parent_id": *parent}
+			raw, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/resource/"+url.PathEscape(pos[0]), nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, resourceColumns...)
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "resource delete", "/resource/", args)
+		},
+	})
+}
+
+func namespaceCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "namespace", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("namespace list")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/rebac-namespace-list", nil, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("NAME", "name"), col("UPDATED_AT", "updated_at"))
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("namespace get")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/rebac-namespace/"+url.PathEscape(pos[0]), nil, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.json(raw)
+		},
+		"set": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("namespace set")
+			file := fs.String("file", "", `JSON config, {"relations": {...}} (required)`)
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if err := required("file", *file); err != nil {
+				return err
+			}
+			data, err := os.ReadFile(*file)
+			if err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/rebac-namespace/"+url.PathEscape(pos[0]), nil,
+				rawBody{data: data, contentType: "application/json"})
+			if err != nil {
+				return err
+			}
+			if c.out.format == outputJSON {
+				return c.out.json(raw)
+			}
+			return c.out.done("namespace " + pos[0] + " saved")
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "namespace delete", "/rebac-namespace/", args)
+		},
+	})
+}
+
+func tupleCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "tuple", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("tuple list")
+			namespace := fs.String("namespace", "", "only tuples on objects of this namespace")
+			object := fs.String("object-id", "", "only tuples on this object id")
+			relation := fs.String("relation", "", "only tuples of this relation")
+			subject := fs.String("subject", "", "only tuples with this subject, e.g. user:alice or group:eng#member")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "namespace", *namespace)
+			setIf(query, "object_id", *object)
+			setIf(query, "relation", *relation)
+			setIf(query, "subject", *subject)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/relation-tuple-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("TUPLE", ""))
+		},
+		"write": func(ctx context.Context, c *cli, args []string) error {
+			return c.tupleBatch(ctx, "tuple write", http.MethodPost, args)
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.tupleBatch(ctx, "tuple delete", http.MethodDelete, args)
+		},
+		"check": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("tuple check")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			userset, subject, ok := strings.Cut(pos[0], "@")
+			object, relation, ok2 := strings.Cut(userset, "#")
+			if !ok || !ok2 {
+				return fmt.Errorf("%w: expected object#relation@subject, got %q", errUsage, pos[0])
+			}
+			body := map[string]string{"object": object, "relation": relation, "subject": subject}
+			raw, err := c.api.do(ctx, http.MethodPost, apiPrefix+"/rebac/check", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("ALLOWED", "allowed"))
+		},
+		"expand": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("tuple expand")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			object, relation, ok := strings.Cut(pos[0], "#")
+			if !ok {
+				return fmt.Errorf("%w: expected object#relation, got %q", errUsage, pos[0])
+			}
+			body := map[string]string{"object": object, "relation": relation}
+			raw, err := c.api.do(ctx, http.MethodPost, apiPrefix+"/rebac/expand", nil, body)
+			if err != nil {
+				return err
+			}
+			if c.out.format == outputJSON {
+				return c.out.json(raw)
+			}
+			var tree expandNode
+			if err := json.Unmarshal(raw, &tree); err != nil {
+				return err
+			}
+			tree.print(c.out.w, 0)
+			return nil
+		},
+	})
+}
+
+// tupleBatch writes or deletes the tuples given as arguments and, with --file, one per
+// line of the file.
+func (c *cli) tupleBatch(ctx context.Context, name, method string, args []string) error {
+	fs := c.flags(name)
+	file := fs.String("file", "", "read tuples from this file, one object#relation@subject per line")
+	tuples, err := parseArgs(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				tuples = append(tuples, line)
+			}
+		}
+	}
+	if len(tuples) == 0 {
+		return fmt.Errorf("%w: %s expects tuples as arguments or --file", errUsage, name)
+	}
+	raw, err := c.api.do(ctx, method, adminPrefix+"/relation-tuple", nil, map[string][]string{"tuples": tuples})
+	if err != nil {
+		return err
+	}
+	return c.out.print(raw)
+}
+
+// expandNode mirrors the userset tree returned by the expand endpoint.
+type expandNode struct {
+	Kind     string       `json:"kind"`
+	Userset  string       `json:"userset"`
+	Subjects []string     `json:"subjects"`
+	Children []expandNode `json:"children"`
+}
+
+func (n expandNode) print(w io.Writer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s %s\n", indent, n.Kind, n.Userset)
+	for _, subject := range n.Subjects {
+		fmt.Fprintf(w, "%s  - %s\n", indent, subject)
+	}
+	for _, child := range n.Children {
+		child.print(w, depth+1)
+	}
+}
+
+var breakGlassColumns = []column{
+	col("ID", "id"), col("PRINCIPAL", "principal_id"), col("KIND", "principal_kind"), col("REASON", "reason"),
+	col("ACTIVATED_AT", "activated_at"), col("EXPIRES_AT", "expires_at"), col("ENDED_AT", "ended_at"), col("REVIEW", "review_status"),
+}
+
+func breakGlassCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "break-glass", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass list")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/break-glass/principal-list", nil, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("PRINCIPAL", "principal_id"), col("KIND", "principal_kind"),
+				col("MAX_DURATION_S", "max_duration_seconds"), col("REGISTERED_AT", "registered_at"))
+		},
+		"register": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass register")
+			principal := fs.String("principal", "", "principal id (required)")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			maxDuration := fs.Duration("max-duration", 0, "longest activation allowed, e.g. 1h (default: service maximum)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("principal", *principal); err != nil {
+				return err
+			}
+			body := map[string]interface{}{
+				"principal_id":         *principal,
+				"principal_kind":       *kind,
+				"max_duration_seconds": int(maxDuration.Seconds()),
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/break-glass/principal", nil, body)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.stderr, "store the secret now; it cannot be shown again")
+			return c.out.print(raw, col("PRINCIPAL", "principal_id"), col("KIND", "principal_kind"), col("SECRET", "secret"))
+		},
+		"unregister": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass unregister")
+			principal := fs.String("principal", "", "principal id (required)")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("principal", *principal); err != nil {
+				return err
+			}
+			body := map[string]string{"principal_id": *principal, "principal_kind": *kind}
+			if _, err := c.api.do(ctx, http.MethodDelete, adminPrefix+"/break-glass/principal", nil, body); err != nil {
+				return err
+			}
+			return c.out.done("break-glass registration removed")
+		},
+		"activate": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass activate")
+			principal := fs.String("principal", "", "principal id (required)")
+			kind := fs.String("principal-kind", "user", "principal kind")
+			reason := fs.String("reason", "", "why emergency access is needed (required)")
+			duration := fs.Duration("for", 0, "how long access is needed (default: registration maximum)")
+			secretFile := fs.String("secret-file", "", "file holding the secret (default: env RBAC_BREAK_GLASS_SECRET)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("principal", *principal, "reason", *reason); err != nil {
+				return err
+			}
+			secret := os.Getenv("RBAC_BREAK_GLASS_SECRET")
+			if *secretFile != "" {
+				data, err := os.ReadFile(*secretFile)
+				if err != nil {
+					return err
+				}
+				secret = strings.TrimSpace(string(data))
+			}
+			if secret == "" {
+				return fmt.Errorf("%w: the secret must be given with --secret-file or RBAC_BREAK_GLASS_SECRET", errUsage)
+			}
+			body := map[string]interface{}{
+				"principal_id":     *principal,
+				"principal_kind":   *kind,
+				"secret":           secret,
+				"reason":           *reason,
+				"duration_seconds": int(duration.Seconds()),
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, apiPrefix+"/break-glass/activate", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, breakGlassColumns...)
+		},
+		"deactivate": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass deactivate")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/break-glass/deactivate", nil, map[string]string{"id": pos[0]})
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, breakGlassColumns...)
+		},
+		"activations": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass activations")
+			review := fs.String("review-status", "", "filter by review status: pending or reviewed")
+			principal := fs.String("principal", "", "filter by principal id")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "review_status", *review)
+			setIf(query, "principal_id", *principal)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/break-glass/activation-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, breakGlassColumns...)
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "break-glass get", "/break-glass/activation/", args)
+		},
+		"review": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("break-glass review")
+			notes := fs.String("notes", "", "review findings (required)")
+			pos, err := parseArgs(fs, args, 1)
+			if err != nil {
+				return err
+			}
+			if err := required("notes", *notes); err != nil {
+				return err
+			}
+			// The server records the review in the name of --actor.
+			body := map[string]string{"id": pos[0], "notes": *notes}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/break-glass/review", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, breakGlassColumns...)
+		},
+	})
+}
+
+var roleLimitColumns = []column{col("ROLE", "role_key"), col("SCOPE", "scope"), col("MAX", "max_principals"), col("UPDATED_AT", "updated_at")}
+
+func roleLimitCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "role-limit", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role-limit list")
+			role := fs.String("role", "", "only limits of this role key")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := url.Values{}
+			setIf(query, "role_key", *role)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/role-cardinality-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, roleLimitColumns...)
+		},
+		"set": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role-limit set")
+			role := fs.String("role", "", "role key (required)")
+			scope := fs.String("scope", "", "count per global, tenant, service or resource (required)")
+			limit := fs.Int("max", 0, "maximum number of principals holding the role (required)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("role", *role, "scope", *scope); err != nil {
+				return err
+			}
+			if *limit < 1 {
+				return fmt.Errorf("%w: --max must be at least 1", errUsage)
+			}
+			body := map[string]interface{}{"role_key": *role, "scope": *scope, "max_principals": *limit}
+			raw, err := c.api.do(ctx, http.MethodPut, adminPrefix+"/role-cardinality", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, roleLimitColumns...)
+		},
+		"remove": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("role-limit remove")
+			role := fs.String("role", "", "role key (required)")
+			scope := fs.String("scope", "", "scope level of the limit (required)")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("role", *role, "scope", *scope); err != nil {
+				return err
+			}
+			body := map[string]string{"role_key": *role, "scope": *scope}
+			if _, err := c.api.do(ctx, http.MethodDelete, adminPrefix+"/role-cardinality", nil, body); err != nil {
+				return err
+			}
+			return c.out.done("limit removed from role " + *role)
+		},
+	})
+}
+
+var sodColumns = []column{
+	col("ID", "id"), col("KEY", "key"), col("ROLE_A", "role_a"), col("ROLE_B", "role_b"),
+	col("KIND", "kind"), col("SAME_SCOPE", "same_scope"), col("DESCRIPTION", "description"),
+}
+
+func sodCmd(ctx context.Context, c *cli, args []string) error {
+	return dispatch(ctx, c, "sod", args, map[string]command{
+		"list": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("sod list")
+			page := pageFlags(fs)
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/sod-rule-list", page.query(), nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, sodColumns...)
+		},
+		"get": func(ctx context.Context, c *cli, args []string) error {
+			return c.get(ctx, "sod get", "/sod-rule/", args)
+		},
+		"create": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("sod create")
+			key := fs.String("key", "", "rule key (required)")
+			roleA := fs.String("role-a", "", "first role key (required)")
+			roleB := fs.String("role-b", "", "second role key (required)")
+			kind := fs.String("kind", "static", "static rejects assignments, dynamic flags checks")
+			sameScope := fs.Bool("same-scope", false, "only conflict when the assignments' scopes overlap")
+			description := fs.String("description", "", "rule description")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			if err := required("key", *key, "role-a", *roleA, "role-b", *roleB); err != nil {
+				return err
+			}
+			body := map[string]interface{}{
+				"key": *key, "role_a": *roleA, "role_b": *roleB, "kind": *kind,
+				"same_scope": *sameScope, "description": *description,
+			}
+			raw, err := c.api.do(ctx, http.MethodPost, adminPrefix+"/sod-rule", nil, body)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, sodColumns...)
+		},
+		"delete": func(ctx context.Context, c *cli, args []string) error {
+			return c.delete(ctx, "sod delete", "/sod-rule/", args)
+		},
+		"violations": func(ctx context.Context, c *cli, args []string) error {
+			fs := c.flags("sod violations")
+			page := pageFlags(fs)
+			rule := fs.String("rule", "", "only this rule key")
+			kind := fs.String("kind", "", "only static or dynamic rules")
+			if _, err := parseArgs(fs, args, 0); err != nil {
+				return err
+			}
+			query := page.query()
+			setIf(query, "rule", *rule)
+			setIf(query, "kind", *kind)
+			raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+"/sod-violation-list", query, nil)
+			if err != nil {
+				return err
+			}
+			return c.out.print(raw, col("RULE", "rule"), col("KIND", "kind"), col("PRINCIPAL", "principal_id"),
+				col("PRINCIPAL_KIND", "principal_kind"), col("ROLE_A", "role_a"), col("ROLE_B", "role_b"))
+		},
+	})
+}
+
+func (c *cli) get(ctx context.Context, name, path string, args []string) error {
+	fs := c.flags(name)
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	raw, err := c.api.do(ctx, http.MethodGet, adminPrefix+path+url.PathEscape(pos[0]), nil, nil)
+	if err != nil {
+		return err
+	}
+	return c.out.print(raw)
+}
+
+func (c *cli) delete(ctx context.Context, name, path string, args []string) error {
+	fs := c.flags(name)
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if _, err := c.api.do(ctx, http.MethodDelete, adminPrefix+path+url.PathEscape(pos[0]), nil, nil); err != nil {
+		return err
+	}
+	return c.out.done(strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/") + " " + pos[0] + " deleted")
+}
+
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("rbacctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// dispatch routes args[0] to the matching subcommand.
+func dispatch(ctx context.Context, c *cli, name string, args []string, subs map[string]command) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: %s requires a subcommand: %s", errUsage, name, strings.Join(sortedKeys(subs), ", "))
+	}
+	sub, ok := subs[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown %s subcommand %q, expected one of: %s", errUsage, name, args[0], strings.Join(sortedKeys(subs), ", "))
+	}
+	return sub(ctx, c, args[1:])
+}
+
+// parseArgs parses flags that may be interleaved with positional arguments and
+// checks the number of positionals; a negative count accepts any number.
+func parseArgs(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+	if positional >= 0 && len(pos) != positional {
+		return nil, fmt.Errorf("%w: %s expects %d argument(s), got %d", errUsage, fs.Name(), positional, len(pos))
+	}
+	return pos, nil
+}
+
+// required takes flag name/value pairs and reports the first empty one.
+func required(pairs ...string) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if strings.TrimSpace(pairs[i+1]) == "" {
+			return fmt.Errorf("%w: --%s is required", errUsage, pairs[i])
+		}
+	}
+	return nil
+}
+
+type pagination struct {
+	page     *int
+	pageSize *int
+}
+
+func pageFlags(fs *flag.FlagSet) pagination {
+	return pagination{
+		page:     fs.Int("page", 0, "page number"),
+		pageSize: fs.Int("page-size", 0, "page size"),
+	}
+}
+
+func (p pagination) query() url.Values {
+	query := url.Values{}
+	if *p.page > 0 {
+		query.Set("page", strconv.Itoa(*p.page))
+	}
+	if *p.pageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(*p.pageSize))
+	}
+	return query
+}
+
+func setIf(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
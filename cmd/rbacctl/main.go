@@ -0,0 +1,155 @@
+// Command rbacctl manages the RBAC service through its HTTP API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `rbacctl manages the RBAC service through its HTTP API.
+
+Usage:
+  rbacctl [--addr URL] [--actor ID] [--output table|json] <command> <subcommand> [flags]
+
+Commands:
+  service     list | get | create | update | delete | bind-role | unbind-role | bind-permission | unbind-permission
+  role        list | get | create | update | delete | members | remove-members
+  role-limit  list | set | remove              how many principals may hold a role per scope
+  permission  list | get | create | update | delete
+  grant       list | add | remove              role-permission grants
+  assignment  list | add | remove              scoped principal role assignments
+  override    list | set | remove              principal allow/deny overrides
+  check       evaluate an authorization request
+  explain     evaluate a request and show the artefact that decided it
+  who-can     list every principal allowed a request and why
+  permissions list a principal's effective permissions with scope and source
+  policy      export | plan | apply            policy-as-code documents
+  backup      export | import                  full RBAC backups
+  superadmin  list | grant | revoke            principals that bypass every rule
+  audit       list                             audit log of administrative changes
+  elevation   list | get | request | approve | reject   just-in-time role requests
+  service-account list | get | create | rotate | disable | enable
+                                               non-human principals with API credentials
+  group       list | get | create | update | delete | add-member | remove-member | members | of
+                                               groups whose members inherit the group's roles
+  resource    list | get | register | update | delete
+                                               resource hierarchy used for grant inheritance
+  namespace   list | get | set | delete        relation rewrites of a ReBAC namespace
+  tuple       list | write | delete | check | expand
+                                               ReBAC relation tuples object#relation@subject
+  break-glass list | register | unregister | activate | deactivate | activations | get | review
+                                               emergency superadmin access
+  sod         list | get | create | delete | violations
+                                               separation-of-duties rules between roles
+
+Global flags:
+  --addr     service base URL (env RBAC_ADDR, default http://localhost:8080)
+  --actor    actor recorded in the audit log (env RBAC_ACTOR, default $USER)
+  --output   output format, table or json (env RBAC_OUTPUT, default table)
+
+Set RBAC_SERVICE_ACCOUNT_ID and RBAC_SERVICE_ACCOUNT_SECRET to authenticate as a
+service account when the service requires API credentials.
+
+Run "rbacctl <command> -h" for command flags.
+`
+
+var errUsage = errors.New("invalid usage")
+
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"service":         serviceCmd,
+	"role":            roleCmd,
+	"role-limit":      roleLimitCmd,
+	"permission":      permissionCmd,
+	"grant":           grantCmd,
+	"assignment":      assignmentCmd,
+	"override":        overrideCmd,
+	"check":           checkCmd,
+	"explain":         explainCmd,
+	"who-can":         whoCanCmd,
+	"permissions":     permissionsCmd,
+	"policy":          policyCmd,
+	"backup":          backupCmd,
+	"superadmin":      superadminCmd,
+	"audit":           auditCmd,
+	"elevation":       elevationCmd,
+	"break-glass":     breakGlassCmd,
+	"group":           groupCmd,
+	"resource":        resourceCmd,
+	"namespace":       namespaceCmd,
+	"tuple":           tupleCmd,
+	"service-account": serviceAccountCmd,
+	"sod":             sodCmd,
+}
+
+// cli carries the dependencies shared by every command.
+type cli struct {
+	api    *client
+	out    *printer
+	stderr io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("rbacctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { fmt.Fprint(stderr, usage) }
+	addr := global.String("addr", envOr("RBAC_ADDR", "http://localhost:8080"), "service base URL")
+	actor := global.String("actor", envOr("RBAC_ACTOR", os.Getenv("USER")), "actor recorded in the audit log")
+	output := global.String("output", envOr("RBAC_OUTPUT", outputTable), "output format: table or json")
+	global.StringVar(output, "o", *output, "shorthand for --output")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(stderr, "rbacctl: unknown output format %q\n", *output)
+		return 2
+	}
+	rest := global.Args()
+	if len(rest) == 0 {
+		global.Usage()
+		return 2
+	}
+	cmd, ok := commands[rest[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "rbacctl: unknown command %q\n\n", rest[0])
+		global.Usage()
+		return 2
+	}
+
+	api := newClient(*addr, *actor)
+	api.accountID = os.Getenv("RBAC_SERVICE_ACCOUNT_ID")
+	api.accountSecret = os.Getenv("RBAC_SERVICE_ACCOUNT_SECRET")
+	c := &cli{api: api, out: &printer{w: stdout, format: *output}, stderr: stderr}
+	if err := cmd(ctx, c, rest[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "rbacctl: %v\n", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
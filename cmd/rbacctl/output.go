@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// column maps a table header onto a field of the JSON response.
+type column struct {
+	header string
+	field  string
+}
+
+func col(header, field string) column {
+	return column{header: header, field: field}
+}
+
+// printer renders API responses either as aligned tables or as indented JSON.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// print renders raw. In table mode paginated results and arrays become one row per
+// item; a single object without columns is rendered as FIELD/VALUE pairs.
+func (p *printer) print(raw json.RawMessage, columns ...column) error {
+	if p.format == outputJSON {
+		return p.json(raw)
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	if page, ok := v.(map[string]interface{}); ok {
+		if items, ok := page["items"]; ok {
+			v = items
+		}
+	}
+	switch t := v.(type) {
+	case []interface{}:
+		return p.table(t, columns)
+	case map[string]interface{}:
+		if len(columns) == 0 {
+			return p.fields(t)
+		}
+		return p.table([]interface{}{t}, columns)
+	default:
+		_, err := fmt.Fprintln(p.w, formatValue(t))
+		return err
+	}
+}
+
+// done reports a successful call without a response body.
+func (p *printer) done(message string) error {
+	if p.format == outputJSON {
+		return p.json(json.RawMessage(`{"status":"ok"}`))
+	}
+	_, err := fmt.Fprintln(p.w, message)
+	return err
+}
+
+func (p *printer) json(raw json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(p.w)
+	return err
+}
+
+func (p *printer) table(rows []interface{}, columns []column) error {
+	if len(columns) == 0 {
+		columns = inferColumns(rows)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = formatValue(lookup(row, c.field))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (p *printer) fields(obj map[string]interface{}) error {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", k, formatValue(obj[k]))
+	}
+	return tw.Flush()
+}
+
+func inferColumns(rows []interface{}) []column {
+	if len(rows) == 0 {
+		return nil
+	}
+	obj, ok := rows[0].(map[string]interface{})
+	if !ok {
+		return []column{col("VALUE", "")}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	columns := make([]column, len(keys))
+	for i, k := range keys {
+		columns[i] = col(strings.ToUpper(k), k)
+	}
+	return columns
+}
+
+// lookup resolves a dotted field path; an empty path returns the value itself.
+func lookup(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[part]
+	}
+	return v
+}
+
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if t == "" {
+			return "-"
+		}
+		return t
+	case bool:
+		return fmt.Sprintf("%t", t)
+	case float64:
+		return fmt.Sprintf("%v", t)
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, item := range t {
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, ",")
+	default:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(data)
+	}
+}
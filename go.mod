@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/jackc/pgx/v5 v5.5.4
 	github.com/nats-io/nats.go v1.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
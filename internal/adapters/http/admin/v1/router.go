@@ -2,6 +2,7 @@ package v1
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/example/ms-rbac-service/internal/adapters/http/handlers"
 )
@@ -10,26 +11,153 @@ import (
 func RegisterRoutes(mux *http.ServeMux, h *handlers.AdminHandlers) {
 	mux.HandleFunc("/service", h.Service.Create)
 	mux.HandleFunc("/service/", methodMux(map[string]http.HandlerFunc{
-		http.MethodGet: h.Service.Get,
-		http.MethodPut: h.Service.Update,
+		http.MethodGet:    h.Service.Get,
+		http.MethodPut:    h.Service.Update,
+		http.MethodDelete: h.Service.Delete,
 	}))
 	mux.HandleFunc("/service-list", h.Service.List)
 
 	mux.HandleFunc("/role", h.Role.Create)
-	mux.HandleFunc("/role/", methodMux(map[string]http.HandlerFunc{
-		http.MethodGet: h.Role.Get,
-		http.MethodPut: h.Role.Update,
-	}))
+	mux.HandleFunc("/role/", subresourceMux("/principals", methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.RoleAssignment.ListByRole,
+		http.MethodDelete: h.RoleAssignment.RemoveMembers,
+	}), methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.Role.Get,
+		http.MethodPut:    h.Role.Update,
+		http.MethodDelete: h.Role.Delete,
+	})))
 	mux.HandleFunc("/role-list", h.Role.List)
 
 	mux.HandleFunc("/permission", h.Permission.Create)
 	mux.HandleFunc("/permission/", methodMux(map[string]http.HandlerFunc{
-		http.MethodGet: h.Permission.Get,
-		http.MethodPut: h.Permission.Update,
+		http.MethodGet:    h.Permission.Get,
+		http.MethodPut:    h.Permission.Update,
+		http.MethodDelete: h.Permission.Delete,
 	}))
 	mux.HandleFunc("/permission-list", h.Permission.List)
 
-	mux.HandleFunc("/role-permission", h.RolePermission.Create)
+	mux.HandleFunc("/role-permission", methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.RolePermission.List,
+		http.MethodPost:   h.RolePermission.Create,
+		http.MethodDelete: h.RolePermission.Delete,
+	}))
+
+	mux.HandleFunc("/service-role", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.ServiceRole.Create,
+		http.MethodDelete: h.ServiceRole.Delete,
+	}))
+	mux.HandleFunc("/service-permission", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.ServicePermission.Create,
+		http.MethodDelete: h.ServicePermission.Delete,
+	}))
+
+	mux.HandleFunc("/principal-role", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.RoleAssignment.Assign,
+		http.MethodDelete: h.RoleAssignment.Revoke,
+	}))
+	mux.HandleFunc("/principal-role-list", h.RoleAssignment.List)
+	mux.HandleFunc("/role-cardinality", methodMux(map[string]http.HandlerFunc{
+		http.MethodPut:    h.RoleCardinality.Set,
+		http.MethodDelete: h.RoleCardinality.Delete,
+	}))
+	mux.HandleFunc("/role-cardinality-list", h.RoleCardinality.List)
+	mux.HandleFunc("/principal-override", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.Override.Set,
+		http.MethodDelete: h.Override.Delete,
+	}))
+	mux.HandleFunc("/principal-override-list", h.Override.List)
+
+	mux.HandleFunc("/superadmin", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.Superadmin.Grant,
+		http.MethodDelete: h.Superadmin.Revoke,
+	}))
+	mux.HandleFunc("/superadmin-list", h.Superadmin.List)
+
+	mux.HandleFunc("/policy", h.Policy.Get)
+	mux.HandleFunc("/policy/plan", h.Policy.Plan)
+	mux.HandleFunc("/policy/apply", h.Policy.Apply)
+
+	mux.HandleFunc("/backup/export", h.Backup.Export)
+	mux.HandleFunc("/backup/import", h.Backup.Import)
+
+	mux.HandleFunc("/audit-log", h.Audit.List)
+	mux.HandleFunc("/access-list", h.Access.List)
+
+	mux.HandleFunc("/elevation", h.Elevation.Create)
+	mux.HandleFunc("/elevation/", h.Elevation.Get)
+	mux.HandleFunc("/elevation/approve", h.Elevation.Approve)
+	mux.HandleFunc("/elevation/reject", h.Elevation.Reject)
+	mux.HandleFunc("/elevation-list", h.Elevation.List)
+
+	mux.HandleFunc("/group", h.Group.Create)
+	mux.HandleFunc("/group/", methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.Group.Get,
+		http.MethodPut:    h.Group.Update,
+		http.MethodDelete: h.Group.Delete,
+	}))
+	mux.HandleFunc("/group-list", h.Group.List)
+	mux.HandleFunc("/group-member", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.Group.AddMember,
+		http.MethodDelete: h.Group.RemoveMember,
+	}))
+	mux.HandleFunc("/group-member-list", h.Group.ListMembers)
+	mux.HandleFunc("/principal-group-list", h.Group.ListByPrincipal)
+
+	mux.HandleFunc("/resource", h.Resource.Create)
+	mux.HandleFunc("/resource/", methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.Resource.Get,
+		http.MethodPut:    h.Resource.Update,
+		http.MethodDelete: h.Resource.Delete,
+	}))
+	mux.HandleFunc("/resource-list", h.Resource.List)
+
+	mux.HandleFunc("/rebac-namespace/", methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.Rebac.GetNamespace,
+		http.MethodPut:    h.Rebac.SetNamespace,
+		http.MethodDelete: h.Rebac.DeleteNamespace,
+	}))
+	mux.HandleFunc("/rebac-namespace-list", h.Rebac.ListNamespaces)
+	mux.HandleFunc("/relation-tuple", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.Rebac.WriteTuples,
+		http.MethodDelete: h.Rebac.DeleteTuples,
+	}))
+	mux.HandleFunc("/relation-tuple-list", h.Rebac.ListTuples)
+
+	mux.HandleFunc("/service-account", h.ServiceAccount.Create)
+	mux.HandleFunc("/service-account/", h.ServiceAccount.Get)
+	mux.HandleFunc("/service-account/rotate", h.ServiceAccount.Rotate)
+	mux.HandleFunc("/service-account/disable", h.ServiceAccount.Disable)
+	mux.HandleFunc("/service-account/enable", h.ServiceAccount.Enable)
+	mux.HandleFunc("/service-account-list", h.ServiceAccount.List)
+
+	mux.HandleFunc("/sod-rule", h.SoD.Create)
+	mux.HandleFunc("/sod-rule/", methodMux(map[string]http.HandlerFunc{
+		http.MethodGet:    h.SoD.Get,
+		http.MethodDelete: h.SoD.Delete,
+	}))
+	mux.HandleFunc("/sod-rule-list", h.SoD.List)
+	mux.HandleFunc("/sod-violation-list", h.SoD.ListViolations)
+
+	mux.HandleFunc("/break-glass/principal", methodMux(map[string]http.HandlerFunc{
+		http.MethodPost:   h.BreakGlass.Register,
+		http.MethodDelete: h.BreakGlass.Unregister,
+	}))
+	mux.HandleFunc("/break-glass/principal-list", h.BreakGlass.ListPrincipals)
+	mux.HandleFunc("/break-glass/activation/", h.BreakGlass.GetActivation)
+	mux.HandleFunc("/break-glass/activation-list", h.BreakGlass.ListActivations)
+	mux.HandleFunc("/break-glass/deactivate", h.BreakGlass.Deactivate)
+	mux.HandleFunc("/break-glass/review", h.BreakGlass.Review)
+}
+
+// subresourceMux sends paths ending in suffix to sub and everything else to item.
+func subresourceMux(suffix string, sub, item http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			sub(w, r)
+			return
+		}
+		item(w, r)
+	}
 }
 
 func methodMux(handlers map[string]http.HandlerFunc) http.HandlerFunc {
@@ -11,6 +11,13 @@ func RegisterRoutes(mux *http.ServeMux, h *handlers.APIHandlers) {
 	mux.HandleFunc("/principal-role/update", h.PrincipalRole.Update)
 	mux.HandleFunc("/principal-role/get", h.PrincipalRole.Get)
 	mux.HandleFunc("/principal-permission/list", h.PrincipalPermission.List)
+	mux.HandleFunc("/principal-permission/effective", h.PrincipalPermission.Effective)
 	mux.HandleFunc("/principal-role/get-by-role", h.PrincipalRole.GetByRole)
 	mux.HandleFunc("/principal-permission/get-by-permission", h.PrincipalPermission.GetByPermission)
+	mux.HandleFunc("/check", h.Check.Check)
+	mux.HandleFunc("/explain", h.Check.Explain)
+	mux.HandleFunc("/service-manifest/register", h.ServiceManifest.Register)
+	mux.HandleFunc("/break-glass/activate", h.BreakGlass.Activate)
+	mux.HandleFunc("/rebac/check", h.Rebac.Check)
+	mux.HandleFunc("/rebac/expand", h.Rebac.Expand)
 }
@@ -1,22 +1,47 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/example/ms-rbac-service/internal/domain/policy"
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
 	"github.com/example/ms-rbac-service/internal/usecase"
 	"github.com/example/ms-rbac-service/pkg/pagination"
 )
 
 // AdminHandlers groups admin handler dependencies.
 type AdminHandlers struct {
-	Service        *ServiceHandler
-	Role           *RoleHandler
-	Permission     *PermissionHandler
-	RolePermission *RolePermissionHandler
+	Service           *ServiceHandler
+	Role              *RoleHandler
+	Permission        *PermissionHandler
+	RolePermission    *RolePermissionHandler
+	ServiceRole       *ServiceRoleHandler
+	ServicePermission *ServicePermissionHandler
+	RoleAssignment    *RoleAssignmentHandler
+	Override          *OverrideHandler
+	Superadmin        *SuperadminHandler
+	Policy            *PolicyHandler
+	Backup            *BackupHandler
+	Audit             *AuditHandler
+	Elevation         *ElevationHandler
+	BreakGlass        *BreakGlassHandler
+	Group             *GroupHandler
+	ServiceAccount    *ServiceAccountHandler
+	SoD               *SoDHandler
+	RoleCardinality   *RoleCardinalityHandler
+	Access            *AccessHandler
+	Resource          *ResourceHandler
+	Rebac             *RebacHandler
 }
 
 // ServiceHandler manages service CRUD endpoints.
@@ -112,6 +137,31 @@ func (h *ServiceHandler) List(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
 }
 
+func (h *ServiceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/service/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "service not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // RoleHandler manages role CRUD endpoints.
 type RoleHandler struct {
 	Usecase *usecase.RoleUsecase
@@ -197,7 +247,8 @@ func (h *RoleHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	params := parsePagination(r)
-	items, total, err := h.Usecase.List(r.Context(), params)
+	filter := repo.RoleListFilter{ServiceID: strings.TrimSpace(r.URL.Query().Get("service_id"))}
+	items, total, err := h.Usecase.List(r.Context(), filter, params)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -205,6 +256,31 @@ func (h *RoleHandler) List(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
 }
 
+func (h *RoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "role use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/role/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // PermissionHandler manages permission CRUD endpoints.
 type PermissionHandler struct {
 	Usecase *usecase.PermissionUsecase
@@ -301,7 +377,8 @@ func (h *PermissionHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	params := parsePagination(r)
-	items, total, err := h.Usecase.List(r.Context(), params)
+	filter := repo.PermissionListFilter{ServiceID: strings.TrimSpace(r.URL.Query().Get("service_id"))}
+	items, total, err := h.Usecase.List(r.Context(), filter, params)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -309,6 +386,31 @@ func (h *PermissionHandler) List(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
 }
 
+func (h *PermissionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "permission use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/permission/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "permission not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // RolePermissionHandler manages role-permission assignments.
 type RolePermissionHandler struct {
 	Usecase *usecase.RolePermissionUsecase
@@ -328,13 +430,16 @@ func (h *RolePermissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
-	roleKey := strings.TrimSpace(payload.RoleKey)
-	permissionID := strings.TrimSpace(payload.PermissionID)
-	if roleKey == "" || permissionID == "" {
+	input, ok := rolePermissionInput(payload)
+	if !ok {
 		writeError(w, http.StatusBadRequest, "role_key and permission_id are required")
 		return
 	}
-	if err := h.Usecase.Create(r.Context(), repo.RolePermissionCreate{RoleKey: roleKey, PermissionID: permissionID}); err != nil {
+	if err := h.Usecase.Create(r.Context(), input); err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if errors.Is(err, repo.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "role or permission not found")
 			return
@@ -345,6 +450,2084 @@ func (h *RolePermissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+func (h *RolePermissionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "role permission use case is unavailable")
+		return
+	}
+	var payload createRolePermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input, ok := rolePermissionInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "role_key and permission_id are required")
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), input); err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role permission not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RolePermissionHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "role permission use case is unavailable")
+		return
+	}
+	roleKey := strings.TrimSpace(r.URL.Query().Get("role_key"))
+	if roleKey == "" {
+		writeError(w, http.StatusBadRequest, "role_key is required")
+		return
+	}
+	items, err := h.Usecase.List(r.Context(), usecase.RolePermissionFilter{RoleKey: roleKey})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func rolePermissionInput(payload createRolePermissionRequest) (repo.RolePermissionCreate, bool) {
+	input := repo.RolePermissionCreate{
+		RoleKey:      strings.TrimSpace(payload.RoleKey),
+		PermissionID: strings.TrimSpace(payload.PermissionID),
+		ResourceID:   strings.TrimSpace(payload.ResourceID),
+		Condition:    payload.Condition,
+	}
+	return input, input.RoleKey != "" && input.PermissionID != ""
+}
+
+// ServiceRoleHandler manages role bindings of services.
+type ServiceRoleHandler struct {
+	Usecase *usecase.ServiceRoleUsecase
+}
+
+func (h *ServiceRoleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service role use case is unavailable")
+		return
+	}
+	var payload serviceRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input := repo.ServiceRoleBinding{
+		ServiceID: strings.TrimSpace(payload.ServiceID),
+		RoleKey:   strings.TrimSpace(payload.RoleKey),
+	}
+	if input.ServiceID == "" || input.RoleKey == "" {
+		writeError(w, http.StatusBadRequest, "service_id and role_key are required")
+		return
+	}
+	if err := h.Usecase.Create(r.Context(), input); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "service or role not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *ServiceRoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service role use case is unavailable")
+		return
+	}
+	var payload serviceRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input := repo.ServiceRoleBinding{
+		ServiceID: strings.TrimSpace(payload.ServiceID),
+		RoleKey:   strings.TrimSpace(payload.RoleKey),
+	}
+	if input.ServiceID == "" || input.RoleKey == "" {
+		writeError(w, http.StatusBadRequest, "service_id and role_key are required")
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), input); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "service role binding not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServicePermissionHandler manages permission bindings of services.
+type ServicePermissionHandler struct {
+	Usecase *usecase.ServicePermissionUsecase
+}
+
+func (h *ServicePermissionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service permission use case is unavailable")
+		return
+	}
+	var payload servicePermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input := repo.ServicePermissionBinding{
+		ServiceID:    strings.TrimSpace(payload.ServiceID),
+		PermissionID: strings.TrimSpace(payload.PermissionID),
+	}
+	if input.ServiceID == "" || input.PermissionID == "" {
+		writeError(w, http.StatusBadRequest, "service_id and permission_id are required")
+		return
+	}
+	if err := h.Usecase.Create(r.Context(), input); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "service or permission not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *ServicePermissionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service permission use case is unavailable")
+		return
+	}
+	var payload servicePermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input := repo.ServicePermissionBinding{
+		ServiceID:    strings.TrimSpace(payload.ServiceID),
+		PermissionID: strings.TrimSpace(payload.PermissionID),
+	}
+	if input.ServiceID == "" || input.PermissionID == "" {
+		writeError(w, http.StatusBadRequest, "service_id and permission_id are required")
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), input); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "service permission binding not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RoleAssignmentHandler manages scoped role assignments of principals.
+type RoleAssignmentHandler struct {
+	Usecase *usecase.PrincipalRoleUsecase
+}
+
+func (h *RoleAssignmentHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal role use case is unavailable")
+		return
+	}
+	var payload roleAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input, ok := roleAssignmentInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "principal_id and role_key are required")
+		return
+	}
+	if err := h.Usecase.Assign(r.Context(), input); err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role or service not found")
+			return
+		}
+		if writeGrantConstraintError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *RoleAssignmentHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal role use case is unavailable")
+		return
+	}
+	var payload roleAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input, ok := roleAssignmentInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "principal_id and role_key are required")
+		return
+	}
+	if err := h.Usecase.Revoke(r.Context(), input); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role assignment not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RoleAssignmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal role use case is unavailable")
+		return
+	}
+	principalID := strings.TrimSpace(r.URL.Query().Get("principal_id"))
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	items, err := h.Usecase.ListByPrincipal(r.Context(), principalID, principalKindOrDefault(r.URL.Query().Get("principal_kind")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// ListByRole serves GET /role/{id}/principals.
+func (h *RoleAssignmentHandler) ListByRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal role use case is unavailable")
+		return
+	}
+	roleID := rolePrincipalsPathID(r.URL.Path)
+	if roleID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	filter := repo.RoleMemberFilter{
+		PrincipalKind: strings.TrimSpace(q.Get("principal_kind")),
+		TenantID:      strings.TrimSpace(q.Get("tenant_id")),
+		ServiceID:     strings.TrimSpace(q.Get("service_id")),
+		ResourceKind:  strings.TrimSpace(q.Get("resource_kind")),
+		ResourceID:    strings.TrimSpace(q.Get("resource_id")),
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.ListByRole(r.Context(), roleID, filter, params)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+// RemoveMembers serves DELETE /role/{id}/principals.
+func (h *RoleAssignmentHandler) RemoveMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal role use case is unavailable")
+		return
+	}
+	roleID := rolePrincipalsPathID(r.URL.Path)
+	if roleID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	var payload removeRoleMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	filter := repo.RoleMemberFilter{
+		PrincipalKind: strings.TrimSpace(payload.PrincipalKind),
+		TenantID:      strings.TrimSpace(payload.TenantID),
+		ServiceID:     strings.TrimSpace(payload.ServiceID),
+		ResourceKind:  strings.TrimSpace(payload.ResourceKind),
+		ResourceID:    strings.TrimSpace(payload.ResourceID),
+	}
+	removed, err := h.Usecase.RemoveMembers(r.Context(), roleID, filter, payload.Principals)
+	if err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"removed": removed})
+}
+
+// rolePrincipalsPathID extracts the role id from /role/{id}/principals.
+func rolePrincipalsPathID(path string) string {
+	id, ok := strings.CutSuffix(trimPathID(path, "/role/"), "/principals")
+	if !ok || strings.Contains(id, "/") {
+		return ""
+	}
+	return id
+}
+
+func roleAssignmentInput(payload roleAssignmentRequest) (repo.PrincipalRoleAssignment, bool) {
+	input := repo.PrincipalRoleAssignment{
+		PrincipalID:   strings.TrimSpace(payload.PrincipalID),
+		PrincipalKind: principalKindOrDefault(payload.PrincipalKind),
+		RoleKey:       strings.TrimSpace(payload.RoleKey),
+		TenantID:      strings.TrimSpace(payload.TenantID),
+		ServiceID:     strings.TrimSpace(payload.ServiceID),
+		ResourceKind:  strings.TrimSpace(payload.ResourceKind),
+		ResourceID:    strings.TrimSpace(payload.ResourceID),
+		ValidFrom:     payload.ValidFrom,
+		ValidUntil:    payload.ValidUntil,
+	}
+	return input, input.PrincipalID != "" && input.RoleKey != ""
+}
+
+// OverrideHandler manages per-principal allow/deny overrides.
+type OverrideHandler struct {
+	Usecase *usecase.PrincipalOverrideUsecase
+}
+
+func (h *OverrideHandler) Set(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal override use case is unavailable")
+		return
+	}
+	var payload overrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input, ok := overrideInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "principal_id and permission_id are required")
+		return
+	}
+	if err := h.Usecase.Set(r.Context(), input); err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "permission or service not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *OverrideHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal override use case is unavailable")
+		return
+	}
+	var payload overrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input, ok := overrideInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "principal_id and permission_id are required")
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), input); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "principal override not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OverrideHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "principal override use case is unavailable")
+		return
+	}
+	principalID := strings.TrimSpace(r.URL.Query().Get("principal_id"))
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	items, err := h.Usecase.ListByPrincipal(r.Context(), principalID, principalKindOrDefault(r.URL.Query().Get("principal_kind")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func overrideInput(payload overrideRequest) (repo.PrincipalOverrideInput, bool) {
+	input := repo.PrincipalOverrideInput{
+		PrincipalID:   strings.TrimSpace(payload.PrincipalID),
+		PrincipalKind: principalKindOrDefault(payload.PrincipalKind),
+		PermissionID:  strings.TrimSpace(payload.PermissionID),
+		Effect:        model.OverrideEffect(strings.ToLower(strings.TrimSpace(payload.Effect))),
+		TenantID:      strings.TrimSpace(payload.TenantID),
+		ServiceID:     strings.TrimSpace(payload.ServiceID),
+		ResourceKind:  strings.TrimSpace(payload.ResourceKind),
+		ResourceID:    strings.TrimSpace(payload.ResourceID),
+		ValidFrom:     payload.ValidFrom,
+		ValidUntil:    payload.ValidUntil,
+		Condition:     payload.Condition,
+	}
+	return input, input.PrincipalID != "" && input.PermissionID != ""
+}
+
+func principalKindOrDefault(v string) model.PrincipalKind {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return model.PrincipalKindUser
+	}
+	return model.PrincipalKind(v)
+}
+
+// SuperadminHandler manages principals that bypass every PDP rule.
+type SuperadminHandler struct {
+	Usecase *usecase.SuperadminUsecase
+}
+
+func (h *SuperadminHandler) Grant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "superadmin use case is unavailable")
+		return
+	}
+	var payload superadminRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	principalID := strings.TrimSpace(payload.PrincipalID)
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	if err := h.Usecase.Grant(r.Context(), principalID, principalKindOrDefault(payload.PrincipalKind)); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *SuperadminHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "superadmin use case is unavailable")
+		return
+	}
+	var payload superadminRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	principalID := strings.TrimSpace(payload.PrincipalID)
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	if err := h.Usecase.Revoke(r.Context(), principalID); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "superadmin not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SuperadminHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "superadmin use case is unavailable")
+		return
+	}
+	items, err := h.Usecase.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// PolicyHandler exposes declarative policy sync (plan/apply) endpoints.
+type PolicyHandler struct {
+	Usecase *usecase.PolicyUsecase
+}
+
+func (h *PolicyHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "policy use case is unavailable")
+		return
+	}
+	doc, err := h.Usecase.Get(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (h *PolicyHandler) Plan(w http.ResponseWriter, r *http.Request) {
+	h.sync(w, r, h.Usecase.Plan)
+}
+
+func (h *PolicyHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	h.sync(w, r, h.Usecase.Apply)
+}
+
+func (h *PolicyHandler) sync(w http.ResponseWriter, r *http.Request, run func(ctx context.Context, doc policy.Document, opts policy.Options) (policy.Plan, error)) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "policy use case is unavailable")
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	format := policy.FormatJSON
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		format = policy.FormatYAML
+	}
+	doc, err := policy.Parse(data, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts := policy.Options{Prune: r.URL.Query().Get("prune") == "true"}
+	plan, err := run(r.Context(), doc, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, policy.ErrInvalidDocument):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, repo.ErrNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// BackupHandler exposes full export and import of the RBAC state.
+type BackupHandler struct {
+	Usecase *usecase.BackupUsecase
+}
+
+func (h *BackupHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "backup use case is unavailable")
+		return
+	}
+	backup, err := h.Usecase.Export(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, backup)
+}
+
+func (h *BackupHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "backup use case is unavailable")
+		return
+	}
+	var backup policy.Backup
+	if err := json.NewDecoder(r.Body).Decode(&backup); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	opts := policy.ImportOptions{ExcludePrincipals: r.URL.Query().Get("exclude_principals") == "true"}
+	report, err := h.Usecase.Import(r.Context(), backup, opts)
+	if err != nil {
+		if errors.Is(err, policy.ErrInvalidDocument) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repo.ErrConflict) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if writeGrantConstraintError(w, err) {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// AuditHandler exposes the audit log of administrative changes.
+type AuditHandler struct {
+	Usecase *usecase.AuditUsecase
+}
+
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "audit use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	filter := audit.Filter{
+		ActorID:       strings.TrimSpace(q.Get("actor_id")),
+		Source:        strings.TrimSpace(q.Get("source")),
+		CorrelationID: strings.TrimSpace(q.Get("correlation_id")),
+		Action:        strings.TrimSpace(q.Get("action")),
+		Entity:        strings.TrimSpace(q.Get("entity")),
+		EntityID:      strings.TrimSpace(q.Get("entity_id")),
+	}
+	var ok bool
+	if filter.From, ok = parseTimeParam(q.Get("from")); !ok {
+		writeError(w, http.StatusBadRequest, "from must be an RFC 3339 timestamp")
+		return
+	}
+	if filter.To, ok = parseTimeParam(q.Get("to")); !ok {
+		writeError(w, http.StatusBadRequest, "to must be an RFC 3339 timestamp")
+		return
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.List(r.Context(), filter, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+// ElevationHandler exposes the just-in-time elevation workflow.
+type ElevationHandler struct {
+	Usecase *usecase.ElevationUsecase
+}
+
+func (h *ElevationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "elevation use case is unavailable")
+		return
+	}
+	var payload elevationRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	input := repo.ElevationRequestInput{
+		PrincipalID:   strings.TrimSpace(payload.PrincipalID),
+		PrincipalKind: principalKindOrDefault(payload.PrincipalKind),
+		RoleKey:       strings.TrimSpace(payload.RoleKey),
+		TenantID:      strings.TrimSpace(payload.TenantID),
+		ServiceID:     strings.TrimSpace(payload.ServiceID),
+		ResourceKind:  strings.TrimSpace(payload.ResourceKind),
+		ResourceID:    strings.TrimSpace(payload.ResourceID),
+		Justification: payload.Justification,
+		Duration:      time.Duration(payload.DurationSeconds) * time.Second,
+	}
+	if input.PrincipalID == "" || input.RoleKey == "" {
+		writeError(w, http.StatusBadRequest, "principal_id and role_key are required")
+		return
+	}
+	item, err := h.Usecase.Request(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "role or service not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (h *ElevationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "elevation use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/elevation/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	item, err := h.Usecase.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "elevation request not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *ElevationHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "elevation use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	filter := repo.ElevationFilter{
+		Status:      strings.TrimSpace(q.Get("status")),
+		PrincipalID: strings.TrimSpace(q.Get("principal_id")),
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.List(r.Context(), filter, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func (h *ElevationHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "elevation use case is unavailable")
+		return
+	}
+	var payload elevationDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	id := strings.TrimSpace(payload.ID)
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	item, err := h.Usecase.Approve(r.Context(), id, strings.TrimSpace(payload.Note))
+	if err != nil {
+		writeElevationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *ElevationHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "elevation use case is unavailable")
+		return
+	}
+	var payload elevationDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	id := strings.TrimSpace(payload.ID)
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	item, err := h.Usecase.Reject(r.Context(), id, strings.TrimSpace(payload.Note))
+	if err != nil {
+		writeElevationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func writeElevationError(w http.ResponseWriter, err error) {
+	if writeGrantConstraintError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrUnauthenticated):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "elevation request not found")
+	case errors.Is(err, repo.ErrConflict):
+		writeError(w, http.StatusConflict, "elevation request is no longer pending")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// BreakGlassHandler manages break-glass registrations, activations and reviews.
+type BreakGlassHandler struct {
+	Usecase *usecase.BreakGlassUsecase
+}
+
+func (h *BreakGlassHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	var payload breakGlassPrincipalRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	principalID := strings.TrimSpace(payload.PrincipalID)
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	kind := principalKindOrDefault(payload.PrincipalKind)
+	secret, err := h.Usecase.Register(r.Context(), principalID, kind, time.Duration(payload.MaxDurationSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"principal_id": principalID, "principal_kind": string(kind), "secret": secret})
+}
+
+func (h *BreakGlassHandler) Unregister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	var payload breakGlassPrincipalRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	principalID := strings.TrimSpace(payload.PrincipalID)
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	if err := h.Usecase.Unregister(r.Context(), principalID, principalKindOrDefault(payload.PrincipalKind)); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "break-glass principal not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BreakGlassHandler) ListPrincipals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	items, err := h.Usecase.ListPrincipals(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (h *BreakGlassHandler) ListActivations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	filter := repo.BreakGlassFilter{
+		ReviewStatus: strings.TrimSpace(q.Get("review_status")),
+		PrincipalID:  strings.TrimSpace(q.Get("principal_id")),
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.ListActivations(r.Context(), filter, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func (h *BreakGlassHandler) GetActivation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/break-glass/activation/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	item, err := h.Usecase.GetActivation(r.Context(), id)
+	if err != nil {
+		writeBreakGlassError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *BreakGlassHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	var payload breakGlassReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	id := strings.TrimSpace(payload.ID)
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	item, err := h.Usecase.Deactivate(r.Context(), id)
+	if err != nil {
+		writeBreakGlassError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *BreakGlassHandler) Review(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	var payload breakGlassReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	id := strings.TrimSpace(payload.ID)
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	item, err := h.Usecase.Review(r.Context(), id, payload.Notes)
+	if err != nil {
+		writeBreakGlassError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func writeBreakGlassError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrUnauthenticated):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, usecase.ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "break-glass activation not found")
+	case errors.Is(err, repo.ErrConflict):
+		writeError(w, http.StatusConflict, "break-glass activation is in the wrong state for this operation")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GroupHandler manages groups and group membership.
+type GroupHandler struct {
+	Usecase *usecase.GroupUsecase
+}
+
+func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	var payload createGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	item, err := h.Usecase.Create(r.Context(), payload.Key, payload.Title)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/group/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	var payload updateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if err := h.Usecase.Update(r.Context(), id, payload.Title); err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/group/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	item, err := h.Usecase.Get(r.Context(), id)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *GroupHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.List(r.Context(), params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func (h *GroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/group/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), id); err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	h.changeMember(w, r, h.Usecase.AddMember)
+}
+
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	h.changeMember(w, r, h.Usecase.RemoveMember)
+}
+
+func (h *GroupHandler) changeMember(w http.ResponseWriter, r *http.Request, change func(context.Context, repo.GroupMember) error) {
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	var payload groupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	member := repo.GroupMember{
+		GroupID:    strings.TrimSpace(payload.GroupID),
+		MemberID:   strings.TrimSpace(payload.MemberID),
+		MemberKind: principalKindOrDefault(payload.MemberKind),
+	}
+	if err := change(r.Context(), member); err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMembers lists the direct members of ?group_id=.
+func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	groupID := strings.TrimSpace(r.URL.Query().Get("group_id"))
+	if groupID == "" {
+		writeError(w, http.StatusBadRequest, "group_id is required")
+		return
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.ListMembers(r.Context(), groupID, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+// ListByPrincipal lists every group ?principal_id= belongs to, including through nesting.
+func (h *GroupHandler) ListByPrincipal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "group use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	principalID := strings.TrimSpace(q.Get("principal_id"))
+	if principalID == "" {
+		writeError(w, http.StatusBadRequest, "principal_id is required")
+		return
+	}
+	items, err := h.Usecase.ListByPrincipal(r.Context(), principalID, principalKindOrDefault(q.Get("principal_kind")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func writeGroupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "group not found")
+	case errors.Is(err, repo.ErrConflict):
+		writeError(w, http.StatusConflict, "membership would create a group cycle")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ResourceHandler manages the resource registry.
+type ResourceHandler struct {
+	Usecase *usecase.ResourceUsecase
+}
+
+func (h *ResourceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "resource use case is unavailable")
+		return
+	}
+	var payload resourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	item, err := h.Usecase.Create(r.Context(), payload.ID, payload.Kind, payload.ParentID)
+	if err != nil {
+		writeResourceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// Update replaces the kind and parent of /resource/{id}; omitting parent_id makes it a root.
+func (h *ResourceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "resource use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/resource/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	var payload resourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	item, err := h.Usecase.Update(r.Context(), id, payload.Kind, payload.ParentID)
+	if err != nil {
+		writeResourceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *ResourceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "resource use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/resource/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	item, err := h.Usecase.Get(r.Context(), id)
+	if err != nil {
+		writeResourceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// List lists registered resources, optionally narrowed by ?kind= and ?parent_id=.
+func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "resource use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	filter := repo.ResourceListFilter{
+		Kind:     strings.TrimSpace(q.Get("kind")),
+		ParentID: strings.TrimSpace(q.Get("parent_id")),
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.List(r.Context(), filter, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func (h *ResourceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "resource use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/resource/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), id); err != nil {
+		writeResourceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeResourceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "resource not found")
+	case errors.Is(err, repo.ErrConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// RebacHandler manages ReBAC namespace configs and relation tuples.
+type RebacHandler struct {
+	Usecase *usecase.RebacUsecase
+}
+
+// SetNamespace creates or replaces the config of /rebac-namespace/{name}.
+func (h *RebacHandler) SetNamespace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	name := trimPathID(r.URL.Path, "/rebac-namespace/")
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	var payload rebacNamespaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	ns := &domainrebac.Namespace{Name: name, Relations: payload.Relations}
+	if err := h.Usecase.SetNamespace(r.Context(), ns); err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ns)
+}
+
+func (h *RebacHandler) GetNamespace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	name := trimPathID(r.URL.Path, "/rebac-namespace/")
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	ns, err := h.Usecase.GetNamespace(r.Context(), name)
+	if err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ns)
+}
+
+func (h *RebacHandler) ListNamespaces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	items, err := h.Usecase.ListNamespaces(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (h *RebacHandler) DeleteNamespace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	name := trimPathID(r.URL.Path, "/rebac-namespace/")
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.DeleteNamespace(r.Context(), name); err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WriteTuples stores {"tuples": ["doc:readme#viewer@user:alice", ...]} atomically.
+func (h *RebacHandler) WriteTuples(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	tuples, ok := decodeRelationTuples(w, r)
+	if !ok {
+		return
+	}
+	written, err := h.Usecase.WriteTuples(r.Context(), tuples)
+	if err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"written": written})
+}
+
+// DeleteTuples removes {"tuples": [...]} atomically; tuples not stored are ignored.
+func (h *RebacHandler) DeleteTuples(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	tuples, ok := decodeRelationTuples(w, r)
+	if !ok {
+		return
+	}
+	deleted, err := h.Usecase.DeleteTuples(r.Context(), tuples)
+	if err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// ListTuples lists stored tuples, optionally narrowed by ?namespace=, ?object_id=,
+// ?relation= and ?subject= (user:alice or group:eng#member).
+func (h *RebacHandler) ListTuples(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	filter := repo.RelationTupleFilter{
+		Namespace: strings.TrimSpace(q.Get("namespace")),
+		ObjectID:  strings.TrimSpace(q.Get("object_id")),
+		Relation:  strings.TrimSpace(q.Get("relation")),
+	}
+	if raw := strings.TrimSpace(q.Get("subject")); raw != "" {
+		subject, err := domainrebac.ParseSubject(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.Subject = &subject
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.ListTuples(r.Context(), filter, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func decodeRelationTuples(w http.ResponseWriter, r *http.Request) ([]domainrebac.Tuple, bool) {
+	var payload relationTuplesRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return nil, false
+	}
+	tuples := make([]domainrebac.Tuple, 0, len(payload.Tuples))
+	for _, raw := range payload.Tuples {
+		t, err := domainrebac.ParseTuple(strings.TrimSpace(raw))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, true
+}
+
+func writeRebacError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "namespace not found")
+	case errors.Is(err, repo.ErrConflict), errors.Is(err, domainrebac.ErrDepthExceeded):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// SoDHandler manages separation-of-duties rules and the violation report.
+type SoDHandler struct {
+	Usecase *usecase.SoDUsecase
+}
+
+func (h *SoDHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "sod use case is unavailable")
+		return
+	}
+	var payload createSoDRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	item, err := h.Usecase.Create(r.Context(), repo.SoDRule{
+		Key:         payload.Key,
+		RoleA:       payload.RoleA,
+		RoleB:       payload.RoleB,
+		Kind:        strings.TrimSpace(payload.Kind),
+		SameScope:   payload.SameScope,
+		Description: payload.Description,
+	})
+	if err != nil {
+		writeSoDError(w, err, "role not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (h *SoDHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "sod use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/sod-rule/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	item, err := h.Usecase.Get(r.Context(), id)
+	if err != nil {
+		writeSoDError(w, err, "sod rule not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *SoDHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "sod use case is unavailable")
+		return
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.List(r.Context(), params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func (h *SoDHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "sod use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/sod-rule/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), id); err != nil {
+		writeSoDError(w, err, "sod rule not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListViolations lists principals holding both roles of a rule, narrowed by ?rule= and ?kind=.
+func (h *SoDHandler) ListViolations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "sod use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	filter := repo.SoDViolationFilter{Rule: strings.TrimSpace(q.Get("rule")), Kind: strings.TrimSpace(q.Get("kind"))}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.ListViolations(r.Context(), filter, params)
+	if err != nil {
+		writeSoDError(w, err, "sod rule not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func writeSoDError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "namespace not found")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// RoleCardinalityHandler manages limits on how many principals may hold a role per scope.
+type RoleCardinalityHandler struct {
+	Usecase *usecase.RoleCardinalityUsecase
+}
+
+func (h *RoleCardinalityHandler) Set(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "role cardinality use case is unavailable")
+		return
+	}
+	var payload roleCardinalityRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	item, err := h.Usecase.Set(r.Context(), repo.RoleCardinality{RoleKey: payload.RoleKey, Scope: payload.Scope, MaxPrincipals: payload.MaxPrincipals})
+	if err != nil {
+		writeRoleCardinalityError(w, err, "role not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *RoleCardinalityHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "role cardinality use case is unavailable")
+		return
+	}
+	var payload roleCardinalityRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if err := h.Usecase.Delete(r.Context(), payload.RoleKey, payload.Scope); err != nil {
+		writeRoleCardinalityError(w, err, "role cardinality limit not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List lists cardinality limits, narrowed to ?role_key= when given.
+func (h *RoleCardinalityHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "role cardinality use case is unavailable")
+		return
+	}
+	items, err := h.Usecase.List(r.Context(), r.URL.Query().Get("role_key"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func writeRoleCardinalityError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "namespace not found")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// AccessHandler answers who is allowed to perform an action on a resource.
+type AccessHandler struct {
+	Usecase *usecase.AccessUsecase
+}
+
+// List lists the principals allowed ?action= on ?resource_kind= (and optionally
+// ?resource_id=, ?tenant_id=, ?service_id=) with the paths that allow them.
+func (h *AccessHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "access use case is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	optional := func(name string) *string {
+		v := q.Get(name)
+		return trimOptional(&v)
+	}
+	items, err := h.Usecase.ListPrincipals(r.Context(), domainpdp.CheckRequest{
+		Action:       strings.TrimSpace(q.Get("action")),
+		ResourceKind: strings.TrimSpace(q.Get("resource_kind")),
+		ResourceID:   optional("resource_id"),
+		TenantID:     optional("tenant_id"),
+		ServiceID:    optional("service_id"),
+	})
+	if err != nil {
+		if errors.Is(err, usecase.ErrValidation) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// ServiceAccountHandler manages service accounts and their secrets.
+type ServiceAccountHandler struct {
+	Usecase *usecase.ServiceAccountUsecase
+}
+
+// serviceAccountSecret is returned once when a secret is issued.
+type serviceAccountSecret struct {
+	repo.ServiceAccount
+	Secret string `json:"secret"`
+}
+
+func (h *ServiceAccountHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service account use case is unavailable")
+		return
+	}
+	var payload createServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	sa, secret, err := h.Usecase.Create(r.Context(), payload.Key, payload.Title)
+	if err != nil {
+		writeServiceAccountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, serviceAccountSecret{ServiceAccount: sa, Secret: secret})
+}
+
+func (h *ServiceAccountHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service account use case is unavailable")
+		return
+	}
+	id := trimPathID(r.URL.Path, "/service-account/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	sa, err := h.Usecase.Get(r.Context(), id)
+	if err != nil {
+		writeServiceAccountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sa)
+}
+
+func (h *ServiceAccountHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service account use case is unavailable")
+		return
+	}
+	params := parsePagination(r)
+	items, total, err := h.Usecase.List(r.Context(), params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pagination.Result{Items: items, Page: params.Page, PageSize: params.PageSize, Total: total})
+}
+
+func (h *ServiceAccountHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.decodeID(w, r)
+	if !ok {
+		return
+	}
+	sa, secret, err := h.Usecase.RotateSecret(r.Context(), id)
+	if err != nil {
+		writeServiceAccountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, serviceAccountSecret{ServiceAccount: sa, Secret: secret})
+}
+
+func (h *ServiceAccountHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.decodeID(w, r)
+	if !ok {
+		return
+	}
+	sa, err := h.Usecase.Disable(r.Context(), id)
+	if err != nil {
+		writeServiceAccountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sa)
+}
+
+func (h *ServiceAccountHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.decodeID(w, r)
+	if !ok {
+		return
+	}
+	sa, err := h.Usecase.Enable(r.Context(), id)
+	if err != nil {
+		writeServiceAccountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sa)
+}
+
+// decodeID handles the method, availability and payload checks shared by the POST
+// endpoints that act on one account.
+func (h *ServiceAccountHandler) decodeID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return "", false
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "service account use case is unavailable")
+		return "", false
+	}
+	var payload serviceAccountIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return "", false
+	}
+	id := strings.TrimSpace(payload.ID)
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return "", false
+	}
+	return id, true
+}
+
+func writeServiceAccountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repo.ErrNotFound):
+		writeError(w, http.StatusNotFound, "service account not found")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func trimPathID(path, prefix string) string {
 	if !strings.HasPrefix(path, prefix) {
 		return ""
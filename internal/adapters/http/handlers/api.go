@@ -5,8 +5,13 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	pdpadapter "github.com/example/ms-rbac-service/internal/adapters/pdp"
 	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
 	"github.com/example/ms-rbac-service/internal/usecase"
 )
 
@@ -14,12 +19,17 @@ import (
 type APIHandlers struct {
 	PrincipalRole       *PrincipalRoleHandler
 	PrincipalPermission *PrincipalPermissionHandler
+	Check               *CheckHandler
+	ServiceManifest     *ServiceManifestHandler
+	BreakGlass          *BreakGlassActivationHandler
+	Rebac               *RebacCheckHandler
 }
 
 type assignRoleRequest struct {
 	Value struct {
-		UserID string `json:"user_id"`
-		Role   string `json:"role"`
+		UserID        string `json:"user_id"`
+		PrincipalKind string `json:"principal_kind"`
+		Role          string `json:"role"`
 	} `json:"value"`
 }
 
@@ -48,11 +58,19 @@ func (h *PrincipalRoleHandler) Update(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "user_id and role are required")
 		return
 	}
-	if err := h.Usecase.Update(r.Context(), userID, repo.PrincipalRoleUpdate{RoleKey: role}); err != nil {
+	kind := principalKindOrDefault(payload.Value.PrincipalKind)
+	if !kind.Valid() {
+		writeError(w, http.StatusBadRequest, "unknown principal_kind")
+		return
+	}
+	if err := h.Usecase.Update(r.Context(), userID, kind, repo.PrincipalRoleUpdate{RoleKey: role}); err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "role not found")
 			return
 		}
+		if writeGrantConstraintError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -73,7 +91,11 @@ func (h *PrincipalRoleHandler) Get(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "user_id is required")
 		return
 	}
-	role, err := h.Usecase.Get(r.Context(), userID)
+	kind, ok := queryPrincipalKind(w, r)
+	if !ok {
+		return
+	}
+	role, err := h.Usecase.Get(r.Context(), userID, kind)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -96,7 +118,11 @@ func (h *PrincipalRoleHandler) GetByRole(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "user_id and role are required")
 		return
 	}
-	allowed, err := h.Usecase.GetByRole(r.Context(), userID, role)
+	kind, ok := queryPrincipalKind(w, r)
+	if !ok {
+		return
+	}
+	allowed, err := h.Usecase.GetByRole(r.Context(), userID, kind, role)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -104,6 +130,17 @@ func (h *PrincipalRoleHandler) GetByRole(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, map[string]bool{"allowed": allowed})
 }
 
+// queryPrincipalKind reads ?principal_kind=, defaulting to user, and answers 400 for an
+// unknown kind.
+func queryPrincipalKind(w http.ResponseWriter, r *http.Request) (model.PrincipalKind, bool) {
+	kind := principalKindOrDefault(r.URL.Query().Get("principal_kind"))
+	if !kind.Valid() {
+		writeError(w, http.StatusBadRequest, "unknown principal_kind")
+		return "", false
+	}
+	return kind, true
+}
+
 // PrincipalPermissionHandler handles permission lookup endpoints.
 type PrincipalPermissionHandler struct {
 	Usecase *usecase.PrincipalPermissionUsecase
@@ -123,7 +160,11 @@ func (h *PrincipalPermissionHandler) List(w http.ResponseWriter, r *http.Request
 		writeError(w, http.StatusBadRequest, "user_id is required")
 		return
 	}
-	perms, err := h.Usecase.List(r.Context(), userID)
+	kind, ok := queryPrincipalKind(w, r)
+	if !ok {
+		return
+	}
+	perms, err := h.Usecase.List(r.Context(), userID, kind)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -131,6 +172,35 @@ func (h *PrincipalPermissionHandler) List(w http.ResponseWriter, r *http.Request
 	writeJSON(w, http.StatusOK, map[string][]string{"permissions": perms})
 }
 
+// Effective lists every permission of the principal with its scope and source.
+func (h *PrincipalPermissionHandler) Effective(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rbac principal permission use case is unavailable")
+		return
+	}
+	userID := strings.TrimSpace(r.URL.Query().Get("user_id"))
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+	kind, ok := queryPrincipalKind(w, r)
+	if !ok {
+		return
+	}
+	tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id"))
+	serviceID := strings.TrimSpace(r.URL.Query().Get("service_id"))
+	result, err := h.Usecase.Effective(r.Context(), userID, kind, tenantID, serviceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (h *PrincipalPermissionHandler) GetByPermission(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.NotFound(w, r)
@@ -146,10 +216,283 @@ func (h *PrincipalPermissionHandler) GetByPermission(w http.ResponseWriter, r *h
 		writeError(w, http.StatusBadRequest, "user_id and permission are required")
 		return
 	}
-	allowed, err := h.Usecase.GetByPermission(r.Context(), userID, permission)
+	kind, ok := queryPrincipalKind(w, r)
+	if !ok {
+		return
+	}
+	allowed, err := h.Usecase.GetByPermission(r.Context(), userID, kind, permission)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]bool{"allowed": allowed})
 }
+
+// ServiceManifestHandler lets services self-register their permission catalog.
+type ServiceManifestHandler struct {
+	Usecase *usecase.ServiceManifestUsecase
+}
+
+func (h *ServiceManifestHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rbac service manifest use case is unavailable")
+		return
+	}
+	var payload serviceManifestRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	manifest := repo.ServiceManifest{Key: payload.Key, Title: payload.Title}
+	for _, perm := range payload.Permissions {
+		manifest.Permissions = append(manifest.Permissions, repo.ManifestPermission{
+			Action:       perm.Action,
+			ResourceKind: perm.ResourceKind,
+			DefaultRoles: perm.DefaultRoles,
+		})
+	}
+	report, err := h.Usecase.Register(r.Context(), manifest)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrValidation):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrUnauthenticated):
+			w.Header().Set("WWW-Authenticate", `Basic realm="rbac"`)
+			writeError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, usecase.ErrForbidden):
+			writeError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, repo.ErrNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+type checkRequest struct {
+	PrincipalID   string  `json:"principal_id"`
+	PrincipalKind string  `json:"principal_kind"`
+	TenantID      *string `json:"tenant_id"`
+	ServiceID     *string `json:"service_id"`
+	Action        string  `json:"action"`
+	ResourceKind  string  `json:"resource_kind"`
+	ResourceID    *string `json:"resource_id"`
+	CorrelationID string  `json:"correlation_id"`
+	// Attributes feed grant and override conditions.
+	Attributes domainpdp.Attributes `json:"attributes"`
+}
+
+// CheckHandler exposes PDP decisions.
+type CheckHandler struct {
+	Engine *pdpadapter.Engine
+}
+
+func (h *CheckHandler) Check(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Engine == nil {
+		writeError(w, http.StatusInternalServerError, "rbac pdp engine is unavailable")
+		return
+	}
+	var payload checkRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	req, ok := checkRequestInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "principal_id, action and resource_kind are required")
+		return
+	}
+	result, err := h.Engine.Check(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *CheckHandler) Explain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Engine == nil {
+		writeError(w, http.StatusInternalServerError, "rbac pdp engine is unavailable")
+		return
+	}
+	var payload checkRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	req, ok := checkRequestInput(payload)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "principal_id, action and resource_kind are required")
+		return
+	}
+	result, err := h.Engine.Explain(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func checkRequestInput(payload checkRequest) (domainpdp.CheckRequest, bool) {
+	req := domainpdp.CheckRequest{
+		PrincipalID:   strings.TrimSpace(payload.PrincipalID),
+		PrincipalKind: model.PrincipalKind(strings.TrimSpace(payload.PrincipalKind)),
+		TenantID:      trimOptional(payload.TenantID),
+		ServiceID:     trimOptional(payload.ServiceID),
+		Action:        strings.TrimSpace(payload.Action),
+		ResourceKind:  strings.TrimSpace(payload.ResourceKind),
+		ResourceID:    trimOptional(payload.ResourceID),
+		CorrelationID: strings.TrimSpace(payload.CorrelationID),
+		Attributes:    payload.Attributes,
+	}
+	if req.PrincipalKind == "" {
+		req.PrincipalKind = model.PrincipalKindUser
+	}
+	return req, req.PrincipalID != "" && req.Action != "" && req.ResourceKind != ""
+}
+
+func trimOptional(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*v)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+type rebacCheckRequest struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	Subject  string `json:"subject"`
+}
+
+// RebacCheckHandler answers relationship-based Check and Expand requests.
+type RebacCheckHandler struct {
+	Usecase *usecase.RebacUsecase
+}
+
+// Check answers whether {"subject"} has {"relation"} on {"object"}.
+func (h *RebacCheckHandler) Check(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	var payload rebacCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	object, relation, ok := rebacUserset(w, payload)
+	if !ok {
+		return
+	}
+	subject, err := domainrebac.ParseSubject(strings.TrimSpace(payload.Subject))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	allowed, err := h.Usecase.Check(r.Context(), object, relation, subject)
+	if err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"allowed": allowed})
+}
+
+// Expand returns the userset tree of {"relation"} on {"object"}.
+func (h *RebacCheckHandler) Expand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "rebac use case is unavailable")
+		return
+	}
+	var payload rebacCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	object, relation, ok := rebacUserset(w, payload)
+	if !ok {
+		return
+	}
+	tree, err := h.Usecase.Expand(r.Context(), object, relation)
+	if err != nil {
+		writeRebacError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tree)
+}
+
+func rebacUserset(w http.ResponseWriter, payload rebacCheckRequest) (domainrebac.Object, string, bool) {
+	relation := strings.TrimSpace(payload.Relation)
+	if relation == "" {
+		writeError(w, http.StatusBadRequest, "object and relation are required")
+		return domainrebac.Object{}, "", false
+	}
+	object, err := domainrebac.ParseObject(strings.TrimSpace(payload.Object))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return domainrebac.Object{}, "", false
+	}
+	return object, relation, true
+}
+
+// BreakGlassActivationHandler lets a registered principal activate emergency superadmin.
+type BreakGlassActivationHandler struct {
+	Usecase *usecase.BreakGlassUsecase
+}
+
+func (h *BreakGlassActivationHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Usecase == nil {
+		writeError(w, http.StatusInternalServerError, "break-glass use case is unavailable")
+		return
+	}
+	var payload breakGlassActivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	principalID := strings.TrimSpace(payload.PrincipalID)
+	if principalID == "" || payload.Secret == "" {
+		writeError(w, http.StatusBadRequest, "principal_id and secret are required")
+		return
+	}
+	item, err := h.Usecase.Activate(r.Context(), principalID, principalKindOrDefault(payload.PrincipalKind),
+		payload.Secret, payload.Reason, time.Duration(payload.DurationSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, repo.ErrConflict) {
+			writeError(w, http.StatusConflict, "break-glass access is already active")
+			return
+		}
+		writeBreakGlassError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
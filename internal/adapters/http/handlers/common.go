@@ -2,9 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
 	"github.com/example/ms-rbac-service/pkg/pagination"
 )
 
@@ -26,6 +31,60 @@ type updateRoleRequest struct {
 	Title string `json:"title"`
 }
 
+type createGroupRequest struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type updateGroupRequest struct {
+	Title string `json:"title"`
+}
+
+type resourceRequest struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	ParentID string `json:"parent_id"`
+}
+
+type rebacNamespaceRequest struct {
+	Relations map[string]domainrebac.Rewrite `json:"relations"`
+}
+
+// relationTuplesRequest carries tuples in their object#relation@subject form.
+type relationTuplesRequest struct {
+	Tuples []string `json:"tuples"`
+}
+
+type createServiceAccountRequest struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type serviceAccountIDRequest struct {
+	ID string `json:"id"`
+}
+
+type groupMemberRequest struct {
+	GroupID    string `json:"group_id"`
+	MemberID   string `json:"member_id"`
+	MemberKind string `json:"member_kind"`
+}
+
+type roleCardinalityRequest struct {
+	RoleKey       string `json:"role_key"`
+	Scope         string `json:"scope"`
+	MaxPrincipals int    `json:"max_principals"`
+}
+
+type createSoDRuleRequest struct {
+	Key         string `json:"key"`
+	RoleA       string `json:"role_a"`
+	RoleB       string `json:"role_b"`
+	Kind        string `json:"kind"`
+	SameScope   bool   `json:"same_scope"`
+	Description string `json:"description"`
+}
+
 type createPermissionRequest struct {
 	Action       string `json:"action"`
 	ResourceKind string `json:"resource_kind"`
@@ -39,6 +98,108 @@ type updatePermissionRequest struct {
 type createRolePermissionRequest struct {
 	RoleKey      string `json:"role_key"`
 	PermissionID string `json:"permission_id"`
+	ResourceID   string `json:"resource_id"`
+	Condition    string `json:"condition"`
+}
+
+type serviceRoleRequest struct {
+	ServiceID string `json:"service_id"`
+	RoleKey   string `json:"role_key"`
+}
+
+type servicePermissionRequest struct {
+	ServiceID    string `json:"service_id"`
+	PermissionID string `json:"permission_id"`
+}
+
+type roleAssignmentRequest struct {
+	PrincipalID   string     `json:"principal_id"`
+	PrincipalKind string     `json:"principal_kind"`
+	RoleKey       string     `json:"role_key"`
+	TenantID      string     `json:"tenant_id"`
+	ServiceID     string     `json:"service_id"`
+	ResourceKind  string     `json:"resource_kind"`
+	ResourceID    string     `json:"resource_id"`
+	ValidFrom     *time.Time `json:"valid_from"`
+	ValidUntil    *time.Time `json:"valid_until"`
+}
+
+type removeRoleMembersRequest struct {
+	Principals    []repo.RoleMember `json:"principals"`
+	PrincipalKind string            `json:"principal_kind"`
+	TenantID      string            `json:"tenant_id"`
+	ServiceID     string            `json:"service_id"`
+	ResourceKind  string            `json:"resource_kind"`
+	ResourceID    string            `json:"resource_id"`
+}
+
+type overrideRequest struct {
+	PrincipalID   string     `json:"principal_id"`
+	PrincipalKind string     `json:"principal_kind"`
+	PermissionID  string     `json:"permission_id"`
+	Effect        string     `json:"effect"`
+	TenantID      string     `json:"tenant_id"`
+	ServiceID     string     `json:"service_id"`
+	ResourceKind  string     `json:"resource_kind"`
+	ResourceID    string     `json:"resource_id"`
+	ValidFrom     *time.Time `json:"valid_from"`
+	ValidUntil    *time.Time `json:"valid_until"`
+	Condition     string     `json:"condition"`
+}
+
+type superadminRequest struct {
+	PrincipalID   string `json:"principal_id"`
+	PrincipalKind string `json:"principal_kind"`
+}
+
+type elevationRequest struct {
+	PrincipalID     string `json:"principal_id"`
+	PrincipalKind   string `json:"principal_kind"`
+	RoleKey         string `json:"role_key"`
+	TenantID        string `json:"tenant_id"`
+	ServiceID       string `json:"service_id"`
+	ResourceKind    string `json:"resource_kind"`
+	ResourceID      string `json:"resource_id"`
+	Justification   string `json:"justification"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// elevationDecisionRequest carries no approver: decisions are made in the name of the
+// caller.
+type elevationDecisionRequest struct {
+	ID   string `json:"id"`
+	Note string `json:"note"`
+}
+
+type breakGlassPrincipalRequest struct {
+	PrincipalID        string `json:"principal_id"`
+	PrincipalKind      string `json:"principal_kind"`
+	MaxDurationSeconds int    `json:"max_duration_seconds"`
+}
+
+type breakGlassActivateRequest struct {
+	PrincipalID     string `json:"principal_id"`
+	PrincipalKind   string `json:"principal_kind"`
+	Secret          string `json:"secret"`
+	Reason          string `json:"reason"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// breakGlassReviewRequest carries no reviewer: reviews are recorded in the name of the
+// caller.
+type breakGlassReviewRequest struct {
+	ID    string `json:"id"`
+	Notes string `json:"notes"`
+}
+
+type serviceManifestRequest struct {
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Permissions []struct {
+		Action       string   `json:"action"`
+		ResourceKind string   `json:"resource_kind"`
+		DefaultRoles []string `json:"default_roles"`
+	} `json:"permissions"`
 }
 
 func parsePagination(r *http.Request) pagination.Params {
@@ -48,6 +209,19 @@ func parsePagination(r *http.Request) pagination.Params {
 	return pagination.NewParams(page, pageSize)
 }
 
+// parseTimeParam parses an optional RFC 3339 query value; ok is false when it is malformed.
+func parseTimeParam(v string) (*time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
 func parseInt(v string) int {
 	if v == "" {
 		return 0
@@ -66,10 +240,32 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 }
 
 func writeError(w http.ResponseWriter, status int, message string) {
+	writeErrorCode(w, status, "RBAC_ERROR", message)
+}
+
+// errCodeCardinalityExceeded marks an assignment rejected by a role cardinality limit.
+const errCodeCardinalityExceeded = "ROLE_CARDINALITY_EXCEEDED"
+
+// writeErrorCode is writeError with a code clients can match instead of RBAC_ERROR.
+func writeErrorCode(w http.ResponseWriter, status int, code, message string) {
 	writeJSON(w, status, map[string]interface{}{
 		"error": map[string]interface{}{
-			"code":    "RBAC_ERROR",
+			"code":    code,
 			"message": message,
 		},
 	})
 }
+
+// writeGrantConstraintError reports a role grant rejected by a separation-of-duties rule
+// or a cardinality limit and returns false for any other error.
+func writeGrantConstraintError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, repo.ErrSoDViolation):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, repo.ErrCardinalityExceeded):
+		writeErrorCode(w, http.StatusConflict, errCodeCardinalityExceeded, err.Error())
+	default:
+		return false
+	}
+	return true
+}
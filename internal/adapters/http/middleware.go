@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/internal/usecase"
+)
+
+const (
+	headerActorID       = "X-Actor-ID"
+	headerCorrelationID = "X-Correlation-ID"
+	headerRequestID     = "X-Request-ID"
+)
+
+// withAuditContext attaches the caller identity and a correlation id to the request
+// context so repository mutations can be attributed in the audit log.
+func withAuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlationID := strings.TrimSpace(r.Header.Get(headerCorrelationID))
+		if correlationID == "" {
+			correlationID = strings.TrimSpace(r.Header.Get(headerRequestID))
+		}
+		if correlationID == "" {
+			correlationID = newCorrelationID()
+		}
+		w.Header().Set(headerCorrelationID, correlationID)
+		ctx := audit.WithActor(r.Context(), audit.Actor{
+			ID:            strings.TrimSpace(r.Header.Get(headerActorID)),
+			Source:        audit.SourceHTTP,
+			CorrelationID: correlationID,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// unauthenticatedPaths carry their own credentials and stay reachable without a
+// service account, so break-glass still works when API authentication is required.
+var unauthenticatedPaths = map[string]bool{
+	"/break-glass/activate": true,
+}
+
+// serviceAccountAuthenticator verifies service-account credentials;
+// *usecase.ServiceAccountUsecase satisfies it.
+type serviceAccountAuthenticator interface {
+	Authenticate(ctx context.Context, id, secret string) (repo.ServiceAccount, error)
+}
+
+// withServiceAccountAuth authenticates public API callers presenting service-account
+// credentials as HTTP Basic auth (account id and secret). Authenticated calls are
+// attributed to the account in the audit log and carry it in their context, where use
+// cases such as manifest registration look it up. Calls without credentials pass
+// through unless required is set.
+func withServiceAccountAuth(next http.Handler, accounts serviceAccountAuthenticator, required bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			if required && !unauthenticatedPaths[r.URL.Path] {
+				writeUnauthorized(w, "service account credentials are required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if accounts == nil {
+			writeAuthError(w, http.StatusInternalServerError, "service account use case is unavailable")
+			return
+		}
+		sa, err := accounts.Authenticate(r.Context(), strings.TrimSpace(id), secret)
+		if err != nil {
+			if errors.Is(err, usecase.ErrUnauthenticated) {
+				writeUnauthorized(w, "invalid service account credentials")
+				return
+			}
+			writeAuthError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		actor := audit.ActorFromContext(r.Context())
+		actor.ID = sa.ID
+		ctx := usecase.WithServiceAccount(audit.WithActor(r.Context(), actor), sa)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="rbac"`)
+	writeAuthError(w, http.StatusUnauthorized, message)
+}
+
+// writeAuthError mirrors the handlers' error body.
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    "RBAC_ERROR",
+			"message": message,
+		},
+	})
+}
+
+func newCorrelationID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/internal/usecase"
+)
+
+type fakeAccounts struct {
+	accounts map[string]string
+	err      error
+}
+
+func (f fakeAccounts) Authenticate(_ context.Context, id, secret string) (repo.ServiceAccount, error) {
+	if f.err != nil {
+		return repo.ServiceAccount{}, f.err
+	}
+	if s, ok := f.accounts[id]; !ok || s != secret {
+		return repo.ServiceAccount{}, usecase.ErrUnauthenticated
+	}
+	return repo.ServiceAccount{ID: id, Key: "course"}, nil
+}
+
+func TestWithServiceAccountAuth(t *testing.T) {
+	accounts := fakeAccounts{accounts: map[string]string{"sa-1": "sa_secret"}}
+	type seen struct {
+		actor   string
+		account string
+	}
+	tests := []struct {
+		name       string
+		accounts   serviceAccountAuthenticator
+		required   bool
+		path       string
+		user, pass string
+		wantStatus int
+		wantSeen   *seen
+	}{
+		{name: "anonymous allowed", accounts: accounts, path: "/check", wantStatus: http.StatusOK, wantSeen: &seen{actor: "header-actor"}},
+		{name: "anonymous rejected", accounts: accounts, required: true, path: "/check", wantStatus: http.StatusUnauthorized},
+		{name: "anonymous break-glass", accounts: accounts, required: true, path: "/break-glass/activate", wantStatus: http.StatusOK, wantSeen: &seen{actor: "header-actor"}},
+		{name: "valid credentials", accounts: accounts, required: true, path: "/check", user: "sa-1", pass: "sa_secret", wantStatus: http.StatusOK, wantSeen: &seen{actor: "sa-1", account: "sa-1"}},
+		{name: "id is trimmed", accounts: accounts, path: "/check", user: " sa-1 ", pass: "sa_secret", wantStatus: http.StatusOK, wantSeen: &seen{actor: "sa-1", account: "sa-1"}},
+		{name: "wrong secret", accounts: accounts, path: "/check", user: "sa-1", pass: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "unknown account", accounts: accounts, path: "/check", user: "sa-2", pass: "sa_secret", wantStatus: http.StatusUnauthorized},
+		{name: "no authenticator", path: "/check", user: "sa-1", pass: "sa_secret", wantStatus: http.StatusInternalServerError},
+		{name: "backend error", accounts: fakeAccounts{err: errors.New("db down")}, path: "/check", user: "sa-1", pass: "sa_secret", wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *seen
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = &seen{actor: audit.ActorFromContext(r.Context()).ID}
+				if sa, ok := usecase.ServiceAccountFromContext(r.Context()); ok {
+					got.account = sa.ID
+				}
+			})
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req = req.WithContext(audit.WithActor(req.Context(), audit.Actor{ID: "header-actor", Source: audit.SourceHTTP}))
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			rec := httptest.NewRecorder()
+			withServiceAccountAuth(next, tt.accounts, tt.required).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+			switch {
+			case tt.wantSeen == nil && got != nil:
+				t.Errorf("request reached the handler: %+v", *got)
+			case tt.wantSeen != nil && got == nil:
+				t.Error("request did not reach the handler")
+			case tt.wantSeen != nil && *got != *tt.wantSeen:
+				t.Errorf("handler saw %+v, want %+v", *got, *tt.wantSeen)
+			}
+		})
+	}
+}
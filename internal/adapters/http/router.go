@@ -6,27 +6,40 @@ import (
 	adminv1 "github.com/example/ms-rbac-service/internal/adapters/http/admin/v1"
 	apiv1 "github.com/example/ms-rbac-service/internal/adapters/http/api/v1"
 	"github.com/example/ms-rbac-service/internal/adapters/http/handlers"
+	"github.com/example/ms-rbac-service/internal/usecase"
 )
 
 type Router struct {
-	adminHandlers *handlers.AdminHandlers
-	apiHandlers   *handlers.APIHandlers
+	adminHandlers   *handlers.AdminHandlers
+	apiHandlers     *handlers.APIHandlers
+	serviceAccounts serviceAccountAuthenticator
+	apiAuthRequired bool
 }
 
 func NewRouter(adminHandlers *handlers.AdminHandlers, apiHandlers *handlers.APIHandlers) *Router {
 	return &Router{adminHandlers: adminHandlers, apiHandlers: apiHandlers}
 }
 
+// WithServiceAccountAuth authenticates public API calls against service accounts;
+// required rejects calls that present no credentials.
+func (r *Router) WithServiceAccountAuth(accounts *usecase.ServiceAccountUsecase, required bool) *Router {
+	if accounts != nil {
+		r.serviceAccounts = accounts
+	}
+	r.apiAuthRequired = required
+	return r
+}
+
 func (r *Router) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	apiMux := http.NewServeMux()
 	apiv1.RegisterRoutes(apiMux, r.apiHandlers)
-	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", apiMux))
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", withServiceAccountAuth(apiMux, r.serviceAccounts, r.apiAuthRequired)))
 
 	adminMux := http.NewServeMux()
 	adminv1.RegisterRoutes(adminMux, r.adminHandlers)
 	mux.Handle("/admin/v1/", http.StripPrefix("/admin/v1", adminMux))
 
-	return mux
+	return withAuditContext(mux)
 }
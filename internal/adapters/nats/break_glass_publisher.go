@@ -0,0 +1,29 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/example/ms-rbac-service/internal/usecase"
+)
+
+// BreakGlassPublisher publishes break-glass events on "<Subject>.<type>", e.g.
+// rbac.break-glass.activated, so alerting can subscribe to "<Subject>.>".
+type BreakGlassPublisher struct {
+	Conn    *natsgo.Conn
+	Subject string
+}
+
+func (p BreakGlassPublisher) PublishBreakGlass(_ context.Context, event usecase.BreakGlassEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := p.Conn.Publish(p.Subject+"."+event.Type, data); err != nil {
+		return err
+	}
+	// Flush so the alert leaves the process before the request returns.
+	return p.Conn.Flush()
+}
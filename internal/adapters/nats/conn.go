@@ -1,6 +1,10 @@
 package nats
 
 import (
+	"context"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/domain/audit"
 	natsgo "github.com/nats-io/nats.go"
 )
 
@@ -8,3 +12,14 @@ import (
 func Connect(url string) (*natsgo.Conn, error) {
 	return natsgo.Connect(url)
 }
+
+// messageContext builds the request context of a message, attributing changes to the
+// X-Actor-ID / X-Correlation-ID headers when the publisher sets them.
+func messageContext(msg *natsgo.Msg) context.Context {
+	actor := audit.Actor{Source: audit.SourceNATS}
+	if msg.Header != nil {
+		actor.ID = strings.TrimSpace(msg.Header.Get("X-Actor-ID"))
+		actor.CorrelationID = strings.TrimSpace(msg.Header.Get("X-Correlation-ID"))
+	}
+	return audit.WithActor(context.Background(), actor)
+}
@@ -0,0 +1,28 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+// DecisionPublisher publishes each logged decision as a JSON message on Subject.
+type DecisionPublisher struct {
+	Conn    *natsgo.Conn
+	Subject string
+}
+
+func (p DecisionPublisher) WriteDecisions(_ context.Context, records []domainpdp.DecisionRecord) error {
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if err := p.Conn.Publish(p.Subject, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
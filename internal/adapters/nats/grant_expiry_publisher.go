@@ -0,0 +1,28 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+
+	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+// GrantExpiryPublisher publishes each expired grant as a JSON message on Subject.
+type GrantExpiryPublisher struct {
+	Conn    *natsgo.Conn
+	Subject string
+}
+
+func (p GrantExpiryPublisher) PublishExpired(_ context.Context, grants []repo.ExpiredGrant) error {
+	for _, g := range grants {
+		data, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		if err := p.Conn.Publish(p.Subject, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,11 +1,12 @@
 package nats
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 
 	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
 	natsgo "github.com/nats-io/nats.go"
 
 	"github.com/example/ms-rbac-service/internal/usecase"
@@ -20,13 +21,16 @@ type RoleAssigner struct {
 }
 
 type assignRoleRequest struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID        string              `json:"user_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	Role          string              `json:"role"`
 }
 
 type assignRoleResponse struct {
 	OK    bool   `json:"ok"`
 	Error string `json:"error,omitempty"`
+	// Code is set for failures clients may want to handle, e.g. ROLE_CARDINALITY_EXCEEDED.
+	Code string `json:"code,omitempty"`
 }
 
 // Listen subscribes to role assignment requests.
@@ -46,8 +50,16 @@ func (c RoleAssigner) Listen() error {
 			_ = msg.Respond(marshal(assignRoleResponse{OK: false, Error: "user_id and role are required"}))
 			return
 		}
-		if err := c.PrincipalUC.Update(context.Background(), req.UserID, repo.PrincipalRoleUpdate{RoleKey: req.Role}); err != nil {
-			_ = msg.Respond(marshal(assignRoleResponse{OK: false, Error: err.Error()}))
+		if req.PrincipalKind != "" && !req.PrincipalKind.Valid() {
+			_ = msg.Respond(marshal(assignRoleResponse{OK: false, Error: "unknown principal_kind"}))
+			return
+		}
+		if err := c.PrincipalUC.Update(messageContext(msg), req.UserID, req.PrincipalKind, repo.PrincipalRoleUpdate{RoleKey: req.Role}); err != nil {
+			resp := assignRoleResponse{OK: false, Error: err.Error()}
+			if errors.Is(err, repo.ErrCardinalityExceeded) {
+				resp.Code = "ROLE_CARDINALITY_EXCEEDED"
+			}
+			_ = msg.Respond(marshal(resp))
 			return
 		}
 		_ = msg.Respond(marshal(assignRoleResponse{OK: true}))
@@ -1,11 +1,11 @@
 package nats
 
 import (
-	"context"
 	"encoding/json"
 
 	natsgo "github.com/nats-io/nats.go"
 
+	"github.com/example/ms-rbac-service/internal/domain/model"
 	"github.com/example/ms-rbac-service/internal/usecase"
 )
 
@@ -18,8 +18,9 @@ type RoleChecker struct {
 }
 
 type roleCheckRequest struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID        string              `json:"user_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	Role          string              `json:"role"`
 }
 
 type roleCheckResponse struct {
@@ -38,7 +39,7 @@ func (c RoleChecker) Listen() error {
 			_ = msg.Respond(marshal(roleCheckResponse{OK: false, Error: "invalid payload"}))
 			return
 		}
-		ok, err := c.PrincipalUC.GetByRole(context.Background(), req.UserID, req.Role)
+		ok, err := c.PrincipalUC.GetByRole(messageContext(msg), req.UserID, req.PrincipalKind, req.Role)
 		if err != nil {
 			_ = msg.Respond(marshal(roleCheckResponse{OK: false, Error: err.Error()}))
 			return
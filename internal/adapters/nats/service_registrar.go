@@ -0,0 +1,113 @@
+package nats
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	repo "github.com/example/ms-rbac-service/internal/adapters/postgres"
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/internal/usecase"
+)
+
+// ServiceRegistrar handles rbac.register-service requests. Publishers authenticate as
+// the service's account with an Authorization header holding HTTP Basic credentials.
+type ServiceRegistrar struct {
+	Conn       *natsgo.Conn
+	Subject    string
+	Queue      string
+	ManifestUC *usecase.ServiceManifestUsecase
+	Accounts   *usecase.ServiceAccountUsecase
+}
+
+type registerServiceRequest struct {
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Permissions []struct {
+		Action       string   `json:"action"`
+		ResourceKind string   `json:"resource_kind"`
+		DefaultRoles []string `json:"default_roles"`
+	} `json:"permissions"`
+}
+
+type registerServiceResponse struct {
+	OK     bool                        `json:"ok"`
+	Error  string                      `json:"error,omitempty"`
+	Report *repo.ServiceManifestReport `json:"report,omitempty"`
+}
+
+// Listen subscribes to service manifest registrations.
+func (c ServiceRegistrar) Listen() error {
+	if c.Conn == nil || c.ManifestUC == nil {
+		return nil
+	}
+	_, err := c.Conn.QueueSubscribe(c.Subject, c.Queue, func(msg *natsgo.Msg) {
+		var req registerServiceRequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			_ = msg.Respond(marshal(registerServiceResponse{OK: false, Error: "invalid payload"}))
+			return
+		}
+		manifest := repo.ServiceManifest{Key: req.Key, Title: req.Title}
+		for _, perm := range req.Permissions {
+			manifest.Permissions = append(manifest.Permissions, repo.ManifestPermission{
+				Action:       perm.Action,
+				ResourceKind: perm.ResourceKind,
+				DefaultRoles: perm.DefaultRoles,
+			})
+		}
+		ctx, err := c.authenticate(msg)
+		if err != nil {
+			_ = msg.Respond(marshal(registerServiceResponse{OK: false, Error: err.Error()}))
+			return
+		}
+		report, err := c.ManifestUC.Register(ctx, manifest)
+		if err != nil {
+			_ = msg.Respond(marshal(registerServiceResponse{OK: false, Error: err.Error()}))
+			return
+		}
+		_ = msg.Respond(marshal(registerServiceResponse{OK: true, Report: report}))
+	})
+	return err
+}
+
+// authenticate returns the message context, carrying the service account when the
+// message presents valid credentials. Messages without credentials are left for the use
+// case to reject.
+func (c ServiceRegistrar) authenticate(msg *natsgo.Msg) (context.Context, error) {
+	ctx := messageContext(msg)
+	if msg.Header == nil {
+		return ctx, nil
+	}
+	id, secret, ok := basicCredentials(msg.Header.Get("Authorization"))
+	if !ok {
+		return ctx, nil
+	}
+	if c.Accounts == nil {
+		return nil, errors.New("service account use case is unavailable")
+	}
+	sa, err := c.Accounts.Authenticate(ctx, id, secret)
+	if err != nil {
+		return nil, err
+	}
+	actor := audit.ActorFromContext(ctx)
+	actor.ID = sa.ID
+	return usecase.WithServiceAccount(audit.WithActor(ctx, actor), sa), nil
+}
+
+// basicCredentials parses an HTTP Basic Authorization header value.
+func basicCredentials(header string) (id, secret string, ok bool) {
+	encoded, found := strings.CutPrefix(header, "Basic ")
+	if !found {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(string(decoded), ":")
+	return strings.TrimSpace(id), secret, ok
+}
@@ -0,0 +1,46 @@
+package pdp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+)
+
+// FileDecisionSink appends decision records to a file as newline-delimited JSON.
+type FileDecisionSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileDecisionSink opens path for appending, creating it when missing.
+func NewFileDecisionSink(path string) (*FileDecisionSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDecisionSink{file: f}, nil
+}
+
+func (s *FileDecisionSink) WriteDecisions(_ context.Context, records []domainpdp.DecisionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Close closes the underlying file.
+func (s *FileDecisionSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,123 @@
+package pdp
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+)
+
+// DecisionLoggerConfig tunes sampling and batching of the decision log.
+type DecisionLoggerConfig struct {
+	// SampleRate is the fraction of decisions recorded, between 0 and 1.
+	SampleRate float64
+	// BatchSize is the number of records written to the sink at once.
+	BatchSize int
+	// FlushInterval bounds how long a partial batch waits before it is written.
+	FlushInterval time.Duration
+	// BufferSize is the queue length; decisions arriving while it is full are dropped.
+	BufferSize int
+}
+
+// DecisionLogger records PDP decisions asynchronously. Log never blocks: records are
+// queued on a buffered channel and written in batches by a background goroutine.
+type DecisionLogger struct {
+	sink    domainpdp.DecisionSink
+	cfg     DecisionLoggerConfig
+	queue   chan domainpdp.DecisionRecord
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// NewDecisionLogger starts a logger writing to sink.
+func NewDecisionLogger(sink domainpdp.DecisionSink, cfg DecisionLoggerConfig) *DecisionLogger {
+	if cfg.SampleRate < 0 {
+		cfg.SampleRate = 0
+	}
+	if cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.BufferSize < cfg.BatchSize {
+		cfg.BufferSize = cfg.BatchSize * 10
+	}
+	l := &DecisionLogger{
+		sink:  sink,
+		cfg:   cfg,
+		queue: make(chan domainpdp.DecisionRecord, cfg.BufferSize),
+		done:  make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Sampled reports whether the next decision should be recorded.
+func (l *DecisionLogger) Sampled() bool {
+	return l.cfg.SampleRate >= 1 || (l.cfg.SampleRate > 0 && rand.Float64() < l.cfg.SampleRate)
+}
+
+// Log queues rec, dropping it when the buffer is full.
+func (l *DecisionLogger) Log(rec domainpdp.DecisionRecord) {
+	select {
+	case l.queue <- rec:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Close stops accepting records and flushes what is queued, waiting until ctx expires.
+func (l *DecisionLogger) Close(ctx context.Context) error {
+	l.once.Do(func() { close(l.queue) })
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *DecisionLogger) run() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.cfg.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]domainpdp.DecisionRecord, 0, l.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := l.sink.WriteDecisions(ctx, batch); err != nil {
+			log.Printf("decision log: write %d records failed: %v", len(batch), err)
+		}
+		cancel()
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case rec, ok := <-l.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= l.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			if n := l.dropped.Swap(0); n > 0 {
+				log.Printf("decision log: dropped %d records, buffer full", n)
+			}
+		}
+	}
+}
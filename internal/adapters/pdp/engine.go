@@ -3,7 +3,7 @@ package pdp
 import (
 	"context"
 	"errors"
-	"sort"
+	"time"
 
 	"github.com/example/ms-rbac-service/internal/domain/model"
 	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
@@ -11,29 +11,97 @@ import (
 
 // Engine evaluates authorisation requests using repository backed data.
 type Engine struct {
-	repo domainpdp.Repository
+	repo      domainpdp.Repository
+	decisions *DecisionLogger
+	sod       domainpdp.SoDRuleSource
+	now       func() time.Time
 }
 
 // NewEngine constructs a new Engine instance.
 func NewEngine(repo domainpdp.Repository) *Engine {
-	return &Engine{repo: repo}
+	return &Engine{repo: repo, now: time.Now}
+}
+
+// WithDecisionLogger records sampled Check decisions through l.
+func (e *Engine) WithDecisionLogger(l *DecisionLogger) *Engine {
+	e.decisions = l
+	return e
+}
+
+// WithSoDRules flags checks whose resolved roles violate a dynamic separation-of-duties
+// rule loaded from src.
+func (e *Engine) WithSoDRules(src domainpdp.SoDRuleSource) *Engine {
+	e.sod = src
+	return e
 }
 
 // Check executes a single PDP decision.
 func (e *Engine) Check(ctx context.Context, req domainpdp.CheckRequest) (domainpdp.CheckResult, error) {
+	if e.decisions == nil || !e.decisions.Sampled() {
+		result, _, err := e.evaluate(ctx, req)
+		return result, err
+	}
+	start := time.Now()
+	result, matched, err := e.evaluate(ctx, req)
+	rec := domainpdp.DecisionRecord{
+		OccurredAt:    start.UTC(),
+		PrincipalID:   req.PrincipalID,
+		PrincipalKind: req.PrincipalKind,
+		TenantID:      req.TenantID,
+		ServiceID:     req.ServiceID,
+		Action:        req.Action,
+		ResourceKind:  req.ResourceKind,
+		ResourceID:    req.ResourceID,
+		CorrelationID: req.CorrelationID,
+		Allow:         result.Allow,
+		Decision:      result.Decision,
+		RoleKeys:      result.RoleKeys,
+		Matched:       matched,
+		SoDConflicts:  result.SoDConflicts,
+		LatencyMicros: time.Since(start).Microseconds(),
+	}
+	if err != nil {
+		rec.Decision = "error"
+		rec.Error = err.Error()
+	}
+	e.decisions.Log(rec)
+	return result, err
+}
+
+// Explain executes a PDP decision and reports the artefact that produced it:
+// the superadmin principal, the winning override or the matched role permission.
+func (e *Engine) Explain(ctx context.Context, req domainpdp.CheckRequest) (domainpdp.ExplainResult, error) {
+	result, matched, err := e.evaluate(ctx, req)
+	if err != nil {
+		return domainpdp.ExplainResult{}, err
+	}
+	return domainpdp.ExplainResult{Allow: result.Allow, Decision: result.Decision, Matched: matched, SoDConflicts: result.SoDConflicts}, nil
+}
+
+func (e *Engine) evaluate(ctx context.Context, req domainpdp.CheckRequest) (domainpdp.CheckResult, interface{}, error) {
 	// Rule 1: superadmin
-	isSuper, err := e.repo.GetByPrincipal(req.PrincipalID, req.PrincipalKind)
+	isSuper, err := e.repo.GetByPrincipal(ctx, req.PrincipalID, req.PrincipalKind)
 	if err != nil {
-		return domainpdp.CheckResult{}, err
+		return domainpdp.CheckResult{}, nil, err
 	}
 	if isSuper {
-		return domainpdp.CheckResult{Allow: true, Decision: "superadmin", RoleKeys: nil, CorrelationID: req.CorrelationID}, nil
+		matched := model.SuperadminPrincipal{PrincipalID: req.PrincipalID, PrincipalKind: req.PrincipalKind}
+		return domainpdp.CheckResult{Allow: true, Decision: "superadmin", RoleKeys: nil, CorrelationID: req.CorrelationID}, matched, nil
+	}
+
+	if req.ResourceID != nil {
+		ancestors, err := e.repo.ResourceAncestors(ctx, *req.ResourceID)
+		if err != nil {
+			return domainpdp.CheckResult{}, nil, err
+		}
+		req.Ancestors = ancestors
 	}
+	req = req.WithBuiltinAttributes(e.now())
 
 	// Rule 2: overrides with specificity ordering
-	override, err := e.repo.GetByRequest(req)
+	override, err := e.repo.GetByRequest(ctx, req)
 	if err != nil {
-		return domainpdp.CheckResult{}, err
+		return domainpdp.CheckResult{}, nil, err
 	}
 	if override != nil {
 		allow := override.Effect == model.OverrideEffectAllow
@@ -41,101 +109,127 @@ func (e *Engine) Check(ctx context.Context, req domainpdp.CheckRequest) (domainp
 		if !allow {
 			decision = "deny"
 		}
-		return domainpdp.CheckResult{Allow: allow, Decision: decision, CorrelationID: req.CorrelationID}, nil
+		return domainpdp.CheckResult{Allow: allow, Decision: decision, CorrelationID: req.CorrelationID}, override, nil
 	}
 
-	roles, err := e.repo.List(req)
+	roles, err := e.repo.List(ctx, req)
 	if err != nil {
-		return domainpdp.CheckResult{}, err
+		return domainpdp.CheckResult{}, nil, err
 	}
 	if len(roles) == 0 {
-		return domainpdp.CheckResult{Allow: false, Decision: "deny", CorrelationID: req.CorrelationID}, nil
+		return domainpdp.CheckResult{Allow: false, Decision: "deny", CorrelationID: req.CorrelationID}, nil, nil
 	}
 
+	// A role held in several scopes, or also inherited through the hierarchy, is
+	// listed once per scope; report and load it once.
 	roleIDs := make([]string, 0, len(roles))
 	roleKeys := make([]string, 0, len(roles))
+	seen := make(map[string]bool, len(roles))
 	for _, r := range roles {
+		if seen[r.RoleID] {
+			continue
+		}
+		seen[r.RoleID] = true
 		roleIDs = append(roleIDs, r.RoleID)
 		roleKeys = append(roleKeys, r.RoleKey)
 	}
 
-	perms, err := e.repo.ListByRoleIDs(roleIDs)
+	perms, err := e.repo.ListByRoleIDs(ctx, roleIDs)
+	if err != nil {
+		return domainpdp.CheckResult{}, nil, err
+	}
+	conflicts, err := e.sodConflicts(ctx, roleKeys)
 	if err != nil {
-		return domainpdp.CheckResult{}, err
+		return domainpdp.CheckResult{}, nil, err
+	}
+
+	if matched, ok := matchPermission(perms, req, roles); ok {
+		return domainpdp.CheckResult{Allow: true, Decision: "role", RoleKeys: roleKeys, CorrelationID: req.CorrelationID, SoDConflicts: conflicts}, matched, nil
 	}
+	return domainpdp.CheckResult{Allow: false, Decision: "deny", RoleKeys: roleKeys, CorrelationID: req.CorrelationID, SoDConflicts: conflicts}, nil, nil
+}
 
-	if matchPermission(perms, req.Action, req.ResourceKind, req.ResourceID, req.ServiceID, roles) {
-		return domainpdp.CheckResult{Allow: true, Decision: "role", RoleKeys: roleKeys, CorrelationID: req.CorrelationID}, nil
+// sodConflicts reports the dynamic separation-of-duties rules violated by roleKeys.
+func (e *Engine) sodConflicts(ctx context.Context, roleKeys []string) ([]domainpdp.SoDConflict, error) {
+	if e.sod == nil || len(roleKeys) < 2 {
+		return nil, nil
+	}
+	rules, err := e.sod.DynamicSoDRules(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return domainpdp.CheckResult{Allow: false, Decision: "deny", RoleKeys: roleKeys, CorrelationID: req.CorrelationID}, nil
+	return domainpdp.DetectSoDConflicts(rules, roleKeys), nil
 }
 
-func matchPermission(perms []domainpdp.RolePermissionItem, action, resourceKind string, resourceID *string, serviceID *string, roles []domainpdp.RoleWithScope) bool {
+// matchPermission finds a permission of roles allowing req. Role scopes and resource
+// grants on an ancestor of the requested resource cover the resource as well. A role
+// listed more than once, held in several scopes or inherited through several assigned
+// roles, allows a permission when any of its entries does. A grant whose condition does
+// not hold, or fails to evaluate, allows nothing.
+func matchPermission(perms []domainpdp.RolePermissionItem, req domainpdp.CheckRequest, roles []domainpdp.RoleWithScope) (domainpdp.RolePermissionItem, bool) {
 	if len(perms) == 0 {
-		return false
+		return domainpdp.RolePermissionItem{}, false
 	}
 
-	roleScopes := map[string]domainpdp.OverrideScope{}
-	roleServiceLimit := map[string][]string{}
+	path := req.ResourcePath()
+	inScope := map[string]bool{}
 	for _, r := range roles {
-		roleScopes[r.RoleID] = r.Scope
-		if len(r.ServiceIDs) > 0 {
-			roleServiceLimit[r.RoleID] = r.ServiceIDs
+		if inScope[r.RoleID] || !scopeMatches(r.Scope, req.ServiceID, path) || !serviceAllowed(r.ServiceIDs, req.ServiceID) {
+			continue
 		}
+		inScope[r.RoleID] = true
 	}
 
-	// pre-sort service limits for binary search
-	for _, ids := range roleServiceLimit {
-		sort.Strings(ids)
-	}
-
+	var best domainpdp.RolePermissionItem
+	bestScore, found := 0, false
 	for _, p := range perms {
-		scope := roleScopes[p.RoleID]
-		if !scopeMatches(scope, serviceID, resourceKind, resourceID) {
+		if !inScope[p.RoleID] {
 			continue
 		}
-		if ids, ok := roleServiceLimit[p.RoleID]; ok {
-			if serviceID == nil {
-				continue
-			}
-			idx := sort.SearchStrings(ids, *serviceID)
-			if idx >= len(ids) || ids[idx] != *serviceID {
-				continue
-			}
-		}
-		if p.Action != action {
+		if !domainpdp.MatchPattern(p.Action, req.Action) || !domainpdp.MatchResourcePermission(path, p.ResourceKind, p.ResourceID) {
 			continue
 		}
-		if p.ResourceKind != resourceKind && p.ResourceKind != "*" {
+		if holds, err := req.ConditionHolds(p.Condition); err != nil || !holds {
 			continue
 		}
+		// Every match allows; the most specific one is reported as the matched
+		// permission so Explain is deterministic.
+		score := domainpdp.PermissionSpecificity(p.Action, p.ResourceKind) << 1
 		if p.ResourceID != nil {
-			if resourceID == nil || *p.ResourceID != *resourceID {
-				continue
-			}
+			score |= 1
+		}
+		if !found || score > bestScore {
+			best, bestScore, found = p, score, true
 		}
+	}
+	return best, found
+}
+
+// serviceAllowed reports whether a role bound to serviceIDs via service_role applies to
+// the requested service. A role bound to no service applies to every service.
+func serviceAllowed(serviceIDs []string, serviceID *string) bool {
+	if len(serviceIDs) == 0 {
 		return true
 	}
+	if serviceID == nil {
+		return false
+	}
+	for _, id := range serviceIDs {
+		if id == *serviceID {
+			return true
+		}
+	}
 	return false
 }
 
-func scopeMatches(scope domainpdp.OverrideScope, serviceID *string, resourceKind string, resourceID *string) bool {
+func scopeMatches(scope domainpdp.OverrideScope, serviceID *string, path []domainpdp.ResourceNode) bool {
 	if scope.ServiceID != nil {
 		if serviceID == nil || *scope.ServiceID != *serviceID {
 			return false
 		}
 	}
-	if scope.ResourceKind != nil {
-		if *scope.ResourceKind != resourceKind {
-			return false
-		}
-	}
-	if scope.ResourceID != nil {
-		if resourceID == nil || *scope.ResourceID != *resourceID {
-			return false
-		}
-	}
-	return true
+	_, ok := domainpdp.MatchResourceScope(path, scope.ResourceKind, scope.ResourceID)
+	return ok
 }
 
 var ErrRepositoryNotImplemented = errors.New("repository method not implemented")
@@ -0,0 +1,256 @@
+package pdp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+)
+
+type stubRepository struct {
+	override  *domainpdp.OverrideMatch
+	roles     []domainpdp.RoleWithScope
+	perms     []domainpdp.RolePermissionItem
+	ancestors map[string][]domainpdp.ResourceNode
+}
+
+func (s stubRepository) GetByPrincipal(context.Context, string, model.PrincipalKind) (bool, error) {
+	return false, nil
+}
+
+func (s stubRepository) GetByRequest(context.Context, domainpdp.CheckRequest) (*domainpdp.OverrideMatch, error) {
+	return s.override, nil
+}
+
+func (s stubRepository) List(context.Context, domainpdp.CheckRequest) ([]domainpdp.RoleWithScope, error) {
+	return s.roles, nil
+}
+
+func (s stubRepository) ListByRoleIDs(context.Context, []string) ([]domainpdp.RolePermissionItem, error) {
+	return s.perms, nil
+}
+
+func (s stubRepository) ResourceAncestors(_ context.Context, id string) ([]domainpdp.ResourceNode, error) {
+	return s.ancestors[id], nil
+}
+
+func grant(id, action, kind string) domainpdp.RolePermissionItem {
+	return domainpdp.RolePermissionItem{RoleID: "r1", RoleKey: "teacher", PermissionID: id, Action: action, ResourceKind: kind}
+}
+
+func explain(t *testing.T, repo stubRepository, action, kind string) domainpdp.ExplainResult {
+	t.Helper()
+	result, err := NewEngine(repo).Explain(context.Background(), domainpdp.CheckRequest{
+		PrincipalID: "p1", PrincipalKind: model.PrincipalKindUser, Action: action, ResourceKind: kind,
+	})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	return result
+}
+
+func TestRolePermissionWildcards(t *testing.T) {
+	roles := []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher"}}
+	tests := []struct {
+		name        string
+		perms       []domainpdp.RolePermissionItem
+		action      string
+		kind        string
+		wantAllow   bool
+		wantMatched string
+	}{
+		{"any action and kind", []domainpdp.RolePermissionItem{grant("all", "*", "*")}, "delete", "course", true, "all"},
+		{"kind prefix", []domainpdp.RolePermissionItem{grant("course", "read", "course.*")}, "read", "course.lesson", true, "course"},
+		{"kind prefix excludes the bare kind", []domainpdp.RolePermissionItem{grant("course", "read", "course.*")}, "read", "course", false, ""},
+		{"action prefix", []domainpdp.RolePermissionItem{grant("read", "read:*", "course")}, "read:draft", "course", true, "read"},
+		{"other action", []domainpdp.RolePermissionItem{grant("read", "read", "*")}, "write", "course", false, ""},
+		{
+			"exact match is reported over wildcards",
+			[]domainpdp.RolePermissionItem{grant("all", "*", "*"), grant("kind", "read", "*"), grant("exact", "read", "course")},
+			"read", "course", true, "exact",
+		},
+		{
+			"exact action outranks exact kind",
+			[]domainpdp.RolePermissionItem{grant("kind", "*", "course"), grant("action", "read", "*")},
+			"read", "course", true, "action",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := explain(t, stubRepository{roles: roles, perms: tt.perms}, tt.action, tt.kind)
+			if result.Allow != tt.wantAllow {
+				t.Fatalf("Allow = %v, want %v", result.Allow, tt.wantAllow)
+			}
+			if !tt.wantAllow {
+				return
+			}
+			matched, ok := result.Matched.(domainpdp.RolePermissionItem)
+			if !ok || matched.PermissionID != tt.wantMatched {
+				t.Fatalf("Matched = %+v, want permission %s", result.Matched, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestOverrideTakesPrecedenceOverWildcardRole(t *testing.T) {
+	repo := stubRepository{
+		override: &domainpdp.OverrideMatch{Effect: model.OverrideEffectDeny, PermissionID: "deny"},
+		roles:    []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher"}},
+		perms:    []domainpdp.RolePermissionItem{grant("all", "*", "*")},
+	}
+	result := explain(t, repo, "read", "course")
+	if result.Allow || result.Decision != "deny" {
+		t.Fatalf("result = %+v, want an override deny", result)
+	}
+}
+
+func TestResourceAncestryInheritance(t *testing.T) {
+	course, lesson, other := "course-1", "lesson-1", "course-2"
+	ancestors := map[string][]domainpdp.ResourceNode{
+		lesson: {{Kind: "course", ID: &course}},
+	}
+	kind := "course"
+	tests := []struct {
+		name      string
+		roles     []domainpdp.RoleWithScope
+		perms     []domainpdp.RolePermissionItem
+		kind      string
+		id        string
+		wantAllow bool
+	}{
+		{
+			name:      "role scoped to the course covers its lesson",
+			roles:     []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher", Scope: domainpdp.OverrideScope{ResourceKind: &kind, ResourceID: &course}}},
+			perms:     []domainpdp.RolePermissionItem{grant("edit", "edit", "lesson")},
+			kind:      "lesson",
+			id:        lesson,
+			wantAllow: true,
+		},
+		{
+			name:  "role scoped to another course does not",
+			roles: []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher", Scope: domainpdp.OverrideScope{ResourceID: &other}}},
+			perms: []domainpdp.RolePermissionItem{grant("edit", "edit", "lesson")},
+			kind:  "lesson",
+			id:    lesson,
+		},
+		{
+			name:      "permission on the course kind covers its lesson",
+			roles:     []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher"}},
+			perms:     []domainpdp.RolePermissionItem{grant("edit", "edit", "course")},
+			kind:      "lesson",
+			id:        lesson,
+			wantAllow: true,
+		},
+		{
+			name:  "unregistered lesson is matched on its own",
+			roles: []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher", Scope: domainpdp.OverrideScope{ResourceID: &course}}},
+			perms: []domainpdp.RolePermissionItem{grant("edit", "edit", "lesson")},
+			kind:  "lesson",
+			id:    "lesson-2",
+		},
+		{
+			name: "role held on another course as well as this one",
+			roles: []domainpdp.RoleWithScope{
+				{RoleID: "r1", RoleKey: "teacher", Scope: domainpdp.OverrideScope{ResourceID: &course}},
+				{RoleID: "r1", RoleKey: "teacher", Scope: domainpdp.OverrideScope{ResourceID: &other}},
+			},
+			perms:     []domainpdp.RolePermissionItem{grant("edit", "edit", "lesson")},
+			kind:      "lesson",
+			id:        lesson,
+			wantAllow: true,
+		},
+		{
+			name:  "grant on a lesson does not cover its course",
+			roles: []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher", Scope: domainpdp.OverrideScope{ResourceID: &lesson}}},
+			perms: []domainpdp.RolePermissionItem{grant("edit", "edit", "*")},
+			kind:  "course",
+			id:    course,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := stubRepository{roles: tt.roles, perms: tt.perms, ancestors: ancestors}
+			id := tt.id
+			result, err := NewEngine(repo).Explain(context.Background(), domainpdp.CheckRequest{
+				PrincipalID: "p1", PrincipalKind: model.PrincipalKindUser, Action: "edit", ResourceKind: tt.kind, ResourceID: &id,
+			})
+			if err != nil {
+				t.Fatalf("Explain: %v", err)
+			}
+			if result.Allow != tt.wantAllow {
+				t.Fatalf("Allow = %v, want %v", result.Allow, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	monday10 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	roles := []domainpdp.RoleWithScope{{RoleID: "r1", RoleKey: "teacher"}}
+	conditional := func(condition string) []domainpdp.RolePermissionItem {
+		perm := grant("edit", "edit", "course")
+		perm.Condition = condition
+		return []domainpdp.RolePermissionItem{perm}
+	}
+	tests := []struct {
+		name       string
+		repo       stubRepository
+		attributes domainpdp.Attributes
+		now        time.Time
+		wantAllow  bool
+	}{
+		{
+			name:       "owner condition holds",
+			repo:       stubRepository{roles: roles, perms: conditional("resource.owner == principal.id")},
+			attributes: domainpdp.Attributes{Resource: map[string]any{"owner": "p1"}},
+			now:        monday10,
+			wantAllow:  true,
+		},
+		{
+			name:       "owner condition fails",
+			repo:       stubRepository{roles: roles, perms: conditional("resource.owner == principal.id")},
+			attributes: domainpdp.Attributes{Resource: map[string]any{"owner": "p2"}},
+			now:        monday10,
+		},
+		{
+			name:       "caller cannot override built-in attributes",
+			repo:       stubRepository{roles: roles, perms: conditional("resource.owner == principal.id")},
+			attributes: domainpdp.Attributes{Principal: map[string]any{"id": "p2"}, Resource: map[string]any{"owner": "p2"}},
+			now:        monday10,
+		},
+		{
+			name:      "business hours",
+			repo:      stubRepository{roles: roles, perms: conditional(`env.hour >= 9 && env.hour < 17 && !(env.weekday in ["saturday", "sunday"])`)},
+			now:       monday10,
+			wantAllow: true,
+		},
+		{
+			name: "outside business hours",
+			repo: stubRepository{roles: roles, perms: conditional(`env.hour >= 9 && env.hour < 17`)},
+			now:  monday10.Add(8 * time.Hour),
+		},
+		{
+			name: "evaluation error denies the grant",
+			repo: stubRepository{roles: roles, perms: conditional("resource.level > 2")},
+			now:  monday10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(tt.repo)
+			engine.now = func() time.Time { return tt.now }
+			result, err := engine.Explain(context.Background(), domainpdp.CheckRequest{
+				PrincipalID: "p1", PrincipalKind: model.PrincipalKindUser, Action: "edit", ResourceKind: "course",
+				Attributes: tt.attributes,
+			})
+			if err != nil {
+				t.Fatalf("Explain: %v", err)
+			}
+			if result.Allow != tt.wantAllow {
+				t.Fatalf("Allow = %v, want %v (%+v)", result.Allow, tt.wantAllow, result)
+			}
+		})
+	}
+}
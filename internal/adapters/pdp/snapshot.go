@@ -0,0 +1,560 @@
+package pdp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+)
+
+// maxSnapshotResourceDepth bounds how far ResourceAncestors walks up the resource
+// registry, as the Postgres repository does.
+const maxSnapshotResourceDepth = 32
+
+const (
+	snapshotRetryMin = time.Second
+	snapshotRetryMax = 30 * time.Second
+)
+
+// After a change arrives the snapshot keeps collecting changes for snapshotBatchWait,
+// up to snapshotBatchMax of them, so a burst touching the same rows loads them once.
+const (
+	snapshotBatchWait = 10 * time.Millisecond
+	snapshotBatchMax  = 256
+)
+
+type principalKey struct {
+	id   string
+	kind model.PrincipalKind
+}
+
+func snapshotPrincipalKey(id string, kind model.PrincipalKind) principalKey {
+	return principalKey{id: strings.ToLower(id), kind: kind}
+}
+
+// snapshotIndex holds a policy snapshot indexed for the engine's lookups.
+type snapshotIndex struct {
+	superadmins map[principalKey]bool
+	activations map[principalKey][]time.Time
+	disabled    map[string]bool
+	overrides   map[principalKey][]domainpdp.SnapshotOverride
+	assignments map[principalKey][]domainpdp.SnapshotAssignment
+	groups      map[principalKey][]string
+	roles       map[string]domainpdp.SnapshotRole
+	grants      map[string][]domainpdp.SnapshotGrant
+	permissions map[string]domainpdp.SnapshotPermission
+	resources   map[string]domainpdp.SnapshotResource
+	sodRules    []domainpdp.SnapshotSoDRule
+}
+
+func newSnapshotIndex() *snapshotIndex {
+	return &snapshotIndex{
+		superadmins: map[principalKey]bool{},
+		activations: map[principalKey][]time.Time{},
+		disabled:    map[string]bool{},
+		overrides:   map[principalKey][]domainpdp.SnapshotOverride{},
+		assignments: map[principalKey][]domainpdp.SnapshotAssignment{},
+		groups:      map[principalKey][]string{},
+		roles:       map[string]domainpdp.SnapshotRole{},
+		grants:      map[string][]domainpdp.SnapshotGrant{},
+		permissions: map[string]domainpdp.SnapshotPermission{},
+		resources:   map[string]domainpdp.SnapshotResource{},
+	}
+}
+
+// add indexes the rows of state.
+func (x *snapshotIndex) add(state domainpdp.SnapshotState) {
+	for _, p := range state.Superadmins {
+		x.superadmins[snapshotPrincipalKey(p.ID, p.Kind)] = true
+	}
+	for _, a := range state.Activations {
+		key := snapshotPrincipalKey(a.Principal.ID, a.Principal.Kind)
+		x.activations[key] = append(x.activations[key], a.ExpiresAt)
+	}
+	for _, id := range state.DisabledServiceAccounts {
+		x.disabled[strings.ToLower(id)] = true
+	}
+	for _, o := range state.Overrides {
+		key := snapshotPrincipalKey(o.Principal.ID, o.Principal.Kind)
+		x.overrides[key] = append(x.overrides[key], o)
+	}
+	for _, a := range state.Assignments {
+		key := snapshotPrincipalKey(a.Principal.ID, a.Principal.Kind)
+		x.assignments[key] = append(x.assignments[key], a)
+	}
+	for _, m := range state.Memberships {
+		key := snapshotPrincipalKey(m.Member.ID, m.Member.Kind)
+		x.groups[key] = append(x.groups[key], strings.ToLower(m.GroupID))
+	}
+	for _, r := range state.Roles {
+		x.roles[strings.ToLower(r.ID)] = r
+	}
+	for _, g := range state.Grants {
+		id := strings.ToLower(g.RoleID)
+		x.grants[id] = append(x.grants[id], g)
+	}
+	for _, p := range state.Permissions {
+		x.permissions[strings.ToLower(p.ID)] = p
+	}
+	for _, r := range state.Resources {
+		x.resources[strings.ToLower(r.ID)] = r
+	}
+	x.sodRules = append(x.sodRules, state.SoDRules...)
+}
+
+// replace drops the rows of change's table under its key and indexes those of state
+// instead. It reports false for a table the index does not hold.
+func (x *snapshotIndex) replace(change domainpdp.SnapshotChange, state domainpdp.SnapshotState) bool {
+	principal := snapshotPrincipalKey(change.KeyPart(0), model.PrincipalKind(change.KeyPart(1)))
+	id := strings.ToLower(change.KeyPart(0))
+	switch change.Table {
+	case "superadmin_principal":
+		delete(x.superadmins, principal)
+	case "break_glass_activation":
+		delete(x.activations, principal)
+	case "service_account":
+		delete(x.disabled, id)
+	case "principal_override":
+		delete(x.overrides, principal)
+	case "principal_role":
+		delete(x.assignments, principal)
+	case "group_member":
+		delete(x.groups, principal)
+	case "role", "service_role", "role_hierarchy":
+		delete(x.roles, id)
+	case "role_permission":
+		delete(x.grants, id)
+	case "permission":
+		delete(x.permissions, id)
+	case "resource":
+		delete(x.resources, id)
+	case "sod_rule":
+		x.sodRules = nil
+	default:
+		return false
+	}
+	x.add(state)
+	return true
+}
+
+// Snapshot is a domainpdp.Repository and domainpdp.SoDRuleSource serving the engine
+// from an in-memory copy of the policy tables, so checks do no database I/O. Start loads
+// the copy and keeps it current from the source's change feed; while the feed is down
+// checks are served from the last copy. Validity windows and break-glass expiries are
+// evaluated at check time.
+type Snapshot struct {
+	source domainpdp.SnapshotSource
+	resync time.Duration
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	index *snapshotIndex
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSnapshot creates a snapshot loaded from source. A positive resync also reloads the
+// whole snapshot when the feed has been quiet that long, bounding the effect of a
+// missed notification.
+func NewSnapshot(source domainpdp.SnapshotSource, resync time.Duration) *Snapshot {
+	return &Snapshot{source: source, resync: resync, now: time.Now, index: newSnapshotIndex()}
+}
+
+// Start subscribes to the change feed, loads the full snapshot and keeps it current
+// until Close. It fails when the first load does.
+func (s *Snapshot) Start(ctx context.Context) error {
+	feed, err := s.source.ListenSnapshotChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.reload(ctx); err != nil {
+		feed.Close()
+		return err
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.run(runCtx, feed)
+	return nil
+}
+
+// Close stops following the change feed.
+func (s *Snapshot) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+	return nil
+}
+
+// run applies changes until ctx is done. A feed error or a failed load drops the feed;
+// the snapshot keeps serving while run reconnects with backoff and reloads in full, as
+// changes may have been missed meanwhile.
+func (s *Snapshot) run(ctx context.Context, feed domainpdp.SnapshotFeed) {
+	defer close(s.done)
+	for {
+		changes, err := s.nextBatch(ctx, feed)
+		switch {
+		case err == nil:
+			err = s.applyBatch(ctx, changes)
+		case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+			err = s.reload(ctx)
+		}
+		if err == nil {
+			continue
+		}
+		feed.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("pdp snapshot: change feed interrupted, serving the last snapshot: %v", err)
+		if feed = s.reconnect(ctx); feed == nil {
+			return
+		}
+	}
+}
+
+// reconnect resubscribes to the change feed and reloads the snapshot, retrying with
+// backoff. It returns nil once ctx is done.
+func (s *Snapshot) reconnect(ctx context.Context) domainpdp.SnapshotFeed {
+	retry := snapshotRetryMin
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retry):
+		}
+		retry = min(2*retry, snapshotRetryMax)
+		feed, err := s.source.ListenSnapshotChanges(ctx)
+		if err != nil {
+			log.Printf("pdp snapshot: reconnect change feed: %v", err)
+			continue
+		}
+		if err := s.reload(ctx); err != nil {
+			feed.Close()
+			log.Printf("pdp snapshot: reload: %v", err)
+			continue
+		}
+		log.Printf("pdp snapshot: change feed restored")
+		return feed
+	}
+}
+
+// next waits for a change, for at most the resync interval when one is set.
+func (s *Snapshot) next(ctx context.Context, feed domainpdp.SnapshotFeed) (domainpdp.SnapshotChange, error) {
+	if s.resync <= 0 {
+		return feed.Next(ctx)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, s.resync)
+	defer cancel()
+	return feed.Next(waitCtx)
+}
+
+// nextBatch waits for a change as next does, then collects the changes that follow it
+// within snapshotBatchWait, dropping those naming the same rows as an earlier one.
+func (s *Snapshot) nextBatch(ctx context.Context, feed domainpdp.SnapshotFeed) ([]domainpdp.SnapshotChange, error) {
+	change, err := s.next(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+	changes := []domainpdp.SnapshotChange{change}
+	seen := map[string]bool{changeKey(change): true}
+	for len(changes) < snapshotBatchMax {
+		waitCtx, cancel := context.WithTimeout(ctx, snapshotBatchWait)
+		change, err := feed.Next(waitCtx)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key := changeKey(change); !seen[key] {
+			seen[key] = true
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func changeKey(change domainpdp.SnapshotChange) string {
+	return change.Table + ":" + strings.Join(change.Key, ":")
+}
+
+// applyBatch applies changes in order, or reloads once if any of them stands for a
+// full reload.
+func (s *Snapshot) applyBatch(ctx context.Context, changes []domainpdp.SnapshotChange) error {
+	for _, change := range changes {
+		if change.Table == "" || change.KeyPart(0) == "" {
+			return s.reload(ctx)
+		}
+	}
+	for _, change := range changes {
+		if err := s.apply(ctx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reload replaces the snapshot with a full load.
+func (s *Snapshot) reload(ctx context.Context) error {
+	state, err := s.source.LoadSnapshot(ctx, domainpdp.SnapshotChange{})
+	if err != nil {
+		return err
+	}
+	index := newSnapshotIndex()
+	index.add(state)
+	s.mu.Lock()
+	s.index = index
+	s.mu.Unlock()
+	return nil
+}
+
+// apply reloads the rows named by change. A change without a key, or on a table the
+// snapshot does not know, reloads everything.
+func (s *Snapshot) apply(ctx context.Context, change domainpdp.SnapshotChange) error {
+	if change.Table == "" || change.KeyPart(0) == "" {
+		return s.reload(ctx)
+	}
+	state, err := s.source.LoadSnapshot(ctx, change)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	replaced := s.index.replace(change, state)
+	s.mu.Unlock()
+	if !replaced {
+		return s.reload(ctx)
+	}
+	return nil
+}
+
+// enabled is false for a disabled service account.
+func (x *snapshotIndex) enabled(key principalKey) bool {
+	return key.kind != model.PrincipalKindServiceAccount || !x.disabled[key.id]
+}
+
+// GetByPrincipal implements domainpdp.Repository.
+func (s *Snapshot) GetByPrincipal(_ context.Context, principalID string, kind model.PrincipalKind) (bool, error) {
+	key := snapshotPrincipalKey(principalID, kind)
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.index.enabled(key) {
+		return false, nil
+	}
+	if s.index.superadmins[key] {
+		return true, nil
+	}
+	for _, expiresAt := range s.index.activations[key] {
+		if expiresAt.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetByRequest implements domainpdp.Repository.
+func (s *Snapshot) GetByRequest(_ context.Context, req domainpdp.CheckRequest) (*domainpdp.OverrideMatch, error) {
+	key := snapshotPrincipalKey(req.PrincipalID, req.PrincipalKind)
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.index.enabled(key) {
+		return nil, nil
+	}
+	candidates := make([]domainpdp.OverrideCandidate, 0)
+	for _, o := range s.index.overrides[key] {
+		if !domainpdp.ActiveAt(o.ValidFrom, o.ValidUntil, now) {
+			continue
+		}
+		perm, ok := s.index.permissions[strings.ToLower(o.Override.PermissionID)]
+		if !ok {
+			continue
+		}
+		depth, ok := o.Override.Scope.Match(req)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, domainpdp.OverrideCandidate{
+			OverrideMatch:    o.Override,
+			Action:           perm.Action,
+			ResourceKind:     perm.ResourceKind,
+			ScopeSpecificity: o.Override.Scope.Specificity(),
+			Depth:            depth,
+		})
+	}
+	return domainpdp.BestOverride(candidates, req), nil
+}
+
+// List implements domainpdp.Repository.
+func (s *Snapshot) List(_ context.Context, req domainpdp.CheckRequest) ([]domainpdp.RoleWithScope, error) {
+	start := snapshotPrincipalKey(req.PrincipalID, req.PrincipalKind)
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	roles := make([]domainpdp.RoleWithScope, 0)
+	if !s.index.enabled(start) {
+		return roles, nil
+	}
+	// The principal and its groups, transitively; seen cuts membership cycles.
+	closure := []principalKey{start}
+	seen := map[principalKey]bool{start: true}
+	for i := 0; i < len(closure); i++ {
+		for _, groupID := range s.index.groups[closure[i]] {
+			group := principalKey{id: groupID, kind: model.PrincipalKindGroup}
+			if !seen[group] {
+				seen[group] = true
+				closure = append(closure, group)
+			}
+		}
+	}
+	type heldRole struct {
+		roleID   string
+		scope    scopeKey
+		services string
+	}
+	held := map[heldRole]bool{}
+	for _, key := range closure {
+		for _, a := range s.index.assignments[key] {
+			if !domainpdp.ActiveAt(a.ValidFrom, a.ValidUntil, now) {
+				continue
+			}
+			assigned, ok := s.index.roles[strings.ToLower(a.RoleID)]
+			if !ok {
+				continue
+			}
+			if _, ok := a.Scope.Match(req); !ok {
+				continue
+			}
+			// The assigned role and every role it inherits, transitively, held in the
+			// assignment's scope and bound to the assigned role's services.
+			services := strings.Join(assigned.ServiceIDs, ",")
+			for _, role := range s.index.ancestry(assigned) {
+				h := heldRole{roleID: role.ID, scope: newScopeKey(a.Scope), services: services}
+				if held[h] {
+					continue
+				}
+				held[h] = true
+				roles = append(roles, domainpdp.RoleWithScope{
+					RoleID:     role.ID,
+					RoleKey:    role.Key,
+					Scope:      a.Scope,
+					ServiceIDs: slices.Clone(assigned.ServiceIDs),
+				})
+			}
+		}
+	}
+	return roles, nil
+}
+
+// ancestry returns role followed by the roles it inherits through the role hierarchy,
+// transitively; seen cuts hierarchy cycles and parents missing from the index are skipped.
+func (x *snapshotIndex) ancestry(role domainpdp.SnapshotRole) []domainpdp.SnapshotRole {
+	roles := []domainpdp.SnapshotRole{role}
+	seen := map[string]bool{strings.ToLower(role.ID): true}
+	for i := 0; i < len(roles); i++ {
+		for _, parentID := range roles[i].ParentIDs {
+			id := strings.ToLower(parentID)
+			parent, ok := x.roles[id]
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			roles = append(roles, parent)
+		}
+	}
+	return roles
+}
+
+// scopeKey flattens a scope into a comparable value.
+type scopeKey struct {
+	tenantID, serviceID, resourceKind, resourceID string
+}
+
+func newScopeKey(scope domainpdp.OverrideScope) scopeKey {
+	value := func(p *string) string {
+		if p == nil {
+			return ""
+		}
+		return *p
+	}
+	return scopeKey{value(scope.TenantID), value(scope.ServiceID), value(scope.ResourceKind), value(scope.ResourceID)}
+}
+
+// ListByRoleIDs implements domainpdp.Repository.
+func (s *Snapshot) ListByRoleIDs(_ context.Context, roleIDs []string) ([]domainpdp.RolePermissionItem, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make([]domainpdp.RolePermissionItem, 0)
+	for _, roleID := range roleIDs {
+		id := strings.ToLower(roleID)
+		role, ok := s.index.roles[id]
+		if !ok {
+			continue
+		}
+		for _, g := range s.index.grants[id] {
+			perm, ok := s.index.permissions[strings.ToLower(g.PermissionID)]
+			if !ok {
+				continue
+			}
+			item := domainpdp.RolePermissionItem{
+				RoleID:       role.ID,
+				RoleKey:      role.Key,
+				PermissionID: perm.ID,
+				Action:       perm.Action,
+				ResourceKind: perm.ResourceKind,
+				Condition:    g.Condition,
+			}
+			if g.ResourceID != nil {
+				resourceID := *g.ResourceID
+				item.ResourceID = &resourceID
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// ResourceAncestors implements domainpdp.ResourceRegistry.
+func (s *Snapshot) ResourceAncestors(_ context.Context, resourceID string) ([]domainpdp.ResourceNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ancestors := make([]domainpdp.ResourceNode, 0)
+	current, ok := s.index.resources[strings.ToLower(resourceID)]
+	for ok && current.ParentID != "" && len(ancestors) < maxSnapshotResourceDepth {
+		current, ok = s.index.resources[strings.ToLower(current.ParentID)]
+		if !ok {
+			break
+		}
+		id := current.ID
+		ancestors = append(ancestors, domainpdp.ResourceNode{Kind: current.Kind, ID: &id})
+	}
+	return ancestors, nil
+}
+
+// DynamicSoDRules implements domainpdp.SoDRuleSource.
+func (s *Snapshot) DynamicSoDRules(context.Context) ([]domainpdp.SoDRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rules := make([]domainpdp.SoDRule, 0, len(s.index.sodRules))
+	for _, rule := range s.index.sodRules {
+		a, okA := s.index.roles[strings.ToLower(rule.RoleAID)]
+		b, okB := s.index.roles[strings.ToLower(rule.RoleBID)]
+		if okA && okB {
+			rules = append(rules, domainpdp.SoDRule{Key: rule.Key, RoleA: a.Key, RoleB: b.Key})
+		}
+	}
+	return rules, nil
+}
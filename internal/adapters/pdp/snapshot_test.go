@@ -0,0 +1,354 @@
+package pdp
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+)
+
+// stubSnapshotSource serves loads from state, filtered by the change's key, and feeds
+// the changes sent on its channel.
+type stubSnapshotSource struct {
+	state   domainpdp.SnapshotState
+	changes chan domainpdp.SnapshotChange
+	loads   chan domainpdp.SnapshotChange
+}
+
+func newStubSnapshotSource(state domainpdp.SnapshotState) *stubSnapshotSource {
+	return &stubSnapshotSource{
+		state:   state,
+		changes: make(chan domainpdp.SnapshotChange),
+		loads:   make(chan domainpdp.SnapshotChange, 16),
+	}
+}
+
+func (s *stubSnapshotSource) LoadSnapshot(_ context.Context, change domainpdp.SnapshotChange) (domainpdp.SnapshotState, error) {
+	defer func() { s.loads <- change }()
+	if change.Table == "" {
+		return s.state, nil
+	}
+	var state domainpdp.SnapshotState
+	principal := func(p domainpdp.SnapshotPrincipal) bool {
+		return p.ID == change.KeyPart(0) && string(p.Kind) == change.KeyPart(1)
+	}
+	switch change.Table {
+	case "principal_override":
+		for _, o := range s.state.Overrides {
+			if principal(o.Principal) {
+				state.Overrides = append(state.Overrides, o)
+			}
+		}
+	case "principal_role":
+		for _, a := range s.state.Assignments {
+			if principal(a.Principal) {
+				state.Assignments = append(state.Assignments, a)
+			}
+		}
+	case "group_member":
+		for _, m := range s.state.Memberships {
+			if principal(m.Member) {
+				state.Memberships = append(state.Memberships, m)
+			}
+		}
+	case "role", "service_role", "role_hierarchy":
+		for _, r := range s.state.Roles {
+			if r.ID == change.KeyPart(0) {
+				state.Roles = append(state.Roles, r)
+			}
+		}
+	case "service_account":
+		for _, id := range s.state.DisabledServiceAccounts {
+			if id == change.KeyPart(0) {
+				state.DisabledServiceAccounts = append(state.DisabledServiceAccounts, id)
+			}
+		}
+	default:
+		return state, errors.New("unexpected table " + change.Table)
+	}
+	return state, nil
+}
+
+func (s *stubSnapshotSource) ListenSnapshotChanges(context.Context) (domainpdp.SnapshotFeed, error) {
+	return stubSnapshotFeed{s.changes}, nil
+}
+
+type stubSnapshotFeed struct {
+	changes chan domainpdp.SnapshotChange
+}
+
+func (f stubSnapshotFeed) Next(ctx context.Context) (domainpdp.SnapshotChange, error) {
+	select {
+	case change := <-f.changes:
+		return change, nil
+	case <-ctx.Done():
+		return domainpdp.SnapshotChange{}, ctx.Err()
+	}
+}
+
+func (f stubSnapshotFeed) Close() {}
+
+// notify sends change to a started snapshot and waits until it has been loaded.
+func (s *stubSnapshotSource) notify(t *testing.T, change domainpdp.SnapshotChange) {
+	t.Helper()
+	s.changes <- change
+	select {
+	case <-s.loads:
+	case <-time.After(time.Second):
+		t.Fatalf("change %v was not loaded", change)
+	}
+}
+
+const (
+	alice   = "0b8c6a5e-1111-4f3e-9a5e-000000000001"
+	robot   = "0b8c6a5e-1111-4f3e-9a5e-000000000002"
+	staff   = "0b8c6a5e-1111-4f3e-9a5e-000000000003"
+	teacher = "0b8c6a5e-2222-4f3e-9a5e-000000000001"
+	editor  = "0b8c6a5e-2222-4f3e-9a5e-000000000002"
+	viewLes = "0b8c6a5e-3333-4f3e-9a5e-000000000001"
+	editLes = "0b8c6a5e-3333-4f3e-9a5e-000000000002"
+	course  = "0b8c6a5e-4444-4f3e-9a5e-000000000001"
+	lesson  = "0b8c6a5e-4444-4f3e-9a5e-000000000002"
+)
+
+func snapshotFixture() domainpdp.SnapshotState {
+	user := domainpdp.SnapshotPrincipal{ID: alice, Kind: model.PrincipalKindUser}
+	return domainpdp.SnapshotState{
+		Memberships: []domainpdp.SnapshotMembership{
+			{GroupID: staff, Member: user},
+		},
+		Assignments: []domainpdp.SnapshotAssignment{
+			{Principal: domainpdp.SnapshotPrincipal{ID: staff, Kind: model.PrincipalKindGroup}, RoleID: teacher},
+		},
+		Roles: []domainpdp.SnapshotRole{
+			{ID: teacher, Key: "teacher", ServiceIDs: []string{"svc-b", "svc-a"}},
+		},
+		Grants: []domainpdp.SnapshotGrant{
+			{RoleID: teacher, PermissionID: viewLes},
+		},
+		Permissions: []domainpdp.SnapshotPermission{
+			{ID: viewLes, Action: "lesson.view", ResourceKind: "lesson"},
+			{ID: editLes, Action: "lesson.edit", ResourceKind: "lesson"},
+		},
+		Resources: []domainpdp.SnapshotResource{
+			{ID: course, Kind: "course"},
+			{ID: lesson, Kind: "lesson", ParentID: course},
+		},
+	}
+}
+
+func startSnapshot(t *testing.T, source *stubSnapshotSource) *Snapshot {
+	t.Helper()
+	snapshot := NewSnapshot(source, 0)
+	if err := snapshot.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	<-source.loads
+	t.Cleanup(func() { _ = snapshot.Close() })
+	return snapshot
+}
+
+func TestSnapshotServesEngine(t *testing.T) {
+	source := newStubSnapshotSource(snapshotFixture())
+	snapshot := startSnapshot(t, source)
+	engine := NewEngine(snapshot).WithSoDRules(snapshot)
+	ctx := context.Background()
+	check := func(action string) bool {
+		t.Helper()
+		lessonID, serviceID := lesson, "svc-a"
+		result, err := engine.Check(ctx, domainpdp.CheckRequest{
+			PrincipalID: alice, PrincipalKind: model.PrincipalKindUser, ServiceID: &serviceID,
+			Action: action, ResourceKind: "lesson", ResourceID: &lessonID,
+		})
+		if err != nil {
+			t.Fatalf("Check(%s): %v", action, err)
+		}
+		return result.Allow
+	}
+
+	if !check("lesson.view") || check("lesson.edit") {
+		t.Fatalf("inherited role: want view allowed and edit denied")
+	}
+
+	// A deny override on view and an allow on edit, scoped to the parent course.
+	courseKind, courseID := "course", course
+	source.state.Overrides = []domainpdp.SnapshotOverride{
+		{
+			Principal: domainpdp.SnapshotPrincipal{ID: alice, Kind: model.PrincipalKindUser},
+			Override:  domainpdp.OverrideMatch{Effect: model.OverrideEffectDeny, PermissionID: viewLes},
+		},
+		{
+			Principal: domainpdp.SnapshotPrincipal{ID: alice, Kind: model.PrincipalKindUser},
+			Override: domainpdp.OverrideMatch{Effect: model.OverrideEffectAllow, PermissionID: editLes,
+				Scope: domainpdp.OverrideScope{ResourceKind: &courseKind, ResourceID: &courseID}},
+		},
+	}
+	source.notify(t, domainpdp.SnapshotChange{Table: "principal_override", Key: []string{alice, "user"}})
+	if check("lesson.view") || !check("lesson.edit") {
+		t.Fatalf("overrides: want view denied and edit allowed")
+	}
+
+	source.state.Overrides = nil
+	source.notify(t, domainpdp.SnapshotChange{Table: "principal_override", Key: []string{alice, "user"}})
+	source.state.Memberships = nil
+	source.notify(t, domainpdp.SnapshotChange{Table: "group_member", Key: []string{alice, "user"}})
+	if check("lesson.view") {
+		t.Fatalf("after leaving the group: want view denied")
+	}
+}
+
+func TestSnapshotList(t *testing.T) {
+	source := newStubSnapshotSource(snapshotFixture())
+	snapshot := startSnapshot(t, source)
+	ctx := context.Background()
+	req := domainpdp.CheckRequest{PrincipalID: alice, PrincipalKind: model.PrincipalKindUser, Action: "lesson.view", ResourceKind: "lesson"}
+
+	roles, err := snapshot.List(ctx, req)
+	if err != nil || len(roles) != 1 || roles[0].RoleKey != "teacher" {
+		t.Fatalf("List = %v, %v; want the inherited teacher role", roles, err)
+	}
+	// The engine sorts ServiceIDs in place; the snapshot must not share its slice.
+	slices.Sort(roles[0].ServiceIDs)
+	if roles, _ := snapshot.List(ctx, req); !slices.Equal(roles[0].ServiceIDs, []string{"svc-b", "svc-a"}) {
+		t.Fatalf("List ServiceIDs changed by the caller: %v", roles[0].ServiceIDs)
+	}
+	source.state.Roles[0].ServiceIDs = []string{"svc-c"}
+	source.notify(t, domainpdp.SnapshotChange{Table: "service_role", Key: []string{teacher}})
+	roles, _ = snapshot.List(ctx, req)
+	if len(roles) != 1 || !slices.Equal(roles[0].ServiceIDs, []string{"svc-c"}) {
+		t.Fatalf("after service_role change: List = %v", roles)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	source.state.Assignments = []domainpdp.SnapshotAssignment{
+		{Principal: domainpdp.SnapshotPrincipal{ID: alice, Kind: model.PrincipalKindUser}, RoleID: teacher, ValidUntil: &past},
+	}
+	source.notify(t, domainpdp.SnapshotChange{Table: "principal_role", Key: []string{alice, "user"}})
+	if roles, _ := snapshot.List(ctx, req); len(roles) != 1 {
+		t.Fatalf("group role alongside expired direct role: List = %v", roles)
+	}
+	snapshot.now = func() time.Time { return past.Add(-time.Minute) }
+	if roles, _ := snapshot.List(ctx, req); len(roles) != 1 {
+		t.Fatalf("direct and group role with equal scope: want one entry, got %v", roles)
+	}
+}
+
+func TestSnapshotRoleHierarchy(t *testing.T) {
+	state := snapshotFixture()
+	state.Roles = append(state.Roles, domainpdp.SnapshotRole{ID: editor, Key: "editor"})
+	state.Roles[0].ParentIDs = []string{editor}
+	state.Grants = append(state.Grants, domainpdp.SnapshotGrant{RoleID: editor, PermissionID: editLes})
+	source := newStubSnapshotSource(state)
+	snapshot := startSnapshot(t, source)
+	engine := NewEngine(snapshot)
+	ctx := context.Background()
+	check := func(serviceID string) domainpdp.CheckResult {
+		t.Helper()
+		result, err := engine.Check(ctx, domainpdp.CheckRequest{
+			PrincipalID: alice, PrincipalKind: model.PrincipalKindUser, ServiceID: &serviceID,
+			Action: "lesson.edit", ResourceKind: "lesson",
+		})
+		if err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+		return result
+	}
+
+	result := check("svc-a")
+	if !result.Allow || !slices.Equal(result.RoleKeys, []string{"teacher", "editor"}) {
+		t.Fatalf("inherited editor grant = %+v; want allowed through teacher and editor", result)
+	}
+	// The inherited role is limited to the services the assigned role is bound to.
+	if check("svc-c").Allow {
+		t.Fatalf("service the assigned role is not bound to: want denied")
+	}
+
+	source.state.Roles[0].ParentIDs = nil
+	source.notify(t, domainpdp.SnapshotChange{Table: "role_hierarchy", Key: []string{teacher}})
+	if check("svc-a").Allow {
+		t.Fatalf("after removing the parent role: want denied")
+	}
+}
+
+func TestSnapshotCoalescesChanges(t *testing.T) {
+	source := newStubSnapshotSource(snapshotFixture())
+	startSnapshot(t, source)
+
+	overrides := domainpdp.SnapshotChange{Table: "principal_override", Key: []string{alice, "user"}}
+	members := domainpdp.SnapshotChange{Table: "group_member", Key: []string{alice, "user"}}
+	for _, change := range []domainpdp.SnapshotChange{overrides, overrides, members, overrides} {
+		source.changes <- change
+	}
+	var loads []domainpdp.SnapshotChange
+	for done := false; !done; {
+		select {
+		case change := <-source.loads:
+			loads = append(loads, change)
+		case <-time.After(100 * time.Millisecond):
+			done = true
+		}
+	}
+	if len(loads) != 2 || loads[0].Table != "principal_override" || loads[1].Table != "group_member" {
+		t.Fatalf("loads = %v; want one per distinct change in order", loads)
+	}
+}
+
+func TestSnapshotDisabledServiceAccount(t *testing.T) {
+	state := snapshotFixture()
+	state.Superadmins = []domainpdp.SnapshotPrincipal{{ID: robot, Kind: model.PrincipalKindServiceAccount}}
+	source := newStubSnapshotSource(state)
+	snapshot := startSnapshot(t, source)
+	ctx := context.Background()
+
+	if ok, _ := snapshot.GetByPrincipal(ctx, robot, model.PrincipalKindServiceAccount); !ok {
+		t.Fatalf("enabled superadmin service account: want true")
+	}
+	source.state.DisabledServiceAccounts = []string{robot}
+	source.notify(t, domainpdp.SnapshotChange{Table: "service_account", Key: []string{robot}})
+	if ok, _ := snapshot.GetByPrincipal(ctx, robot, model.PrincipalKindServiceAccount); ok {
+		t.Fatalf("disabled service account: want false")
+	}
+	if ok, _ := snapshot.GetByPrincipal(ctx, robot, model.PrincipalKindUser); ok {
+		t.Fatalf("user with the id of a superadmin service account: want false")
+	}
+}
+
+func TestSnapshotBreakGlassExpiry(t *testing.T) {
+	now := time.Now()
+	state := snapshotFixture()
+	state.Activations = []domainpdp.SnapshotActivation{
+		{Principal: domainpdp.SnapshotPrincipal{ID: alice, Kind: model.PrincipalKindUser}, ExpiresAt: now.Add(time.Minute)},
+	}
+	snapshot := startSnapshot(t, newStubSnapshotSource(state))
+	snapshot.now = func() time.Time { return now }
+	if ok, _ := snapshot.GetByPrincipal(context.Background(), alice, model.PrincipalKindUser); !ok {
+		t.Fatalf("open activation: want true")
+	}
+	snapshot.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if ok, _ := snapshot.GetByPrincipal(context.Background(), alice, model.PrincipalKindUser); ok {
+		t.Fatalf("expired activation: want false")
+	}
+}
+
+func TestSnapshotResourceAncestors(t *testing.T) {
+	// Ids are UUIDs and match whatever their case, as they do in Postgres.
+	state := snapshotFixture()
+	state.Resources[1].ParentID = strings.ToUpper(course)
+	snapshot := startSnapshot(t, newStubSnapshotSource(state))
+	ancestors, err := snapshot.ResourceAncestors(context.Background(), lesson)
+	if err != nil || len(ancestors) != 1 || ancestors[0].Kind != "course" || *ancestors[0].ID != course {
+		t.Fatalf("ResourceAncestors(lesson) = %v, %v", ancestors, err)
+	}
+	ancestors, err = snapshot.ResourceAncestors(context.Background(), strings.ToUpper(lesson))
+	if err != nil || len(ancestors) != 1 || *ancestors[0].ID != course {
+		t.Fatalf("ResourceAncestors(upper-case lesson) = %v, %v", ancestors, err)
+	}
+	if ancestors, _ := snapshot.ResourceAncestors(context.Background(), "unregistered"); len(ancestors) != 0 {
+		t.Fatalf("ResourceAncestors(unregistered) = %v", ancestors)
+	}
+}
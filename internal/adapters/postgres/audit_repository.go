@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	AuditActionCreate     = "create"
+	AuditActionUpdate     = "update"
+	AuditActionDelete     = "delete"
+	AuditActionApply      = "apply"
+	AuditActionImport     = "import"
+	AuditActionExpire     = "expire"
+	AuditActionApprove    = "approve"
+	AuditActionReject     = "reject"
+	AuditActionActivate   = "activate"
+	AuditActionDeactivate = "deactivate"
+	AuditActionDeny       = "deny"
+	AuditActionReview     = "review"
+)
+
+// auditChange is a single mutation recorded in audit_log. Before and After hold the
+// affected row as JSON; either is nil when the row did not exist on that side.
+type auditChange struct {
+	Action   string
+	Entity   string
+	EntityID string
+	Before   []byte
+	After    []byte
+}
+
+// recordAudit writes the change attributed to the actor in ctx. It must be called with
+// the transaction performing the mutation so both commit or roll back together.
+func recordAudit(ctx context.Context, q dbtx, change auditChange) error {
+	actor := audit.ActorFromContext(ctx)
+	_, err := q.Exec(ctx, `INSERT INTO audit_log
+		(actor_id, source, correlation_id, action, entity, entity_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		actor.ID, actor.Source, actor.CorrelationID, change.Action, change.Entity, change.EntityID,
+		nullableJSON(change.Before), nullableJSON(change.After))
+	return err
+}
+
+// auditRow loads a row as JSON for the audit trail; the query must select a single
+// to_jsonb(...) column. A missing row yields nil.
+func auditRow(ctx context.Context, q dbtx, query string, args ...any) ([]byte, error) {
+	var data []byte
+	if err := q.QueryRow(ctx, query, args...).Scan(&data); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// auditValue marshals v for the audit trail.
+func auditValue(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func nullableJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// AuditRepository reads the audit log.
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// List returns matching entries, newest first, with the total match count.
+func (r *AuditRepository) List(ctx context.Context, filter audit.Filter, offset, limit int) ([]audit.Entry, int64, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Source != "" {
+		add("source = $%d", filter.Source)
+	}
+	if filter.CorrelationID != "" {
+		add("correlation_id = $%d", filter.CorrelationID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.Entity != "" {
+		add("entity = $%d", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if filter.From != nil {
+		add("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("occurred_at < $%d", *filter.To)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM audit_log `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf(`SELECT id, occurred_at, actor_id, source, correlation_id, action, entity, entity_id, before, after
+		FROM audit_log %s ORDER BY occurred_at DESC, id DESC LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	items := make([]audit.Entry, 0)
+	for rows.Next() {
+		var e audit.Entry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.ActorID, &e.Source, &e.CorrelationID, &e.Action, &e.Entity, &e.EntityID, &before, &after); err != nil {
+			return nil, 0, err
+		}
+		if len(before) > 0 {
+			e.Before = json.RawMessage(before)
+		}
+		if len(after) > 0 {
+			e.After = json.RawMessage(after)
+		}
+		items = append(items, e)
+	}
+	return items, total, rows.Err()
+}
@@ -0,0 +1,644 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/policy"
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BackupRepository exports and restores every RBAC table.
+type BackupRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewBackupRepository(pool *pgxpool.Pool) *BackupRepository {
+	return &BackupRepository{pool: pool}
+}
+
+// Export reads all tables from a single repeatable-read snapshot.
+func (r *BackupRepository) Export(ctx context.Context) (policy.Backup, error) {
+	backup := policy.Backup{
+		Version:            policy.BackupVersion,
+		ExportedAt:         time.Now().UTC(),
+		Services:           []policy.BackupService{},
+		Roles:              []policy.BackupRole{},
+		RoleHierarchy:      []policy.BackupRoleHierarchy{},
+		Permissions:        []policy.BackupPermission{},
+		RolePermissions:    []policy.BackupRolePermission{},
+		ServiceRoles:       []policy.BackupServiceRole{},
+		ServicePermissions: []policy.BackupServicePermission{},
+		PrincipalRoles:     []policy.BackupPrincipalRole{},
+		PrincipalOverrides: []policy.BackupPrincipalOverride{},
+		Superadmins:        []policy.BackupSuperadmin{},
+		Groups:             []policy.BackupGroup{},
+		GroupMembers:       []policy.BackupGroupMember{},
+		SoDRules:           []policy.BackupSoDRule{},
+		RoleCardinality:    []policy.BackupRoleCardinality{},
+		Resources:          []policy.BackupResource{},
+		RebacNamespaces:    []policy.BackupRebacNamespace{},
+		RelationTuples:     []domainrebac.Tuple{},
+	}
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return policy.Backup{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	steps := []struct {
+		query string
+		scan  func(pgx.Rows) error
+	}{
+		{`SELECT id::text, key, title FROM service ORDER BY key`, func(rows pgx.Rows) error {
+			var item policy.BackupService
+			if err := rows.Scan(&item.ID, &item.Key, &item.Title); err != nil {
+				return err
+			}
+			backup.Services = append(backup.Services, item)
+			return nil
+		}},
+		{`SELECT id::text, key, title FROM role ORDER BY key`, func(rows pgx.Rows) error {
+			var item policy.BackupRole
+			if err := rows.Scan(&item.ID, &item.Key, &item.Title); err != nil {
+				return err
+			}
+			backup.Roles = append(backup.Roles, item)
+			return nil
+		}},
+		{`SELECT r.key, p.key FROM role_hierarchy h
+			JOIN role r ON r.id = h.role_id
+			JOIN role p ON p.id = h.parent_role_id
+			ORDER BY r.key, p.key`, func(rows pgx.Rows) error {
+			var item policy.BackupRoleHierarchy
+			if err := rows.Scan(&item.Role, &item.Parent); err != nil {
+				return err
+			}
+			backup.RoleHierarchy = append(backup.RoleHierarchy, item)
+			return nil
+		}},
+		{`SELECT id::text, action, resource_kind FROM permission ORDER BY action, resource_kind`, func(rows pgx.Rows) error {
+			var item policy.BackupPermission
+			if err := rows.Scan(&item.ID, &item.Action, &item.ResourceKind); err != nil {
+				return err
+			}
+			backup.Permissions = append(backup.Permissions, item)
+			return nil
+		}},
+		{`SELECT r.key, p.action, p.resource_kind, rp.resource_id::text, coalesce(rp.condition, '') FROM role_permission rp
+			JOIN role r ON r.id = rp.role_id
+			JOIN permission p ON p.id = rp.permission_id
+			ORDER BY r.key, p.action, p.resource_kind, rp.resource_id`, func(rows pgx.Rows) error {
+			var item policy.BackupRolePermission
+			if err := rows.Scan(&item.Role, &item.Action, &item.ResourceKind, &item.ResourceID, &item.Condition); err != nil {
+				return err
+			}
+			backup.RolePermissions = append(backup.RolePermissions, item)
+			return nil
+		}},
+		{`SELECT s.key, r.key FROM service_role sr
+			JOIN service s ON s.id = sr.service_id
+			JOIN role r ON r.id = sr.role_id
+			ORDER BY s.key, r.key`, func(rows pgx.Rows) error {
+			var item policy.BackupServiceRole
+			if err := rows.Scan(&item.Service, &item.Role); err != nil {
+				return err
+			}
+			backup.ServiceRoles = append(backup.ServiceRoles, item)
+			return nil
+		}},
+		{`SELECT s.key, p.action, p.resource_kind FROM service_permission sp
+			JOIN service s ON s.id = sp.service_id
+			JOIN permission p ON p.id = sp.permission_id
+			ORDER BY s.key, p.action, p.resource_kind`, func(rows pgx.Rows) error {
+			var item policy.BackupServicePermission
+			if err := rows.Scan(&item.Service, &item.Action, &item.ResourceKind); err != nil {
+				return err
+			}
+			backup.ServicePermissions = append(backup.ServicePermissions, item)
+			return nil
+		}},
+		{`SELECT s.key, ra.key, rb.key, s.kind, s.same_scope, s.description FROM sod_rule s
+			JOIN role ra ON ra.id = s.role_a_id
+			JOIN role rb ON rb.id = s.role_b_id
+			ORDER BY s.key`, func(rows pgx.Rows) error {
+			var item policy.BackupSoDRule
+			if err := rows.Scan(&item.Key, &item.RoleA, &item.RoleB, &item.Kind, &item.SameScope, &item.Description); err != nil {
+				return err
+			}
+			backup.SoDRules = append(backup.SoDRules, item)
+			return nil
+		}},
+		{`SELECT r.key, rc.scope_level, rc.max_principals FROM role_cardinality rc
+			JOIN role r ON r.id = rc.role_id
+			ORDER BY r.key, rc.scope_level`, func(rows pgx.Rows) error {
+			var item policy.BackupRoleCardinality
+			if err := rows.Scan(&item.Role, &item.Scope, &item.MaxPrincipals); err != nil {
+				return err
+			}
+			backup.RoleCardinality = append(backup.RoleCardinality, item)
+			return nil
+		}},
+		{`SELECT pr.principal_id::text, pr.principal_kind::text, r.key,
+			pr.tenant_id::text, s.key, pr.resource_kind, pr.resource_id::text, pr.valid_from, pr.valid_until
+			FROM principal_role pr
+			JOIN role r ON r.id = pr.role_id
+			JOIN service s ON s.id = pr.service_id
+			ORDER BY pr.principal_id, pr.principal_kind, r.key`, func(rows pgx.Rows) error {
+			var item policy.BackupPrincipalRole
+			if err := rows.Scan(&item.PrincipalID, &item.PrincipalKind, &item.Role,
+				&item.Scope.TenantID, &item.Scope.Service, &item.Scope.ResourceKind, &item.Scope.ResourceID,
+				&item.ValidFrom, &item.ValidUntil); err != nil {
+				return err
+			}
+			backup.PrincipalRoles = append(backup.PrincipalRoles, item)
+			return nil
+		}},
+		{`SELECT po.principal_id::text, po.principal_kind::text, p.action, p.resource_kind, po.effect::text,
+			po.tenant_id::text, s.key, po.resource_kind, po.resource_id::text, po.valid_from, po.valid_until, coalesce(po.condition, '')
+			FROM principal_override po
+			JOIN permission p ON p.id = po.permission_id
+			JOIN service s ON s.id = po.service_id
+			ORDER BY po.principal_id, po.principal_kind, p.action, p.resource_kind`, func(rows pgx.Rows) error {
+			var item policy.BackupPrincipalOverride
+			if err := rows.Scan(&item.PrincipalID, &item.PrincipalKind, &item.Action, &item.ResourceKind, &item.Effect,
+				&item.Scope.TenantID, &item.Scope.Service, &item.Scope.ResourceKind, &item.Scope.ResourceID,
+				&item.ValidFrom, &item.ValidUntil, &item.Condition); err != nil {
+				return err
+			}
+			backup.PrincipalOverrides = append(backup.PrincipalOverrides, item)
+			return nil
+		}},
+		{`SELECT principal_id::text, principal_kind::text FROM superadmin_principal ORDER BY principal_id`, func(rows pgx.Rows) error {
+			var item policy.BackupSuperadmin
+			if err := rows.Scan(&item.PrincipalID, &item.PrincipalKind); err != nil {
+				return err
+			}
+			backup.Superadmins = append(backup.Superadmins, item)
+			return nil
+		}},
+		{`SELECT id::text, key, title FROM principal_group ORDER BY key`, func(rows pgx.Rows) error {
+			var item policy.BackupGroup
+			if err := rows.Scan(&item.ID, &item.Key, &item.Title); err != nil {
+				return err
+			}
+			backup.Groups = append(backup.Groups, item)
+			return nil
+		}},
+		{`SELECT id::text, kind, coalesce(parent_id::text, '') FROM resource ORDER BY id`, func(rows pgx.Rows) error {
+			var item policy.BackupResource
+			if err := rows.Scan(&item.ID, &item.Kind, &item.ParentID); err != nil {
+				return err
+			}
+			backup.Resources = append(backup.Resources, item)
+			return nil
+		}},
+		{`SELECT name, config FROM rebac_namespace ORDER BY name`, func(rows pgx.Rows) error {
+			var item policy.BackupRebacNamespace
+			if err := rows.Scan(&item.Name, &item.Relations); err != nil {
+				return err
+			}
+			backup.RebacNamespaces = append(backup.RebacNamespaces, item)
+			return nil
+		}},
+		{`SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation FROM relation_tuple
+			ORDER BY namespace, object_id, relation, subject_namespace, subject_id, subject_relation`, func(rows pgx.Rows) error {
+			var t domainrebac.Tuple
+			if err := rows.Scan(&t.Object.Namespace, &t.Object.ID, &t.Relation, &t.Subject.Namespace, &t.Subject.ID, &t.Subject.Relation); err != nil {
+				return err
+			}
+			backup.RelationTuples = append(backup.RelationTuples, t)
+			return nil
+		}},
+		{`SELECT group_id::text, member_id::text, member_kind::text FROM group_member
+			ORDER BY group_id, member_kind, member_id`, func(rows pgx.Rows) error {
+			var item policy.BackupGroupMember
+			if err := rows.Scan(&item.GroupID, &item.MemberID, &item.MemberKind); err != nil {
+				return err
+			}
+			backup.GroupMembers = append(backup.GroupMembers, item)
+			return nil
+		}},
+	}
+	for _, step := range steps {
+		if err := scanRows(ctx, tx, step.query, nil, step.scan); err != nil {
+			return policy.Backup{}, err
+		}
+	}
+	return backup, nil
+}
+
+// Import restores a backup transactionally. Services, roles and permissions are matched
+// by natural key (keeping the target's ids), objects absent from the backup are deleted,
+// and link tables are replaced wholesale. With opts.ExcludePrincipals the import fails
+// with ErrConflict rather than delete a service, role or permission that the target's
+// assignments or overrides still reference. The report counts the rows each statement
+// wrote, so backup rows that already existed or did not resolve are not counted.
+func (r *BackupRepository) Import(ctx context.Context, backup policy.Backup, opts policy.ImportOptions) (policy.ImportReport, error) {
+	report := policy.ImportReport{Restored: map[string]int{}}
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(policySyncLockKey)); err != nil {
+			return err
+		}
+		before, err := backupTableCounts(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		serviceKeys := make([]string, 0, len(backup.Services))
+		for _, s := range backup.Services {
+			serviceKeys = append(serviceKeys, s.Key)
+		}
+		roleKeys := make([]string, 0, len(backup.Roles))
+		for _, ro := range backup.Roles {
+			roleKeys = append(roleKeys, ro.Key)
+		}
+		permissionKeys := make([]string, 0, len(backup.Permissions))
+		for _, p := range backup.Permissions {
+			permissionKeys = append(permissionKeys, policy.PermissionKey(p.Action, p.ResourceKind))
+		}
+		restoreRebac := backup.Version != policy.LegacyBackupVersion
+		if opts.ExcludePrincipals {
+			if err := checkKeptPrincipalReferences(ctx, tx, serviceKeys, roleKeys, permissionKeys); err != nil {
+				return err
+			}
+			if restoreRebac {
+				if err := checkKeptRelationTuples(ctx, tx, backup.RebacNamespaces); err != nil {
+					return err
+				}
+			}
+		}
+		type statement struct {
+			query string
+			args  []any
+		}
+		cleanup := []statement{
+			{`DELETE FROM role_hierarchy`, nil},
+			{`DELETE FROM role_permission`, nil},
+			{`DELETE FROM service_role`, nil},
+			{`DELETE FROM service_permission`, nil},
+			{`DELETE FROM sod_rule`, nil},
+			{`DELETE FROM role_cardinality`, nil},
+			{`DELETE FROM service WHERE NOT (key = ANY($1))`, []any{serviceKeys}},
+			{`DELETE FROM role WHERE NOT (key = ANY($1))`, []any{roleKeys}},
+			{`DELETE FROM permission WHERE NOT (action || ':' || resource_kind = ANY($1))`, []any{permissionKeys}},
+		}
+		restoreResources := !opts.ExcludePrincipals && backup.Version != policy.LegacyBackupVersion
+		if !opts.ExcludePrincipals {
+			cleanup = append(cleanup,
+				statement{`DELETE FROM principal_role`, nil},
+				statement{`DELETE FROM principal_override`, nil},
+				statement{`DELETE FROM superadmin_principal`, nil},
+				statement{`DELETE FROM principal_group`, nil},
+			)
+		}
+		if restoreRebac {
+			namespaceNames := make([]string, 0, len(backup.RebacNamespaces))
+			for _, ns := range backup.RebacNamespaces {
+				namespaceNames = append(namespaceNames, ns.Name)
+			}
+			if !opts.ExcludePrincipals {
+				cleanup = append(cleanup, statement{`DELETE FROM relation_tuple`, nil})
+			}
+			cleanup = append(cleanup, statement{`DELETE FROM rebac_namespace WHERE NOT (name = ANY($1::text[]))`, []any{namespaceNames}})
+		}
+		if restoreResources {
+			// Parent links restrict deletes, so they are cut first.
+			cleanup = append(cleanup,
+				statement{`UPDATE resource SET parent_id = NULL WHERE parent_id IS NOT NULL`, nil},
+				statement{`DELETE FROM resource`, nil},
+			)
+		}
+		for _, c := range cleanup {
+			if _, err := tx.Exec(ctx, c.query, c.args...); err != nil {
+				return err
+			}
+		}
+
+		// restore runs one insert and counts the rows it wrote under table.
+		restore := func(table, query string, args ...any) error {
+			cmd, err := tx.Exec(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+			report.Restored[table] += int(cmd.RowsAffected())
+			return nil
+		}
+		for _, s := range backup.Services {
+			if err := restore("service", `INSERT INTO service (id, key, title)
+				SELECT CASE WHEN $1 = '' OR EXISTS (SELECT 1 FROM service WHERE id::text=$1) THEN gen_random_uuid() ELSE $1::uuid END, $2, $3
+				ON CONFLICT (key) DO UPDATE SET title = excluded.title`, s.ID, s.Key, s.Title); err != nil {
+				return fmt.Errorf("service %q: %w", s.Key, err)
+			}
+		}
+		for _, ro := range backup.Roles {
+			if err := restore("role", `INSERT INTO role (id, key, title)
+				SELECT CASE WHEN $1 = '' OR EXISTS (SELECT 1 FROM role WHERE id::text=$1) THEN gen_random_uuid() ELSE $1::uuid END, $2, $3
+				ON CONFLICT (key) DO UPDATE SET title = excluded.title`, ro.ID, ro.Key, ro.Title); err != nil {
+				return fmt.Errorf("role %q: %w", ro.Key, err)
+			}
+		}
+		for _, p := range backup.Permissions {
+			if err := restore("permission", `INSERT INTO permission (id, action, resource_kind)
+				SELECT CASE WHEN $1 = '' OR EXISTS (SELECT 1 FROM permission WHERE id::text=$1) THEN gen_random_uuid() ELSE $1::uuid END, $2, $3
+				ON CONFLICT (action, resource_kind) DO NOTHING`, p.ID, p.Action, p.ResourceKind); err != nil {
+				return fmt.Errorf("permission %q: %w", policy.PermissionKey(p.Action, p.ResourceKind), err)
+			}
+		}
+
+		for _, h := range backup.RoleHierarchy {
+			if err := restore("role_hierarchy", `INSERT INTO role_hierarchy (role_id, parent_role_id)
+				SELECT r.id, p.id FROM role r, role p WHERE r.key=$1 AND p.key=$2
+				ON CONFLICT DO NOTHING`, h.Role, h.Parent); err != nil {
+				return err
+			}
+		}
+		for _, rp := range backup.RolePermissions {
+			if err := restore("role_permission", `INSERT INTO role_permission (role_id, permission_id, resource_id, condition)
+				SELECT r.id, p.id, $4::uuid, nullif($5::text, '') FROM role r, permission p
+				WHERE r.key=$1 AND p.action=$2 AND p.resource_kind=$3
+				ON CONFLICT DO NOTHING`,
+				rp.Role, rp.Action, rp.ResourceKind, resourceIDOrDefault(rp.ResourceID), rp.Condition); err != nil {
+				return err
+			}
+		}
+		for _, sr := range backup.ServiceRoles {
+			if err := restore("service_role", `INSERT INTO service_role (role_id, service_id)
+				SELECT r.id, s.id FROM role r, service s WHERE r.key=$1 AND s.key=$2
+				ON CONFLICT DO NOTHING`, sr.Role, sr.Service); err != nil {
+				return err
+			}
+		}
+		for _, sp := range backup.ServicePermissions {
+			if err := restore("service_permission", `INSERT INTO service_permission (permission_id, service_id)
+				SELECT p.id, s.id FROM permission p, service s
+				WHERE p.action=$1 AND p.resource_kind=$2 AND s.key=$3
+				ON CONFLICT DO NOTHING`, sp.Action, sp.ResourceKind, sp.Service); err != nil {
+				return err
+			}
+		}
+		for _, rule := range backup.SoDRules {
+			if err := restore("sod_rule", `INSERT INTO sod_rule (key, role_a_id, role_b_id, kind, same_scope, description)
+				SELECT $1, a.id, b.id, $4, $5, $6 FROM role a, role b WHERE a.key=$2 AND b.key=$3`,
+				rule.Key, rule.RoleA, rule.RoleB, rule.Kind, rule.SameScope, rule.Description); err != nil {
+				return fmt.Errorf("sod rule %q: %w", rule.Key, err)
+			}
+		}
+		for _, rc := range backup.RoleCardinality {
+			if err := restore("role_cardinality", `INSERT INTO role_cardinality (role_id, scope_level, max_principals)
+				SELECT id, $2, $3 FROM role WHERE key=$1`, rc.Role, rc.Scope, rc.MaxPrincipals); err != nil {
+				return fmt.Errorf("role cardinality %q: %w", rc.Role, err)
+			}
+		}
+
+		if restoreRebac {
+			for _, ns := range backup.RebacNamespaces {
+				config, err := json.Marshal(ns.Relations)
+				if err != nil {
+					return err
+				}
+				if err := restore("rebac_namespace", `INSERT INTO rebac_namespace (name, config) VALUES ($1, $2::jsonb)
+					ON CONFLICT (name) DO UPDATE SET config = excluded.config, updated_at = now()`, ns.Name, string(config)); err != nil {
+					return fmt.Errorf("rebac namespace %q: %w", ns.Name, err)
+				}
+			}
+		}
+
+		if opts.ExcludePrincipals {
+			return recordBackupImport(ctx, tx, backup, opts, report, before)
+		}
+		// Restored assignments pass the static separation-of-duties rules and cardinality
+		// limits restored above, as every other path writing principal_role does. Ones
+		// already expired cannot conflict and are restored as they are.
+		now := time.Now()
+		for _, pr := range backup.PrincipalRoles {
+			if pr.ValidUntil == nil || pr.ValidUntil.After(now) {
+				if err := checkBackupRoleGrant(ctx, tx, pr); err != nil {
+					return fmt.Errorf("principal_role %s %s %s: %w", pr.PrincipalKind, pr.PrincipalID, pr.Role, err)
+				}
+			}
+			if err := restore("principal_role", `INSERT INTO principal_role
+				(principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until)
+				SELECT $1::uuid, $2::principal_kind, r.id, $4::uuid, s.id, $6::text, $7::uuid, $8::timestamptz, $9::timestamptz
+				FROM role r, service s WHERE r.key=$3 AND s.key=$5
+				ON CONFLICT DO NOTHING`,
+				pr.PrincipalID, pr.PrincipalKind, pr.Role, pr.Scope.TenantID, pr.Scope.Service, pr.Scope.ResourceKind, pr.Scope.ResourceID,
+				pr.ValidFrom, pr.ValidUntil); err != nil {
+				return err
+			}
+		}
+		for _, po := range backup.PrincipalOverrides {
+			if err := restore("principal_override", `INSERT INTO principal_override
+				(principal_id, principal_kind, permission_id, effect, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until, condition)
+				SELECT $1::uuid, $2::principal_kind, p.id, $5::override_effect, $6::uuid, s.id, $8::text, $9::uuid, $10::timestamptz, $11::timestamptz,
+					nullif($12::text, '')
+				FROM permission p, service s
+				WHERE p.action=$3 AND p.resource_kind=$4 AND s.key=$7
+				ON CONFLICT DO NOTHING`,
+				po.PrincipalID, po.PrincipalKind, po.Action, po.ResourceKind, po.Effect,
+				po.Scope.TenantID, po.Scope.Service, po.Scope.ResourceKind, po.Scope.ResourceID, po.ValidFrom, po.ValidUntil,
+				po.Condition); err != nil {
+				return err
+			}
+		}
+		for _, sa := range backup.Superadmins {
+			if err := restore("superadmin_principal", `INSERT INTO superadmin_principal (principal_id, principal_kind)
+				VALUES ($1, $2::principal_kind) ON CONFLICT DO NOTHING`, sa.PrincipalID, sa.PrincipalKind); err != nil {
+				return err
+			}
+		}
+		for _, g := range backup.Groups {
+			if err := restore("group", `INSERT INTO principal_group (id, key, title) VALUES ($1::uuid, $2, $3)`,
+				g.ID, g.Key, g.Title); err != nil {
+				return fmt.Errorf("group %q: %w", g.Key, err)
+			}
+		}
+		for _, m := range backup.GroupMembers {
+			if err := restore("group_member", `INSERT INTO group_member (group_id, member_id, member_kind)
+				VALUES ($1::uuid, $2::uuid, $3::principal_kind) ON CONFLICT DO NOTHING`,
+				m.GroupID, m.MemberID, m.MemberKind); err != nil {
+				return err
+			}
+		}
+		if restoreRebac {
+			for _, t := range backup.RelationTuples {
+				if err := restore("relation_tuple", `INSERT INTO relation_tuple
+					(namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+					VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+					t.Object.Namespace, t.Object.ID, t.Relation, t.Subject.Namespace, t.Subject.ID, t.Subject.Relation); err != nil {
+					return fmt.Errorf("relation tuple %s: %w", t, err)
+				}
+			}
+		}
+		if !restoreResources {
+			return recordBackupImport(ctx, tx, backup, opts, report, before)
+		}
+		// Resources are inserted as roots and linked to their parents once all exist.
+		for _, res := range backup.Resources {
+			if err := restore("resource", `INSERT INTO resource (id, kind) VALUES ($1::uuid, $2)`, res.ID, res.Kind); err != nil {
+				return fmt.Errorf("resource %q: %w", res.ID, err)
+			}
+		}
+		for _, res := range backup.Resources {
+			if res.ParentID == "" {
+				continue
+			}
+			if _, err := tx.Exec(ctx, `UPDATE resource SET parent_id = $2::uuid WHERE id = $1::uuid`, res.ID, res.ParentID); err != nil {
+				return fmt.Errorf("resource %q: %w", res.ID, err)
+			}
+		}
+		return recordBackupImport(ctx, tx, backup, opts, report, before)
+	})
+	if err != nil {
+		return policy.ImportReport{}, err
+	}
+	return report, nil
+}
+
+// checkKeptPrincipalReferences returns ErrConflict naming the services, roles and
+// permissions missing from the backup that principal assignments or overrides, which
+// an import excluding principals keeps, still reference: deleting them would cascade
+// to those rows.
+func checkKeptPrincipalReferences(ctx context.Context, tx pgx.Tx, serviceKeys, roleKeys, permissionKeys []string) error {
+	referenced := make([]string, 0)
+	err := scanRows(ctx, tx, `SELECT 'service ' || s.key FROM service s
+			WHERE NOT (s.key = ANY($1))
+				AND (EXISTS (SELECT 1 FROM principal_role pr WHERE pr.service_id = s.id)
+					OR EXISTS (SELECT 1 FROM principal_override po WHERE po.service_id = s.id))
+		UNION ALL
+		SELECT 'role ' || r.key FROM role r
+			WHERE NOT (r.key = ANY($2)) AND EXISTS (SELECT 1 FROM principal_role pr WHERE pr.role_id = r.id)
+		UNION ALL
+		SELECT 'permission ' || p.action || ':' || p.resource_kind FROM permission p
+			WHERE NOT (p.action || ':' || p.resource_kind = ANY($3))
+				AND EXISTS (SELECT 1 FROM principal_override po WHERE po.permission_id = p.id)
+		ORDER BY 1`, []any{serviceKeys, roleKeys, permissionKeys}, func(rows pgx.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		referenced = append(referenced, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(referenced) > 0 {
+		return fmt.Errorf("%w: the backup drops %s, still referenced by principal assignments or overrides kept by exclude_principals",
+			ErrConflict, strings.Join(referenced, ", "))
+	}
+	return nil
+}
+
+// checkKeptRelationTuples returns ErrConflict when relation tuples, which an import
+// excluding principals keeps, belong to a namespace or relation that the namespaces of
+// the backup drop or no longer store tuples for. It locks the namespaces so no tuple is
+// written meanwhile.
+func checkKeptRelationTuples(ctx context.Context, tx pgx.Tx, namespaces []policy.BackupRebacNamespace) error {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM rebac_namespace ORDER BY name FOR UPDATE`); err != nil {
+		return err
+	}
+	stored := make([]string, 0)
+	for _, ns := range namespaces {
+		for name, rewrite := range ns.Relations {
+			if rewrite.AllowsTuples() {
+				stored = append(stored, ns.Name+"#"+name)
+			}
+		}
+	}
+	orphaned := make([]string, 0)
+	err := scanRows(ctx, tx, `SELECT DISTINCT namespace || '#' || relation FROM relation_tuple
+		WHERE NOT (namespace || '#' || relation = ANY($1::text[]))
+		ORDER BY 1`, []any{stored}, func(rows pgx.Rows) error {
+		var relation string
+		if err := rows.Scan(&relation); err != nil {
+			return err
+		}
+		orphaned = append(orphaned, relation)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(orphaned) > 0 {
+		return fmt.Errorf("%w: the backup drops %s, still holding relation tuples kept by exclude_principals",
+			ErrConflict, strings.Join(orphaned, ", "))
+	}
+	return nil
+}
+
+// checkBackupRoleGrant runs a restored assignment through checkRoleGrant. An assignment
+// whose role or service the backup does not restore is skipped, as its insert is.
+func checkBackupRoleGrant(ctx context.Context, tx pgx.Tx, pr policy.BackupPrincipalRole) error {
+	grant := roleGrant{
+		PrincipalID:   pr.PrincipalID,
+		PrincipalKind: pr.PrincipalKind,
+		TenantID:      pr.Scope.TenantID,
+		ResourceKind:  pr.Scope.ResourceKind,
+		ResourceID:    pr.Scope.ResourceID,
+	}
+	err := tx.QueryRow(ctx, `SELECT r.id::text, s.id::text FROM role r, service s WHERE r.key=$1 AND s.key=$2`,
+		pr.Role, pr.Scope.Service).Scan(&grant.RoleID, &grant.ServiceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return checkRoleGrant(ctx, tx, grant)
+}
+
+// backupTables are the tables an import may change, counted before and after it for
+// the audit log.
+var backupTables = []string{
+	"service", "role", "permission", "role_hierarchy", "role_permission", "service_role", "service_permission",
+	"sod_rule", "role_cardinality", "principal_role", "principal_override", "superadmin_principal",
+	"principal_group", "group_member", "resource", "rebac_namespace", "relation_tuple",
+}
+
+// backupTableCounts returns the number of rows in each of backupTables.
+func backupTableCounts(ctx context.Context, tx pgx.Tx) (map[string]int, error) {
+	selects := make([]string, 0, len(backupTables))
+	for _, table := range backupTables {
+		selects = append(selects, fmt.Sprintf(`SELECT '%[1]s', count(*) FROM %[1]s`, table))
+	}
+	counts := make(map[string]int, len(backupTables))
+	err := scanRows(ctx, tx, strings.Join(selects, "\nUNION ALL "), nil, func(rows pgx.Rows) error {
+		var table string
+		var n int
+		if err := rows.Scan(&table, &n); err != nil {
+			return err
+		}
+		counts[table] = n
+		return nil
+	})
+	return counts, err
+}
+
+// recordBackupImport audits the import with the row count of every table before and
+// after it, and the rows each table had restored from the backup.
+func recordBackupImport(ctx context.Context, tx pgx.Tx, backup policy.Backup, opts policy.ImportOptions, report policy.ImportReport, before map[string]int) error {
+	after, err := backupTableCounts(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, auditChange{
+		Action: AuditActionImport,
+		Entity: "backup",
+		Before: auditValue(map[string]any{"rows": before}),
+		After: auditValue(map[string]any{
+			"exported_at":        backup.ExportedAt,
+			"exclude_principals": opts.ExcludePrincipals,
+			"restored":           report.Restored,
+			"rows":               after,
+		}),
+	})
+}
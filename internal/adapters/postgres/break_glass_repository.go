@@ -0,0 +1,353 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	BreakGlassReviewPending  = "pending"
+	BreakGlassReviewReviewed = "reviewed"
+)
+
+// BreakGlassPrincipal is a principal allowed to activate emergency superadmin.
+type BreakGlassPrincipal struct {
+	PrincipalID        string              `json:"principal_id"`
+	PrincipalKind      model.PrincipalKind `json:"principal_kind"`
+	MaxDurationSeconds int                 `json:"max_duration_seconds"`
+	RegisteredAt       time.Time           `json:"registered_at"`
+}
+
+// BreakGlassActivation is one use of break-glass access and its after-the-fact review.
+type BreakGlassActivation struct {
+	ID            string              `json:"id"`
+	PrincipalID   string              `json:"principal_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	Reason        string              `json:"reason"`
+	ActivatedAt   time.Time           `json:"activated_at"`
+	ExpiresAt     time.Time           `json:"expires_at"`
+	EndedAt       *time.Time          `json:"ended_at,omitempty"`
+	ReviewStatus  string              `json:"review_status"`
+	ReviewedAt    *time.Time          `json:"reviewed_at,omitempty"`
+	ReviewedBy    string              `json:"reviewed_by,omitempty"`
+	ReviewNotes   string              `json:"review_notes,omitempty"`
+}
+
+// BreakGlassFilter narrows ListActivations; empty fields match everything.
+type BreakGlassFilter struct {
+	ReviewStatus string
+	PrincipalID  string
+}
+
+// BreakGlassRepository stores break-glass registrations and activations.
+type BreakGlassRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewBreakGlassRepository(pool *pgxpool.Pool) *BreakGlassRepository {
+	return &BreakGlassRepository{pool: pool}
+}
+
+// The secret hash never leaves the database, not even into the audit log.
+const (
+	breakGlassPrincipalAuditQuery = `SELECT to_jsonb(b) - 'secret_hash' FROM break_glass_principal b
+		WHERE b.principal_id::text=$1 AND b.principal_kind=$2`
+	breakGlassActivationAuditQuery = `SELECT to_jsonb(a) FROM break_glass_activation a WHERE a.id::text=$1`
+	breakGlassActivationSelect     = `SELECT id::text, principal_id::text, principal_kind::text, reason, activated_at, expires_at,
+		ended_at, review_status, reviewed_at, reviewed_by, review_notes
+		FROM break_glass_activation`
+)
+
+func scanBreakGlassActivation(row pgx.Row) (BreakGlassActivation, error) {
+	var a BreakGlassActivation
+	var kind string
+	if err := row.Scan(&a.ID, &a.PrincipalID, &kind, &a.Reason, &a.ActivatedAt, &a.ExpiresAt,
+		&a.EndedAt, &a.ReviewStatus, &a.ReviewedAt, &a.ReviewedBy, &a.ReviewNotes); err != nil {
+		return BreakGlassActivation{}, err
+	}
+	a.PrincipalKind = model.PrincipalKind(kind)
+	return a, nil
+}
+
+func getBreakGlassActivation(ctx context.Context, q dbtx, id string) (BreakGlassActivation, error) {
+	a, err := scanBreakGlassActivation(q.QueryRow(ctx, breakGlassActivationSelect+` WHERE id::text=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BreakGlassActivation{}, ErrNotFound
+	}
+	return a, err
+}
+
+// Register enrols the principal or replaces its secret and maximum duration.
+func (r *BreakGlassRepository) Register(ctx context.Context, p BreakGlassPrincipal, secretHash string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		key := []any{p.PrincipalID, string(p.PrincipalKind)}
+		before, err := auditRow(ctx, tx, breakGlassPrincipalAuditQuery+` FOR UPDATE`, key...)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO break_glass_principal (principal_id, principal_kind, secret_hash, max_duration_seconds)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (principal_id, principal_kind)
+			DO UPDATE SET secret_hash = excluded.secret_hash, max_duration_seconds = excluded.max_duration_seconds, registered_at = now()`,
+			p.PrincipalID, string(p.PrincipalKind), secretHash, p.MaxDurationSeconds); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, breakGlassPrincipalAuditQuery, key...)
+		if err != nil {
+			return err
+		}
+		action := AuditActionCreate
+		if before != nil {
+			action = AuditActionUpdate
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "break_glass_principal", EntityID: p.PrincipalID, Before: before, After: after})
+	})
+}
+
+// Unregister removes the principal's break-glass registration. Activations are kept
+// for review.
+func (r *BreakGlassRepository) Unregister(ctx context.Context, principalID string, kind model.PrincipalKind) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		key := []any{principalID, string(kind)}
+		before, err := auditRow(ctx, tx, breakGlassPrincipalAuditQuery+` FOR UPDATE`, key...)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM break_glass_principal WHERE principal_id::text=$1 AND principal_kind=$2`, key...); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "break_glass_principal", EntityID: principalID, Before: before})
+	})
+}
+
+func (r *BreakGlassRepository) ListPrincipals(ctx context.Context) ([]BreakGlassPrincipal, error) {
+	items := make([]BreakGlassPrincipal, 0)
+	err := scanRows(ctx, r.pool, `SELECT principal_id::text, principal_kind::text, max_duration_seconds, registered_at
+		FROM break_glass_principal ORDER BY principal_id, principal_kind`, nil, func(rows pgx.Rows) error {
+		var p BreakGlassPrincipal
+		var kind string
+		if err := rows.Scan(&p.PrincipalID, &kind, &p.MaxDurationSeconds, &p.RegisteredAt); err != nil {
+			return err
+		}
+		p.PrincipalKind = model.PrincipalKind(kind)
+		items = append(items, p)
+		return nil
+	})
+	return items, err
+}
+
+// Credential returns the registration and stored secret hash of the principal.
+func (r *BreakGlassRepository) Credential(ctx context.Context, principalID string, kind model.PrincipalKind) (BreakGlassPrincipal, string, error) {
+	p := BreakGlassPrincipal{PrincipalID: principalID, PrincipalKind: kind}
+	var hash string
+	err := r.pool.QueryRow(ctx, `SELECT secret_hash, max_duration_seconds, registered_at FROM break_glass_principal
+		WHERE principal_id::text=$1 AND principal_kind=$2`, principalID, string(kind)).Scan(&hash, &p.MaxDurationSeconds, &p.RegisteredAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BreakGlassPrincipal{}, "", ErrNotFound
+	}
+	return p, hash, err
+}
+
+// Activate opens an activation lasting duration. A principal has at most one active
+// activation; a second attempt while one is active returns ErrConflict.
+func (r *BreakGlassRepository) Activate(ctx context.Context, principalID string, kind model.PrincipalKind, reason string, duration time.Duration) (BreakGlassActivation, error) {
+	var out BreakGlassActivation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// Lock the registration so concurrent activations serialise on it.
+		var locked int
+		if err := tx.QueryRow(ctx, `SELECT 1 FROM break_glass_principal
+			WHERE principal_id::text=$1 AND principal_kind=$2 FOR UPDATE`, principalID, string(kind)).Scan(&locked); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		var active bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM break_glass_activation
+			WHERE principal_id::text=$1 AND principal_kind=$2 AND ended_at IS NULL AND expires_at > now())`,
+			principalID, string(kind)).Scan(&active); err != nil {
+			return err
+		}
+		if active {
+			return ErrConflict
+		}
+		var id string
+		if err := tx.QueryRow(ctx, `INSERT INTO break_glass_activation (principal_id, principal_kind, reason, expires_at)
+			VALUES ($1, $2, $3, now() + make_interval(secs => $4)) RETURNING id::text`,
+			principalID, string(kind), reason, duration.Seconds()).Scan(&id); err != nil {
+			return err
+		}
+		var err error
+		if out, err = getBreakGlassActivation(ctx, tx, id); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, breakGlassActivationAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionActivate, Entity: "break_glass_activation", EntityID: id, After: after})
+	})
+	return out, err
+}
+
+// RecordDenied audits a failed activation attempt.
+func (r *BreakGlassRepository) RecordDenied(ctx context.Context, principalID string, kind model.PrincipalKind, reason string) error {
+	return recordAudit(ctx, r.pool, auditChange{
+		Action:   AuditActionDeny,
+		Entity:   "break_glass_activation",
+		EntityID: principalID,
+		After:    auditValue(map[string]string{"principal_id": principalID, "principal_kind": string(kind), "reason": reason}),
+	})
+}
+
+// Deactivate ends an active activation early.
+func (r *BreakGlassRepository) Deactivate(ctx context.Context, id string) (BreakGlassActivation, error) {
+	var out BreakGlassActivation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, breakGlassActivationAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		cmd, err := tx.Exec(ctx, `UPDATE break_glass_activation SET ended_at = now()
+			WHERE id::text=$1 AND ended_at IS NULL AND expires_at > now()`, id)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return ErrConflict
+		}
+		if out, err = getBreakGlassActivation(ctx, tx, id); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, breakGlassActivationAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDeactivate, Entity: "break_glass_activation", EntityID: id, Before: before, After: after})
+	})
+	return out, err
+}
+
+// EndExpired closes activations whose expiry has passed and returns them.
+func (r *BreakGlassRepository) EndExpired(ctx context.Context) ([]BreakGlassActivation, error) {
+	var ended []BreakGlassActivation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		ended = ended[:0]
+		var ids []string
+		if err := scanRows(ctx, tx, `WITH due AS (
+				SELECT id FROM break_glass_activation
+				WHERE ended_at IS NULL AND expires_at <= now()
+				FOR UPDATE SKIP LOCKED
+			)
+			UPDATE break_glass_activation a SET ended_at = a.expires_at
+			FROM due WHERE a.id = due.id
+			RETURNING a.id::text`, nil, func(rows pgx.Rows) error {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			a, err := getBreakGlassActivation(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			ended = append(ended, a)
+			if err := recordAudit(ctx, tx, auditChange{Action: AuditActionExpire, Entity: "break_glass_activation", EntityID: id, After: auditValue(a)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return ended, err
+}
+
+// ListActivations returns matching activations, newest first, with the total count.
+func (r *BreakGlassRepository) ListActivations(ctx context.Context, filter BreakGlassFilter, offset, limit int) ([]BreakGlassActivation, int64, error) {
+	var conds []string
+	var args []any
+	if filter.ReviewStatus != "" {
+		args = append(args, filter.ReviewStatus)
+		conds = append(conds, fmt.Sprintf("review_status = $%d", len(args)))
+	}
+	if filter.PrincipalID != "" {
+		args = append(args, filter.PrincipalID)
+		conds = append(conds, fmt.Sprintf("principal_id::text = $%d", len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM break_glass_activation`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf(`%s%s ORDER BY activated_at DESC, id LIMIT $%d OFFSET $%d`, breakGlassActivationSelect, where, len(args)+1, len(args)+2)
+	items := make([]BreakGlassActivation, 0)
+	err := scanRows(ctx, r.pool, query, append(args, limit, offset), func(rows pgx.Rows) error {
+		a, err := scanBreakGlassActivation(rows)
+		if err != nil {
+			return err
+		}
+		items = append(items, a)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *BreakGlassRepository) GetActivation(ctx context.Context, id string) (BreakGlassActivation, error) {
+	return getBreakGlassActivation(ctx, r.pool, id)
+}
+
+// Review records the mandatory after-the-fact review of an activation. Only ended,
+// unreviewed activations can be reviewed.
+func (r *BreakGlassRepository) Review(ctx context.Context, id, reviewer, notes string) (BreakGlassActivation, error) {
+	var out BreakGlassActivation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, breakGlassActivationAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		cmd, err := tx.Exec(ctx, `UPDATE break_glass_activation
+			SET review_status = 'reviewed', reviewed_at = now(), reviewed_by = $2, review_notes = $3
+			WHERE id::text=$1 AND review_status = 'pending' AND (ended_at IS NOT NULL OR expires_at <= now())`, id, reviewer, notes)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return ErrConflict
+		}
+		if out, err = getBreakGlassActivation(ctx, tx, id); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, breakGlassActivationAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionReview, Entity: "break_glass_activation", EntityID: id, Before: before, After: after})
+	})
+	return out, err
+}
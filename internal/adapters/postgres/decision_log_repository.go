@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DecisionLogRepository stores PDP decisions in decision_log.
+type DecisionLogRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewDecisionLogRepository(pool *pgxpool.Pool) *DecisionLogRepository {
+	return &DecisionLogRepository{pool: pool}
+}
+
+var decisionLogColumns = []string{
+	"occurred_at", "principal_id", "principal_kind", "tenant_id", "service_id", "action",
+	"resource_kind", "resource_id", "correlation_id", "allow", "decision", "role_keys",
+	"matched", "latency_us", "error", "sod_conflicts",
+}
+
+// WriteDecisions bulk-inserts records with COPY.
+func (r *DecisionLogRepository) WriteDecisions(ctx context.Context, records []domainpdp.DecisionRecord) error {
+	rows := make([][]any, 0, len(records))
+	for _, rec := range records {
+		var matched any
+		if rec.Matched != nil {
+			data, err := json.Marshal(rec.Matched)
+			if err != nil {
+				return err
+			}
+			matched = string(data)
+		}
+		var conflicts any
+		if len(rec.SoDConflicts) > 0 {
+			data, err := json.Marshal(rec.SoDConflicts)
+			if err != nil {
+				return err
+			}
+			conflicts = string(data)
+		}
+		roleKeys := rec.RoleKeys
+		if roleKeys == nil {
+			roleKeys = []string{}
+		}
+		rows = append(rows, []any{
+			rec.OccurredAt, rec.PrincipalID, string(rec.PrincipalKind), rec.TenantID, rec.ServiceID, rec.Action,
+			rec.ResourceKind, rec.ResourceID, rec.CorrelationID, rec.Allow, rec.Decision, roleKeys,
+			matched, rec.LatencyMicros, rec.Error, conflicts,
+		})
+	}
+	_, err := r.pool.CopyFrom(ctx, pgx.Identifier{"decision_log"}, decisionLogColumns, pgx.CopyFromRows(rows))
+	return err
+}
@@ -0,0 +1,351 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ElevationPending  = "pending"
+	ElevationApproved = "approved"
+	ElevationRejected = "rejected"
+	ElevationExpired  = "expired"
+)
+
+// ElevationRequestInput asks for a role for a limited time. Empty scope fields fall back
+// to the global defaults like role assignments do.
+type ElevationRequestInput struct {
+	PrincipalID   string              `json:"principal_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	RoleKey       string              `json:"role_key"`
+	TenantID      string              `json:"tenant_id"`
+	ServiceID     string              `json:"service_id"`
+	ResourceKind  string              `json:"resource_kind"`
+	ResourceID    string              `json:"resource_id"`
+	Justification string              `json:"justification"`
+	// Duration is how long the role is granted for once approved.
+	Duration time.Duration `json:"-"`
+	// PendingTTL is how long the request waits for a decision before it expires.
+	PendingTTL time.Duration `json:"-"`
+}
+
+// ElevationRequest is a stored elevation request. Scope fields are nil when global.
+type ElevationRequest struct {
+	ID              string              `json:"id"`
+	PrincipalID     string              `json:"principal_id"`
+	PrincipalKind   model.PrincipalKind `json:"principal_kind"`
+	RoleID          string              `json:"role_id"`
+	RoleKey         string              `json:"role_key"`
+	TenantID        *string             `json:"tenant_id,omitempty"`
+	ServiceID       *string             `json:"service_id,omitempty"`
+	ResourceKind    *string             `json:"resource_kind,omitempty"`
+	ResourceID      *string             `json:"resource_id,omitempty"`
+	Justification   string              `json:"justification"`
+	DurationSeconds int                 `json:"duration_seconds"`
+	Status          string              `json:"status"`
+	RequestedAt     time.Time           `json:"requested_at"`
+	ExpiresAt       time.Time           `json:"expires_at"`
+	DecidedAt       *time.Time          `json:"decided_at,omitempty"`
+	DecidedBy       string              `json:"decided_by,omitempty"`
+	DecisionNote    string              `json:"decision_note,omitempty"`
+	GrantedUntil    *time.Time          `json:"granted_until,omitempty"`
+}
+
+// ElevationDecision identifies who approved or rejected a request and why.
+type ElevationDecision struct {
+	ApproverID   string              `json:"approver_id"`
+	ApproverKind model.PrincipalKind `json:"approver_kind"`
+	Note         string              `json:"note"`
+}
+
+// ElevationFilter narrows ElevationRepository.List; empty fields match everything.
+type ElevationFilter struct {
+	Status      string
+	PrincipalID string
+}
+
+// ElevationRepository stores just-in-time elevation requests.
+type ElevationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewElevationRepository(pool *pgxpool.Pool) *ElevationRepository {
+	return &ElevationRepository{pool: pool}
+}
+
+const elevationSelect = `SELECT e.id::text, e.principal_id::text, e.principal_kind::text, e.role_id::text, r.key,
+	e.tenant_id::text, e.service_id::text, e.resource_kind, e.resource_id::text,
+	e.justification, e.duration_seconds, e.status, e.requested_at, e.expires_at,
+	e.decided_at, e.decided_by, e.decision_note, e.granted_until
+	FROM elevation_request e
+	JOIN role r ON r.id = e.role_id`
+
+const elevationAuditQuery = `SELECT to_jsonb(e) FROM elevation_request e WHERE e.id::text=$1`
+
+// elevationGrantAuditQuery loads the principal_role row matching a request's scope.
+const elevationGrantAuditQuery = `SELECT to_jsonb(pr) || jsonb_build_object('role_key', r.key)
+	FROM elevation_request e
+	JOIN principal_role pr ON (pr.principal_id, pr.principal_kind, pr.role_id, pr.tenant_id, pr.service_id, pr.resource_kind, pr.resource_id)
+		= (e.principal_id, e.principal_kind, e.role_id, e.tenant_id, e.service_id, e.resource_kind, e.resource_id)
+	JOIN role r ON r.id = pr.role_id
+	WHERE e.id::text=$1`
+
+func scanElevation(row pgx.Row) (ElevationRequest, error) {
+	var e ElevationRequest
+	var kind, tenantID, serviceID, resourceKind, resourceID string
+	if err := row.Scan(&e.ID, &e.PrincipalID, &kind, &e.RoleID, &e.RoleKey,
+		&tenantID, &serviceID, &resourceKind, &resourceID,
+		&e.Justification, &e.DurationSeconds, &e.Status, &e.RequestedAt, &e.ExpiresAt,
+		&e.DecidedAt, &e.DecidedBy, &e.DecisionNote, &e.GrantedUntil); err != nil {
+		return ElevationRequest{}, err
+	}
+	e.PrincipalKind = model.PrincipalKind(kind)
+	e.TenantID = ptrIfNotDefault(tenantID, defaultTenantID)
+	e.ServiceID = ptrIfNotDefault(serviceID, defaultServiceID)
+	e.ResourceKind = ptrIfNotDefault(resourceKind, defaultScopeKind)
+	e.ResourceID = ptrIfNotDefault(resourceID, defaultResourceID)
+	return e, nil
+}
+
+func getElevation(ctx context.Context, q dbtx, id string) (ElevationRequest, error) {
+	e, err := scanElevation(q.QueryRow(ctx, elevationSelect+` WHERE e.id::text=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ElevationRequest{}, ErrNotFound
+	}
+	return e, err
+}
+
+// Create stores a pending request.
+func (r *ElevationRepository) Create(ctx context.Context, input ElevationRequestInput) (ElevationRequest, error) {
+	if input.PrincipalKind == "" {
+		input.PrincipalKind = defaultRoleKind
+	}
+	if input.TenantID == "" {
+		input.TenantID = defaultTenantID
+	}
+	if input.ServiceID == "" {
+		input.ServiceID = defaultServiceID
+	}
+	if input.ResourceKind == "" {
+		input.ResourceKind = defaultScopeKind
+	}
+	input.ResourceID = resourceIDOrDefault(input.ResourceID)
+
+	var out ElevationRequest
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		if err := ensureServiceExists(ctx, tx, input.ServiceID); err != nil {
+			return err
+		}
+		var id string
+		if err := tx.QueryRow(ctx, `INSERT INTO elevation_request
+			(principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id,
+				justification, duration_seconds, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now() + make_interval(secs => $10))
+			RETURNING id::text`,
+			input.PrincipalID, string(input.PrincipalKind), roleID, input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID,
+			input.Justification, int(input.Duration/time.Second), input.PendingTTL.Seconds()).Scan(&id); err != nil {
+			return err
+		}
+		if out, err = getElevation(ctx, tx, id); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, elevationAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "elevation_request", EntityID: id, After: after})
+	})
+	return out, err
+}
+
+func (r *ElevationRepository) Get(ctx context.Context, id string) (ElevationRequest, error) {
+	return getElevation(ctx, r.pool, id)
+}
+
+// List returns matching requests, newest first, with the total match count.
+func (r *ElevationRepository) List(ctx context.Context, filter ElevationFilter, offset, limit int) ([]ElevationRequest, int64, error) {
+	var conds []string
+	var args []any
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conds = append(conds, fmt.Sprintf("e.status = $%d", len(args)))
+	}
+	if filter.PrincipalID != "" {
+		args = append(args, filter.PrincipalID)
+		conds = append(conds, fmt.Sprintf("e.principal_id::text = $%d", len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM elevation_request e`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf(`%s%s ORDER BY e.requested_at DESC, e.id LIMIT $%d OFFSET $%d`, elevationSelect, where, len(args)+1, len(args)+2)
+	items := make([]ElevationRequest, 0)
+	err := scanRows(ctx, r.pool, query, append(args, limit, offset), func(rows pgx.Rows) error {
+		e, err := scanElevation(rows)
+		if err != nil {
+			return err
+		}
+		items = append(items, e)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// Approve marks a pending request approved and grants its role from now until now plus
+// the requested duration. An existing permanent assignment of the same scope is left
+// untouched and an existing time-bound one is only ever extended. A grant forbidden by a
+// separation-of-duties rule or cardinality limit fails like PrincipalRoleRepository.Assign
+// and leaves the request pending.
+func (r *ElevationRepository) Approve(ctx context.Context, id string, decision ElevationDecision) (ElevationRequest, error) {
+	var out ElevationRequest
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := decideElevation(ctx, tx, id, `status = 'approved', decided_at = now(), decided_by = $2, decision_note = $3,
+			granted_until = now() + make_interval(secs => duration_seconds)`, decision)
+		if err != nil {
+			return err
+		}
+
+		var grant roleGrant
+		if err := tx.QueryRow(ctx, `SELECT principal_id::text, principal_kind::text, role_id::text,
+			tenant_id::text, service_id::text, resource_kind, resource_id::text
+			FROM elevation_request WHERE id::text=$1`, id).Scan(&grant.PrincipalID, &grant.PrincipalKind, &grant.RoleID,
+			&grant.TenantID, &grant.ServiceID, &grant.ResourceKind, &grant.ResourceID); err != nil {
+			return err
+		}
+		if err := checkRoleGrant(ctx, tx, grant); err != nil {
+			return err
+		}
+		grantBefore, err := auditRow(ctx, tx, elevationGrantAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		cmd, err := tx.Exec(ctx, `INSERT INTO principal_role
+			(principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until)
+			SELECT principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id, decided_at, granted_until
+			FROM elevation_request WHERE id::text=$1
+			ON CONFLICT (principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id)
+			DO UPDATE SET valid_from = excluded.valid_from, valid_until = excluded.valid_until
+			WHERE principal_role.valid_until IS NOT NULL AND principal_role.valid_until < excluded.valid_until`, id)
+		if err != nil {
+			return err
+		}
+		if out, err = getElevation(ctx, tx, id); err != nil {
+			return err
+		}
+		if cmd.RowsAffected() > 0 {
+			grantAfter, err := auditRow(ctx, tx, elevationGrantAuditQuery, id)
+			if err != nil {
+				return err
+			}
+			action := AuditActionCreate
+			if grantBefore != nil {
+				action = AuditActionUpdate
+			}
+			if err := recordAudit(ctx, tx, auditChange{Action: action, Entity: "principal_role", EntityID: out.PrincipalID, Before: grantBefore, After: grantAfter}); err != nil {
+				return err
+			}
+		}
+		after, err := auditRow(ctx, tx, elevationAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionApprove, Entity: "elevation_request", EntityID: id, Before: before, After: after})
+	})
+	return out, err
+}
+
+// Reject marks a pending request rejected.
+func (r *ElevationRepository) Reject(ctx context.Context, id string, decision ElevationDecision) (ElevationRequest, error) {
+	var out ElevationRequest
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := decideElevation(ctx, tx, id, `status = 'rejected', decided_at = now(), decided_by = $2, decision_note = $3`, decision)
+		if err != nil {
+			return err
+		}
+		if out, err = getElevation(ctx, tx, id); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, elevationAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionReject, Entity: "elevation_request", EntityID: id, Before: before, After: after})
+	})
+	return out, err
+}
+
+// decideElevation locks the request and applies set when it is still pending and not
+// past its deadline. It returns the row as it was before the update.
+func decideElevation(ctx context.Context, tx pgx.Tx, id, set string, decision ElevationDecision) ([]byte, error) {
+	before, err := auditRow(ctx, tx, elevationAuditQuery+` FOR UPDATE`, id)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		return nil, ErrNotFound
+	}
+	cmd, err := tx.Exec(ctx, `UPDATE elevation_request SET `+set+`
+		WHERE id::text=$1 AND status = 'pending' AND expires_at > now()`, id, decision.ApproverID, decision.Note)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return nil, ErrConflict
+	}
+	return before, nil
+}
+
+// ExpirePending marks pending requests past their deadline as expired.
+func (r *ElevationRepository) ExpirePending(ctx context.Context) (int, error) {
+	var expired int
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		type change struct {
+			id    string
+			after []byte
+		}
+		var changes []change
+		if err := scanRows(ctx, tx, `WITH due AS (
+				SELECT id FROM elevation_request
+				WHERE status = 'pending' AND expires_at <= now()
+				FOR UPDATE SKIP LOCKED
+			)
+			UPDATE elevation_request e SET status = 'expired', decided_at = now()
+			FROM due WHERE e.id = due.id
+			RETURNING e.id::text, to_jsonb(e)`, nil, func(rows pgx.Rows) error {
+			var c change
+			if err := rows.Scan(&c.id, &c.after); err != nil {
+				return err
+			}
+			changes = append(changes, c)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if err := recordAudit(ctx, tx, auditChange{Action: AuditActionExpire, Entity: "elevation_request", EntityID: c.id, After: c.after}); err != nil {
+				return err
+			}
+		}
+		expired = len(changes)
+		return nil
+	})
+	return expired, err
+}
@@ -5,4 +5,11 @@ import "errors"
 var (
 	ErrNotFound       = errors.New("record not found")
 	ErrNotImplemented = errors.New("not implemented")
+	// ErrConflict reports a mutation that is invalid for the record's current state.
+	ErrConflict = errors.New("conflicting state")
+	// ErrSoDViolation reports an assignment forbidden by a static separation-of-duties rule.
+	ErrSoDViolation = errors.New("separation of duties violation")
+	// ErrCardinalityExceeded reports an assignment that would exceed a role's limit on
+	// the number of principals holding it in a scope.
+	ErrCardinalityExceeded = errors.New("role cardinality exceeded")
 )
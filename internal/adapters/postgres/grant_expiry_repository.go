@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ExpiredGrantRole     = "principal_role"
+	ExpiredGrantOverride = "principal_override"
+)
+
+// ExpiredGrant is a role assignment or override removed because its validity window ended.
+type ExpiredGrant struct {
+	Kind          string     `json:"kind"`
+	PrincipalID   string     `json:"principal_id"`
+	PrincipalKind string     `json:"principal_kind"`
+	RoleKey       string     `json:"role_key,omitempty"`
+	PermissionID  string     `json:"permission_id,omitempty"`
+	Effect        string     `json:"effect,omitempty"`
+	TenantID      string     `json:"tenant_id"`
+	ServiceID     string     `json:"service_id"`
+	ResourceKind  string     `json:"resource_kind"`
+	ResourceID    string     `json:"resource_id"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    time.Time  `json:"valid_until"`
+	Archived      bool       `json:"archived"`
+}
+
+// GrantExpiryRepository removes expired principal grants.
+type GrantExpiryRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewGrantExpiryRepository(pool *pgxpool.Pool) *GrantExpiryRepository {
+	return &GrantExpiryRepository{pool: pool}
+}
+
+// SweepExpired deletes every role assignment and override whose valid_until has passed,
+// copying them to the archive tables first when archive is set. Each removal is audited.
+func (r *GrantExpiryRepository) SweepExpired(ctx context.Context, archive bool) ([]ExpiredGrant, error) {
+	var expired []ExpiredGrant
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		expired = expired[:0]
+		if err := scanRows(ctx, tx, `WITH expired AS (
+				DELETE FROM principal_role WHERE valid_until <= now()
+				RETURNING *
+			), archived AS (
+				INSERT INTO principal_role_archive
+					(principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until)
+				SELECT principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until
+				FROM expired WHERE $1::boolean
+			)
+			SELECT e.principal_id::text, e.principal_kind::text, r.key, e.tenant_id::text, e.service_id::text,
+				e.resource_kind, e.resource_id::text, e.valid_from, e.valid_until
+			FROM expired e JOIN role r ON r.id = e.role_id
+			ORDER BY e.valid_until`, []any{archive}, func(rows pgx.Rows) error {
+			g := ExpiredGrant{Kind: ExpiredGrantRole, Archived: archive}
+			if err := rows.Scan(&g.PrincipalID, &g.PrincipalKind, &g.RoleKey, &g.TenantID, &g.ServiceID,
+				&g.ResourceKind, &g.ResourceID, &g.ValidFrom, &g.ValidUntil); err != nil {
+				return err
+			}
+			expired = append(expired, g)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := scanRows(ctx, tx, `WITH expired AS (
+				DELETE FROM principal_override WHERE valid_until <= now()
+				RETURNING *
+			), archived AS (
+				INSERT INTO principal_override_archive
+					(principal_id, principal_kind, permission_id, effect, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until, condition)
+				SELECT principal_id, principal_kind, permission_id, effect, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until, condition
+				FROM expired WHERE $1::boolean
+			)
+			SELECT principal_id::text, principal_kind::text, permission_id::text, effect::text, tenant_id::text, service_id::text,
+				resource_kind, resource_id::text, valid_from, valid_until
+			FROM expired
+			ORDER BY valid_until`, []any{archive}, func(rows pgx.Rows) error {
+			g := ExpiredGrant{Kind: ExpiredGrantOverride, Archived: archive}
+			if err := rows.Scan(&g.PrincipalID, &g.PrincipalKind, &g.PermissionID, &g.Effect, &g.TenantID, &g.ServiceID,
+				&g.ResourceKind, &g.ResourceID, &g.ValidFrom, &g.ValidUntil); err != nil {
+				return err
+			}
+			expired = append(expired, g)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, g := range expired {
+			if err := recordAudit(ctx, tx, auditChange{Action: AuditActionExpire, Entity: g.Kind, EntityID: g.PrincipalID, Before: auditValue(g)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return expired, err
+}
@@ -0,0 +1,256 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Group is a principal of kind "group". Roles and overrides assigned to it use the group
+// id as principal_id; its members inherit the roles.
+type Group struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GroupMember places a user, service account or nested group in a group.
+type GroupMember struct {
+	GroupID    string              `json:"group_id"`
+	MemberID   string              `json:"member_id"`
+	MemberKind model.PrincipalKind `json:"member_kind"`
+	AddedAt    time.Time           `json:"added_at"`
+}
+
+// GroupRepository manages groups and their members.
+type GroupRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewGroupRepository(pool *pgxpool.Pool) *GroupRepository {
+	return &GroupRepository{pool: pool}
+}
+
+func (r *GroupRepository) Create(ctx context.Context, group *Group) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `INSERT INTO principal_group (key, title) VALUES ($1, $2) RETURNING id::text, created_at`,
+			group.Key, group.Title).Scan(&group.ID, &group.CreatedAt); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, groupAuditQuery, group.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "group", EntityID: group.ID, After: after})
+	})
+}
+
+func (r *GroupRepository) Update(ctx context.Context, id, title string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, groupAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `UPDATE principal_group SET title=$2 WHERE id::text=$1`, id, title); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, groupAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionUpdate, Entity: "group", EntityID: id, Before: before, After: after})
+	})
+}
+
+func (r *GroupRepository) Get(ctx context.Context, id string) (*Group, error) {
+	var group Group
+	row := r.pool.QueryRow(ctx, `SELECT id::text, key, title, created_at FROM principal_group WHERE id::text=$1`, id)
+	if err := row.Scan(&group.ID, &group.Key, &group.Title, &group.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GroupRepository) List(ctx context.Context, offset, limit int) ([]Group, int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM principal_group`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items, err := r.scanGroups(ctx, `SELECT id::text, key, title, created_at FROM principal_group ORDER BY key LIMIT $1 OFFSET $2`, limit, offset)
+	return items, total, err
+}
+
+// Delete removes the group together with its memberships in other groups and the role
+// assignments and overrides held by the group.
+func (r *GroupRepository) Delete(ctx context.Context, id string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, groupAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		for _, query := range []string{
+			`DELETE FROM group_member WHERE member_id::text=$1 AND member_kind='group'`,
+			`DELETE FROM principal_role WHERE principal_id::text=$1 AND principal_kind='group'`,
+			`DELETE FROM principal_override WHERE principal_id::text=$1 AND principal_kind='group'`,
+			`DELETE FROM principal_group WHERE id::text=$1`,
+		} {
+			if _, err := tx.Exec(ctx, query, id); err != nil {
+				return err
+			}
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "group", EntityID: id, Before: before})
+	})
+}
+
+// AddMember adds a principal to the group; adding an existing member is a no-op. Nesting
+// a group that already contains this group, directly or indirectly, returns ErrConflict.
+func (r *GroupRepository) AddMember(ctx context.Context, member GroupMember) error {
+	if member.MemberKind == "" {
+		member.MemberKind = defaultRoleKind
+	}
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var locked string
+		if err := tx.QueryRow(ctx, `SELECT id::text FROM principal_group WHERE id::text=$1 FOR UPDATE`, member.GroupID).Scan(&locked); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if member.MemberKind == model.PrincipalKindGroup {
+			if err := r.checkNesting(ctx, tx, member.GroupID, member.MemberID); err != nil {
+				return err
+			}
+		}
+		tag, err := tx.Exec(ctx, `INSERT INTO group_member (group_id, member_id, member_kind) VALUES ($1::uuid, $2::uuid, $3::principal_kind)
+			ON CONFLICT DO NOTHING`, member.GroupID, member.MemberID, string(member.MemberKind))
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		after, err := auditRow(ctx, tx, groupMemberAuditQuery, member.GroupID, member.MemberID, string(member.MemberKind))
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "group_member", EntityID: member.GroupID, After: after})
+	})
+}
+
+// checkNesting verifies that child is an existing group and that nesting it in parent
+// would not create a membership cycle.
+func (r *GroupRepository) checkNesting(ctx context.Context, tx pgx.Tx, parent, child string) error {
+	var exists, cycle bool
+	err := tx.QueryRow(ctx, `WITH RECURSIVE descendant(id) AS (
+			SELECT $2::uuid
+			UNION
+			SELECT gm.member_id FROM group_member gm
+			JOIN descendant d ON gm.group_id = d.id
+			WHERE gm.member_kind = 'group'
+		)
+		SELECT EXISTS(SELECT 1 FROM principal_group WHERE id = $2::uuid),
+			EXISTS(SELECT 1 FROM descendant WHERE id = $1::uuid)`, parent, child).Scan(&exists, &cycle)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	if cycle {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *GroupRepository) RemoveMember(ctx context.Context, member GroupMember) error {
+	if member.MemberKind == "" {
+		member.MemberKind = defaultRoleKind
+	}
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		key := []any{member.GroupID, member.MemberID, string(member.MemberKind)}
+		before, err := auditRow(ctx, tx, groupMemberAuditQuery+` FOR UPDATE`, key...)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM group_member
+			WHERE group_id::text=$1 AND member_id::text=$2 AND member_kind::text=$3`, key...); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "group_member", EntityID: member.GroupID, Before: before})
+	})
+}
+
+// ListMembers returns the direct members of the group.
+func (r *GroupRepository) ListMembers(ctx context.Context, groupID string, offset, limit int) ([]GroupMember, int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM group_member WHERE group_id::text=$1`, groupID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.pool.Query(ctx, `SELECT group_id::text, member_id::text, member_kind::text, added_at
+		FROM group_member WHERE group_id::text=$1
+		ORDER BY member_kind, member_id LIMIT $2 OFFSET $3`, groupID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	items := make([]GroupMember, 0)
+	for rows.Next() {
+		var item GroupMember
+		var kind string
+		if err := rows.Scan(&item.GroupID, &item.MemberID, &kind, &item.AddedAt); err != nil {
+			return nil, 0, err
+		}
+		item.MemberKind = model.PrincipalKind(kind)
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
+
+// ListByPrincipal returns every group the principal belongs to, directly or through
+// nested groups.
+func (r *GroupRepository) ListByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) ([]Group, error) {
+	if kind == "" {
+		kind = defaultRoleKind
+	}
+	return r.scanGroups(ctx, principalClosure+`SELECT g.id::text, g.key, g.title, g.created_at
+		FROM principal_group g
+		JOIN principal_closure pc ON pc.principal_id = g.id AND pc.principal_kind = 'group'
+		WHERE NOT (g.id = $1::uuid AND $2::principal_kind = 'group')
+		ORDER BY g.key`, principalID, string(kind))
+}
+
+func (r *GroupRepository) scanGroups(ctx context.Context, query string, args ...any) ([]Group, error) {
+	items := make([]Group, 0)
+	err := scanRows(ctx, r.pool, query, args, func(rows pgx.Rows) error {
+		var group Group
+		if err := rows.Scan(&group.ID, &group.Key, &group.Title, &group.CreatedAt); err != nil {
+			return err
+		}
+		items = append(items, group)
+		return nil
+	})
+	return items, err
+}
+
+const (
+	groupAuditQuery       = `SELECT to_jsonb(g) FROM principal_group g WHERE g.id::text=$1`
+	groupMemberAuditQuery = `SELECT to_jsonb(gm) FROM group_member gm
+		WHERE gm.group_id::text=$1 AND gm.member_id::text=$2 AND gm.member_kind::text=$3`
+)
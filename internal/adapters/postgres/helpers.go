@@ -6,6 +6,7 @@ import (
 
 	"github.com/example/ms-rbac-service/internal/domain/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,7 +18,64 @@ const (
 	defaultRoleKind   = model.PrincipalKindUser
 )
 
-func roleIDByKey(ctx context.Context, pool *pgxpool.Pool, roleKey string) (string, error) {
+// activePrincipalRole and activePrincipalOverride restrict principal_role (pr) and
+// principal_override (po) rows to those whose validity window contains now().
+const (
+	activePrincipalRole     = `(pr.valid_from IS NULL OR pr.valid_from <= now()) AND (pr.valid_until IS NULL OR pr.valid_until > now())`
+	activePrincipalOverride = `(po.valid_from IS NULL OR po.valid_from <= now()) AND (po.valid_until IS NULL OR po.valid_until > now())`
+)
+
+// principalEnabled is false when the principal ($1, $2) is a disabled service account.
+const principalEnabled = `NOT EXISTS (
+	SELECT 1 FROM service_account sa
+	WHERE sa.id = $1::uuid AND $2::principal_kind = 'service_account' AND sa.disabled_at IS NOT NULL
+)`
+
+// principalEnabledAt is principalEnabled for the principal_id and principal_kind columns
+// of the given table alias.
+func principalEnabledAt(alias string) string {
+	return `NOT EXISTS (
+	SELECT 1 FROM service_account sa
+	WHERE sa.id = ` + alias + `.principal_id AND ` + alias + `.principal_kind = 'service_account' AND sa.disabled_at IS NOT NULL
+)`
+}
+
+// principalClosure is a CTE naming principal_closure: the principal ($1, $2) and every
+// group it belongs to directly or through nested groups. UNION drops rows already seen,
+// so a membership cycle cannot make the recursion run forever. A disabled service
+// account resolves to no principals at all.
+const principalClosure = `WITH RECURSIVE principal_closure(principal_id, principal_kind) AS (
+	SELECT $1::uuid, $2::principal_kind WHERE ` + principalEnabled + `
+	UNION
+	SELECT gm.group_id, 'group'::principal_kind
+	FROM group_member gm
+	JOIN principal_closure pc ON pc.principal_id = gm.member_id AND pc.principal_kind = gm.member_kind
+)
+`
+
+// roleAncestry is a CTE naming role_ancestry: every role paired with itself and each role
+// it inherits from through role_hierarchy, transitively. UNION stops a hierarchy cycle
+// from recursing forever. It has no WITH of its own so it can follow another CTE.
+const roleAncestry = `role_ancestry(role_id, ancestor_id) AS (
+	SELECT id, id FROM role
+	UNION
+	SELECT ra.role_id, rh.parent_role_id
+	FROM role_ancestry ra
+	JOIN role_hierarchy rh ON rh.role_id = ra.ancestor_id
+)
+`
+
+// inPrincipalClosure restricts principal_role (pr) rows to principal_closure.
+const inPrincipalClosure = `(pr.principal_id, pr.principal_kind) IN (SELECT principal_id, principal_kind FROM principal_closure)`
+
+// dbtx is satisfied by both *pgxpool.Pool and pgx.Tx so lookups can run inside a transaction.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func roleIDByKey(ctx context.Context, pool dbtx, roleKey string) (string, error) {
 	var roleID string
 	row := pool.QueryRow(ctx, `SELECT id::text FROM role WHERE key=$1`, roleKey)
 	if err := row.Scan(&roleID); err != nil {
@@ -29,7 +87,31 @@ func roleIDByKey(ctx context.Context, pool *pgxpool.Pool, roleKey string) (strin
 	return roleID, nil
 }
 
-func ensurePermissionExists(ctx context.Context, pool *pgxpool.Pool, permissionID string) error {
+func serviceIDByKey(ctx context.Context, pool dbtx, serviceKey string) (string, error) {
+	var serviceID string
+	row := pool.QueryRow(ctx, `SELECT id::text FROM service WHERE key=$1`, serviceKey)
+	if err := row.Scan(&serviceID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return serviceID, nil
+}
+
+func permissionIDByKey(ctx context.Context, pool dbtx, action, resourceKind string) (string, error) {
+	var permissionID string
+	row := pool.QueryRow(ctx, `SELECT id::text FROM permission WHERE action=$1 AND resource_kind=$2`, action, resourceKind)
+	if err := row.Scan(&permissionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return permissionID, nil
+}
+
+func ensurePermissionExists(ctx context.Context, pool dbtx, permissionID string) error {
 	var exists bool
 	row := pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM permission WHERE id::text=$1)`, permissionID)
 	if err := row.Scan(&exists); err != nil {
@@ -41,6 +123,25 @@ func ensurePermissionExists(ctx context.Context, pool *pgxpool.Pool, permissionI
 	return nil
 }
 
+func ensureServiceExists(ctx context.Context, pool dbtx, serviceID string) error {
+	var exists bool
+	row := pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM service WHERE id::text=$1)`, serviceID)
+	if err := row.Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func resourceIDOrDefault(resourceID string) string {
+	if resourceID == "" {
+		return defaultResourceID
+	}
+	return resourceID
+}
+
 func withTx(ctx context.Context, pool *pgxpool.Pool, fn func(pgx.Tx) error) error {
 	tx, err := pool.Begin(ctx)
 	if err != nil {
@@ -0,0 +1,285 @@
+// Package migrate applies the versioned SQL migrations embedded in the binary and
+// records them in schema_migrations.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey serialises migration runs across replicas via pg_advisory_lock.
+const lockKey = 7_203_114_000
+
+var (
+	ErrNoDownMigration = errors.New("migration has no down script")
+
+	fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Modified reports that the up script changed after it was applied.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Migrator runs migrations against Postgres.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New loads the migrations from fsys (typically migrations.FS).
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Load reads NNN_name.up.sql / NNN_name.down.sql pairs ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	// files names the script already loaded for a version and direction, so 1_x.up.sql
+	// and 001_x.up.sql cannot silently replace each other.
+	files := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := fileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, mig.Name, m[2])
+		}
+		script := strconv.FormatInt(version, 10) + "." + m[3]
+		if other, ok := files[script]; ok {
+			return nil, fmt.Errorf("migration version %d has two %s scripts: %s and %s", version, m[3], other, entry.Name())
+		}
+		files[script] = entry.Name()
+		if m[3] == "up" {
+			mig.Up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					mig.Version, mig.Name, mig.Checksum)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, ErrNoDownMigration)
+			}
+			if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version=$1`, mig.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Baseline records every migration up to and including version as applied without
+// running it. It is meant for databases created before migrations were tracked.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	var recorded []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			cmd, err := conn.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)
+				ON CONFLICT (version) DO NOTHING`, mig.Version, mig.Name, mig.Checksum)
+			if err != nil {
+				return err
+			}
+			if cmd.RowsAffected() > 0 {
+				recorded = append(recorded, mig)
+			}
+		}
+		return nil
+	})
+	return recorded, err
+}
+
+// Status lists every known migration, plus any applied version missing from the binary.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var out []Status
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			st := Status{Version: mig.Version, Name: mig.Name}
+			if row, ok := done[mig.Version]; ok {
+				appliedAt := row.appliedAt
+				st.Applied = true
+				st.AppliedAt = &appliedAt
+				st.Modified = row.checksum != "" && row.checksum != mig.Checksum
+				delete(done, mig.Version)
+			}
+			out = append(out, st)
+		}
+		for version, row := range done {
+			appliedAt := row.appliedAt
+			out = append(out, Status{Version: version, Name: row.name, Applied: true, AppliedAt: &appliedAt})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+		return nil
+	})
+	return out, err
+}
+
+// withLock runs fn on a dedicated connection holding the session advisory lock, so
+// concurrently booting replicas apply migrations one at a time.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, int64(lockKey)); err != nil {
+		return err
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even when ctx was cancelled.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, int64(lockKey))
+	}()
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint primary key,
+		name text not null,
+		checksum text not null default '',
+		applied_at timestamptz not null default now()
+	)`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+type appliedRow struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]appliedRow, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	done := map[int64]appliedRow{}
+	for rows.Next() {
+		var version int64
+		var row appliedRow
+		if err := rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = row
+	}
+	return done, rows.Err()
+}
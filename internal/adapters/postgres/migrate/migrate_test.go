@@ -0,0 +1,164 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/example/ms-rbac-service/migrations"
+)
+
+func file(data string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(data)}
+}
+
+func checksum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "pairs up and down scripts",
+			fsys: fstest.MapFS{
+				"001_init.up.sql":   file("create table a ();"),
+				"001_init.down.sql": file("drop table a;"),
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "create table a ();", Down: "drop table a;", Checksum: checksum("create table a ();")},
+			},
+		},
+		{
+			name: "up script without a down script",
+			fsys: fstest.MapFS{"002_seed.up.sql": file("insert into a default values;")},
+			want: []Migration{
+				{Version: 2, Name: "seed", Up: "insert into a default values;", Checksum: checksum("insert into a default values;")},
+			},
+		},
+		{
+			name: "ignores other files and directories",
+			fsys: fstest.MapFS{
+				"001_init.up.sql":         file("up"),
+				"embed.go":                file("package migrations"),
+				"README.md":               file("notes"),
+				"001_init.sql":            file("no direction"),
+				"x_init.up.sql":           file("no version"),
+				"archive/003_old.up.sql":  file("nested"),
+				"001_init.sideways.sql":   file("unknown direction"),
+				"001_init.up.sql.orig":    file("backup copy"),
+				"004_multi_word.down.sql": file("down"),
+				"004_multi_word.up.sql":   file("up"),
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "up", Checksum: checksum("up")},
+				{Version: 4, Name: "multi_word", Up: "up", Down: "down", Checksum: checksum("up")},
+			},
+		},
+		{
+			name: "orders by numeric version",
+			fsys: fstest.MapFS{
+				"10_ten.up.sql":  file("ten"),
+				"9_nine.up.sql":  file("nine"),
+				"002_two.up.sql": file("two"),
+			},
+			want: []Migration{
+				{Version: 2, Name: "two", Up: "two", Checksum: checksum("two")},
+				{Version: 9, Name: "nine", Up: "nine", Checksum: checksum("nine")},
+				{Version: 10, Name: "ten", Up: "ten", Checksum: checksum("ten")},
+			},
+		},
+		{
+			name:    "down script without an up script",
+			fsys:    fstest.MapFS{"003_orphan.down.sql": file("drop table a;")},
+			wantErr: "migration 3_orphan has no up script",
+		},
+		{
+			name: "version used by two names",
+			fsys: fstest.MapFS{
+				"005_a.up.sql": file("a"),
+				"005_b.up.sql": file("b"),
+			},
+			wantErr: `migration version 5 is used by both "a" and "b"`,
+		},
+		{
+			name: "version written with and without leading zeros",
+			fsys: fstest.MapFS{
+				"5_a.up.sql":   file("a"),
+				"005_a.up.sql": file("a again"),
+			},
+			wantErr: "migration version 5 has two up scripts",
+		},
+		{
+			name:    "version out of range",
+			fsys:    fstest.MapFS{"99999999999999999999_big.up.sql": file("big")},
+			wantErr: "migration 99999999999999999999_big.up.sql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Load = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("migration %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChecksumCoversOnlyTheUpScript(t *testing.T) {
+	load := func(up, down string) string {
+		t.Helper()
+		got, err := Load(fstest.MapFS{"001_init.up.sql": file(up), "001_init.down.sql": file(down)})
+		if err != nil || len(got) != 1 {
+			t.Fatalf("Load = %+v, %v", got, err)
+		}
+		return got[0].Checksum
+	}
+	base := load("create table a ();", "drop table a;")
+	// The SHA-256 of the up script, hex encoded, as recorded in schema_migrations.
+	if base != "0db38149e0c353608ccb4a4dfc5867eeca6c9eb16f56c7e30595fdb1a85d4700" {
+		t.Fatalf("checksum = %s", base)
+	}
+	if got := load("create table a ();", "drop table if exists a;"); got != base {
+		t.Fatalf("changing the down script changed the checksum: %s != %s", got, base)
+	}
+	if got := load("create table a (id int);", "drop table a;"); got == base {
+		t.Fatalf("changing the up script kept the checksum %s", got)
+	}
+}
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load(migrations.FS): %v", err)
+	}
+	for i, mig := range got {
+		if mig.Version != int64(i+1) {
+			t.Fatalf("migration %d_%s: want version %d, versions must be contiguous", mig.Version, mig.Name, i+1)
+		}
+		if mig.Down == "" {
+			t.Fatalf("migration %d_%s has no down script", mig.Version, mig.Name)
+		}
+	}
+}
@@ -2,9 +2,13 @@ package repo
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/example/ms-rbac-service/internal/domain/model"
 	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,100 +21,134 @@ func NewPDPRepository(pool *pgxpool.Pool) *PDPRepository {
 	return &PDPRepository{pool: pool}
 }
 
-// GetByPrincipal returns true when a principal is marked as superadmin.
+// GetByPrincipal returns true when a principal is marked as superadmin or holds an
+// active break-glass activation, unless it is a disabled service account.
 func (r *PDPRepository) GetByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) (bool, error) {
 	var exists bool
-	row := r.pool.QueryRow(ctx, `SELECT EXISTS(
+	row := r.pool.QueryRow(ctx, `SELECT (EXISTS(
 		SELECT 1 FROM superadmin_principal WHERE principal_id=$1 AND principal_kind=$2
-	)`, principalID, string(kind))
+	) OR EXISTS(
+		SELECT 1 FROM break_glass_activation
+		WHERE principal_id=$1 AND principal_kind=$2 AND ended_at IS NULL AND expires_at > now()
+	)) AND `+principalEnabled, principalID, string(kind))
 	if err := row.Scan(&exists); err != nil {
 		return false, err
 	}
 	return exists, nil
 }
 
-// GetByRequest finds the most specific override matching the request.
+// GetByRequest finds the override deciding the request, ranked by
+// domainpdp.BestOverride. Overridden permissions may be wildcard patterns.
+//
+// Postgres only returns overrides whose permission, scope and validity cover the
+// request, looked up through the permission (action, resource_kind) key and the
+// override primary key, ranked as BestOverride ranks them. Conditions are evaluated
+// here, and scopes are checked again because Postgres compares uuids regardless of case.
 func (r *PDPRepository) GetByRequest(ctx context.Context, req domainpdp.CheckRequest) (*domainpdp.OverrideMatch, error) {
-	rows, err := r.pool.Query(ctx, `SELECT
-		po.permission_id::text,
-		po.effect,
-		po.tenant_id::text,
-		po.service_id::text,
-		po.resource_kind,
-		po.resource_id::text,
-		p.action,
-		p.resource_kind
-		FROM principal_override po
-		JOIN permission p ON p.id = po.permission_id
-		WHERE po.principal_id=$1 AND po.principal_kind=$2`, req.PrincipalID, string(req.PrincipalKind))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var bestMatch *domainpdp.OverrideMatch
-	bestScore := -1
-
-	for rows.Next() {
-		var permissionID, effect, tenantID, serviceID, resourceKind, resourceID, action, permResourceKind string
-		if err := rows.Scan(&permissionID, &effect, &tenantID, &serviceID, &resourceKind, &resourceID, &action, &permResourceKind); err != nil {
-			return nil, err
-		}
-		if action != req.Action || permResourceKind != req.ResourceKind {
-			continue
-		}
-		scope := normalizeScope(tenantID, serviceID, resourceKind, resourceID)
-		if !scopeMatchesOverride(scope, req) {
-			continue
-		}
-		score := calculateSpecificity(scope)
-		if score > bestScore {
-			bestScore = score
-			bestMatch = &domainpdp.OverrideMatch{
-				Effect:       model.OverrideEffect(effect),
-				PermissionID: permissionID,
-				Scope:        scope,
+	p := newCheckParams(req)
+	candidates := make([]domainpdp.OverrideCandidate, 0)
+	err := scanRows(ctx, r.pool, `WITH perm AS (
+			SELECT p.id, p.action, p.resource_kind FROM permission p
+			WHERE p.action = ANY($3::text[]) AND p.resource_kind = ANY($4::text[])
+		)
+		SELECT po.permission_id::text, po.effect::text,
+			po.tenant_id::text, po.service_id::text, po.resource_kind, po.resource_id::text,
+			perm.action, perm.resource_kind, coalesce(po.condition, '')
+		FROM perm
+		JOIN principal_override po
+			ON po.principal_id = $1::uuid AND po.principal_kind = $2::principal_kind AND po.permission_id = perm.id
+		CROSS JOIN LATERAL (
+			SELECT min(n.depth) AS depth
+			FROM unnest($9::text[], $10::text[]) WITH ORDINALITY n(kind, id, depth)
+			WHERE (po.resource_kind = $11::text OR po.resource_kind = n.kind)
+				AND (po.resource_id = $12::uuid OR po.resource_id::text = n.id)
+		) d
+		WHERE po.tenant_id = ANY($5::uuid[]) AND po.service_id = ANY($6::uuid[])
+			AND po.resource_kind = ANY($7::text[]) AND po.resource_id = ANY($8::uuid[])
+			AND d.depth IS NOT NULL
+			AND `+activePrincipalOverride+` AND `+principalEnabled+`
+		ORDER BY `+scopeSpecificity("po")+` DESC, d.depth,
+			`+patternSpecificity("perm.action")+` DESC, `+patternSpecificity("perm.resource_kind")+` DESC,
+			po.effect = 'deny' DESC`,
+		[]any{req.PrincipalID, string(req.PrincipalKind), p.actions, p.permissionKinds,
+			p.tenantIDs, p.serviceIDs, p.scopeKinds, p.scopeIDs, p.nodeKinds, p.nodeIDs,
+			defaultScopeKind, defaultResourceID},
+		func(rows pgx.Rows) error {
+			var permissionID, effect, tenantID, serviceID, resourceKind, resourceID, action, permResourceKind, condition string
+			if err := rows.Scan(&permissionID, &effect, &tenantID, &serviceID, &resourceKind, &resourceID, &action, &permResourceKind, &condition); err != nil {
+				return err
 			}
-		}
-	}
-	if err := rows.Err(); err != nil {
+			scope := normalizeScope(tenantID, serviceID, resourceKind, resourceID)
+			depth, ok := scope.Match(req)
+			if !ok {
+				return nil
+			}
+			candidates = append(candidates, domainpdp.OverrideCandidate{
+				OverrideMatch: domainpdp.OverrideMatch{
+					Effect:       model.OverrideEffect(effect),
+					PermissionID: permissionID,
+					Scope:        scope,
+					Condition:    condition,
+				},
+				Action:           action,
+				ResourceKind:     permResourceKind,
+				ScopeSpecificity: scope.Specificity(),
+				Depth:            depth,
+			})
+			return nil
+		})
+	if err != nil {
 		return nil, err
 	}
-	return bestMatch, nil
+	return domainpdp.BestOverride(candidates, req), nil
 }
 
-// List returns all roles for a principal with their scopes.
+// List returns the roles a principal holds in a scope covering the request, including
+// those inherited from the groups it belongs to transitively and the roles those inherit
+// through the role hierarchy. An inherited role carries the scope of the assignment and
+// the services the assigned role is bound to via service_role. Roles are not filtered by action: the engine
+// reports every role in scope. Scopes are filtered in Postgres through the principal
+// index and checked again here, as in GetByRequest.
 func (r *PDPRepository) List(ctx context.Context, req domainpdp.CheckRequest) ([]domainpdp.RoleWithScope, error) {
-	rows, err := r.pool.Query(ctx, `SELECT
+	p := newCheckParams(req)
+	roles := make([]domainpdp.RoleWithScope, 0)
+	err := scanRows(ctx, r.pool, principalClosure+`, `+roleAncestry+`SELECT DISTINCT
 		r.id::text,
 		r.key,
 		pr.tenant_id::text,
 		pr.service_id::text,
 		pr.resource_kind,
-		pr.resource_id::text
+		pr.resource_id::text,
+		ARRAY(SELECT sr.service_id::text FROM service_role sr WHERE sr.role_id = pr.role_id ORDER BY 1)
 		FROM principal_role pr
-		JOIN role r ON r.id = pr.role_id
-		WHERE pr.principal_id=$1 AND pr.principal_kind=$2`, req.PrincipalID, string(req.PrincipalKind))
+		JOIN role_ancestry ra ON ra.role_id = pr.role_id
+		JOIN role r ON r.id = ra.ancestor_id
+		WHERE `+inPrincipalClosure+` AND `+activePrincipalRole+`
+			AND pr.tenant_id = ANY($3::uuid[]) AND pr.service_id = ANY($4::uuid[])
+			AND pr.resource_kind = ANY($5::text[]) AND pr.resource_id = ANY($6::uuid[])
+			AND EXISTS (
+				SELECT 1 FROM unnest($7::text[], $8::text[]) n(kind, id)
+				WHERE (pr.resource_kind = $9::text OR pr.resource_kind = n.kind)
+					AND (pr.resource_id = $10::uuid OR pr.resource_id::text = n.id)
+			)`,
+		[]any{req.PrincipalID, string(req.PrincipalKind), p.tenantIDs, p.serviceIDs, p.scopeKinds, p.scopeIDs,
+			p.nodeKinds, p.nodeIDs, defaultScopeKind, defaultResourceID},
+		func(rows pgx.Rows) error {
+			var roleID, roleKey, tenantID, serviceID, resourceKind, resourceID string
+			var serviceIDs []string
+			if err := rows.Scan(&roleID, &roleKey, &tenantID, &serviceID, &resourceKind, &resourceID, &serviceIDs); err != nil {
+				return err
+			}
+			scope := normalizeScope(tenantID, serviceID, resourceKind, resourceID)
+			if _, ok := scope.Match(req); !ok {
+				return nil
+			}
+			roles = append(roles, domainpdp.RoleWithScope{RoleID: roleID, RoleKey: roleKey, Scope: scope, ServiceIDs: serviceIDs})
+			return nil
+		})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	roles := make([]domainpdp.RoleWithScope, 0)
-	for rows.Next() {
-		var roleID, roleKey, tenantID, serviceID, resourceKind, resourceID string
-		if err := rows.Scan(&roleID, &roleKey, &tenantID, &serviceID, &resourceKind, &resourceID); err != nil {
-			return nil, err
-		}
-		scope := normalizeScope(tenantID, serviceID, resourceKind, resourceID)
-		if !scopeMatches(scope, req) {
-			continue
-		}
-		roles = append(roles, domainpdp.RoleWithScope{RoleID: roleID, RoleKey: roleKey, Scope: scope})
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
 	return roles, nil
 }
 
@@ -125,7 +163,8 @@ func (r *PDPRepository) ListByRoleIDs(ctx context.Context, roleIDs []string) ([]
 		p.id::text,
 		p.action,
 		p.resource_kind,
-		rp.resource_id::text
+		rp.resource_id::text,
+		coalesce(rp.condition, '')
 		FROM role_permission rp
 		JOIN role r ON r.id = rp.role_id
 		JOIN permission p ON p.id = rp.permission_id
@@ -136,8 +175,8 @@ func (r *PDPRepository) ListByRoleIDs(ctx context.Context, roleIDs []string) ([]
 	defer rows.Close()
 	items := make([]domainpdp.RolePermissionItem, 0)
 	for rows.Next() {
-		var roleID, roleKey, permID, action, resourceKind, resourceID string
-		if err := rows.Scan(&roleID, &roleKey, &permID, &action, &resourceKind, &resourceID); err != nil {
+		var roleID, roleKey, permID, action, resourceKind, resourceID, condition string
+		if err := rows.Scan(&roleID, &roleKey, &permID, &action, &resourceKind, &resourceID, &condition); err != nil {
 			return nil, err
 		}
 		item := domainpdp.RolePermissionItem{
@@ -146,6 +185,7 @@ func (r *PDPRepository) ListByRoleIDs(ctx context.Context, roleIDs []string) ([]
 			PermissionID: permID,
 			Action:       action,
 			ResourceKind: resourceKind,
+			Condition:    condition,
 		}
 		if resourceID != "" && resourceID != defaultResourceID {
 			item.ResourceID = strPtr(resourceID)
@@ -158,78 +198,381 @@ func (r *PDPRepository) ListByRoleIDs(ctx context.Context, roleIDs []string) ([]
 	return items, nil
 }
 
-func normalizeScope(tenantID, serviceID, resourceKind, resourceID string) domainpdp.OverrideScope {
-	return domainpdp.OverrideScope{
-		TenantID:     ptrIfNotDefault(tenantID, defaultTenantID),
-		ServiceID:    ptrIfNotDefault(serviceID, defaultServiceID),
-		ResourceKind: ptrIfNotDefault(resourceKind, defaultScopeKind),
-		ResourceID:   ptrIfNotDefault(resourceID, defaultResourceID),
+// maxResourceDepth bounds how far ResourceAncestors walks up the resource registry.
+const maxResourceDepth = 32
+
+// ResourceAncestors implements pdp.ResourceRegistry.
+func (r *PDPRepository) ResourceAncestors(ctx context.Context, resourceID string) ([]domainpdp.ResourceNode, error) {
+	ancestors := make([]domainpdp.ResourceNode, 0)
+	// Registered resources have UUID ids; any other id has no ancestors.
+	if !model.IsUUID(resourceID) {
+		return ancestors, nil
 	}
+	err := scanRows(ctx, r.pool, `WITH RECURSIVE up(id, kind, parent_id, depth) AS (
+			SELECT p.id, p.kind, p.parent_id, 1
+			FROM resource c
+			JOIN resource p ON p.id = c.parent_id
+			WHERE c.id = $1::uuid
+			UNION ALL
+			SELECT p.id, p.kind, p.parent_id, up.depth + 1
+			FROM up
+			JOIN resource p ON p.id = up.parent_id
+			WHERE up.depth < $2
+		)
+		SELECT id::text, kind FROM up ORDER BY depth`, []any{resourceID, maxResourceDepth}, func(rows pgx.Rows) error {
+		var id string
+		node := domainpdp.ResourceNode{}
+		if err := rows.Scan(&id, &node.Kind); err != nil {
+			return err
+		}
+		node.ID = &id
+		ancestors = append(ancestors, node)
+		return nil
+	})
+	return ancestors, err
 }
 
-func scopeMatchesOverride(scope domainpdp.OverrideScope, req domainpdp.CheckRequest) bool {
-	if scope.TenantID != nil {
-		if req.TenantID == nil || *scope.TenantID != *req.TenantID {
-			return false
+// ListAccess answers "who is allowed this request": every enabled principal the engine
+// would allow, with the paths that allow it. It applies the engine's precedence:
+// superadmins and active break-glass activations always pass, a principal's most specific
+// matching override decides next (a deny excludes it), and otherwise a role held directly
+// or through groups, or a role it inherits, must grant the permission in a matching scope. Groups holding a role
+// are listed themselves and their members transitively. A request on a registered
+// resource is also matched against scopes and grants on its ancestors. Override
+// conditions are evaluated per principal against the built-in attributes and
+// req.Attributes; role paths relying on conditional grants are marked instead.
+func (r *PDPRepository) ListAccess(ctx context.Context, req domainpdp.CheckRequest) ([]domainpdp.PrincipalAccess, error) {
+	if req.ResourceID != nil {
+		ancestors, err := r.ResourceAncestors(ctx, *req.ResourceID)
+		if err != nil {
+			return nil, err
 		}
+		req.Ancestors = ancestors
 	}
-	if scope.ServiceID != nil {
-		if req.ServiceID == nil || *scope.ServiceID != *req.ServiceID {
-			return false
-		}
+	path := req.ResourcePath()
+	nodeKinds := make([]string, 0, len(path))
+	nodeIDs := make([]string, 0, len(path))
+	for _, node := range path {
+		nodeKinds = append(nodeKinds, node.Kind)
+		nodeIDs = append(nodeIDs, optionalText(node.ID))
 	}
-	if scope.ResourceKind != nil {
-		if *scope.ResourceKind != req.ResourceKind {
-			return false
+
+	type principalKey struct{ id, kind string }
+	access := map[principalKey]*domainpdp.PrincipalAccess{}
+	order := make([]principalKey, 0)
+	add := func(key principalKey, path domainpdp.AccessPath) {
+		item, ok := access[key]
+		if !ok {
+			item = &domainpdp.PrincipalAccess{PrincipalID: key.id, PrincipalKind: model.PrincipalKind(key.kind)}
+			access[key] = item
+			order = append(order, key)
 		}
+		item.Paths = append(item.Paths, path)
 	}
-	if scope.ResourceID != nil {
-		if req.ResourceID == nil || *scope.ResourceID != *req.ResourceID {
-			return false
+
+	err := scanRows(ctx, r.pool, `SELECT sp.principal_id::text, sp.principal_kind::text, 'superadmin'
+		FROM superadmin_principal sp WHERE `+principalEnabledAt("sp")+`
+		UNION ALL
+		SELECT DISTINCT bg.principal_id::text, bg.principal_kind::text, 'break_glass'
+		FROM break_glass_activation bg
+		WHERE bg.ended_at IS NULL AND bg.expires_at > now() AND `+principalEnabledAt("bg"), nil, func(rows pgx.Rows) error {
+		var key principalKey
+		var kind string
+		if err := rows.Scan(&key.id, &key.kind, &kind); err != nil {
+			return err
 		}
+		add(key, domainpdp.AccessPath{Kind: kind})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return true
-}
 
-func scopeMatches(scope domainpdp.OverrideScope, req domainpdp.CheckRequest) bool {
-	if scope.TenantID != nil {
-		if req.TenantID == nil || *scope.TenantID != *req.TenantID {
-			return false
-		}
+	candidates := map[principalKey][]domainpdp.OverrideCandidate{}
+	err = scanRows(ctx, r.pool, `SELECT po.principal_id::text, po.principal_kind::text, po.permission_id::text, po.effect::text,
+		po.tenant_id::text, po.service_id::text, po.resource_kind, po.resource_id::text, p.action, p.resource_kind,
+		coalesce(po.condition, '')
+		FROM principal_override po
+		JOIN permission p ON p.id = po.permission_id
+		WHERE `+patternMatches("p.action", "$1")+` AND `+activePrincipalOverride+` AND `+principalEnabledAt("po"),
+		[]any{req.Action}, func(rows pgx.Rows) error {
+			var key principalKey
+			var permissionID, effect, tenantID, serviceID, resourceKind, resourceID, action, permResourceKind, condition string
+			if err := rows.Scan(&key.id, &key.kind, &permissionID, &effect, &tenantID, &serviceID, &resourceKind, &resourceID,
+				&action, &permResourceKind, &condition); err != nil {
+				return err
+			}
+			scope := normalizeScope(tenantID, serviceID, resourceKind, resourceID)
+			depth, ok := scope.Match(req)
+			if !ok {
+				return nil
+			}
+			candidates[key] = append(candidates[key], domainpdp.OverrideCandidate{
+				OverrideMatch: domainpdp.OverrideMatch{
+					Effect: model.OverrideEffect(effect), PermissionID: permissionID, Scope: scope, Condition: condition,
+				},
+				Action:           action,
+				ResourceKind:     permResourceKind,
+				ScopeSpecificity: scope.Specificity(),
+				Depth:            depth,
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, err
 	}
-	if scope.ServiceID != nil {
-		if req.ServiceID == nil || *scope.ServiceID != *req.ServiceID {
-			return false
+	overrides := map[principalKey]*domainpdp.OverrideMatch{}
+	now := time.Now()
+	for key, list := range candidates {
+		principalReq := req
+		principalReq.PrincipalID, principalReq.PrincipalKind = key.id, model.PrincipalKind(key.kind)
+		match := domainpdp.BestOverride(list, principalReq.WithBuiltinAttributes(now))
+		if match == nil {
+			continue
 		}
-	}
-	if scope.ResourceKind != nil {
-		if *scope.ResourceKind != req.ResourceKind {
-			return false
+		overrides[key] = match
+		if match.Effect == model.OverrideEffectAllow {
+			add(key, domainpdp.AccessPath{Kind: domainpdp.AccessPathOverride, PermissionID: match.PermissionID})
 		}
 	}
-	if scope.ResourceID != nil {
-		if req.ResourceID == nil || *scope.ResourceID != *req.ResourceID {
-			return false
+
+	// grantMatches selects the grants of pr's role, or of a role it inherits, that cover
+	// the request. A holder
+	// whose matching grants all carry a condition is reported as conditional.
+	grantMatches := `SELECT 1 FROM role_permission rp
+					JOIN permission p ON p.id = rp.permission_id
+					CROSS JOIN unnest($2::text[], $3::text[]) n(kind, id)
+					WHERE rp.role_id IN (SELECT ra.ancestor_id FROM role_ancestry ra WHERE ra.role_id = pr.role_id)
+						AND ` + patternMatches("p.action", "$1::text") + ` AND ` + patternMatches("p.resource_kind", "n.kind") + `
+						AND (rp.resource_id = $9::uuid OR rp.resource_id::text = n.id)`
+	err = scanRows(ctx, r.pool, `WITH RECURSIVE `+roleAncestry+`, holder(principal_id, principal_kind, role_key, group_id, conditional) AS (
+			SELECT pr.principal_id, pr.principal_kind, r.key, NULL::uuid,
+				NOT EXISTS (`+grantMatches+` AND rp.condition IS NULL)
+			FROM principal_role pr
+			JOIN role r ON r.id = pr.role_id
+			WHERE `+activePrincipalRole+`
+				AND (pr.tenant_id = $6::uuid OR pr.tenant_id::text = $4::text)
+				AND (pr.service_id = $7::uuid OR pr.service_id::text = $5::text)
+				AND EXISTS (
+					SELECT 1 FROM unnest($2::text[], $3::text[]) n(kind, id)
+					WHERE (pr.resource_kind = $8::text OR pr.resource_kind = n.kind)
+						AND (pr.resource_id = $9::uuid OR pr.resource_id::text = n.id)
+				)
+				AND EXISTS (`+grantMatches+`)
+				AND (NOT EXISTS (SELECT 1 FROM service_role sr WHERE sr.role_id = pr.role_id)
+					OR EXISTS (SELECT 1 FROM service_role sr WHERE sr.role_id = pr.role_id AND sr.service_id::text = $5::text))
+			UNION
+			SELECT gm.member_id, gm.member_kind, h.role_key, coalesce(h.group_id, h.principal_id), h.conditional
+			FROM holder h
+			JOIN group_member gm ON gm.group_id = h.principal_id AND h.principal_kind = 'group'
+		)
+		SELECT h.principal_id::text, h.principal_kind::text, h.role_key, coalesce(h.group_id::text, ''), h.conditional
+		FROM holder h
+		WHERE `+principalEnabledAt("h")+`
+		ORDER BY 1, 2, 4, 3`,
+		[]any{req.Action, nodeKinds, nodeIDs, optionalText(req.TenantID), optionalText(req.ServiceID),
+			defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID},
+		func(rows pgx.Rows) error {
+			var key principalKey
+			path := domainpdp.AccessPath{Kind: domainpdp.AccessPathRole}
+			if err := rows.Scan(&key.id, &key.kind, &path.RoleKey, &path.GroupID, &path.Conditional); err != nil {
+				return err
+			}
+			if match, ok := overrides[key]; ok && match.Effect == model.OverrideEffectDeny {
+				return nil
+			}
+			if path.GroupID != "" {
+				path.Kind = domainpdp.AccessPathInheritedRole
+			}
+			add(key, path)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].kind != order[j].kind {
+			return order[i].kind < order[j].kind
 		}
+		return order[i].id < order[j].id
+	})
+	items := make([]domainpdp.PrincipalAccess, 0, len(order))
+	for _, key := range order {
+		items = append(items, *access[key])
 	}
-	return true
+	return items, nil
 }
 
-func calculateSpecificity(scope domainpdp.OverrideScope) int {
-	score := 0
-	if scope.TenantID != nil {
-		score += 1000
+// EffectivePermissions lists every permission the principal holds through its role
+// assignments, the roles those inherit through the role hierarchy and its groups, plus
+// its own overrides with their effect. A role grant narrowed to a resource instance
+// applies to that instance only; one whose scope can never match is left out.
+func (r *PDPRepository) EffectivePermissions(ctx context.Context, principalID string, kind model.PrincipalKind) (domainpdp.EffectivePermissions, error) {
+	result := domainpdp.EffectivePermissions{PrincipalID: principalID, PrincipalKind: kind, Permissions: make([]domainpdp.EffectivePermission, 0)}
+	superadmin, err := r.GetByPrincipal(ctx, principalID, kind)
+	if err != nil {
+		return result, err
 	}
-	if scope.ServiceID != nil {
-		score += 100
+	result.Superadmin = superadmin
+
+	err = scanRows(ctx, r.pool, `WITH RECURSIVE member_of(principal_id, principal_kind, inherited) AS (
+			SELECT $1::uuid, $2::principal_kind, false WHERE `+principalEnabled+`
+			UNION
+			SELECT gm.group_id, 'group'::principal_kind, true
+			FROM member_of m
+			JOIN group_member gm ON gm.member_id = m.principal_id AND gm.member_kind = m.principal_kind
+		), held(role_id, assigned_role_id, group_id, tenant_id, service_id, resource_kind, resource_id) AS (
+			SELECT pr.role_id, pr.role_id, CASE WHEN m.inherited THEN pr.principal_id END,
+				pr.tenant_id, pr.service_id, pr.resource_kind, pr.resource_id
+			FROM member_of m
+			JOIN principal_role pr ON pr.principal_id = m.principal_id AND pr.principal_kind = m.principal_kind
+			WHERE `+activePrincipalRole+`
+			UNION
+			SELECT rh.parent_role_id, h.assigned_role_id, h.group_id, h.tenant_id, h.service_id, h.resource_kind, h.resource_id
+			FROM held h
+			JOIN role_hierarchy rh ON rh.role_id = h.role_id
+		)
+		SELECT p.id::text, p.action, p.resource_kind, coalesce(rp.resource_id::text, ''),
+			h.tenant_id::text, h.service_id::text, h.resource_kind, h.resource_id::text,
+			ar.key, gr.key, coalesce(h.group_id::text, ''),
+			ARRAY(SELECT sr.service_id::text FROM service_role sr WHERE sr.role_id = h.assigned_role_id ORDER BY 1),
+			coalesce(rp.condition, '')
+		FROM held h
+		JOIN role_permission rp ON rp.role_id = h.role_id
+		JOIN permission p ON p.id = rp.permission_id
+		JOIN role ar ON ar.id = h.assigned_role_id
+		JOIN role gr ON gr.id = h.role_id
+		ORDER BY p.action, p.resource_kind, ar.key, gr.key, 11, 5, 6, 7, 8`,
+		[]any{principalID, string(kind)}, func(rows pgx.Rows) error {
+			var grantResourceID, tenantID, serviceID, resourceKind, resourceID, grantedBy string
+			perm := domainpdp.EffectivePermission{Effect: model.OverrideEffectAllow, Source: domainpdp.PermissionSource{Kind: domainpdp.AccessPathRole}}
+			if err := rows.Scan(&perm.PermissionID, &perm.Action, &perm.ResourceKind, &grantResourceID,
+				&tenantID, &serviceID, &resourceKind, &resourceID,
+				&perm.Source.RoleKey, &grantedBy, &perm.Source.GroupID, &perm.ServiceIDs, &perm.Condition); err != nil {
+				return err
+			}
+			scope := normalizeScope(tenantID, serviceID, resourceKind, resourceID)
+			if scope.ResourceKind != nil && !domainpdp.MatchPattern(perm.ResourceKind, *scope.ResourceKind) {
+				return nil
+			}
+			if grantResourceID != "" && grantResourceID != defaultResourceID {
+				if scope.ResourceID != nil && *scope.ResourceID != grantResourceID {
+					return nil
+				}
+				scope.ResourceID = strPtr(grantResourceID)
+			}
+			perm.Scope = domainpdp.PermissionScope(scope)
+			if grantedBy != perm.Source.RoleKey {
+				perm.Source.GrantedBy = grantedBy
+			}
+			if perm.Source.GroupID != "" {
+				perm.Source.Kind = domainpdp.AccessPathInheritedRole
+			}
+			result.Permissions = append(result.Permissions, perm)
+			return nil
+		})
+	if err != nil {
+		return result, err
 	}
-	if scope.ResourceKind != nil {
-		score += 10
+
+	err = scanRows(ctx, r.pool, `SELECT p.id::text, p.action, p.resource_kind, po.effect::text,
+		po.tenant_id::text, po.service_id::text, po.resource_kind, po.resource_id::text, coalesce(po.condition, '')
+		FROM principal_override po
+		JOIN permission p ON p.id = po.permission_id
+		WHERE po.principal_id = $1::uuid AND po.principal_kind = $2::principal_kind
+			AND `+activePrincipalOverride+` AND `+principalEnabled+`
+		ORDER BY p.action, p.resource_kind, po.effect, 5, 6, 7, 8`,
+		[]any{principalID, string(kind)}, func(rows pgx.Rows) error {
+			var effect, tenantID, serviceID, resourceKind, resourceID string
+			perm := domainpdp.EffectivePermission{Source: domainpdp.PermissionSource{Kind: domainpdp.AccessPathOverride}}
+			if err := rows.Scan(&perm.PermissionID, &perm.Action, &perm.ResourceKind, &effect,
+				&tenantID, &serviceID, &resourceKind, &resourceID, &perm.Condition); err != nil {
+				return err
+			}
+			perm.Effect = model.OverrideEffect(effect)
+			perm.Scope = domainpdp.PermissionScope(normalizeScope(tenantID, serviceID, resourceKind, resourceID))
+			result.Permissions = append(result.Permissions, perm)
+			return nil
+		})
+	return result, err
+}
+
+// patternMatches is domainpdp.MatchPattern in SQL: the pattern column matches the
+// value parameter exactly, as "*", or as a ".*" / ":*" prefix wildcard.
+func patternMatches(column, value string) string {
+	return fmt.Sprintf(`(%[1]s = %[2]s OR %[1]s = '*' OR (right(%[1]s, 2) IN ('.*', ':*') AND starts_with(%[2]s, left(%[1]s, -1))))`, column, value)
+}
+
+// checkParams holds the values GetByRequest and List filter on. Scope columns are
+// matched with "= ANY" against their default and the requested values, so the
+// principal indexes apply; a requested value that is not a uuid cannot equal a uuid
+// column and is left out of the uuid arrays.
+type checkParams struct {
+	// actions and permissionKinds are the permission patterns matching the action and
+	// the kind of any node of the resource path.
+	actions, permissionKinds []string
+	tenantIDs, serviceIDs    []string
+	scopeKinds, scopeIDs     []string
+	// nodeKinds and nodeIDs are the resource path, for matching kind and id on the same node.
+	nodeKinds, nodeIDs []string
+}
+
+func newCheckParams(req domainpdp.CheckRequest) checkParams {
+	p := checkParams{
+		actions:    domainpdp.MatchingPatterns(req.Action),
+		tenantIDs:  []string{defaultTenantID},
+		serviceIDs: []string{defaultServiceID},
+		scopeKinds: []string{defaultScopeKind},
+		scopeIDs:   []string{defaultResourceID},
+	}
+	if req.TenantID != nil && model.IsUUID(*req.TenantID) {
+		p.tenantIDs = append(p.tenantIDs, *req.TenantID)
 	}
-	if scope.ResourceID != nil {
-		score += 1
+	if req.ServiceID != nil && model.IsUUID(*req.ServiceID) {
+		p.serviceIDs = append(p.serviceIDs, *req.ServiceID)
+	}
+	for _, node := range req.ResourcePath() {
+		p.permissionKinds = append(p.permissionKinds, domainpdp.MatchingPatterns(node.Kind)...)
+		p.scopeKinds = append(p.scopeKinds, node.Kind)
+		if node.ID != nil && model.IsUUID(*node.ID) {
+			p.scopeIDs = append(p.scopeIDs, *node.ID)
+		}
+		p.nodeKinds = append(p.nodeKinds, node.Kind)
+		p.nodeIDs = append(p.nodeIDs, optionalText(node.ID))
+	}
+	return p
+}
+
+// scopeSpecificity is domainpdp.OverrideScope.Specificity in SQL for the scope columns
+// of the given table alias.
+func scopeSpecificity(alias string) string {
+	return fmt.Sprintf(`((%[1]s.tenant_id <> '%[2]s'::uuid)::int * 1000 + (%[1]s.service_id <> '%[3]s'::uuid)::int * 100
+			+ (%[1]s.resource_kind <> '%[4]s')::int * 10 + (%[1]s.resource_id <> '%[5]s'::uuid)::int)`,
+		alias, defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID)
+}
+
+// patternSpecificity is domainpdp.PatternSpecificity in SQL.
+func patternSpecificity(column string) string {
+	return fmt.Sprintf(`(CASE WHEN %[1]s = '*' THEN 0
+			WHEN right(%[1]s, 2) IN ('.*', ':*') THEN least(octet_length(%[1]s) - 1, 1023)
+			ELSE 1024 END)`, column)
+}
+
+func optionalText(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func normalizeScope(tenantID, serviceID, resourceKind, resourceID string) domainpdp.OverrideScope {
+	return domainpdp.OverrideScope{
+		TenantID:     ptrIfNotDefault(tenantID, defaultTenantID),
+		ServiceID:    ptrIfNotDefault(serviceID, defaultServiceID),
+		ResourceKind: ptrIfNotDefault(resourceKind, defaultScopeKind),
+		ResourceID:   ptrIfNotDefault(resourceID, defaultResourceID),
 	}
-	return score
 }
 
 func ptrIfNotDefault(value, defaultValue string) *string {
@@ -0,0 +1,280 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pdpSnapshotChannel is the channel the triggers of migration 015 notify.
+const pdpSnapshotChannel = "pdp_snapshot"
+
+// PDPSnapshotRepository loads the rows in-memory PDP snapshots are built from and
+// feeds them the change notifications of the pdp_snapshot channel. It implements
+// domainpdp.SnapshotSource.
+type PDPSnapshotRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPDPSnapshotRepository(pool *pgxpool.Pool) *PDPSnapshotRepository {
+	return &PDPSnapshotRepository{pool: pool}
+}
+
+// snapshotTable loads the rows of one table. A full load runs query with the where
+// clause all; a keyed load runs it with keyed, whose $1 is the first key column as a
+// uuid and $2, for principal tables, the second as a principal_kind, so both use the
+// table's indexes.
+type snapshotTable struct {
+	query     string
+	all       string
+	keyed     string
+	principal bool
+	scan      func(pgx.Rows) error
+}
+
+// LoadSnapshot reads the rows of change.Table whose key columns equal change.Key, or
+// every table for a zero change, in one read-only repeatable-read transaction so a full
+// load is consistent. Validity windows and disabled service accounts are left to the
+// snapshot, which evaluates them at check time; open break-glass activations are loaded
+// whether or not they have expired.
+func (r *PDPSnapshotRepository) LoadSnapshot(ctx context.Context, change domainpdp.SnapshotChange) (domainpdp.SnapshotState, error) {
+	var state domainpdp.SnapshotState
+	tables := map[string]snapshotTable{
+		"superadmin_principal": {
+			query:     `SELECT principal_id::text, principal_kind::text FROM superadmin_principal`,
+			keyed:     `WHERE principal_id = $1::uuid AND principal_kind = $2::principal_kind`,
+			principal: true,
+			scan: func(rows pgx.Rows) error {
+				var p domainpdp.SnapshotPrincipal
+				if err := rows.Scan(&p.ID, &p.Kind); err != nil {
+					return err
+				}
+				state.Superadmins = append(state.Superadmins, p)
+				return nil
+			},
+		},
+		"break_glass_activation": {
+			query:     `SELECT principal_id::text, principal_kind::text, expires_at FROM break_glass_activation`,
+			all:       `WHERE ended_at IS NULL`,
+			keyed:     `WHERE ended_at IS NULL AND principal_id = $1::uuid AND principal_kind = $2::principal_kind`,
+			principal: true,
+			scan: func(rows pgx.Rows) error {
+				var a domainpdp.SnapshotActivation
+				if err := rows.Scan(&a.Principal.ID, &a.Principal.Kind, &a.ExpiresAt); err != nil {
+					return err
+				}
+				state.Activations = append(state.Activations, a)
+				return nil
+			},
+		},
+		"service_account": {
+			query: `SELECT id::text FROM service_account`,
+			all:   `WHERE disabled_at IS NOT NULL`,
+			keyed: `WHERE disabled_at IS NOT NULL AND id = $1::uuid`,
+			scan: func(rows pgx.Rows) error {
+				var id string
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+				state.DisabledServiceAccounts = append(state.DisabledServiceAccounts, id)
+				return nil
+			},
+		},
+		"principal_override": {
+			query: `SELECT po.principal_id::text, po.principal_kind::text, po.permission_id::text, po.effect::text,
+				po.tenant_id::text, po.service_id::text, po.resource_kind, po.resource_id::text,
+				coalesce(po.condition, ''), po.valid_from, po.valid_until
+				FROM principal_override po`,
+			keyed:     `WHERE po.principal_id = $1::uuid AND po.principal_kind = $2::principal_kind`,
+			principal: true,
+			scan: func(rows pgx.Rows) error {
+				var (
+					o                                             domainpdp.SnapshotOverride
+					effect                                        string
+					tenantID, serviceID, resourceKind, resourceID string
+				)
+				if err := rows.Scan(&o.Principal.ID, &o.Principal.Kind, &o.Override.PermissionID, &effect,
+					&tenantID, &serviceID, &resourceKind, &resourceID, &o.Override.Condition, &o.ValidFrom, &o.ValidUntil); err != nil {
+					return err
+				}
+				o.Override.Effect = model.OverrideEffect(effect)
+				o.Override.Scope = normalizeScope(tenantID, serviceID, resourceKind, resourceID)
+				state.Overrides = append(state.Overrides, o)
+				return nil
+			},
+		},
+		"principal_role": {
+			query: `SELECT pr.principal_id::text, pr.principal_kind::text, pr.role_id::text,
+				pr.tenant_id::text, pr.service_id::text, pr.resource_kind, pr.resource_id::text, pr.valid_from, pr.valid_until
+				FROM principal_role pr`,
+			keyed:     `WHERE pr.principal_id = $1::uuid AND pr.principal_kind = $2::principal_kind`,
+			principal: true,
+			scan: func(rows pgx.Rows) error {
+				var (
+					a                                             domainpdp.SnapshotAssignment
+					tenantID, serviceID, resourceKind, resourceID string
+				)
+				if err := rows.Scan(&a.Principal.ID, &a.Principal.Kind, &a.RoleID,
+					&tenantID, &serviceID, &resourceKind, &resourceID, &a.ValidFrom, &a.ValidUntil); err != nil {
+					return err
+				}
+				a.Scope = normalizeScope(tenantID, serviceID, resourceKind, resourceID)
+				state.Assignments = append(state.Assignments, a)
+				return nil
+			},
+		},
+		"group_member": {
+			query:     `SELECT group_id::text, member_id::text, member_kind::text FROM group_member`,
+			keyed:     `WHERE member_id = $1::uuid AND member_kind = $2::principal_kind`,
+			principal: true,
+			scan: func(rows pgx.Rows) error {
+				var m domainpdp.SnapshotMembership
+				if err := rows.Scan(&m.GroupID, &m.Member.ID, &m.Member.Kind); err != nil {
+					return err
+				}
+				state.Memberships = append(state.Memberships, m)
+				return nil
+			},
+		},
+		"role": {
+			query: `SELECT r.id::text, r.key,
+				ARRAY(SELECT sr.service_id::text FROM service_role sr WHERE sr.role_id = r.id ORDER BY 1),
+				ARRAY(SELECT rh.parent_role_id::text FROM role_hierarchy rh WHERE rh.role_id = r.id ORDER BY 1)
+				FROM role r`,
+			keyed: `WHERE r.id = $1::uuid`,
+			scan: func(rows pgx.Rows) error {
+				var role domainpdp.SnapshotRole
+				if err := rows.Scan(&role.ID, &role.Key, &role.ServiceIDs, &role.ParentIDs); err != nil {
+					return err
+				}
+				state.Roles = append(state.Roles, role)
+				return nil
+			},
+		},
+		"role_permission": {
+			query: `SELECT role_id::text, permission_id::text, resource_id::text, coalesce(condition, '')
+				FROM role_permission`,
+			keyed: `WHERE role_id = $1::uuid`,
+			scan: func(rows pgx.Rows) error {
+				var (
+					g          domainpdp.SnapshotGrant
+					resourceID string
+				)
+				if err := rows.Scan(&g.RoleID, &g.PermissionID, &resourceID, &g.Condition); err != nil {
+					return err
+				}
+				g.ResourceID = ptrIfNotDefault(resourceID, defaultResourceID)
+				state.Grants = append(state.Grants, g)
+				return nil
+			},
+		},
+		"permission": {
+			query: `SELECT id::text, action, resource_kind FROM permission`,
+			keyed: `WHERE id = $1::uuid`,
+			scan: func(rows pgx.Rows) error {
+				var p domainpdp.SnapshotPermission
+				if err := rows.Scan(&p.ID, &p.Action, &p.ResourceKind); err != nil {
+					return err
+				}
+				state.Permissions = append(state.Permissions, p)
+				return nil
+			},
+		},
+		"resource": {
+			query: `SELECT id::text, kind, coalesce(parent_id::text, '') FROM resource`,
+			keyed: `WHERE id = $1::uuid`,
+			scan: func(rows pgx.Rows) error {
+				var res domainpdp.SnapshotResource
+				if err := rows.Scan(&res.ID, &res.Kind, &res.ParentID); err != nil {
+					return err
+				}
+				state.Resources = append(state.Resources, res)
+				return nil
+			},
+		},
+		// A change to any rule reloads them all: there are few and they are ordered.
+		"sod_rule": {
+			query: `SELECT key, role_a_id::text, role_b_id::text FROM sod_rule`,
+			all:   `WHERE kind = 'dynamic' ORDER BY key`,
+			scan: func(rows pgx.Rows) error {
+				var rule domainpdp.SnapshotSoDRule
+				if err := rows.Scan(&rule.Key, &rule.RoleAID, &rule.RoleBID); err != nil {
+					return err
+				}
+				state.SoDRules = append(state.SoDRules, rule)
+				return nil
+			},
+		},
+	}
+	// service_role and role_hierarchy rows are loaded with their role.
+	if change.Table == "service_role" || change.Table == "role_hierarchy" {
+		change.Table = "role"
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return state, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	table, ok := tables[change.Table]
+	switch {
+	case ok && table.keyed != "":
+		// Every key the triggers send is a uuid; any other matches no row.
+		if !model.IsUUID(change.KeyPart(0)) {
+			return state, nil
+		}
+		args := []any{change.KeyPart(0)}
+		if table.principal {
+			args = append(args, change.KeyPart(1))
+		}
+		return state, scanRows(ctx, tx, table.query+` `+table.keyed, args, table.scan)
+	case ok:
+		return state, scanRows(ctx, tx, table.query+` `+table.all, nil, table.scan)
+	}
+	for _, table := range tables {
+		if err := scanRows(ctx, tx, table.query+` `+table.all, nil, table.scan); err != nil {
+			return domainpdp.SnapshotState{}, err
+		}
+	}
+	return state, nil
+}
+
+// ListenSnapshotChanges takes a connection out of the pool for the lifetime of the feed
+// and listens on the pdp_snapshot channel.
+func (r *PDPSnapshotRepository) ListenSnapshotChanges(ctx context.Context) (domainpdp.SnapshotFeed, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, `LISTEN `+pdpSnapshotChannel); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	return &pdpSnapshotFeed{conn: conn.Hijack()}, nil
+}
+
+type pdpSnapshotFeed struct {
+	conn *pgx.Conn
+}
+
+func (f *pdpSnapshotFeed) Next(ctx context.Context) (domainpdp.SnapshotChange, error) {
+	n, err := f.conn.WaitForNotification(ctx)
+	if err != nil {
+		return domainpdp.SnapshotChange{}, err
+	}
+	return domainpdp.ParseSnapshotChange(n.Payload), nil
+}
+
+// Close closes the listening connection rather than returning it to the pool.
+func (f *pdpSnapshotFeed) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = f.conn.Close(ctx)
+}
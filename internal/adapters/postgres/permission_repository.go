@@ -18,33 +18,48 @@ func NewPermissionRepository(pool *pgxpool.Pool) *PermissionRepository {
 }
 
 func (r *PermissionRepository) Create(ctx context.Context, perm *Permission) error {
-	query := `INSERT INTO permission (action, resource_kind) VALUES ($1, $2) RETURNING id::text`
-	return r.pool.QueryRow(ctx, query, perm.Action, perm.ResourceKind).Scan(&perm.ID)
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `INSERT INTO permission (action, resource_kind) VALUES ($1, $2) RETURNING id::text`
+		if err := tx.QueryRow(ctx, query, perm.Action, perm.ResourceKind).Scan(&perm.ID); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, permissionAuditQuery, perm.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "permission", EntityID: perm.ID, After: after})
+	})
 }
 
 func (r *PermissionRepository) Update(ctx context.Context, id string, attrs map[string]interface{}) error {
-	var current Permission
-	row := r.pool.QueryRow(ctx, `SELECT id::text, action, resource_kind FROM permission WHERE id::text=$1`, id)
-	if err := row.Scan(&current.ID, &current.Action, &current.ResourceKind); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrNotFound
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var current Permission
+		row := tx.QueryRow(ctx, `SELECT id::text, action, resource_kind FROM permission WHERE id::text=$1 FOR UPDATE`, id)
+		if err := row.Scan(&current.ID, &current.Action, &current.ResourceKind); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
 		}
-		return err
-	}
-	if v, ok := attrs["action"].(string); ok {
-		current.Action = v
-	}
-	if v, ok := attrs["resource_kind"].(string); ok {
-		current.ResourceKind = v
-	}
-	cmd, err := r.pool.Exec(ctx, `UPDATE permission SET action=$2, resource_kind=$3 WHERE id::text=$1`, id, current.Action, current.ResourceKind)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+		before, err := auditRow(ctx, tx, permissionAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		if v, ok := attrs["action"].(string); ok {
+			current.Action = v
+		}
+		if v, ok := attrs["resource_kind"].(string); ok {
+			current.ResourceKind = v
+		}
+		if _, err := tx.Exec(ctx, `UPDATE permission SET action=$2, resource_kind=$3 WHERE id::text=$1`, id, current.Action, current.ResourceKind); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, permissionAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionUpdate, Entity: "permission", EntityID: id, Before: before, After: after})
+	})
 }
 
 func (r *PermissionRepository) Get(ctx context.Context, id string) (*Permission, error) {
@@ -59,12 +74,20 @@ func (r *PermissionRepository) Get(ctx context.Context, id string) (*Permission,
 	return &item, nil
 }
 
-func (r *PermissionRepository) List(ctx context.Context, offset, limit int) ([]Permission, int64, error) {
+// PermissionListFilter narrows permission listings; an empty ServiceID lists every permission.
+type PermissionListFilter struct {
+	ServiceID string
+}
+
+func (r *PermissionRepository) List(ctx context.Context, filter PermissionListFilter, offset, limit int) ([]Permission, int64, error) {
+	const where = `WHERE $1 = '' OR EXISTS (
+		SELECT 1 FROM service_permission sp WHERE sp.permission_id = permission.id AND sp.service_id::text = $1
+	)`
 	var total int64
-	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM permission`).Scan(&total); err != nil {
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM permission `+where, filter.ServiceID).Scan(&total); err != nil {
 		return nil, 0, err
 	}
-	rows, err := r.pool.Query(ctx, `SELECT id::text, action, resource_kind FROM permission ORDER BY action, resource_kind LIMIT $1 OFFSET $2`, limit, offset)
+	rows, err := r.pool.Query(ctx, `SELECT id::text, action, resource_kind FROM permission `+where+` ORDER BY action, resource_kind LIMIT $2 OFFSET $3`, filter.ServiceID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -81,12 +104,19 @@ func (r *PermissionRepository) List(ctx context.Context, offset, limit int) ([]P
 }
 
 func (r *PermissionRepository) Delete(ctx context.Context, id string) error {
-	cmd, err := r.pool.Exec(ctx, `DELETE FROM permission WHERE id::text=$1`, id)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, permissionAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM permission WHERE id::text=$1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "permission", EntityID: id, Before: before})
+	})
 }
+
+const permissionAuditQuery = `SELECT to_jsonb(p) FROM permission p WHERE p.id::text=$1`
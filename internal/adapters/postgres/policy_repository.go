@@ -0,0 +1,305 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/example/ms-rbac-service/internal/domain/policy"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// policySyncLockKey serialises policy syncs across replicas via pg_advisory_xact_lock.
+const policySyncLockKey = 7_203_114_001
+
+// PolicyRepository reconciles declarative policy documents with the database.
+type PolicyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPolicyRepository(pool *pgxpool.Pool) *PolicyRepository {
+	return &PolicyRepository{pool: pool}
+}
+
+// Current returns the database state expressed as a policy document.
+func (r *PolicyRepository) Current(ctx context.Context) (policy.Document, error) {
+	return loadPolicyDocument(ctx, r.pool)
+}
+
+// Sync diffs the desired document against the current state inside one transaction.
+// When apply is false the plan is computed and the transaction rolled back. Each applied
+// change is audited with the row it touched, as the admin API audits the same edit.
+func (r *PolicyRepository) Sync(ctx context.Context, desired policy.Document, opts policy.Options, apply bool) (policy.Plan, error) {
+	var plan policy.Plan
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(policySyncLockKey)); err != nil {
+			return err
+		}
+		current, err := loadPolicyDocument(ctx, tx)
+		if err != nil {
+			return err
+		}
+		plan = policy.Diff(desired, current, opts)
+		if !apply {
+			return errPolicyDryRun
+		}
+		for _, change := range plan.Changes {
+			if err := applyPolicyChange(ctx, tx, change); err != nil {
+				return err
+			}
+		}
+		plan.Applied = true
+		return nil
+	})
+	if errors.Is(err, errPolicyDryRun) {
+		return plan, nil
+	}
+	if err != nil {
+		return policy.Plan{}, err
+	}
+	return plan, nil
+}
+
+var errPolicyDryRun = errors.New("policy dry run")
+
+func loadPolicyDocument(ctx context.Context, q dbtx) (policy.Document, error) {
+	doc := policy.Document{Version: policy.DocumentVersion}
+	roleIndex := map[string]int{}
+	permIndex := map[string]int{}
+
+	if err := scanRows(ctx, q, `SELECT key, title FROM role ORDER BY key`, nil, func(rows pgx.Rows) error {
+		var role policy.Role
+		if err := rows.Scan(&role.Key, &role.Title); err != nil {
+			return err
+		}
+		roleIndex[role.Key] = len(doc.Roles)
+		doc.Roles = append(doc.Roles, role)
+		return nil
+	}); err != nil {
+		return policy.Document{}, err
+	}
+	if err := scanRows(ctx, q, `SELECT action, resource_kind FROM permission ORDER BY action, resource_kind`, nil, func(rows pgx.Rows) error {
+		var perm policy.Permission
+		if err := rows.Scan(&perm.Action, &perm.ResourceKind); err != nil {
+			return err
+		}
+		permIndex[policy.PermissionKey(perm.Action, perm.ResourceKind)] = len(doc.Permissions)
+		doc.Permissions = append(doc.Permissions, perm)
+		return nil
+	}); err != nil {
+		return policy.Document{}, err
+	}
+	if err := scanRows(ctx, q, `SELECT r.key, p.key
+		FROM role_hierarchy h
+		JOIN role r ON r.id = h.role_id
+		JOIN role p ON p.id = h.parent_role_id
+		ORDER BY r.key, p.key`, nil, func(rows pgx.Rows) error {
+		var roleKey, parentKey string
+		if err := rows.Scan(&roleKey, &parentKey); err != nil {
+			return err
+		}
+		i := roleIndex[roleKey]
+		doc.Roles[i].Inherits = append(doc.Roles[i].Inherits, parentKey)
+		return nil
+	}); err != nil {
+		return policy.Document{}, err
+	}
+	if err := scanRows(ctx, q, `SELECT r.key, s.key
+		FROM service_role sr
+		JOIN role r ON r.id = sr.role_id
+		JOIN service s ON s.id = sr.service_id
+		ORDER BY r.key, s.key`, nil, func(rows pgx.Rows) error {
+		var roleKey, serviceKey string
+		if err := rows.Scan(&roleKey, &serviceKey); err != nil {
+			return err
+		}
+		i := roleIndex[roleKey]
+		doc.Roles[i].Services = append(doc.Roles[i].Services, serviceKey)
+		return nil
+	}); err != nil {
+		return policy.Document{}, err
+	}
+	if err := scanRows(ctx, q, `SELECT p.action, p.resource_kind, s.key
+		FROM service_permission sp
+		JOIN permission p ON p.id = sp.permission_id
+		JOIN service s ON s.id = sp.service_id
+		ORDER BY p.action, p.resource_kind, s.key`, nil, func(rows pgx.Rows) error {
+		var action, resourceKind, serviceKey string
+		if err := rows.Scan(&action, &resourceKind, &serviceKey); err != nil {
+			return err
+		}
+		i := permIndex[policy.PermissionKey(action, resourceKind)]
+		doc.Permissions[i].Services = append(doc.Permissions[i].Services, serviceKey)
+		return nil
+	}); err != nil {
+		return policy.Document{}, err
+	}
+	if err := scanRows(ctx, q, `SELECT r.key, p.action, p.resource_kind, rp.resource_id::text
+		FROM role_permission rp
+		JOIN role r ON r.id = rp.role_id
+		JOIN permission p ON p.id = rp.permission_id
+		ORDER BY r.key, p.action, p.resource_kind, rp.resource_id`, nil, func(rows pgx.Rows) error {
+		var roleKey, resourceID string
+		var grant policy.Grant
+		if err := rows.Scan(&roleKey, &grant.Action, &grant.ResourceKind, &resourceID); err != nil {
+			return err
+		}
+		if resourceID != defaultResourceID {
+			grant.ResourceID = resourceID
+		}
+		i := roleIndex[roleKey]
+		doc.Roles[i].Grants = append(doc.Roles[i].Grants, grant.String())
+		return nil
+	}); err != nil {
+		return policy.Document{}, err
+	}
+	return doc, nil
+}
+
+// roleHierarchyAuditQuery loads a role_hierarchy edge with the keys of both roles.
+const roleHierarchyAuditQuery = `SELECT to_jsonb(rh) || jsonb_build_object('role_key', r.key, 'parent_role_key', p.key)
+	FROM role_hierarchy rh
+	JOIN role r ON r.id = rh.role_id
+	JOIN role p ON p.id = rh.parent_role_id
+	WHERE rh.role_id::text=$1 AND rh.parent_role_id::text=$2`
+
+// applyPolicyChange applies c and records it in the audit log with the row it touched
+// before and after, under the entity and entity id the admin API uses for that row.
+// A link that already existed or was already gone changes nothing and is not recorded.
+func applyPolicyChange(ctx context.Context, tx pgx.Tx, c policy.Change) error {
+	// audited runs stmt between loading the row named by query and args, before and after.
+	audited := func(entity, entityID, query string, args []any, stmt string, stmtArgs ...any) error {
+		before, err := auditRow(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, stmt, stmtArgs...); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(before, after) {
+			return nil
+		}
+		// policy.Op values are the audit actions create, update and delete.
+		return recordAudit(ctx, tx, auditChange{Action: string(c.Op), Entity: entity, EntityID: entityID, Before: before, After: after})
+	}
+
+	switch c.Kind {
+	case policy.KindPermission:
+		if c.Op == policy.OpCreate {
+			var id string
+			if err := tx.QueryRow(ctx, `INSERT INTO permission (action, resource_kind) VALUES ($1, $2) RETURNING id::text`,
+				c.Action, c.ResourceKind).Scan(&id); err != nil {
+				return err
+			}
+			after, err := auditRow(ctx, tx, permissionAuditQuery, id)
+			if err != nil {
+				return err
+			}
+			return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "permission", EntityID: id, After: after})
+		}
+		id, err := permissionIDByKey(ctx, tx, c.Action, c.ResourceKind)
+		if err != nil {
+			return fmt.Errorf("permission %q: %w", policy.PermissionKey(c.Action, c.ResourceKind), err)
+		}
+		return audited("permission", id, permissionAuditQuery, []any{id}, `DELETE FROM permission WHERE id=$1`, id)
+	case policy.KindRole:
+		if c.Op == policy.OpCreate {
+			var id string
+			if err := tx.QueryRow(ctx, `INSERT INTO role (key, title) VALUES ($1, $2) RETURNING id::text`, c.Role, c.Title).Scan(&id); err != nil {
+				return err
+			}
+			after, err := auditRow(ctx, tx, roleAuditQuery, id)
+			if err != nil {
+				return err
+			}
+			return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "role", EntityID: id, After: after})
+		}
+		id, err := roleIDByKey(ctx, tx, c.Role)
+		if err != nil {
+			return fmt.Errorf("role %q: %w", c.Role, err)
+		}
+		if c.Op == policy.OpUpdate {
+			return audited("role", id, roleAuditQuery, []any{id}, `UPDATE role SET title=$2 WHERE id=$1`, id, c.Title)
+		}
+		return audited("role", id, roleAuditQuery, []any{id}, `DELETE FROM role WHERE id=$1`, id)
+	case policy.KindRoleHierarchy:
+		roleID, err := roleIDByKey(ctx, tx, c.Role)
+		if err != nil {
+			return fmt.Errorf("role %q: %w", c.Role, err)
+		}
+		parentID, err := roleIDByKey(ctx, tx, c.Parent)
+		if err != nil {
+			return fmt.Errorf("role %q: %w", c.Parent, err)
+		}
+		stmt := `DELETE FROM role_hierarchy WHERE role_id=$1 AND parent_role_id=$2`
+		if c.Op == policy.OpCreate {
+			stmt = `INSERT INTO role_hierarchy (role_id, parent_role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		}
+		return audited("role_hierarchy", roleID, roleHierarchyAuditQuery, []any{roleID, parentID}, stmt, roleID, parentID)
+	case policy.KindServiceRole:
+		roleID, err := roleIDByKey(ctx, tx, c.Role)
+		if err != nil {
+			return fmt.Errorf("role %q: %w", c.Role, err)
+		}
+		serviceID, err := serviceIDByKey(ctx, tx, c.Service)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", c.Service, err)
+		}
+		stmt := `DELETE FROM service_role WHERE role_id=$1 AND service_id=$2`
+		if c.Op == policy.OpCreate {
+			stmt = `INSERT INTO service_role (role_id, service_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		}
+		return audited("service_role", serviceID, serviceRoleAuditQuery, []any{roleID, serviceID}, stmt, roleID, serviceID)
+	case policy.KindServicePermission:
+		permissionID, err := permissionIDByKey(ctx, tx, c.Action, c.ResourceKind)
+		if err != nil {
+			return fmt.Errorf("permission %q: %w", policy.PermissionKey(c.Action, c.ResourceKind), err)
+		}
+		serviceID, err := serviceIDByKey(ctx, tx, c.Service)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", c.Service, err)
+		}
+		stmt := `DELETE FROM service_permission WHERE permission_id=$1 AND service_id=$2`
+		if c.Op == policy.OpCreate {
+			stmt = `INSERT INTO service_permission (permission_id, service_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		}
+		return audited("service_permission", serviceID, servicePermissionAuditQuery, []any{permissionID, serviceID}, stmt, permissionID, serviceID)
+	case policy.KindRolePermission:
+		roleID, err := roleIDByKey(ctx, tx, c.Role)
+		if err != nil {
+			return fmt.Errorf("role %q: %w", c.Role, err)
+		}
+		permissionID, err := permissionIDByKey(ctx, tx, c.Action, c.ResourceKind)
+		if err != nil {
+			return fmt.Errorf("permission %q: %w", policy.PermissionKey(c.Action, c.ResourceKind), err)
+		}
+		resourceID := resourceIDOrDefault(c.ResourceID)
+		stmt := `DELETE FROM role_permission WHERE role_id=$1 AND permission_id=$2 AND resource_id=$3`
+		if c.Op == policy.OpCreate {
+			stmt = `INSERT INTO role_permission (role_id, permission_id, resource_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+		}
+		return audited("role_permission", roleID, rolePermissionAuditQuery, []any{roleID, permissionID, resourceID}, stmt, roleID, permissionID, resourceID)
+	default:
+		return fmt.Errorf("unsupported policy change kind %q", c.Kind)
+	}
+}
+
+func scanRows(ctx context.Context, q dbtx, query string, args []any, fn func(pgx.Rows) error) error {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PrincipalOverrideInput describes a per-principal allow/deny override. Empty scope
+// fields fall back to the global defaults like role assignments do.
+type PrincipalOverrideInput struct {
+	PrincipalID   string               `json:"principal_id"`
+	PrincipalKind model.PrincipalKind  `json:"principal_kind"`
+	PermissionID  string               `json:"permission_id"`
+	Effect        model.OverrideEffect `json:"effect"`
+	TenantID      string               `json:"tenant_id"`
+	ServiceID     string               `json:"service_id"`
+	ResourceKind  string               `json:"resource_kind"`
+	ResourceID    string               `json:"resource_id"`
+	// ValidFrom and ValidUntil bound when the override is in effect; nil is open-ended.
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// Condition must hold for the override to apply; empty means always.
+	Condition string `json:"condition,omitempty"`
+}
+
+func (o PrincipalOverrideInput) withDefaults() PrincipalOverrideInput {
+	if o.PrincipalKind == "" {
+		o.PrincipalKind = defaultRoleKind
+	}
+	if o.TenantID == "" {
+		o.TenantID = defaultTenantID
+	}
+	if o.ServiceID == "" {
+		o.ServiceID = defaultServiceID
+	}
+	if o.ResourceKind == "" {
+		o.ResourceKind = defaultScopeKind
+	}
+	o.ResourceID = resourceIDOrDefault(o.ResourceID)
+	return o
+}
+
+// PrincipalOverrideRepository manages principal overrides.
+type PrincipalOverrideRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPrincipalOverrideRepository(pool *pgxpool.Pool) *PrincipalOverrideRepository {
+	return &PrincipalOverrideRepository{pool: pool}
+}
+
+// Set creates the override or replaces the effect, validity window and condition of an
+// existing one with the same scope.
+func (r *PrincipalOverrideRepository) Set(ctx context.Context, input PrincipalOverrideInput) error {
+	input = input.withDefaults()
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := ensurePermissionExists(ctx, tx, input.PermissionID); err != nil {
+			return err
+		}
+		key := []any{input.PrincipalID, string(input.PrincipalKind), input.PermissionID,
+			input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID}
+		before, err := auditRow(ctx, tx, principalOverrideAuditQuery+` FOR UPDATE`, key...)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO principal_override
+			(principal_id, principal_kind, permission_id, effect, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until, condition)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, nullif($11, ''))
+			ON CONFLICT (principal_id, principal_kind, permission_id, tenant_id, service_id, resource_kind, resource_id)
+			DO UPDATE SET effect = excluded.effect, valid_from = excluded.valid_from, valid_until = excluded.valid_until,
+				condition = excluded.condition`,
+			input.PrincipalID, string(input.PrincipalKind), input.PermissionID, string(input.Effect),
+			input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID, input.ValidFrom, input.ValidUntil, input.Condition)
+		if err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, principalOverrideAuditQuery, key...)
+		if err != nil {
+			return err
+		}
+		action := AuditActionCreate
+		if before != nil {
+			action = AuditActionUpdate
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "principal_override", EntityID: input.PrincipalID, Before: before, After: after})
+	})
+}
+
+func (r *PrincipalOverrideRepository) Delete(ctx context.Context, input PrincipalOverrideInput) error {
+	input = input.withDefaults()
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		key := []any{input.PrincipalID, string(input.PrincipalKind), input.PermissionID,
+			input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID}
+		before, err := auditRow(ctx, tx, principalOverrideAuditQuery+` FOR UPDATE`, key...)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM principal_override
+			WHERE principal_id=$1 AND principal_kind=$2 AND permission_id::text=$3
+				AND tenant_id=$4 AND service_id=$5 AND resource_kind=$6 AND resource_id=$7`, key...); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "principal_override", EntityID: input.PrincipalID, Before: before})
+	})
+}
+
+const principalOverrideAuditQuery = `SELECT to_jsonb(po) FROM principal_override po
+	WHERE po.principal_id=$1 AND po.principal_kind=$2 AND po.permission_id::text=$3
+		AND po.tenant_id=$4 AND po.service_id=$5 AND po.resource_kind=$6 AND po.resource_id=$7`
+
+// ListByPrincipal returns every override held by the principal, including ones outside
+// their validity window that the sweeper has not removed yet.
+func (r *PrincipalOverrideRepository) ListByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) ([]PrincipalOverrideInput, error) {
+	if kind == "" {
+		kind = defaultRoleKind
+	}
+	rows, err := r.pool.Query(ctx, `SELECT permission_id::text, effect::text, tenant_id::text, service_id::text, resource_kind, resource_id::text,
+		valid_from, valid_until, coalesce(condition, '')
+		FROM principal_override
+		WHERE principal_id=$1 AND principal_kind=$2
+		ORDER BY permission_id, tenant_id, service_id, resource_kind, resource_id`, principalID, string(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]PrincipalOverrideInput, 0)
+	for rows.Next() {
+		item := PrincipalOverrideInput{PrincipalID: principalID, PrincipalKind: kind}
+		var effect string
+		if err := rows.Scan(&item.PermissionID, &effect, &item.TenantID, &item.ServiceID, &item.ResourceKind, &item.ResourceID,
+			&item.ValidFrom, &item.ValidUntil, &item.Condition); err != nil {
+			return nil, err
+		}
+		item.Effect = model.OverrideEffect(effect)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -3,7 +3,9 @@ package repo
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/example/ms-rbac-service/internal/domain/model"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -22,35 +24,68 @@ func NewPrincipalRoleRepository(pool *pgxpool.Pool) *PrincipalRoleRepository {
 	return &PrincipalRoleRepository{pool: pool}
 }
 
-func (r *PrincipalRoleRepository) Update(ctx context.Context, principalID string, input PrincipalRoleUpdate) error {
-	roleID, err := roleIDByKey(ctx, r.pool, input.RoleKey)
-	if err != nil {
-		return err
+// Update replaces the principal's global role assignments with the given role. It fails
+// like Assign when a constraint forbids the new assignment.
+func (r *PrincipalRoleRepository) Update(ctx context.Context, principalID string, kind model.PrincipalKind, input PrincipalRoleUpdate) error {
+	if kind == "" {
+		kind = defaultRoleKind
 	}
 	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
-		_, err := tx.Exec(ctx, `DELETE FROM principal_role
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		scopeArgs := []any{principalID, string(kind), defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID}
+		before, err := auditRow(ctx, tx, principalGlobalRolesAuditQuery, scopeArgs...)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM principal_role
 			WHERE principal_id=$1 AND principal_kind=$2 AND tenant_id=$3 AND service_id=$4 AND resource_kind=$5 AND resource_id=$6`,
-			principalID, string(defaultRoleKind), defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID)
+			scopeArgs...)
+		if err != nil {
+			return err
+		}
+		err = checkRoleGrant(ctx, tx, roleGrant{principalID, string(kind), roleID, defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID})
 		if err != nil {
 			return err
 		}
 		_, err = tx.Exec(ctx, `INSERT INTO principal_role
 			(principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id)
 			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-			principalID, string(defaultRoleKind), roleID, defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID)
-		return err
+			principalID, string(kind), roleID, defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID)
+		if err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, principalGlobalRolesAuditQuery, scopeArgs...)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionUpdate, Entity: "principal_role", EntityID: principalID, Before: before, After: after})
 	})
 }
 
-func (r *PrincipalRoleRepository) Get(ctx context.Context, principalID string) (string, error) {
+const principalGlobalRolesAuditQuery = `SELECT jsonb_agg(to_jsonb(pr) || jsonb_build_object('role_key', r.key))
+	FROM principal_role pr
+	JOIN role r ON r.id = pr.role_id
+	WHERE pr.principal_id=$1 AND pr.principal_kind=$2
+		AND pr.tenant_id=$3 AND pr.service_id=$4 AND pr.resource_kind=$5 AND pr.resource_id=$6`
+
+// Get returns the key of the principal's current role: its global assignment if there is
+// one, otherwise the first role it holds by key. Disabled service accounts hold none.
+func (r *PrincipalRoleRepository) Get(ctx context.Context, principalID string, kind model.PrincipalKind) (string, error) {
+	if kind == "" {
+		kind = defaultRoleKind
+	}
 	var roleKey string
 	row := r.pool.QueryRow(ctx, `SELECT r.key
 		FROM principal_role pr
 		JOIN role r ON r.id = pr.role_id
 		WHERE pr.principal_id=$1 AND pr.principal_kind=$2
 			AND pr.tenant_id=$3 AND pr.service_id=$4 AND pr.resource_kind=$5 AND pr.resource_id=$6
+			AND `+activePrincipalRole+` AND `+principalEnabled+`
 		LIMIT 1`,
-		principalID, string(defaultRoleKind), defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID)
+		principalID, string(kind), defaultTenantID, defaultServiceID, defaultScopeKind, defaultResourceID)
 	if err := row.Scan(&roleKey); err == nil {
 		return roleKey, nil
 	} else if !errors.Is(err, pgx.ErrNoRows) {
@@ -60,9 +95,9 @@ func (r *PrincipalRoleRepository) Get(ctx context.Context, principalID string) (
 	row = r.pool.QueryRow(ctx, `SELECT r.key
 		FROM principal_role pr
 		JOIN role r ON r.id = pr.role_id
-		WHERE pr.principal_id=$1 AND pr.principal_kind=$2
+		WHERE pr.principal_id=$1 AND pr.principal_kind=$2 AND `+activePrincipalRole+` AND `+principalEnabled+`
 		ORDER BY r.key
-		LIMIT 1`, principalID, string(defaultRoleKind))
+		LIMIT 1`, principalID, string(kind))
 	if err := row.Scan(&roleKey); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return "", nil
@@ -71,3 +106,299 @@ func (r *PrincipalRoleRepository) Get(ctx context.Context, principalID string) (
 	}
 	return roleKey, nil
 }
+
+// InheritedRoleKeys returns the keys of the active roles assigned to the groups the
+// principal belongs to, directly or through nested groups.
+func (r *PrincipalRoleRepository) InheritedRoleKeys(ctx context.Context, principalID string, kind model.PrincipalKind) ([]string, error) {
+	if kind == "" {
+		kind = defaultRoleKind
+	}
+	rows, err := r.pool.Query(ctx, principalClosure+`SELECT DISTINCT r.key
+		FROM principal_role pr
+		JOIN role r ON r.id = pr.role_id
+		WHERE `+inPrincipalClosure+` AND pr.principal_kind = 'group' AND `+activePrincipalRole+`
+		ORDER BY r.key`, principalID, string(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// PrincipalRoleAssignment is a scoped role assignment. Empty scope fields fall back
+// to the global defaults (any tenant, core service, any resource).
+type PrincipalRoleAssignment struct {
+	PrincipalID   string              `json:"principal_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	RoleKey       string              `json:"role_key"`
+	TenantID      string              `json:"tenant_id"`
+	ServiceID     string              `json:"service_id"`
+	ResourceKind  string              `json:"resource_kind"`
+	ResourceID    string              `json:"resource_id"`
+	// ValidFrom and ValidUntil bound when the assignment is in effect; nil is open-ended.
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+func (a PrincipalRoleAssignment) withDefaults() PrincipalRoleAssignment {
+	if a.PrincipalKind == "" {
+		a.PrincipalKind = defaultRoleKind
+	}
+	if a.TenantID == "" {
+		a.TenantID = defaultTenantID
+	}
+	if a.ServiceID == "" {
+		a.ServiceID = defaultServiceID
+	}
+	if a.ResourceKind == "" {
+		a.ResourceKind = defaultScopeKind
+	}
+	a.ResourceID = resourceIDOrDefault(a.ResourceID)
+	return a
+}
+
+// Assign adds a scoped role assignment. Assigning an existing scope again replaces its
+// validity window and is a no-op when the window is unchanged. A static
+// separation-of-duties rule against one of the principal's other roles returns
+// ErrSoDViolation, and a full role cardinality limit returns ErrCardinalityExceeded.
+func (r *PrincipalRoleRepository) Assign(ctx context.Context, input PrincipalRoleAssignment) error {
+	input = input.withDefaults()
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		args := []any{input.PrincipalID, string(input.PrincipalKind), roleID, input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID}
+		before, err := auditRow(ctx, tx, principalRoleAuditQuery+` FOR UPDATE OF pr`, args...)
+		if err != nil {
+			return err
+		}
+		if err := checkRoleGrant(ctx, tx, roleGrant{input.PrincipalID, string(input.PrincipalKind), roleID,
+			input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID}); err != nil {
+			return err
+		}
+		cmd, err := tx.Exec(ctx, `INSERT INTO principal_role
+			(principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id, valid_from, valid_until)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id)
+			DO UPDATE SET valid_from = excluded.valid_from, valid_until = excluded.valid_until
+			WHERE principal_role.valid_from IS DISTINCT FROM excluded.valid_from
+				OR principal_role.valid_until IS DISTINCT FROM excluded.valid_until`,
+			append(args, input.ValidFrom, input.ValidUntil)...)
+		if err != nil || cmd.RowsAffected() == 0 {
+			return err
+		}
+		after, err := auditRow(ctx, tx, principalRoleAuditQuery, args...)
+		if err != nil {
+			return err
+		}
+		action := AuditActionCreate
+		if before != nil {
+			action = AuditActionUpdate
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "principal_role", EntityID: input.PrincipalID, Before: before, After: after})
+	})
+}
+
+// roleGrant is a role assignment about to be written. Scope fields hold the stored
+// values, i.e. defaults rather than empty strings.
+type roleGrant struct {
+	PrincipalID   string
+	PrincipalKind string
+	RoleID        string
+	TenantID      string
+	ServiceID     string
+	ResourceKind  string
+	ResourceID    string
+}
+
+// checkRoleGrant enforces the constraints every path that writes principal_role must
+// honour before inserting grant.
+func checkRoleGrant(ctx context.Context, tx pgx.Tx, grant roleGrant) error {
+	if err := checkStaticSoD(ctx, tx, grant); err != nil {
+		return err
+	}
+	return checkRoleCardinality(ctx, tx, grant)
+}
+
+// Revoke removes a scoped role assignment.
+func (r *PrincipalRoleRepository) Revoke(ctx context.Context, input PrincipalRoleAssignment) error {
+	input = input.withDefaults()
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		args := []any{input.PrincipalID, string(input.PrincipalKind), roleID, input.TenantID, input.ServiceID, input.ResourceKind, input.ResourceID}
+		before, err := auditRow(ctx, tx, principalRoleAuditQuery, args...)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM principal_role
+			WHERE principal_id=$1 AND principal_kind=$2 AND role_id=$3
+				AND tenant_id=$4 AND service_id=$5 AND resource_kind=$6 AND resource_id=$7`, args...); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "principal_role", EntityID: input.PrincipalID, Before: before})
+	})
+}
+
+const principalRoleAuditQuery = `SELECT to_jsonb(pr) || jsonb_build_object('role_key', r.key)
+	FROM principal_role pr
+	JOIN role r ON r.id = pr.role_id
+	WHERE pr.principal_id=$1 AND pr.principal_kind=$2 AND pr.role_id=$3
+		AND pr.tenant_id=$4 AND pr.service_id=$5 AND pr.resource_kind=$6 AND pr.resource_id=$7`
+
+// ListByPrincipal returns every role assignment held by the principal, including ones
+// outside their validity window that the sweeper has not removed yet.
+func (r *PrincipalRoleRepository) ListByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) ([]PrincipalRoleAssignment, error) {
+	if kind == "" {
+		kind = defaultRoleKind
+	}
+	rows, err := r.pool.Query(ctx, `SELECT r.key, pr.tenant_id::text, pr.service_id::text, pr.resource_kind, pr.resource_id::text,
+		pr.valid_from, pr.valid_until
+		FROM principal_role pr
+		JOIN role r ON r.id = pr.role_id
+		WHERE pr.principal_id=$1 AND pr.principal_kind=$2
+		ORDER BY r.key, pr.tenant_id, pr.service_id, pr.resource_kind, pr.resource_id`, principalID, string(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]PrincipalRoleAssignment, 0)
+	for rows.Next() {
+		item := PrincipalRoleAssignment{PrincipalID: principalID, PrincipalKind: kind}
+		if err := rows.Scan(&item.RoleKey, &item.TenantID, &item.ServiceID, &item.ResourceKind, &item.ResourceID,
+			&item.ValidFrom, &item.ValidUntil); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// RoleMember identifies a principal holding a role.
+type RoleMember struct {
+	PrincipalID   string              `json:"principal_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+}
+
+// RoleMemberFilter narrows ListByRole and RemoveMembers to assignments with the given
+// stored scope values; empty fields match everything, so an empty TenantID matches
+// every tenant rather than the global default.
+type RoleMemberFilter struct {
+	PrincipalKind string
+	TenantID      string
+	ServiceID     string
+	ResourceKind  string
+	ResourceID    string
+}
+
+func (f RoleMemberFilter) args(roleID string) []any {
+	return []any{roleID, f.PrincipalKind, f.TenantID, f.ServiceID, f.ResourceKind, f.ResourceID}
+}
+
+const roleMemberWhere = `pr.role_id = $1::uuid
+		AND ($2::text = '' OR pr.principal_kind::text = $2)
+		AND ($3::text = '' OR pr.tenant_id::text = $3)
+		AND ($4::text = '' OR pr.service_id::text = $4)
+		AND ($5::text = '' OR pr.resource_kind = $5)
+		AND ($6::text = '' OR pr.resource_id::text = $6)`
+
+func roleExists(ctx context.Context, q dbtx, roleID string) error {
+	var exists bool
+	if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM role WHERE id::text=$1)`, roleID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListByRole returns the direct assignments of the role, one per principal and scope,
+// including ones outside their validity window that the sweeper has not removed yet.
+// Principals holding the role only through a group are not expanded.
+func (r *PrincipalRoleRepository) ListByRole(ctx context.Context, roleID string, filter RoleMemberFilter, offset, limit int) ([]PrincipalRoleAssignment, int64, error) {
+	if err := roleExists(ctx, r.pool, roleID); err != nil {
+		return nil, 0, err
+	}
+	args := filter.args(roleID)
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM principal_role pr WHERE `+roleMemberWhere, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items := make([]PrincipalRoleAssignment, 0)
+	err := scanRows(ctx, r.pool, `SELECT pr.principal_id::text, pr.principal_kind::text, r.key, pr.tenant_id::text, pr.service_id::text,
+		pr.resource_kind, pr.resource_id::text, pr.valid_from, pr.valid_until
+		FROM principal_role pr
+		JOIN role r ON r.id = pr.role_id
+		WHERE `+roleMemberWhere+`
+		ORDER BY pr.principal_kind, pr.principal_id, pr.tenant_id, pr.service_id, pr.resource_kind, pr.resource_id
+		LIMIT $7 OFFSET $8`, append(args, limit, offset), func(rows pgx.Rows) error {
+		var item PrincipalRoleAssignment
+		if err := rows.Scan(&item.PrincipalID, &item.PrincipalKind, &item.RoleKey, &item.TenantID, &item.ServiceID,
+			&item.ResourceKind, &item.ResourceID, &item.ValidFrom, &item.ValidUntil); err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	return items, total, err
+}
+
+// RemoveMembers revokes the role's assignments matching filter, limited to principals
+// when it is not empty, and returns how many were removed. Each removed assignment is
+// audited like a single Revoke.
+func (r *PrincipalRoleRepository) RemoveMembers(ctx context.Context, roleID string, filter RoleMemberFilter, principals []RoleMember) (int64, error) {
+	refs := make([]string, 0, len(principals))
+	for _, p := range principals {
+		kind := p.PrincipalKind
+		if kind == "" {
+			kind = defaultRoleKind
+		}
+		refs = append(refs, p.PrincipalID+":"+string(kind))
+	}
+	var removed int64
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := roleExists(ctx, tx, roleID); err != nil {
+			return err
+		}
+		changes := make([]auditChange, 0)
+		err := scanRows(ctx, tx, `DELETE FROM principal_role pr
+			USING role r
+			WHERE r.id = pr.role_id AND `+roleMemberWhere+`
+				AND (cardinality($7::text[]) = 0 OR pr.principal_id::text || ':' || pr.principal_kind::text = ANY($7::text[]))
+			RETURNING pr.principal_id::text, to_jsonb(pr) || jsonb_build_object('role_key', r.key)`,
+			append(filter.args(roleID), refs), func(rows pgx.Rows) error {
+				change := auditChange{Action: AuditActionDelete, Entity: "principal_role"}
+				if err := rows.Scan(&change.EntityID, &change.Before); err != nil {
+					return err
+				}
+				changes = append(changes, change)
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := recordAudit(ctx, tx, change); err != nil {
+				return err
+			}
+		}
+		removed = int64(len(changes))
+		return nil
+	})
+	return removed, err
+}
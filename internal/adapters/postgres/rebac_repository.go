@@ -0,0 +1,283 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
+)
+
+// RelationTupleFilter narrows RebacRepository.ListTuples; empty fields match everything.
+// Subject matches a plain subject exactly, or a userset when it has a relation.
+type RelationTupleFilter struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	Subject   *domainrebac.Subject
+}
+
+// RebacRepository stores ReBAC namespace configs and relation tuples. It implements
+// domainrebac.Store for the checker.
+type RebacRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRebacRepository(pool *pgxpool.Pool) *RebacRepository {
+	return &RebacRepository{pool: pool}
+}
+
+const (
+	rebacNamespaceSelect     = `SELECT name, config, updated_at FROM rebac_namespace`
+	rebacNamespaceAuditQuery = `SELECT to_jsonb(n) FROM rebac_namespace n WHERE n.name=$1`
+	relationTupleSelect      = `SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation FROM relation_tuple`
+)
+
+func scanRebacNamespace(row pgx.Row) (domainrebac.Namespace, error) {
+	var (
+		ns     domainrebac.Namespace
+		config []byte
+	)
+	if err := row.Scan(&ns.Name, &config, &ns.UpdatedAt); err != nil {
+		return ns, err
+	}
+	if err := json.Unmarshal(config, &ns.Relations); err != nil {
+		return ns, fmt.Errorf("namespace %s: decode config: %w", ns.Name, err)
+	}
+	return ns, nil
+}
+
+func scanRelationTuple(row pgx.Row) (domainrebac.Tuple, error) {
+	var t domainrebac.Tuple
+	err := row.Scan(&t.Object.Namespace, &t.Object.ID, &t.Relation, &t.Subject.Namespace, &t.Subject.ID, &t.Subject.Relation)
+	return t, err
+}
+
+// Namespace returns the config of a namespace, or nil when none is configured.
+func (r *RebacRepository) Namespace(ctx context.Context, name string) (*domainrebac.Namespace, error) {
+	ns, err := r.GetNamespace(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return ns, err
+}
+
+// Subjects returns the subjects of the tuples object#relation@...
+func (r *RebacRepository) Subjects(ctx context.Context, object domainrebac.Object, relation string) ([]domainrebac.Subject, error) {
+	subjects := make([]domainrebac.Subject, 0)
+	err := scanRows(ctx, r.pool, `SELECT subject_namespace, subject_id, subject_relation FROM relation_tuple
+		WHERE namespace=$1 AND object_id=$2 AND relation=$3
+		ORDER BY subject_namespace, subject_id, subject_relation`,
+		[]any{object.Namespace, object.ID, relation}, func(rows pgx.Rows) error {
+			var s domainrebac.Subject
+			if err := rows.Scan(&s.Namespace, &s.ID, &s.Relation); err != nil {
+				return err
+			}
+			subjects = append(subjects, s)
+			return nil
+		})
+	return subjects, err
+}
+
+// SetNamespace creates or replaces a namespace config. Removing a relation, or making
+// it computed, while tuples of it are stored returns ErrConflict.
+func (r *RebacRepository) SetNamespace(ctx context.Context, ns *domainrebac.Namespace) error {
+	config, err := json.Marshal(ns.Relations)
+	if err != nil {
+		return err
+	}
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, rebacNamespaceAuditQuery+` FOR UPDATE`, ns.Name)
+		if err != nil {
+			return err
+		}
+		if before != nil {
+			stored := make([]string, 0)
+			for _, name := range ns.RelationNames() {
+				if ns.Relations[name].AllowsTuples() {
+					stored = append(stored, name)
+				}
+			}
+			var orphaned string
+			err := tx.QueryRow(ctx, `SELECT relation FROM relation_tuple WHERE namespace=$1 AND NOT (relation = ANY($2::text[])) LIMIT 1`,
+				ns.Name, stored).Scan(&orphaned)
+			if err == nil {
+				return fmt.Errorf("%w: namespace %s still stores tuples of relation %s", ErrConflict, ns.Name, orphaned)
+			}
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+		if err := tx.QueryRow(ctx, `INSERT INTO rebac_namespace (name, config) VALUES ($1, $2::jsonb)
+			ON CONFLICT (name) DO UPDATE SET config = EXCLUDED.config, updated_at = now()
+			RETURNING updated_at`, ns.Name, string(config)).Scan(&ns.UpdatedAt); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, rebacNamespaceAuditQuery, ns.Name)
+		if err != nil {
+			return err
+		}
+		action := AuditActionUpdate
+		if before == nil {
+			action = AuditActionCreate
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "rebac_namespace", EntityID: ns.Name, Before: before, After: after})
+	})
+}
+
+func (r *RebacRepository) GetNamespace(ctx context.Context, name string) (*domainrebac.Namespace, error) {
+	ns, err := scanRebacNamespace(r.pool.QueryRow(ctx, rebacNamespaceSelect+` WHERE name=$1`, name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ns, nil
+}
+
+func (r *RebacRepository) ListNamespaces(ctx context.Context) ([]domainrebac.Namespace, error) {
+	items := make([]domainrebac.Namespace, 0)
+	err := scanRows(ctx, r.pool, rebacNamespaceSelect+` ORDER BY name`, nil, func(rows pgx.Rows) error {
+		ns, err := scanRebacNamespace(rows)
+		if err != nil {
+			return err
+		}
+		items = append(items, ns)
+		return nil
+	})
+	return items, err
+}
+
+// DeleteNamespace removes a namespace config. While tuples on its objects, or tuples
+// with usersets of it as subjects, are stored it returns ErrConflict.
+func (r *RebacRepository) DeleteNamespace(ctx context.Context, name string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, rebacNamespaceAuditQuery+` FOR UPDATE`, name)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		var used bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM relation_tuple
+			WHERE namespace=$1 OR (subject_namespace=$1 AND subject_relation <> ''))`, name).Scan(&used); err != nil {
+			return err
+		}
+		if used {
+			return fmt.Errorf("%w: namespace %s has relation tuples", ErrConflict, name)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM rebac_namespace WHERE name=$1`, name); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "rebac_namespace", EntityID: name, Before: before})
+	})
+}
+
+// WriteTuples stores the tuples in one transaction and returns how many were new;
+// tuples already stored are left as they are. Namespaces are locked against concurrent
+// config changes until the transaction commits.
+func (r *RebacRepository) WriteTuples(ctx context.Context, tuples []domainrebac.Tuple) (int64, error) {
+	var written int64
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockRebacNamespaces(ctx, tx, tuples); err != nil {
+			return err
+		}
+		for _, t := range tuples {
+			tag, err := tx.Exec(ctx, `INSERT INTO relation_tuple
+				(namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+				VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+				t.Object.Namespace, t.Object.ID, t.Relation, t.Subject.Namespace, t.Subject.ID, t.Subject.Relation)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				continue
+			}
+			written++
+			if err := recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "relation_tuple", EntityID: t.String(), After: auditValue(t)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return written, err
+}
+
+// DeleteTuples removes the tuples in one transaction and returns how many were stored.
+func (r *RebacRepository) DeleteTuples(ctx context.Context, tuples []domainrebac.Tuple) (int64, error) {
+	var deleted int64
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		for _, t := range tuples {
+			tag, err := tx.Exec(ctx, `DELETE FROM relation_tuple
+				WHERE namespace=$1 AND object_id=$2 AND relation=$3
+				AND subject_namespace=$4 AND subject_id=$5 AND subject_relation=$6`,
+				t.Object.Namespace, t.Object.ID, t.Relation, t.Subject.Namespace, t.Subject.ID, t.Subject.Relation)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				continue
+			}
+			deleted++
+			if err := recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "relation_tuple", EntityID: t.String(), Before: auditValue(t)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// lockRebacNamespaces share-locks the namespace rows the tuples refer to, so a config
+// validated by the caller cannot change before the tuples commit.
+func lockRebacNamespaces(ctx context.Context, tx pgx.Tx, tuples []domainrebac.Tuple) error {
+	seen := map[string]bool{}
+	names := make([]string, 0)
+	for _, t := range tuples {
+		for _, name := range []string{t.Object.Namespace, t.Subject.Namespace} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	_, err := tx.Exec(ctx, `SELECT 1 FROM rebac_namespace WHERE name = ANY($1::text[]) ORDER BY name FOR SHARE`, names)
+	return err
+}
+
+func (r *RebacRepository) ListTuples(ctx context.Context, filter RelationTupleFilter, offset, limit int) ([]domainrebac.Tuple, int64, error) {
+	var subjectNamespace, subjectID, subjectRelation string
+	if filter.Subject != nil {
+		subjectNamespace, subjectID, subjectRelation = filter.Subject.Namespace, filter.Subject.ID, filter.Subject.Relation
+	}
+	where := strings.Join([]string{
+		`($1::text = '' OR namespace = $1)`,
+		`($2::text = '' OR object_id = $2)`,
+		`($3::text = '' OR relation = $3)`,
+		`($4::text = '' OR (subject_namespace = $4 AND subject_id = $5 AND subject_relation = $6))`,
+	}, " AND ")
+	args := []any{filter.Namespace, filter.ObjectID, filter.Relation, subjectNamespace, subjectID, subjectRelation}
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM relation_tuple WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items := make([]domainrebac.Tuple, 0)
+	err := scanRows(ctx, r.pool, relationTupleSelect+` WHERE `+where+`
+		ORDER BY namespace, object_id, relation, subject_namespace, subject_id, subject_relation LIMIT $7 OFFSET $8`,
+		append(args, limit, offset), func(rows pgx.Rows) error {
+			t, err := scanRelationTuple(rows)
+			if err != nil {
+				return err
+			}
+			items = append(items, t)
+			return nil
+		})
+	return items, total, err
+}
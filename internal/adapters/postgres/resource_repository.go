@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Resource is a registered resource. ID is the id grants, overrides and checks use as
+// resource_id; a resource without ParentID is a root.
+type Resource struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ResourceListFilter narrows ResourceRepository.List; empty fields match everything.
+type ResourceListFilter struct {
+	Kind     string
+	ParentID string
+}
+
+// ResourceRepository manages the resource registry.
+type ResourceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewResourceRepository(pool *pgxpool.Pool) *ResourceRepository {
+	return &ResourceRepository{pool: pool}
+}
+
+const (
+	resourceSelect     = `SELECT id::text, kind, coalesce(parent_id::text, ''), created_at FROM resource`
+	resourceAuditQuery = `SELECT to_jsonb(r) FROM resource r WHERE r.id::text=$1`
+)
+
+func scanResource(row pgx.Row) (Resource, error) {
+	var item Resource
+	err := row.Scan(&item.ID, &item.Kind, &item.ParentID, &item.CreatedAt)
+	return item, err
+}
+
+// lockResourceTree serializes changes to parent links for the rest of tx, so two
+// concurrent moves cannot together create a cycle.
+func lockResourceTree(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('resource_tree'))`)
+	return err
+}
+
+// checkResourceParent returns ErrNotFound when parentID is not registered and
+// ErrConflict when id is parentID or one of its ancestors.
+func checkResourceParent(ctx context.Context, tx pgx.Tx, id, parentID string) error {
+	var exists, cycle bool
+	err := tx.QueryRow(ctx, `WITH RECURSIVE up(id, depth) AS (
+			SELECT id, 0 FROM resource WHERE id::text = $2
+			UNION ALL
+			SELECT r.parent_id, up.depth + 1
+			FROM resource r
+			JOIN up ON r.id = up.id
+			WHERE r.parent_id IS NOT NULL AND up.depth < $3
+		)
+		SELECT EXISTS(SELECT 1 FROM resource WHERE id::text = $2),
+			EXISTS(SELECT 1 FROM up WHERE id::text = $1)`, id, parentID, maxResourceDepth).Scan(&exists, &cycle)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: parent resource %s", ErrNotFound, parentID)
+	}
+	if cycle {
+		return fmt.Errorf("%w: resource %s cannot be nested under its own descendant %s", ErrConflict, id, parentID)
+	}
+	return nil
+}
+
+// Create registers a resource under an existing parent, if any. Registering an id twice
+// returns ErrConflict.
+func (r *ResourceRepository) Create(ctx context.Context, item *Resource) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockResourceTree(ctx, tx); err != nil {
+			return err
+		}
+		if item.ParentID != "" {
+			if err := checkResourceParent(ctx, tx, item.ID, item.ParentID); err != nil {
+				return err
+			}
+		}
+		err := tx.QueryRow(ctx, `INSERT INTO resource (id, kind, parent_id) VALUES ($1::uuid, $2, nullif($3, '')::uuid)
+			ON CONFLICT (id) DO NOTHING RETURNING created_at`, item.ID, item.Kind, item.ParentID).Scan(&item.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: resource %s is already registered", ErrConflict, item.ID)
+		}
+		if err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, resourceAuditQuery, item.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "resource", EntityID: item.ID, After: after})
+	})
+}
+
+// Update changes the kind and parent of a resource. Moving a resource under one of its
+// descendants returns ErrConflict.
+func (r *ResourceRepository) Update(ctx context.Context, item *Resource) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockResourceTree(ctx, tx); err != nil {
+			return err
+		}
+		before, err := auditRow(ctx, tx, resourceAuditQuery+` FOR UPDATE`, item.ID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if item.ParentID != "" {
+			if err := checkResourceParent(ctx, tx, item.ID, item.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := tx.QueryRow(ctx, `UPDATE resource SET kind=$2, parent_id=nullif($3, '')::uuid WHERE id::text=$1 RETURNING created_at`,
+			item.ID, item.Kind, item.ParentID).Scan(&item.CreatedAt); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, resourceAuditQuery, item.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionUpdate, Entity: "resource", EntityID: item.ID, Before: before, After: after})
+	})
+}
+
+func (r *ResourceRepository) Get(ctx context.Context, id string) (*Resource, error) {
+	item, err := scanResource(r.pool.QueryRow(ctx, resourceSelect+` WHERE id::text=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *ResourceRepository) List(ctx context.Context, filter ResourceListFilter, offset, limit int) ([]Resource, int64, error) {
+	const where = ` WHERE ($1::text = '' OR kind = $1) AND ($2::text = '' OR parent_id::text = $2)`
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM resource`+where, filter.Kind, filter.ParentID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items := make([]Resource, 0)
+	err := scanRows(ctx, r.pool, resourceSelect+where+` ORDER BY kind, id LIMIT $3 OFFSET $4`,
+		[]any{filter.Kind, filter.ParentID, limit, offset}, func(rows pgx.Rows) error {
+			item, err := scanResource(rows)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+	return items, total, err
+}
+
+// Delete unregisters a resource. A resource with children returns ErrConflict; grants
+// and overrides on it are kept and keep matching the resource itself.
+func (r *ResourceRepository) Delete(ctx context.Context, id string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockResourceTree(ctx, tx); err != nil {
+			return err
+		}
+		before, err := auditRow(ctx, tx, resourceAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		var children bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM resource WHERE parent_id::text=$1)`, id).Scan(&children); err != nil {
+			return err
+		}
+		if children {
+			return fmt.Errorf("%w: resource %s has children", ErrConflict, id)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM resource WHERE id::text=$1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "resource", EntityID: id, Before: before})
+	})
+}
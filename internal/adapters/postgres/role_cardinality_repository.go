@@ -0,0 +1,185 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Scope levels of a cardinality limit, from the widest to the narrowest partition.
+const (
+	CardinalityScopeGlobal   = "global"
+	CardinalityScopeTenant   = "tenant"
+	CardinalityScopeService  = "service"
+	CardinalityScopeResource = "resource"
+)
+
+// RoleCardinality limits how many distinct principals may hold a role per scope level.
+type RoleCardinality struct {
+	RoleKey       string    `json:"role_key"`
+	Scope         string    `json:"scope"`
+	MaxPrincipals int       `json:"max_principals"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RoleCardinalityRepository stores role cardinality limits.
+type RoleCardinalityRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleCardinalityRepository(pool *pgxpool.Pool) *RoleCardinalityRepository {
+	return &RoleCardinalityRepository{pool: pool}
+}
+
+const roleCardinalityAuditQuery = `SELECT to_jsonb(rc) || jsonb_build_object('role_key', r.key)
+	FROM role_cardinality rc
+	JOIN role r ON r.id = rc.role_id
+	WHERE rc.role_id::text=$1 AND rc.scope_level=$2`
+
+// Set creates or replaces the limit for the role and scope level. Principals already
+// above a lowered limit keep their assignments; only new ones are rejected.
+func (r *RoleCardinalityRepository) Set(ctx context.Context, limit *RoleCardinality) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, limit.RoleKey)
+		if err != nil {
+			return err
+		}
+		before, err := auditRow(ctx, tx, roleCardinalityAuditQuery+` FOR UPDATE OF rc`, roleID, limit.Scope)
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, `INSERT INTO role_cardinality (role_id, scope_level, max_principals) VALUES ($1, $2, $3)
+			ON CONFLICT (role_id, scope_level) DO UPDATE SET max_principals = excluded.max_principals, updated_at = now()
+			RETURNING updated_at`, roleID, limit.Scope, limit.MaxPrincipals).Scan(&limit.UpdatedAt); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, roleCardinalityAuditQuery, roleID, limit.Scope)
+		if err != nil {
+			return err
+		}
+		action := AuditActionCreate
+		if before != nil {
+			action = AuditActionUpdate
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "role_cardinality", EntityID: roleID, Before: before, After: after})
+	})
+}
+
+func (r *RoleCardinalityRepository) Delete(ctx context.Context, roleKey, scope string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, roleKey)
+		if err != nil {
+			return err
+		}
+		before, err := auditRow(ctx, tx, roleCardinalityAuditQuery+` FOR UPDATE OF rc`, roleID, scope)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM role_cardinality WHERE role_id::text=$1 AND scope_level=$2`, roleID, scope); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "role_cardinality", EntityID: roleID, Before: before})
+	})
+}
+
+// List returns every limit, or only the role's when roleKey is not empty.
+func (r *RoleCardinalityRepository) List(ctx context.Context, roleKey string) ([]RoleCardinality, error) {
+	items := make([]RoleCardinality, 0)
+	err := scanRows(ctx, r.pool, `SELECT r.key, rc.scope_level, rc.max_principals, rc.updated_at
+		FROM role_cardinality rc
+		JOIN role r ON r.id = rc.role_id
+		WHERE $1::text = '' OR r.key = $1
+		ORDER BY r.key, rc.scope_level`, []any{roleKey}, func(rows pgx.Rows) error {
+		var item RoleCardinality
+		if err := rows.Scan(&item.RoleKey, &item.Scope, &item.MaxPrincipals, &item.UpdatedAt); err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// checkRoleCardinality returns ErrCardinalityExceeded when granting the role would take
+// a limited scope above its maximum number of principals. Principals already holding the
+// role in that scope do not count twice. Locking the role's limit rows serializes
+// concurrent grants of the role, so two transactions cannot both take the last slot.
+func checkRoleCardinality(ctx context.Context, tx pgx.Tx, grant roleGrant) error {
+	var limits []RoleCardinality
+	err := scanRows(ctx, tx, `SELECT r.key, rc.scope_level, rc.max_principals
+		FROM role_cardinality rc
+		JOIN role r ON r.id = rc.role_id
+		WHERE rc.role_id = $1::uuid
+		ORDER BY rc.scope_level
+		FOR UPDATE OF rc`, []any{grant.RoleID}, func(rows pgx.Rows) error {
+		var limit RoleCardinality
+		if err := rows.Scan(&limit.RoleKey, &limit.Scope, &limit.MaxPrincipals); err != nil {
+			return err
+		}
+		limits = append(limits, limit)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, limit := range limits {
+		partition, scopeArgs, err := cardinalityPartition(limit.Scope, grant)
+		if err != nil {
+			return err
+		}
+		var held int
+		err = tx.QueryRow(ctx, `SELECT count(DISTINCT pr.principal_id::text || ':' || pr.principal_kind::text)
+			FROM principal_role pr
+			WHERE pr.role_id = $1::uuid
+				AND NOT (pr.principal_id = $2::uuid AND pr.principal_kind = $3::principal_kind)
+				AND (pr.valid_until IS NULL OR pr.valid_until > now())`+partition,
+			append([]any{grant.RoleID, grant.PrincipalID, grant.PrincipalKind}, scopeArgs...)...).Scan(&held)
+		if err != nil {
+			return err
+		}
+		if held >= limit.MaxPrincipals {
+			return fmt.Errorf("%w: role %s allows at most %d principals per %s", ErrCardinalityExceeded, limit.RoleKey, limit.MaxPrincipals, limit.Scope)
+		}
+	}
+	return nil
+}
+
+// cardinalityPartition returns the conditions restricting a count of the role's holders
+// to the grant's partition at the scope level, and their arguments, numbered from $4.
+func cardinalityPartition(scope string, grant roleGrant) (string, []any, error) {
+	columns := []struct {
+		predicate string
+		value     string
+	}{
+		{"pr.tenant_id = $4::uuid", grant.TenantID},
+		{"pr.service_id = $5::uuid", grant.ServiceID},
+		{"pr.resource_kind = $6", grant.ResourceKind},
+		{"pr.resource_id = $7::uuid", grant.ResourceID},
+	}
+	var n int
+	switch scope {
+	case CardinalityScopeGlobal:
+		n = 0
+	case CardinalityScopeTenant:
+		n = 1
+	case CardinalityScopeService:
+		n = 2
+	case CardinalityScopeResource:
+		n = 4
+	default:
+		return "", nil, fmt.Errorf("unknown cardinality scope %q", scope)
+	}
+	partition := ""
+	args := make([]any, 0, n)
+	for _, col := range columns[:n] {
+		partition += " AND " + col.predicate
+		args = append(args, col.value)
+	}
+	return partition, args, nil
+}
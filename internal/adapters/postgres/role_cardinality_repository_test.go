@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCardinalityPartition(t *testing.T) {
+	grant := roleGrant{
+		PrincipalID:   "00000000-0000-0000-0000-0000000000a1",
+		PrincipalKind: "user",
+		RoleID:        "00000000-0000-0000-0000-0000000000f1",
+		TenantID:      "00000000-0000-0000-0000-0000000000e1",
+		ServiceID:     "00000000-0000-0000-0000-0000000000d1",
+		ResourceKind:  "course",
+		ResourceID:    "00000000-0000-0000-0000-0000000000c1",
+	}
+	tests := []struct {
+		scope     string
+		partition string
+		args      []any
+		wantErr   bool
+	}{
+		{CardinalityScopeGlobal, "", []any{}, false},
+		{CardinalityScopeTenant, " AND pr.tenant_id = $4::uuid", []any{grant.TenantID}, false},
+		{CardinalityScopeService, " AND pr.tenant_id = $4::uuid AND pr.service_id = $5::uuid",
+			[]any{grant.TenantID, grant.ServiceID}, false},
+		{CardinalityScopeResource, " AND pr.tenant_id = $4::uuid AND pr.service_id = $5::uuid AND pr.resource_kind = $6 AND pr.resource_id = $7::uuid",
+			[]any{grant.TenantID, grant.ServiceID, grant.ResourceKind, grant.ResourceID}, false},
+		{"region", "", nil, true},
+		{"", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			partition, args, err := cardinalityPartition(tt.scope, grant)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cardinalityPartition(%q) error = %v, wantErr %v", tt.scope, err, tt.wantErr)
+			}
+			if partition != tt.partition {
+				t.Errorf("cardinalityPartition(%q) partition = %q, want %q", tt.scope, partition, tt.partition)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("cardinalityPartition(%q) args = %v, want %v", tt.scope, args, tt.args)
+			}
+		})
+	}
+}
@@ -3,13 +3,26 @@ package repo
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // RolePermissionCreate describes a role-permission assignment request.
+// An empty ResourceID grants the permission on every resource of its kind, and an
+// empty Condition grants it unconditionally.
 type RolePermissionCreate struct {
 	RoleKey      string
 	PermissionID string
+	ResourceID   string
+	Condition    string
+}
+
+// RolePermissionGrant is a permission granted to a role, optionally narrowed to a resource
+// instance and guarded by a condition.
+type RolePermissionGrant struct {
+	Permission
+	ResourceID *string
+	Condition  string `json:",omitempty"`
 }
 
 // RolePermissionRepository manages role-permission assignments.
@@ -21,40 +34,94 @@ func NewRolePermissionRepository(pool *pgxpool.Pool) *RolePermissionRepository {
 	return &RolePermissionRepository{pool: pool}
 }
 
+// Create grants the permission, or replaces the condition of an existing grant on the
+// same resource.
 func (r *RolePermissionRepository) Create(ctx context.Context, input RolePermissionCreate) error {
-	roleID, err := roleIDByKey(ctx, r.pool, input.RoleKey)
-	if err != nil {
-		return err
-	}
-	if err := ensurePermissionExists(ctx, r.pool, input.PermissionID); err != nil {
-		return err
-	}
-	_, err = r.pool.Exec(ctx, `INSERT INTO role_permission (role_id, permission_id, resource_id)
-		VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, roleID, input.PermissionID, defaultResourceID)
-	return err
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		if err := ensurePermissionExists(ctx, tx, input.PermissionID); err != nil {
+			return err
+		}
+		resourceID := resourceIDOrDefault(input.ResourceID)
+		before, err := auditRow(ctx, tx, rolePermissionAuditQuery+` FOR UPDATE OF rp`, roleID, input.PermissionID, resourceID)
+		if err != nil {
+			return err
+		}
+		cmd, err := tx.Exec(ctx, `INSERT INTO role_permission (role_id, permission_id, resource_id, condition)
+			VALUES ($1, $2, $3, nullif($4, ''))
+			ON CONFLICT (role_id, permission_id, resource_id) DO UPDATE SET condition = excluded.condition
+			WHERE role_permission.condition IS DISTINCT FROM excluded.condition`,
+			roleID, input.PermissionID, resourceID, input.Condition)
+		if err != nil || cmd.RowsAffected() == 0 {
+			return err
+		}
+		after, err := auditRow(ctx, tx, rolePermissionAuditQuery, roleID, input.PermissionID, resourceID)
+		if err != nil {
+			return err
+		}
+		action := AuditActionCreate
+		if before != nil {
+			action = AuditActionUpdate
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "role_permission", EntityID: roleID, Before: before, After: after})
+	})
 }
 
-func (r *RolePermissionRepository) ListByRoleKey(ctx context.Context, roleKey string) ([]Permission, error) {
+func (r *RolePermissionRepository) Delete(ctx context.Context, input RolePermissionCreate) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		resourceID := resourceIDOrDefault(input.ResourceID)
+		before, err := auditRow(ctx, tx, rolePermissionAuditQuery, roleID, input.PermissionID, resourceID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM role_permission
+			WHERE role_id=$1 AND permission_id::text=$2 AND resource_id=$3`, roleID, input.PermissionID, resourceID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "role_permission", EntityID: roleID, Before: before})
+	})
+}
+
+const rolePermissionAuditQuery = `SELECT to_jsonb(rp) || jsonb_build_object('role_key', r.key)
+	FROM role_permission rp
+	JOIN role r ON r.id = rp.role_id
+	WHERE rp.role_id=$1 AND rp.permission_id::text=$2 AND rp.resource_id=$3`
+
+func (r *RolePermissionRepository) ListByRoleKey(ctx context.Context, roleKey string) ([]RolePermissionGrant, error) {
 	rows, err := r.pool.Query(ctx, `SELECT
 		p.id::text,
 		p.action,
-		p.resource_kind
+		p.resource_kind,
+		rp.resource_id::text,
+		coalesce(rp.condition, '')
 		FROM role_permission rp
 		JOIN role r ON r.id = rp.role_id
 		JOIN permission p ON p.id = rp.permission_id
 		WHERE r.key=$1
-		ORDER BY p.action, p.resource_kind`, roleKey)
+		ORDER BY p.action, p.resource_kind, rp.resource_id`, roleKey)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := make([]Permission, 0)
+	items := make([]RolePermissionGrant, 0)
 	for rows.Next() {
-		var perm Permission
-		if err := rows.Scan(&perm.ID, &perm.Action, &perm.ResourceKind); err != nil {
+		var grant RolePermissionGrant
+		var resourceID string
+		if err := rows.Scan(&grant.ID, &grant.Action, &grant.ResourceKind, &resourceID, &grant.Condition); err != nil {
 			return nil, err
 		}
-		items = append(items, perm)
+		grant.ResourceID = ptrIfNotDefault(resourceID, defaultResourceID)
+		items = append(items, grant)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
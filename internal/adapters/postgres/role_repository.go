@@ -18,19 +18,37 @@ func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
 }
 
 func (r *RoleRepository) Create(ctx context.Context, role *Role) error {
-	query := `INSERT INTO role (key, title) VALUES ($1, $2) RETURNING id::text`
-	return r.pool.QueryRow(ctx, query, role.Key, role.Title).Scan(&role.ID)
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `INSERT INTO role (key, title) VALUES ($1, $2) RETURNING id::text`
+		if err := tx.QueryRow(ctx, query, role.Key, role.Title).Scan(&role.ID); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, roleAuditQuery, role.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "role", EntityID: role.ID, After: after})
+	})
 }
 
 func (r *RoleRepository) Update(ctx context.Context, id, title string) error {
-	cmd, err := r.pool.Exec(ctx, `UPDATE role SET title=$2 WHERE id::text=$1`, id, title)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, roleAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `UPDATE role SET title=$2 WHERE id::text=$1`, id, title); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, roleAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionUpdate, Entity: "role", EntityID: id, Before: before, After: after})
+	})
 }
 
 func (r *RoleRepository) Get(ctx context.Context, id string) (*Role, error) {
@@ -45,12 +63,20 @@ func (r *RoleRepository) Get(ctx context.Context, id string) (*Role, error) {
 	return &role, nil
 }
 
-func (r *RoleRepository) List(ctx context.Context, offset, limit int) ([]Role, int64, error) {
+// RoleListFilter narrows role listings; an empty ServiceID lists every role.
+type RoleListFilter struct {
+	ServiceID string
+}
+
+func (r *RoleRepository) List(ctx context.Context, filter RoleListFilter, offset, limit int) ([]Role, int64, error) {
+	const where = `WHERE $1 = '' OR EXISTS (
+		SELECT 1 FROM service_role sr WHERE sr.role_id = role.id AND sr.service_id::text = $1
+	)`
 	var total int64
-	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM role`).Scan(&total); err != nil {
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM role `+where, filter.ServiceID).Scan(&total); err != nil {
 		return nil, 0, err
 	}
-	rows, err := r.pool.Query(ctx, `SELECT id::text, key, title FROM role ORDER BY key LIMIT $1 OFFSET $2`, limit, offset)
+	rows, err := r.pool.Query(ctx, `SELECT id::text, key, title FROM role `+where+` ORDER BY key LIMIT $2 OFFSET $3`, filter.ServiceID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -67,12 +93,19 @@ func (r *RoleRepository) List(ctx context.Context, offset, limit int) ([]Role, i
 }
 
 func (r *RoleRepository) Delete(ctx context.Context, id string) error {
-	cmd, err := r.pool.Exec(ctx, `DELETE FROM role WHERE id::text=$1`, id)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, roleAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM role WHERE id::text=$1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "role", EntityID: id, Before: before})
+	})
 }
+
+const roleAuditQuery = `SELECT to_jsonb(r) FROM role r WHERE r.id::text=$1`
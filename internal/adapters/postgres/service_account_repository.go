@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ServiceAccount is a non-human principal of kind "service_account". Roles and overrides
+// assigned to it use the account id as principal_id.
+type ServiceAccount struct {
+	ID              string     `json:"id"`
+	Key             string     `json:"key"`
+	Title           string     `json:"title"`
+	CreatedAt       time.Time  `json:"created_at"`
+	SecretRotatedAt time.Time  `json:"secret_rotated_at"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty"`
+}
+
+// ServiceAccountRepository stores service accounts and their secret hashes.
+type ServiceAccountRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceAccountRepository(pool *pgxpool.Pool) *ServiceAccountRepository {
+	return &ServiceAccountRepository{pool: pool}
+}
+
+// The secret hash never leaves the database, not even into the audit log.
+const (
+	serviceAccountAuditQuery = `SELECT to_jsonb(sa) - 'secret_hash' FROM service_account sa WHERE sa.id::text=$1`
+	serviceAccountSelect     = `SELECT id::text, key, title, created_at, secret_rotated_at, disabled_at FROM service_account`
+)
+
+func scanServiceAccount(row pgx.Row) (ServiceAccount, error) {
+	var sa ServiceAccount
+	err := row.Scan(&sa.ID, &sa.Key, &sa.Title, &sa.CreatedAt, &sa.SecretRotatedAt, &sa.DisabledAt)
+	return sa, err
+}
+
+func (r *ServiceAccountRepository) Create(ctx context.Context, sa *ServiceAccount, secretHash string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		created, err := scanServiceAccount(tx.QueryRow(ctx, `INSERT INTO service_account (key, title, secret_hash) VALUES ($1, $2, $3)
+			RETURNING id::text, key, title, created_at, secret_rotated_at, disabled_at`, sa.Key, sa.Title, secretHash))
+		if err != nil {
+			return err
+		}
+		*sa = created
+		after, err := auditRow(ctx, tx, serviceAccountAuditQuery, sa.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "service_account", EntityID: sa.ID, After: after})
+	})
+}
+
+func (r *ServiceAccountRepository) Get(ctx context.Context, id string) (ServiceAccount, error) {
+	sa, err := scanServiceAccount(r.pool.QueryRow(ctx, serviceAccountSelect+` WHERE id::text=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ServiceAccount{}, ErrNotFound
+	}
+	return sa, err
+}
+
+func (r *ServiceAccountRepository) List(ctx context.Context, offset, limit int) ([]ServiceAccount, int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM service_account`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items := make([]ServiceAccount, 0)
+	err := scanRows(ctx, r.pool, serviceAccountSelect+` ORDER BY key LIMIT $1 OFFSET $2`, []any{limit, offset}, func(rows pgx.Rows) error {
+		sa, err := scanServiceAccount(rows)
+		if err != nil {
+			return err
+		}
+		items = append(items, sa)
+		return nil
+	})
+	return items, total, err
+}
+
+// Rotate replaces the account's secret; the previous secret stops working immediately.
+func (r *ServiceAccountRepository) Rotate(ctx context.Context, id, secretHash string) (ServiceAccount, error) {
+	return r.update(ctx, id, AuditActionUpdate, `UPDATE service_account SET secret_hash=$2, secret_rotated_at=now() WHERE id::text=$1`, secretHash)
+}
+
+// SetDisabled disables or re-enables the account. Disabled accounts cannot authenticate
+// and hold no roles or overrides for policy decisions.
+func (r *ServiceAccountRepository) SetDisabled(ctx context.Context, id string, disabled bool) (ServiceAccount, error) {
+	if disabled {
+		return r.update(ctx, id, AuditActionDeactivate, `UPDATE service_account SET disabled_at=coalesce(disabled_at, now()) WHERE id::text=$1`)
+	}
+	return r.update(ctx, id, AuditActionActivate, `UPDATE service_account SET disabled_at=NULL WHERE id::text=$1`)
+}
+
+func (r *ServiceAccountRepository) update(ctx context.Context, id, action, query string, args ...any) (ServiceAccount, error) {
+	var sa ServiceAccount
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, serviceAccountAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, query, append([]any{id}, args...)...); err != nil {
+			return err
+		}
+		if sa, err = scanServiceAccount(tx.QueryRow(ctx, serviceAccountSelect+` WHERE id::text=$1`, id)); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, serviceAccountAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: action, Entity: "service_account", EntityID: id, Before: before, After: after})
+	})
+	return sa, err
+}
+
+// Credential returns the account and its secret hash for authentication.
+func (r *ServiceAccountRepository) Credential(ctx context.Context, id string) (ServiceAccount, string, error) {
+	var sa ServiceAccount
+	var hash string
+	err := r.pool.QueryRow(ctx, `SELECT id::text, key, title, created_at, secret_rotated_at, disabled_at, secret_hash
+		FROM service_account WHERE id::text=$1`, id).
+		Scan(&sa.ID, &sa.Key, &sa.Title, &sa.CreatedAt, &sa.SecretRotatedAt, &sa.DisabledAt, &hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ServiceAccount{}, "", ErrNotFound
+	}
+	return sa, hash, err
+}
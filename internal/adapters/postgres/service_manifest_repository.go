@@ -0,0 +1,241 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ManifestStatusAdded     = "added"
+	ManifestStatusUpdated   = "updated"
+	ManifestStatusUnchanged = "unchanged"
+)
+
+// ServiceManifest is the permission catalog a service registers about itself.
+type ServiceManifest struct {
+	Key         string
+	Title       string
+	Permissions []ManifestPermission
+}
+
+// ManifestPermission declares a permission and the roles granted it by default.
+type ManifestPermission struct {
+	Action       string   `json:"action"`
+	ResourceKind string   `json:"resource_kind"`
+	DefaultRoles []string `json:"default_roles,omitempty"`
+}
+
+// ManifestGrant identifies a kind-wide role-permission grant.
+type ManifestGrant struct {
+	RoleKey      string `json:"role_key"`
+	Action       string `json:"action"`
+	ResourceKind string `json:"resource_kind"`
+}
+
+// ManifestPermissionRef identifies a permission by its natural key.
+type ManifestPermissionRef struct {
+	Action       string `json:"action"`
+	ResourceKind string `json:"resource_kind"`
+}
+
+// ServiceManifestReport describes what registering a manifest changed.
+type ServiceManifestReport struct {
+	ServiceID     string                    `json:"service_id"`
+	ServiceStatus string                    `json:"service_status"`
+	Permissions   ManifestPermissionsReport `json:"permissions"`
+	Grants        ManifestGrantsReport      `json:"grants"`
+}
+
+type ManifestPermissionsReport struct {
+	Added     []ManifestPermissionRef `json:"added"`
+	Unchanged []ManifestPermissionRef `json:"unchanged"`
+	Orphaned  []ManifestPermissionRef `json:"orphaned"`
+}
+
+type ManifestGrantsReport struct {
+	Added     []ManifestGrant `json:"added"`
+	Unchanged []ManifestGrant `json:"unchanged"`
+	Orphaned  []ManifestGrant `json:"orphaned"`
+}
+
+// ServiceManifestRepository upserts service catalogs in a single transaction.
+type ServiceManifestRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceManifestRepository(pool *pgxpool.Pool) *ServiceManifestRepository {
+	return &ServiceManifestRepository{pool: pool}
+}
+
+// Apply idempotently registers the manifest and records it as the service's last
+// applied manifest. Permissions and grants the previous manifest declared, this one
+// drops and the database still holds are reported as orphaned and left untouched.
+func (r *ServiceManifestRepository) Apply(ctx context.Context, manifest ServiceManifest) (*ServiceManifestReport, error) {
+	report := &ServiceManifestReport{
+		Permissions: ManifestPermissionsReport{
+			Added:     make([]ManifestPermissionRef, 0),
+			Unchanged: make([]ManifestPermissionRef, 0),
+			Orphaned:  make([]ManifestPermissionRef, 0),
+		},
+		Grants: ManifestGrantsReport{
+			Added:     make([]ManifestGrant, 0),
+			Unchanged: make([]ManifestGrant, 0),
+			Orphaned:  make([]ManifestGrant, 0),
+		},
+	}
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		serviceID, status, err := upsertManifestService(ctx, tx, manifest.Key, manifest.Title)
+		if err != nil {
+			return err
+		}
+		report.ServiceID = serviceID
+		report.ServiceStatus = status
+
+		previous, err := lastAppliedManifest(ctx, tx, serviceID)
+		if err != nil {
+			return err
+		}
+		declaredPermissions := make(map[string]struct{}, len(manifest.Permissions))
+		declaredGrants := make(map[string]struct{})
+		for _, perm := range manifest.Permissions {
+			ref := ManifestPermissionRef{Action: perm.Action, ResourceKind: perm.ResourceKind}
+			declaredPermissions[perm.Action+":"+perm.ResourceKind] = struct{}{}
+			permissionID, err := upsertPermission(ctx, tx, perm.Action, perm.ResourceKind)
+			if err != nil {
+				return err
+			}
+
+			cmd, err := tx.Exec(ctx, `INSERT INTO service_permission (permission_id, service_id)
+				VALUES ($1, $2) ON CONFLICT DO NOTHING`, permissionID, serviceID)
+			if err != nil {
+				return err
+			}
+			if cmd.RowsAffected() > 0 {
+				report.Permissions.Added = append(report.Permissions.Added, ref)
+			} else {
+				report.Permissions.Unchanged = append(report.Permissions.Unchanged, ref)
+			}
+
+			for _, roleKey := range perm.DefaultRoles {
+				roleID, err := roleIDByKey(ctx, tx, roleKey)
+				if err != nil {
+					if errors.Is(err, ErrNotFound) {
+						return fmt.Errorf("role %q: %w", roleKey, ErrNotFound)
+					}
+					return err
+				}
+				grant := ManifestGrant{RoleKey: roleKey, Action: perm.Action, ResourceKind: perm.ResourceKind}
+				declaredGrants[roleKey+"/"+perm.Action+":"+perm.ResourceKind] = struct{}{}
+				cmd, err := tx.Exec(ctx, `INSERT INTO role_permission (role_id, permission_id, resource_id)
+					VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, roleID, permissionID, defaultResourceID)
+				if err != nil {
+					return err
+				}
+				if cmd.RowsAffected() > 0 {
+					report.Grants.Added = append(report.Grants.Added, grant)
+				} else {
+					report.Grants.Unchanged = append(report.Grants.Unchanged, grant)
+				}
+			}
+		}
+
+		for _, perm := range previous {
+			if _, ok := declaredPermissions[perm.Action+":"+perm.ResourceKind]; !ok {
+				var linked bool
+				if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM service_permission sp
+					JOIN permission p ON p.id = sp.permission_id
+					WHERE sp.service_id=$1 AND p.action=$2 AND p.resource_kind=$3)`,
+					serviceID, perm.Action, perm.ResourceKind).Scan(&linked); err != nil {
+					return err
+				}
+				if linked {
+					report.Permissions.Orphaned = append(report.Permissions.Orphaned,
+						ManifestPermissionRef{Action: perm.Action, ResourceKind: perm.ResourceKind})
+				}
+			}
+			for _, roleKey := range perm.DefaultRoles {
+				if _, ok := declaredGrants[roleKey+"/"+perm.Action+":"+perm.ResourceKind]; ok {
+					continue
+				}
+				var granted bool
+				if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM role_permission rp
+					JOIN role r ON r.id = rp.role_id
+					JOIN permission p ON p.id = rp.permission_id
+					WHERE r.key=$1 AND p.action=$2 AND p.resource_kind=$3 AND rp.resource_id=$4)`,
+					roleKey, perm.Action, perm.ResourceKind, defaultResourceID).Scan(&granted); err != nil {
+					return err
+				}
+				if granted {
+					report.Grants.Orphaned = append(report.Grants.Orphaned,
+						ManifestGrant{RoleKey: roleKey, Action: perm.Action, ResourceKind: perm.ResourceKind})
+				}
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO service_manifest (service_id, permissions) VALUES ($1, $2)
+			ON CONFLICT (service_id) DO UPDATE SET permissions = excluded.permissions, applied_at = now()`,
+			serviceID, manifest.Permissions); err != nil {
+			return err
+		}
+		if report.ServiceStatus == ManifestStatusUnchanged && len(report.Permissions.Added) == 0 && len(report.Grants.Added) == 0 {
+			return nil
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionApply, Entity: "service_manifest", EntityID: serviceID, After: auditValue(report)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// lastAppliedManifest returns the permissions of the manifest the service registered
+// last, or none when it never registered one.
+func lastAppliedManifest(ctx context.Context, tx pgx.Tx, serviceID string) ([]ManifestPermission, error) {
+	var permissions []ManifestPermission
+	err := tx.QueryRow(ctx, `SELECT permissions FROM service_manifest WHERE service_id=$1`, serviceID).Scan(&permissions)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return permissions, err
+}
+
+func upsertManifestService(ctx context.Context, tx pgx.Tx, key, title string) (string, string, error) {
+	var serviceID string
+	err := tx.QueryRow(ctx, `INSERT INTO service (key, title) VALUES ($1, $2)
+		ON CONFLICT (key) DO NOTHING RETURNING id::text`, key, title).Scan(&serviceID)
+	if err == nil {
+		return serviceID, ManifestStatusAdded, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", "", err
+	}
+	var currentTitle string
+	if err := tx.QueryRow(ctx, `SELECT id::text, title FROM service WHERE key=$1 FOR UPDATE`, key).Scan(&serviceID, &currentTitle); err != nil {
+		return "", "", err
+	}
+	if title == "" || title == currentTitle {
+		return serviceID, ManifestStatusUnchanged, nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE service SET title=$2 WHERE id=$1`, serviceID, title); err != nil {
+		return "", "", err
+	}
+	return serviceID, ManifestStatusUpdated, nil
+}
+
+func upsertPermission(ctx context.Context, tx pgx.Tx, action, resourceKind string) (string, error) {
+	var permissionID string
+	err := tx.QueryRow(ctx, `INSERT INTO permission (action, resource_kind) VALUES ($1, $2)
+		ON CONFLICT (action, resource_kind) DO NOTHING RETURNING id::text`, action, resourceKind).Scan(&permissionID)
+	if err == nil {
+		return permissionID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	err = tx.QueryRow(ctx, `SELECT id::text FROM permission WHERE action=$1 AND resource_kind=$2`, action, resourceKind).Scan(&permissionID)
+	return permissionID, err
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ServicePermissionBinding describes a permission attached to a service.
+type ServicePermissionBinding struct {
+	ServiceID    string
+	PermissionID string
+}
+
+// ServicePermissionRepository manages service-permission bindings.
+type ServicePermissionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewServicePermissionRepository(pool *pgxpool.Pool) *ServicePermissionRepository {
+	return &ServicePermissionRepository{pool: pool}
+}
+
+func (r *ServicePermissionRepository) Create(ctx context.Context, input ServicePermissionBinding) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := ensurePermissionExists(ctx, tx, input.PermissionID); err != nil {
+			return err
+		}
+		if err := ensureServiceExists(ctx, tx, input.ServiceID); err != nil {
+			return err
+		}
+		cmd, err := tx.Exec(ctx, `INSERT INTO service_permission (permission_id, service_id)
+			VALUES ($1, $2) ON CONFLICT DO NOTHING`, input.PermissionID, input.ServiceID)
+		if err != nil || cmd.RowsAffected() == 0 {
+			return err
+		}
+		after, err := auditRow(ctx, tx, servicePermissionAuditQuery, input.PermissionID, input.ServiceID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "service_permission", EntityID: input.ServiceID, After: after})
+	})
+}
+
+func (r *ServicePermissionRepository) Delete(ctx context.Context, input ServicePermissionBinding) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, servicePermissionAuditQuery, input.PermissionID, input.ServiceID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM service_permission
+			WHERE permission_id::text=$1 AND service_id::text=$2`, input.PermissionID, input.ServiceID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "service_permission", EntityID: input.ServiceID, Before: before})
+	})
+}
+
+const servicePermissionAuditQuery = `SELECT to_jsonb(sp) FROM service_permission sp WHERE sp.permission_id::text=$1 AND sp.service_id::text=$2`
@@ -18,19 +18,37 @@ func NewServiceRepository(pool *pgxpool.Pool) *ServiceRepository {
 }
 
 func (r *ServiceRepository) Create(ctx context.Context, service *Service) error {
-	query := `INSERT INTO service (key, title) VALUES ($1, $2) RETURNING id::text`
-	return r.pool.QueryRow(ctx, query, service.Key, service.Title).Scan(&service.ID)
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `INSERT INTO service (key, title) VALUES ($1, $2) RETURNING id::text`
+		if err := tx.QueryRow(ctx, query, service.Key, service.Title).Scan(&service.ID); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, serviceAuditQuery, service.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "service", EntityID: service.ID, After: after})
+	})
 }
 
 func (r *ServiceRepository) Update(ctx context.Context, id, title string) error {
-	cmd, err := r.pool.Exec(ctx, `UPDATE service SET title=$2 WHERE id::text=$1`, id, title)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, serviceAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `UPDATE service SET title=$2 WHERE id::text=$1`, id, title); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, serviceAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionUpdate, Entity: "service", EntityID: id, Before: before, After: after})
+	})
 }
 
 func (r *ServiceRepository) Get(ctx context.Context, id string) (*Service, error) {
@@ -67,12 +85,19 @@ func (r *ServiceRepository) List(ctx context.Context, offset, limit int) ([]Serv
 }
 
 func (r *ServiceRepository) Delete(ctx context.Context, id string) error {
-	cmd, err := r.pool.Exec(ctx, `DELETE FROM service WHERE id::text=$1`, id)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, serviceAuditQuery+` FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM service WHERE id::text=$1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "service", EntityID: id, Before: before})
+	})
 }
+
+const serviceAuditQuery = `SELECT to_jsonb(s) FROM service s WHERE s.id::text=$1`
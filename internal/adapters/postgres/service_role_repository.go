@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ServiceRoleBinding describes a role attached to a service.
+type ServiceRoleBinding struct {
+	ServiceID string
+	RoleKey   string
+}
+
+// ServiceRoleRepository manages service-role bindings.
+type ServiceRoleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceRoleRepository(pool *pgxpool.Pool) *ServiceRoleRepository {
+	return &ServiceRoleRepository{pool: pool}
+}
+
+func (r *ServiceRoleRepository) Create(ctx context.Context, input ServiceRoleBinding) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		if err := ensureServiceExists(ctx, tx, input.ServiceID); err != nil {
+			return err
+		}
+		cmd, err := tx.Exec(ctx, `INSERT INTO service_role (role_id, service_id)
+			VALUES ($1, $2) ON CONFLICT DO NOTHING`, roleID, input.ServiceID)
+		if err != nil || cmd.RowsAffected() == 0 {
+			return err
+		}
+		after, err := auditRow(ctx, tx, serviceRoleAuditQuery, roleID, input.ServiceID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "service_role", EntityID: input.ServiceID, After: after})
+	})
+}
+
+func (r *ServiceRoleRepository) Delete(ctx context.Context, input ServiceRoleBinding) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleID, err := roleIDByKey(ctx, tx, input.RoleKey)
+		if err != nil {
+			return err
+		}
+		before, err := auditRow(ctx, tx, serviceRoleAuditQuery, roleID, input.ServiceID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM service_role WHERE role_id=$1 AND service_id::text=$2`, roleID, input.ServiceID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "service_role", EntityID: input.ServiceID, Before: before})
+	})
+}
+
+const serviceRoleAuditQuery = `SELECT to_jsonb(sr) || jsonb_build_object('role_key', r.key)
+	FROM service_role sr
+	JOIN role r ON r.id = sr.role_id
+	WHERE sr.role_id=$1 AND sr.service_id::text=$2`
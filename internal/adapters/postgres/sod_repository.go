@@ -0,0 +1,244 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	SoDKindStatic  = "static"
+	SoDKindDynamic = "dynamic"
+)
+
+// SoDRule is a separation-of-duties rule between two roles. Static rules reject
+// assignments that would give a principal both roles; dynamic rules only flag checks
+// where both apply. SameScope limits the rule to assignments whose scopes overlap.
+type SoDRule struct {
+	ID          string    `json:"id"`
+	Key         string    `json:"key"`
+	RoleA       string    `json:"role_a"`
+	RoleB       string    `json:"role_b"`
+	Kind        string    `json:"kind"`
+	SameScope   bool      `json:"same_scope"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SoDViolation is a principal directly holding both roles of a rule.
+type SoDViolation struct {
+	Rule          string `json:"rule"`
+	Kind          string `json:"kind"`
+	PrincipalID   string `json:"principal_id"`
+	PrincipalKind string `json:"principal_kind"`
+	RoleA         string `json:"role_a"`
+	RoleB         string `json:"role_b"`
+}
+
+// SoDViolationFilter narrows SoDRepository.ListViolations; empty fields match everything.
+type SoDViolationFilter struct {
+	Rule string
+	Kind string
+}
+
+// SoDRepository stores separation-of-duties rules.
+type SoDRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSoDRepository(pool *pgxpool.Pool) *SoDRepository {
+	return &SoDRepository{pool: pool}
+}
+
+const (
+	sodRuleSelect = `SELECT s.id::text, s.key, ra.key, rb.key, s.kind, s.same_scope, s.description, s.created_at
+	FROM sod_rule s
+	JOIN role ra ON ra.id = s.role_a_id
+	JOIN role rb ON rb.id = s.role_b_id`
+	sodRuleAuditQuery = `SELECT to_jsonb(s) || jsonb_build_object('role_a', ra.key, 'role_b', rb.key)
+	FROM sod_rule s
+	JOIN role ra ON ra.id = s.role_a_id
+	JOIN role rb ON rb.id = s.role_b_id
+	WHERE s.id::text=$1`
+)
+
+func scanSoDRule(row pgx.Row) (SoDRule, error) {
+	var rule SoDRule
+	err := row.Scan(&rule.ID, &rule.Key, &rule.RoleA, &rule.RoleB, &rule.Kind, &rule.SameScope, &rule.Description, &rule.CreatedAt)
+	return rule, err
+}
+
+// Create stores a rule between two existing roles. Existing assignments are not
+// checked; ListViolations reports them.
+func (r *SoDRepository) Create(ctx context.Context, rule *SoDRule) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		roleA, err := roleIDByKey(ctx, tx, rule.RoleA)
+		if err != nil {
+			return err
+		}
+		roleB, err := roleIDByKey(ctx, tx, rule.RoleB)
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, `INSERT INTO sod_rule (key, role_a_id, role_b_id, kind, same_scope, description)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id::text, created_at`,
+			rule.Key, roleA, roleB, rule.Kind, rule.SameScope, rule.Description).Scan(&rule.ID, &rule.CreatedAt); err != nil {
+			return err
+		}
+		after, err := auditRow(ctx, tx, sodRuleAuditQuery, rule.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "sod_rule", EntityID: rule.ID, After: after})
+	})
+}
+
+func (r *SoDRepository) Get(ctx context.Context, id string) (SoDRule, error) {
+	rule, err := scanSoDRule(r.pool.QueryRow(ctx, sodRuleSelect+` WHERE s.id::text=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SoDRule{}, ErrNotFound
+	}
+	return rule, err
+}
+
+func (r *SoDRepository) List(ctx context.Context, offset, limit int) ([]SoDRule, int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM sod_rule`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items := make([]SoDRule, 0)
+	err := scanRows(ctx, r.pool, sodRuleSelect+` ORDER BY s.key LIMIT $1 OFFSET $2`, []any{limit, offset}, func(rows pgx.Rows) error {
+		rule, err := scanSoDRule(rows)
+		if err != nil {
+			return err
+		}
+		items = append(items, rule)
+		return nil
+	})
+	return items, total, err
+}
+
+func (r *SoDRepository) Delete(ctx context.Context, id string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, sodRuleAuditQuery+` FOR UPDATE OF s`, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM sod_rule WHERE id::text=$1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "sod_rule", EntityID: id, Before: before})
+	})
+}
+
+// sodViolationsQuery pairs the active direct assignments (a, b) of each rule's two roles
+// held by the same principal. $1 and $2 filter by rule key and kind when not empty.
+var sodViolationsQuery = `SELECT DISTINCT s.key, s.kind, a.principal_id::text, a.principal_kind::text, ra.key, rb.key
+	FROM sod_rule s
+	JOIN role ra ON ra.id = s.role_a_id
+	JOIN role rb ON rb.id = s.role_b_id
+	JOIN principal_role a ON a.role_id = s.role_a_id
+	JOIN principal_role b ON b.role_id = s.role_b_id AND b.principal_id = a.principal_id AND b.principal_kind = a.principal_kind
+	WHERE (a.valid_until IS NULL OR a.valid_until > now()) AND (b.valid_until IS NULL OR b.valid_until > now())
+		AND (NOT s.same_scope OR ` + scopesOverlap("a", "b") + `)
+		AND ($1::text = '' OR s.key = $1) AND ($2::text = '' OR s.kind = $2)`
+
+// ListViolations reports principals that currently hold both roles of a rule, e.g. ones
+// assigned before a static rule was created or under a dynamic rule. Roles inherited
+// through groups are not expanded.
+func (r *SoDRepository) ListViolations(ctx context.Context, filter SoDViolationFilter, offset, limit int) ([]SoDViolation, int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM (`+sodViolationsQuery+`) v`, filter.Rule, filter.Kind).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items := make([]SoDViolation, 0)
+	err := scanRows(ctx, r.pool, sodViolationsQuery+` ORDER BY 1, 3, 4 LIMIT $3 OFFSET $4`,
+		[]any{filter.Rule, filter.Kind, limit, offset}, func(rows pgx.Rows) error {
+			var v SoDViolation
+			if err := rows.Scan(&v.Rule, &v.Kind, &v.PrincipalID, &v.PrincipalKind, &v.RoleA, &v.RoleB); err != nil {
+				return err
+			}
+			items = append(items, v)
+			return nil
+		})
+	return items, total, err
+}
+
+// DynamicSoDRules implements pdp.SoDRuleSource.
+func (r *SoDRepository) DynamicSoDRules(ctx context.Context) ([]domainpdp.SoDRule, error) {
+	rules := make([]domainpdp.SoDRule, 0)
+	err := scanRows(ctx, r.pool, `SELECT s.key, ra.key, rb.key
+		FROM sod_rule s
+		JOIN role ra ON ra.id = s.role_a_id
+		JOIN role rb ON rb.id = s.role_b_id
+		WHERE s.kind = 'dynamic'
+		ORDER BY s.key`, nil, func(rows pgx.Rows) error {
+		var rule domainpdp.SoDRule
+		if err := rows.Scan(&rule.Key, &rule.RoleA, &rule.RoleB); err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+		return nil
+	})
+	return rules, err
+}
+
+// scopesOverlap is a predicate on two principal_role-shaped aliases: every scope column
+// is equal or the global default on either side.
+func scopesOverlap(a, b string) string {
+	overlap := ""
+	for _, col := range []struct{ name, def string }{
+		{"tenant_id", "'" + defaultTenantID + "'::uuid"},
+		{"service_id", "'" + defaultServiceID + "'::uuid"},
+		{"resource_kind", "'" + defaultScopeKind + "'"},
+		{"resource_id", "'" + defaultResourceID + "'::uuid"},
+	} {
+		if overlap != "" {
+			overlap += " AND "
+		}
+		overlap += fmt.Sprintf("(%[1]s.%[3]s = %[2]s.%[3]s OR %[1]s.%[3]s = %[4]s OR %[2]s.%[3]s = %[4]s)", a, b, col.name, col.def)
+	}
+	return overlap
+}
+
+// checkStaticSoD returns ErrSoDViolation when a static rule forbids the principal from
+// holding the granted role next to one it already holds directly and has not expired.
+// It serializes concurrent assignments to the same principal for the rest of tx.
+func checkStaticSoD(ctx context.Context, tx pgx.Tx, c roleGrant) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('principal_role:' || $1 || ':' || $2))`,
+		c.PrincipalID, c.PrincipalKind); err != nil {
+		return err
+	}
+	var rule, roleA, roleB string
+	err := tx.QueryRow(ctx, `WITH c AS (
+			SELECT $3::uuid AS role_id, $4::uuid AS tenant_id, $5::uuid AS service_id, $6::text AS resource_kind, $7::uuid AS resource_id
+		)
+		SELECT s.key, ra.key, rb.key
+		FROM sod_rule s
+		CROSS JOIN c
+		JOIN principal_role pr ON pr.principal_id = $1::uuid AND pr.principal_kind = $2::principal_kind
+			AND pr.role_id = CASE WHEN s.role_a_id = c.role_id THEN s.role_b_id ELSE s.role_a_id END
+		JOIN role ra ON ra.id = s.role_a_id
+		JOIN role rb ON rb.id = s.role_b_id
+		WHERE s.kind = 'static' AND c.role_id IN (s.role_a_id, s.role_b_id)
+			AND (pr.valid_until IS NULL OR pr.valid_until > now())
+			AND (NOT s.same_scope OR `+scopesOverlap("c", "pr")+`)
+		ORDER BY s.key
+		LIMIT 1`,
+		c.PrincipalID, c.PrincipalKind, c.RoleID, c.TenantID, c.ServiceID, c.ResourceKind, c.ResourceID).Scan(&rule, &roleA, &roleB)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: rule %s forbids holding both %s and %s", ErrSoDViolation, rule, roleA, roleB)
+}
@@ -0,0 +1,26 @@
+package repo
+
+import "testing"
+
+func TestScopesOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"c", "pr",
+			"(c.tenant_id = pr.tenant_id OR c.tenant_id = '00000000-0000-0000-0000-000000000000'::uuid OR pr.tenant_id = '00000000-0000-0000-0000-000000000000'::uuid)" +
+				" AND (c.service_id = pr.service_id OR c.service_id = '00000000-0000-0000-0000-000000000100'::uuid OR pr.service_id = '00000000-0000-0000-0000-000000000100'::uuid)" +
+				" AND (c.resource_kind = pr.resource_kind OR c.resource_kind = 'global' OR pr.resource_kind = 'global')" +
+				" AND (c.resource_id = pr.resource_id OR c.resource_id = '00000000-0000-0000-0000-000000000000'::uuid OR pr.resource_id = '00000000-0000-0000-0000-000000000000'::uuid)"},
+		{"pa", "pb",
+			"(pa.tenant_id = pb.tenant_id OR pa.tenant_id = '00000000-0000-0000-0000-000000000000'::uuid OR pb.tenant_id = '00000000-0000-0000-0000-000000000000'::uuid)" +
+				" AND (pa.service_id = pb.service_id OR pa.service_id = '00000000-0000-0000-0000-000000000100'::uuid OR pb.service_id = '00000000-0000-0000-0000-000000000100'::uuid)" +
+				" AND (pa.resource_kind = pb.resource_kind OR pa.resource_kind = 'global' OR pb.resource_kind = 'global')" +
+				" AND (pa.resource_id = pb.resource_id OR pa.resource_id = '00000000-0000-0000-0000-000000000000'::uuid OR pb.resource_id = '00000000-0000-0000-0000-000000000000'::uuid)"},
+	}
+	for _, tt := range tests {
+		if got := scopesOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("scopesOverlap(%q, %q) =\n%s\nwant\n%s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SuperadminRepository manages principals that bypass every PDP rule.
+type SuperadminRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSuperadminRepository(pool *pgxpool.Pool) *SuperadminRepository {
+	return &SuperadminRepository{pool: pool}
+}
+
+// Grant marks the principal as superadmin; granting twice is a no-op.
+func (r *SuperadminRepository) Grant(ctx context.Context, principalID string, kind model.PrincipalKind) error {
+	if kind == "" {
+		kind = defaultRoleKind
+	}
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, `INSERT INTO superadmin_principal (principal_id, principal_kind)
+			VALUES ($1, $2) ON CONFLICT (principal_id) DO NOTHING`, principalID, string(kind))
+		if err != nil || cmd.RowsAffected() == 0 {
+			return err
+		}
+		after, err := auditRow(ctx, tx, superadminAuditQuery, principalID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionCreate, Entity: "superadmin", EntityID: principalID, After: after})
+	})
+}
+
+// Revoke removes the superadmin flag from the principal.
+func (r *SuperadminRepository) Revoke(ctx context.Context, principalID string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := auditRow(ctx, tx, superadminAuditQuery+` FOR UPDATE`, principalID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM superadmin_principal WHERE principal_id::text=$1`, principalID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{Action: AuditActionDelete, Entity: "superadmin", EntityID: principalID, Before: before})
+	})
+}
+
+func (r *SuperadminRepository) List(ctx context.Context) ([]model.SuperadminPrincipal, error) {
+	rows, err := r.pool.Query(ctx, `SELECT principal_id::text, principal_kind::text FROM superadmin_principal ORDER BY principal_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]model.SuperadminPrincipal, 0)
+	for rows.Next() {
+		var item model.SuperadminPrincipal
+		var kind string
+		if err := rows.Scan(&item.PrincipalID, &kind); err != nil {
+			return nil, err
+		}
+		item.PrincipalKind = model.PrincipalKind(kind)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+const superadminAuditQuery = `SELECT to_jsonb(sp) FROM superadmin_principal sp WHERE sp.principal_id::text=$1`
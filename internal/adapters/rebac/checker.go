@@ -0,0 +1,241 @@
+package rebac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
+)
+
+// Checker answers Check and Expand requests over the relation tuples and namespace
+// configs of a store.
+type Checker struct {
+	store domainrebac.Store
+}
+
+// NewChecker constructs a new Checker instance.
+func NewChecker(store domainrebac.Store) *Checker {
+	return &Checker{store: store}
+}
+
+// Check reports whether subject has relation on object, directly or through the
+// relation's rewrites. Cycles in the tuple graph are cut rather than reported.
+func (c *Checker) Check(ctx context.Context, object domainrebac.Object, relation string, subject domainrebac.Subject) (bool, error) {
+	ev := c.evaluation(ctx)
+	if _, err := ev.definition(object, relation); err != nil {
+		return false, err
+	}
+	return ev.check(object, relation, subject, 0)
+}
+
+// Expand returns the userset tree of object#relation. Userset subjects of stored
+// tuples are returned as leaves for the caller to expand further.
+func (c *Checker) Expand(ctx context.Context, object domainrebac.Object, relation string) (domainrebac.ExpandNode, error) {
+	ev := c.evaluation(ctx)
+	rewrite, err := ev.definition(object, relation)
+	if err != nil {
+		return domainrebac.ExpandNode{}, err
+	}
+	ev.visiting[userset(object, relation)] = true
+	return ev.expand(object, relation, rewrite, 0)
+}
+
+func (c *Checker) evaluation(ctx context.Context) *evaluation {
+	return &evaluation{
+		ctx:        ctx,
+		store:      c.store,
+		namespaces: map[string]*domainrebac.Namespace{},
+		visiting:   map[string]bool{},
+		results:    map[string]bool{},
+	}
+}
+
+// evaluation is the state of one Check or Expand: namespace configs are loaded once,
+// and usersets found to contain the subject are remembered since it is fixed. Negative
+// results are not, as they may stem from a cycle cut further up.
+type evaluation struct {
+	ctx        context.Context
+	store      domainrebac.Store
+	namespaces map[string]*domainrebac.Namespace
+	visiting   map[string]bool
+	results    map[string]bool
+}
+
+func userset(object domainrebac.Object, relation string) string {
+	return object.String() + "#" + relation
+}
+
+func (ev *evaluation) namespace(name string) (*domainrebac.Namespace, error) {
+	if ns, ok := ev.namespaces[name]; ok {
+		return ns, nil
+	}
+	ns, err := ev.store.Namespace(ev.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	ev.namespaces[name] = ns
+	return ns, nil
+}
+
+// definition returns the rewrite of relation on object's namespace, or
+// ErrUnknownRelation.
+func (ev *evaluation) definition(object domainrebac.Object, relation string) (domainrebac.Rewrite, error) {
+	ns, err := ev.namespace(object.Namespace)
+	if err != nil {
+		return domainrebac.Rewrite{}, err
+	}
+	if ns != nil {
+		if rewrite, ok := ns.Relations[relation]; ok {
+			return rewrite, nil
+		}
+	}
+	return domainrebac.Rewrite{}, fmt.Errorf("%w: %s", domainrebac.ErrUnknownRelation, userset(object, relation))
+}
+
+// check evaluates object#relation for the subject. A relation the object's namespace
+// does not define, reached through a userset or tuple-to-userset, has no subjects.
+func (ev *evaluation) check(object domainrebac.Object, relation string, subject domainrebac.Subject, depth int) (bool, error) {
+	if depth > domainrebac.MaxDepth {
+		return false, fmt.Errorf("%w: %d levels checking %s", domainrebac.ErrDepthExceeded, domainrebac.MaxDepth, userset(object, relation))
+	}
+	key := userset(object, relation)
+	if ev.results[key] {
+		return true, nil
+	}
+	if ev.visiting[key] {
+		return false, nil
+	}
+	rewrite, err := ev.definition(object, relation)
+	if err != nil {
+		return false, ev.unknownIsEmpty(err)
+	}
+	ev.visiting[key] = true
+	result, err := ev.checkRewrite(object, relation, rewrite, subject, depth)
+	delete(ev.visiting, key)
+	if err != nil {
+		return false, err
+	}
+	if result {
+		ev.results[key] = true
+	}
+	return result, nil
+}
+
+func (ev *evaluation) checkRewrite(object domainrebac.Object, relation string, rewrite domainrebac.Rewrite, subject domainrebac.Subject, depth int) (bool, error) {
+	switch {
+	case rewrite.IsThis():
+		subjects, err := ev.store.Subjects(ev.ctx, object, relation)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range subjects {
+			if s == subject {
+				return true, nil
+			}
+		}
+		for _, s := range subjects {
+			if s.Relation == "" {
+				continue
+			}
+			if ok, err := ev.check(s.Object, s.Relation, subject, depth+1); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case rewrite.ComputedUserset != "":
+		return ev.check(object, rewrite.ComputedUserset, subject, depth+1)
+	case rewrite.TupleToUserset != nil:
+		related, err := ev.store.Subjects(ev.ctx, object, rewrite.TupleToUserset.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range related {
+			if ok, err := ev.check(s.Object, rewrite.TupleToUserset.ComputedUserset, subject, depth+1); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	for _, child := range rewrite.Union {
+		if ok, err := ev.checkRewrite(object, relation, child, subject, depth); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (ev *evaluation) unknownIsEmpty(err error) error {
+	if errors.Is(err, domainrebac.ErrUnknownRelation) {
+		return nil
+	}
+	return err
+}
+
+// expandRelation expands object#relation reached from another node, or returns false
+// when the relation is undefined or already being expanded higher up the tree.
+func (ev *evaluation) expandRelation(object domainrebac.Object, relation string, depth int) (domainrebac.ExpandNode, bool, error) {
+	if depth > domainrebac.MaxDepth {
+		return domainrebac.ExpandNode{}, false, fmt.Errorf("%w: %d levels expanding %s", domainrebac.ErrDepthExceeded, domainrebac.MaxDepth, userset(object, relation))
+	}
+	key := userset(object, relation)
+	if ev.visiting[key] {
+		return domainrebac.ExpandNode{}, false, nil
+	}
+	rewrite, err := ev.definition(object, relation)
+	if err != nil {
+		return domainrebac.ExpandNode{}, false, ev.unknownIsEmpty(err)
+	}
+	ev.visiting[key] = true
+	node, err := ev.expand(object, relation, rewrite, depth)
+	delete(ev.visiting, key)
+	return node, err == nil, err
+}
+
+func (ev *evaluation) expand(object domainrebac.Object, relation string, rewrite domainrebac.Rewrite, depth int) (domainrebac.ExpandNode, error) {
+	switch {
+	case rewrite.IsThis():
+		subjects, err := ev.store.Subjects(ev.ctx, object, relation)
+		if err != nil {
+			return domainrebac.ExpandNode{}, err
+		}
+		node := domainrebac.ExpandNode{Kind: "this", Userset: userset(object, relation), Subjects: make([]string, 0, len(subjects))}
+		for _, s := range subjects {
+			node.Subjects = append(node.Subjects, s.String())
+		}
+		return node, nil
+	case rewrite.ComputedUserset != "":
+		node := domainrebac.ExpandNode{Kind: "computed_userset", Userset: userset(object, rewrite.ComputedUserset)}
+		child, ok, err := ev.expandRelation(object, rewrite.ComputedUserset, depth+1)
+		if ok {
+			node.Children = append(node.Children, child)
+		}
+		return node, err
+	case rewrite.TupleToUserset != nil:
+		ttu := rewrite.TupleToUserset
+		node := domainrebac.ExpandNode{Kind: "tuple_to_userset", Userset: userset(object, ttu.Tupleset)}
+		related, err := ev.store.Subjects(ev.ctx, object, ttu.Tupleset)
+		if err != nil {
+			return domainrebac.ExpandNode{}, err
+		}
+		for _, s := range related {
+			child, ok, err := ev.expandRelation(s.Object, ttu.ComputedUserset, depth+1)
+			if err != nil {
+				return domainrebac.ExpandNode{}, err
+			}
+			if ok {
+				node.Children = append(node.Children, child)
+			}
+		}
+		return node, nil
+	}
+	node := domainrebac.ExpandNode{Kind: "union", Userset: userset(object, relation)}
+	for _, child := range rewrite.Union {
+		expanded, err := ev.expand(object, relation, child, depth)
+		if err != nil {
+			return domainrebac.ExpandNode{}, err
+		}
+		node.Children = append(node.Children, expanded)
+	}
+	return node, nil
+}
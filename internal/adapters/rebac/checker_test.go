@@ -0,0 +1,176 @@
+package rebac
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
+)
+
+type stubStore struct {
+	namespaces map[string]*domainrebac.Namespace
+	tuples     []domainrebac.Tuple
+}
+
+func (s stubStore) Namespace(_ context.Context, name string) (*domainrebac.Namespace, error) {
+	return s.namespaces[name], nil
+}
+
+func (s stubStore) Subjects(_ context.Context, object domainrebac.Object, relation string) ([]domainrebac.Subject, error) {
+	var subjects []domainrebac.Subject
+	for _, t := range s.tuples {
+		if t.Object == object && t.Relation == relation {
+			subjects = append(subjects, t.Subject)
+		}
+	}
+	return subjects, nil
+}
+
+func tuples(t *testing.T, specs ...string) []domainrebac.Tuple {
+	t.Helper()
+	out := make([]domainrebac.Tuple, 0, len(specs))
+	for _, spec := range specs {
+		tuple, err := domainrebac.ParseTuple(spec)
+		if err != nil {
+			t.Fatalf("ParseTuple(%q): %v", spec, err)
+		}
+		out = append(out, tuple)
+	}
+	return out
+}
+
+// documentStore configures documents whose viewers include their editors, their
+// owner's groups and the viewers of their parent folder.
+func documentStore(t *testing.T, specs ...string) stubStore {
+	return stubStore{
+		namespaces: map[string]*domainrebac.Namespace{
+			"group": {Name: "group", Relations: map[string]domainrebac.Rewrite{"member": {}}},
+			"folder": {Name: "folder", Relations: map[string]domainrebac.Rewrite{
+				"parent": {},
+				"viewer": {Union: []domainrebac.Rewrite{
+					{This: true},
+					{TupleToUserset: &domainrebac.TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}},
+				}},
+			}},
+			"doc": {Name: "doc", Relations: map[string]domainrebac.Rewrite{
+				"parent": {},
+				"editor": {},
+				"viewer": {Union: []domainrebac.Rewrite{
+					{This: true},
+					{ComputedUserset: "editor"},
+					{TupleToUserset: &domainrebac.TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}},
+				}},
+			}},
+		},
+		tuples: tuples(t, specs...),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	store := documentStore(t,
+		"doc:readme#editor@user:alice",
+		"doc:readme#viewer@group:eng#member",
+		"doc:readme#parent@folder:docs",
+		"folder:docs#parent@folder:root",
+		"folder:root#viewer@user:carol",
+		"group:eng#member@user:bob",
+		"group:eng#member@group:leads#member",
+		"group:leads#member@user:dave",
+	)
+	tests := []struct {
+		subject string
+		want    bool
+	}{
+		{"user:alice", true},
+		{"user:bob", true},
+		{"user:carol", true},
+		{"user:dave", true},
+		{"user:eve", false},
+		{"group:eng#member", true},
+		{"group:leads#member", true},
+		{"group:ops#member", false},
+	}
+	checker := NewChecker(store)
+	object := domainrebac.Object{Namespace: "doc", ID: "readme"}
+	for _, tt := range tests {
+		subject, err := domainrebac.ParseSubject(tt.subject)
+		if err != nil {
+			t.Fatalf("ParseSubject(%q): %v", tt.subject, err)
+		}
+		got, err := checker.Check(context.Background(), object, "viewer", subject)
+		if err != nil {
+			t.Fatalf("Check(%s): %v", tt.subject, err)
+		}
+		if got != tt.want {
+			t.Errorf("Check(doc:readme#viewer@%s) = %v, want %v", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCycles(t *testing.T) {
+	store := documentStore(t,
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+		"group:b#member@user:bob",
+		"folder:x#parent@folder:y",
+		"folder:y#parent@folder:x",
+	)
+	checker := NewChecker(store)
+	bob := domainrebac.Subject{Object: domainrebac.Object{Namespace: "user", ID: "bob"}}
+	if ok, err := checker.Check(context.Background(), domainrebac.Object{Namespace: "group", ID: "a"}, "member", bob); err != nil || !ok {
+		t.Errorf("Check(group:a#member@user:bob) = %v, %v, want true", ok, err)
+	}
+	if ok, err := checker.Check(context.Background(), domainrebac.Object{Namespace: "folder", ID: "x"}, "viewer", bob); err != nil || ok {
+		t.Errorf("Check(folder:x#viewer@user:bob) = %v, %v, want false", ok, err)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	bob := domainrebac.Subject{Object: domainrebac.Object{Namespace: "user", ID: "bob"}}
+	checker := NewChecker(documentStore(t))
+	if _, err := checker.Check(context.Background(), domainrebac.Object{Namespace: "doc", ID: "x"}, "owner", bob); !errors.Is(err, domainrebac.ErrUnknownRelation) {
+		t.Errorf("Check of an undefined relation error = %v, want ErrUnknownRelation", err)
+	}
+
+	var specs []string
+	for i := 0; i <= domainrebac.MaxDepth+1; i++ {
+		specs = append(specs, "group:g"+strconv.Itoa(i)+"#member@group:g"+strconv.Itoa(i+1)+"#member")
+	}
+	checker = NewChecker(documentStore(t, specs...))
+	if _, err := checker.Check(context.Background(), domainrebac.Object{Namespace: "group", ID: "g0"}, "member", bob); !errors.Is(err, domainrebac.ErrDepthExceeded) {
+		t.Errorf("Check of a deep chain error = %v, want ErrDepthExceeded", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	store := documentStore(t,
+		"doc:readme#editor@user:alice",
+		"doc:readme#viewer@group:eng#member",
+		"doc:readme#parent@folder:docs",
+		"folder:docs#viewer@user:carol",
+	)
+	tree, err := NewChecker(store).Expand(context.Background(), domainrebac.Object{Namespace: "doc", ID: "readme"}, "viewer")
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if tree.Kind != "union" || len(tree.Children) != 3 {
+		t.Fatalf("Expand root = %+v, want a union of three rewrites", tree)
+	}
+	if got := tree.Children[0].Subjects; len(got) != 1 || got[0] != "group:eng#member" {
+		t.Errorf("direct viewers = %v, want [group:eng#member]", got)
+	}
+	editors := tree.Children[1]
+	if editors.Kind != "computed_userset" || len(editors.Children) != 1 || editors.Children[0].Subjects[0] != "user:alice" {
+		t.Errorf("editors = %+v, want user:alice", editors)
+	}
+	parent := tree.Children[2]
+	if parent.Kind != "tuple_to_userset" || len(parent.Children) != 1 {
+		t.Fatalf("parent = %+v, want one folder", parent)
+	}
+	folder := parent.Children[0]
+	if folder.Userset != "folder:docs#viewer" || folder.Children[0].Subjects[0] != "user:carol" {
+		t.Errorf("folder viewers = %+v, want user:carol", folder)
+	}
+}
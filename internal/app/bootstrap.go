@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -10,14 +11,23 @@ import (
 	httpadapter "github.com/example/ms-rbac-service/internal/adapters/http"
 	"github.com/example/ms-rbac-service/internal/adapters/http/handlers"
 	natsadapter "github.com/example/ms-rbac-service/internal/adapters/nats"
+	pdpadapter "github.com/example/ms-rbac-service/internal/adapters/pdp"
 	"github.com/example/ms-rbac-service/internal/adapters/postgres"
 	"github.com/example/ms-rbac-service/internal/config"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/example/ms-rbac-service/internal/domain/policy"
 	"github.com/example/ms-rbac-service/internal/usecase"
 	"github.com/jackc/pgx/v5/pgxpool"
 	natsgo "github.com/nats-io/nats.go"
 )
 
-var dbPool *pgxpool.Pool
+var (
+	dbPool         *pgxpool.Pool
+	decisionLogger *pdpadapter.DecisionLogger
+	closers        []io.Closer
+	stopSweeper    context.CancelFunc
+)
 
 // Bootstrap wires dependencies and returns an HTTP server instance.
 func Bootstrap() (*http.Server, error) {
@@ -35,40 +45,112 @@ func Bootstrap() (*http.Server, error) {
 		return nil, err
 	}
 	dbPool = pool
+	if cfg.MigrateOnBoot {
+		if err := migrateOnBoot(pool); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+	var natsConn *natsgo.Conn
+	if cfg.NATSURL != "" {
+		natsConn, err = connectNATSWithRetry(cfg.NATSURL)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
 	serviceRepo := repo.NewServiceRepository(pool)
 	roleRepo := repo.NewRoleRepository(pool)
 	permissionRepo := repo.NewPermissionRepository(pool)
 	principalRoleRepo := repo.NewPrincipalRoleRepository(pool)
+	principalOverrideRepo := repo.NewPrincipalOverrideRepository(pool)
 	rolePermissionRepo := repo.NewRolePermissionRepository(pool)
+	serviceRoleRepo := repo.NewServiceRoleRepository(pool)
+	servicePermissionRepo := repo.NewServicePermissionRepository(pool)
+	serviceManifestRepo := repo.NewServiceManifestRepository(pool)
+	policyRepo := repo.NewPolicyRepository(pool)
+	backupRepo := repo.NewBackupRepository(pool)
+	superadminRepo := repo.NewSuperadminRepository(pool)
+	auditRepo := repo.NewAuditRepository(pool)
+	pdpRepo := repo.NewPDPRepository(pool)
+	sodRepo := repo.NewSoDRepository(pool)
 
 	serviceUC := usecase.NewServiceUsecase(serviceRepo)
 	roleUC := usecase.NewRoleUsecase(roleRepo)
 	permissionUC := usecase.NewPermissionUsecase(permissionRepo)
 	rolePermissionUC := usecase.NewRolePermissionUsecase(rolePermissionRepo)
+	serviceRoleUC := usecase.NewServiceRoleUsecase(serviceRoleRepo)
+	servicePermissionUC := usecase.NewServicePermissionUsecase(servicePermissionRepo)
+	serviceManifestUC := usecase.NewServiceManifestUsecase(serviceManifestRepo, cfg.ManifestDefaultRoles)
 	principalRoleUC := usecase.NewPrincipalRoleUsecase(principalRoleRepo)
-	principalPermissionUC := usecase.NewPrincipalPermissionUsecase(principalRoleRepo, rolePermissionRepo)
+	principalOverrideUC := usecase.NewPrincipalOverrideUsecase(principalOverrideRepo)
+	principalPermissionUC := usecase.NewPrincipalPermissionUsecase(principalRoleRepo, rolePermissionRepo, pdpRepo)
+	policyUC := usecase.NewPolicyUsecase(policyRepo)
+	backupUC := usecase.NewBackupUsecase(backupRepo)
+	superadminUC := usecase.NewSuperadminUsecase(superadminRepo)
+	auditUC := usecase.NewAuditUsecase(auditRepo)
+	pdpEngine := pdpadapter.NewEngine(pdpRepo).WithSoDRules(sodRepo)
+	if cfg.PDP.Source == "snapshot" {
+		snapshot, err := startPDPSnapshot(pool, cfg.PDP)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pdpEngine = pdpadapter.NewEngine(snapshot).WithSoDRules(snapshot)
+	}
+	elevationUC := usecase.NewElevationUsecase(repo.NewElevationRepository(pool), pdpEngine, cfg.Elevation.MaxDuration, cfg.Elevation.PendingTTL)
+	var breakGlassPublisher usecase.BreakGlassPublisher
+	if natsConn != nil {
+		breakGlassPublisher = natsadapter.BreakGlassPublisher{Conn: natsConn, Subject: cfg.BreakGlass.Subject}
+	}
+	serviceAccountUC := usecase.NewServiceAccountUsecase(repo.NewServiceAccountRepository(pool))
+	rebacUC := usecase.NewRebacUsecase(repo.NewRebacRepository(pool))
+	breakGlassUC := usecase.NewBreakGlassUsecase(repo.NewBreakGlassRepository(pool), breakGlassPublisher, cfg.BreakGlass.MaxDuration)
+
+	if cfg.PolicyFile != "" {
+		if err := applyPolicyFile(policyUC, cfg.PolicyFile, cfg.PolicyPrune); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
 
 	adminHandlers := &handlers.AdminHandlers{
-		Service:        &handlers.ServiceHandler{Usecase: serviceUC},
-		Role:           &handlers.RoleHandler{Usecase: roleUC},
-		Permission:     &handlers.PermissionHandler{Usecase: permissionUC},
-		RolePermission: &handlers.RolePermissionHandler{Usecase: rolePermissionUC},
+		Service:           &handlers.ServiceHandler{Usecase: serviceUC},
+		Role:              &handlers.RoleHandler{Usecase: roleUC},
+		Permission:        &handlers.PermissionHandler{Usecase: permissionUC},
+		RolePermission:    &handlers.RolePermissionHandler{Usecase: rolePermissionUC},
+		ServiceRole:       &handlers.ServiceRoleHandler{Usecase: serviceRoleUC},
+		ServicePermission: &handlers.ServicePermissionHandler{Usecase: servicePermissionUC},
+		RoleAssignment:    &handlers.RoleAssignmentHandler{Usecase: principalRoleUC},
+		Override:          &handlers.OverrideHandler{Usecase: principalOverrideUC},
+		Superadmin:        &handlers.SuperadminHandler{Usecase: superadminUC},
+		Policy:            &handlers.PolicyHandler{Usecase: policyUC},
+		Backup:            &handlers.BackupHandler{Usecase: backupUC},
+		Audit:             &handlers.AuditHandler{Usecase: auditUC},
+		Elevation:         &handlers.ElevationHandler{Usecase: elevationUC},
+		BreakGlass:        &handlers.BreakGlassHandler{Usecase: breakGlassUC},
+		ServiceAccount:    &handlers.ServiceAccountHandler{Usecase: serviceAccountUC},
+		Group:             &handlers.GroupHandler{Usecase: usecase.NewGroupUsecase(repo.NewGroupRepository(pool))},
+		SoD:               &handlers.SoDHandler{Usecase: usecase.NewSoDUsecase(sodRepo)},
+		RoleCardinality:   &handlers.RoleCardinalityHandler{Usecase: usecase.NewRoleCardinalityUsecase(repo.NewRoleCardinalityRepository(pool))},
+		Access:            &handlers.AccessHandler{Usecase: usecase.NewAccessUsecase(pdpRepo)},
+		Resource:          &handlers.ResourceHandler{Usecase: usecase.NewResourceUsecase(repo.NewResourceRepository(pool))},
+		Rebac:             &handlers.RebacHandler{Usecase: rebacUC},
 	}
 	apiHandlers := &handlers.APIHandlers{
 		PrincipalRole:       &handlers.PrincipalRoleHandler{Usecase: principalRoleUC},
 		PrincipalPermission: &handlers.PrincipalPermissionHandler{Usecase: principalPermissionUC},
+		Check:               &handlers.CheckHandler{Engine: pdpEngine},
+		ServiceManifest:     &handlers.ServiceManifestHandler{Usecase: serviceManifestUC},
+		BreakGlass:          &handlers.BreakGlassActivationHandler{Usecase: breakGlassUC},
+		Rebac:               &handlers.RebacCheckHandler{Usecase: rebacUC},
 	}
-	router := httpadapter.NewRouter(adminHandlers, apiHandlers)
-
-	if cfg.NATSURL != "" {
-		conn, err := connectNATSWithRetry(cfg.NATSURL)
-		if err != nil {
-			pool.Close()
-			return nil, err
-		}
+	router := httpadapter.NewRouter(adminHandlers, apiHandlers).WithServiceAccountAuth(serviceAccountUC, cfg.APIAuthRequired)
 
+	if natsConn != nil {
 		assigner := natsadapter.RoleAssigner{
-			Conn:        conn,
+			Conn:        natsConn,
 			Subject:     "rbac.assign-role",
 			Queue:       "ms-go-rbac",
 			PrincipalUC: principalRoleUC,
@@ -78,7 +160,7 @@ func Bootstrap() (*http.Server, error) {
 		}
 
 		checker := natsadapter.RoleChecker{
-			Conn:        conn,
+			Conn:        natsConn,
 			Subject:     "rbac.checkRole",
 			Queue:       "ms-go-rbac",
 			PrincipalUC: principalRoleUC,
@@ -86,7 +168,52 @@ func Bootstrap() (*http.Server, error) {
 		if err := checker.Listen(); err != nil {
 			log.Printf("nats subscribe failed (rbac.checkRole): %v", err)
 		}
+
+		registrar := natsadapter.ServiceRegistrar{
+			Conn:       natsConn,
+			Subject:    "rbac.register-service",
+			Queue:      "ms-go-rbac",
+			ManifestUC: serviceManifestUC,
+			Accounts:   serviceAccountUC,
+		}
+		if err := registrar.Listen(); err != nil {
+			log.Printf("nats subscribe failed (rbac.register-service): %v", err)
+		}
+	}
+
+	if cfg.DecisionLog.Sink != "" {
+		sink, err := decisionSink(cfg.DecisionLog, pool, natsConn)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		decisionLogger = pdpadapter.NewDecisionLogger(sink, pdpadapter.DecisionLoggerConfig{
+			SampleRate:    cfg.DecisionLog.SampleRate,
+			BatchSize:     cfg.DecisionLog.BatchSize,
+			FlushInterval: cfg.DecisionLog.FlushInterval,
+			BufferSize:    cfg.DecisionLog.BufferSize,
+		})
+		pdpEngine.WithDecisionLogger(decisionLogger)
+	}
+
+	if cfg.GrantSweep.Interval > 0 {
+		var publisher usecase.GrantExpiryPublisher
+		if natsConn != nil {
+			publisher = natsadapter.GrantExpiryPublisher{Conn: natsConn, Subject: cfg.GrantSweep.Subject}
+		}
+		grantExpiryUC := usecase.NewGrantExpiryUsecase(repo.NewGrantExpiryRepository(pool), publisher, cfg.GrantSweep.Archive)
+		var ctx context.Context
+		ctx, stopSweeper = context.WithCancel(context.Background())
+		go runSweeper(ctx, cfg.GrantSweep.Interval, []sweepTask{
+			{name: "grant expiry", run: func(ctx context.Context) (int, error) {
+				expired, err := grantExpiryUC.Sweep(ctx)
+				return len(expired), err
+			}},
+			{name: "elevation expiry", run: elevationUC.ExpirePending},
+			{name: "break-glass expiry", run: breakGlassUC.EndExpired},
+		})
 	}
+
 	httpServer := &http.Server{
 		Addr:    cfg.HTTPAddr,
 		Handler: router.Handler(),
@@ -98,12 +225,75 @@ func Bootstrap() (*http.Server, error) {
 func Shutdown(ctx context.Context, srv *http.Server) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		return err
+	// Release everything else even when in-flight requests did not drain in time;
+	// the shutdown error is still reported to the caller.
+	err := srv.Shutdown(ctx)
+	if stopSweeper != nil {
+		stopSweeper()
+	}
+	if decisionLogger != nil {
+		// The flush gets its own deadline: ctx may have run out waiting for requests.
+		flushCtx, cancelFlush := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		if err := decisionLogger.Close(flushCtx); err != nil {
+			log.Printf("decision log flush: %v", err)
+		}
+		cancelFlush()
+	}
+	for _, c := range closers {
+		_ = c.Close()
 	}
 	if dbPool != nil {
 		dbPool.Close()
 	}
+	return err
+}
+
+func decisionSink(cfg config.DecisionLogConfig, pool *pgxpool.Pool, conn *natsgo.Conn) (domainpdp.DecisionSink, error) {
+	switch cfg.Sink {
+	case "postgres":
+		return repo.NewDecisionLogRepository(pool), nil
+	case "file":
+		sink, err := pdpadapter.NewFileDecisionSink(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("open decision log file %s: %w", cfg.File, err)
+		}
+		closers = append(closers, sink)
+		return sink, nil
+	case "nats":
+		if conn == nil {
+			return nil, fmt.Errorf("DECISION_LOG_SINK=nats requires NATS_URL")
+		}
+		return natsadapter.DecisionPublisher{Conn: conn, Subject: cfg.Subject}, nil
+	}
+	return nil, fmt.Errorf("unknown decision log sink %q", cfg.Sink)
+}
+
+// startPDPSnapshot loads the in-memory policy snapshot and follows the change feed
+// until Shutdown.
+func startPDPSnapshot(pool *pgxpool.Pool, cfg config.PDPConfig) (*pdpadapter.Snapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	snapshot := pdpadapter.NewSnapshot(repo.NewPDPSnapshotRepository(pool), cfg.ResyncInterval)
+	if err := snapshot.Start(ctx); err != nil {
+		return nil, fmt.Errorf("load pdp snapshot: %w", err)
+	}
+	closers = append(closers, snapshot)
+	return snapshot, nil
+}
+
+func applyPolicyFile(uc *usecase.PolicyUsecase, path string, prune bool) error {
+	doc, err := policy.LoadFile(path)
+	if err != nil {
+		return fmt.Errorf("load policy file %s: %w", path, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = audit.WithActor(ctx, audit.Actor{ID: "policy-file:" + path, Source: audit.SourceSystem})
+	plan, err := uc.Apply(ctx, doc, policy.Options{Prune: prune})
+	if err != nil {
+		return fmt.Errorf("apply policy file %s: %w", path, err)
+	}
+	log.Printf("policy file %s applied: %d changes", path, len(plan.Changes))
 	return nil
 }
 
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres/migrate"
+	"github.com/example/ms-rbac-service/internal/config"
+	"github.com/example/ms-rbac-service/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrate runs the "migrate" subcommand: up, down [steps], status or baseline <version>.
+func Migrate(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | status | baseline <version>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.DBDSN == "" {
+		return fmt.Errorf("DB_DSN is required")
+	}
+	pool, err := connectPostgresWithRetry(cfg.DBDSN)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %03d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, st := range statuses {
+			state, appliedAt := "pending", "-"
+			if st.Applied {
+				state = "applied"
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05Z07:00")
+			}
+			if st.Modified {
+				state = "modified"
+			}
+			fmt.Fprintf(tw, "%03d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+		}
+		return tw.Flush()
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: migrate baseline <version>")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		recorded, err := migrator.Baseline(ctx, version)
+		for _, m := range recorded {
+			fmt.Fprintf(out, "marked %03d_%s as applied\n", m.Version, m.Name)
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func migrateOnBoot(pool *pgxpool.Pool) error {
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		log.Printf("migration %03d_%s applied", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate on boot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+)
+
+// sweepTask is one periodic clean-up step; run reports how many rows it handled.
+type sweepTask struct {
+	name string
+	run  func(ctx context.Context) (int, error)
+}
+
+// runSweeper runs every task once per interval until ctx is cancelled: expired grants
+// are removed, undecided elevation requests expire and lapsed break-glass activations
+// end. Concurrent replicas are safe: rows are deleted or locked, so each change is
+// reported by exactly one sweep.
+func runSweeper(ctx context.Context, interval time.Duration, tasks []sweepTask) {
+	ctx = audit.WithActor(ctx, audit.Actor{ID: "grant-sweeper", Source: audit.SourceSystem})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, task := range tasks {
+			sweepCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			n, err := task.run(sweepCtx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				log.Printf("%s sweep failed: %v", task.name, err)
+			} else if n > 0 {
+				log.Printf("%s sweep handled %d rows", task.name, n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
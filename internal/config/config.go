@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,65 @@ type Config struct {
 	AuthModeratorIss string
 	AuthModeratorAud string
 	CacheTTL         time.Duration
+	PolicyFile       string
+	PolicyPrune      bool
+	MigrateOnBoot    bool
+	APIAuthRequired  bool
+	// ManifestDefaultRoles lists the roles service manifests may grant by default.
+	ManifestDefaultRoles []string
+	DecisionLog          DecisionLogConfig
+	GrantSweep           GrantSweepConfig
+	Elevation            ElevationConfig
+	BreakGlass           BreakGlassConfig
+	PDP                  PDPConfig
+}
+
+// PDPConfig selects where the PDP engine reads policy from.
+type PDPConfig struct {
+	// Source is "postgres", querying the database per check, or "snapshot", serving
+	// checks from an in-memory copy kept current by the pdp_snapshot change feed.
+	Source string
+	// ResyncInterval reloads the whole snapshot after that long without changes; zero
+	// disables it.
+	ResyncInterval time.Duration
+}
+
+// BreakGlassConfig bounds emergency superadmin activations.
+type BreakGlassConfig struct {
+	// MaxDuration caps how long any registration may keep break-glass access active.
+	MaxDuration time.Duration
+	// Subject is the NATS subject prefix for break-glass events; the event type is appended.
+	Subject string
+}
+
+// ElevationConfig bounds just-in-time elevation requests.
+type ElevationConfig struct {
+	// MaxDuration caps how long an approved request grants its role.
+	MaxDuration time.Duration
+	// PendingTTL is how long a request waits for a decision before it expires.
+	PendingTTL time.Duration
+}
+
+// GrantSweepConfig controls removal of expired role assignments and overrides.
+type GrantSweepConfig struct {
+	// Interval between sweeps; zero disables the sweeper.
+	Interval time.Duration
+	// Archive copies expired grants to the archive tables instead of discarding them.
+	Archive bool
+	// Subject receives an event per expired grant when NATS is configured.
+	Subject string
+}
+
+// DecisionLogConfig selects where PDP decisions are recorded and how they are sampled.
+type DecisionLogConfig struct {
+	// Sink is one of "postgres", "file" or "nats"; empty disables the decision log.
+	Sink          string
+	File          string
+	Subject       string
+	SampleRate    float64
+	BatchSize     int
+	FlushInterval time.Duration
+	BufferSize    int
 }
 
 // Load reads configuration from environment variables applying defaults where necessary.
@@ -27,12 +87,77 @@ func Load() (Config, error) {
 		NATSURL:          getEnv("NATS_URL", "nats://nats:4222"),
 		AuthModeratorIss: os.Getenv("AUTH_MODERATOR_JWT_ISS"),
 		AuthModeratorAud: os.Getenv("AUTH_MODERATOR_JWT_AUD"),
+		PolicyFile:       os.Getenv("POLICY_FILE"),
+		PolicyPrune:      getEnv("POLICY_PRUNE", "false") == "true",
+		MigrateOnBoot:    getEnv("MIGRATE_ON_BOOT", "false") == "true",
+		APIAuthRequired:  getEnv("API_AUTH_REQUIRED", "false") == "true",
+	}
+	for _, role := range strings.Split(os.Getenv("MANIFEST_DEFAULT_ROLES"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			cfg.ManifestDefaultRoles = append(cfg.ManifestDefaultRoles, role)
+		}
 	}
 	ttl, err := parseDurationSeconds(getEnv("CACHE_TTL_SECONDS", "60"))
 	if err != nil {
 		return Config{}, fmt.Errorf("invalid CACHE_TTL_SECONDS: %w", err)
 	}
 	cfg.CacheTTL = ttl
+
+	cfg.DecisionLog = DecisionLogConfig{
+		Sink:    os.Getenv("DECISION_LOG_SINK"),
+		File:    getEnv("DECISION_LOG_FILE", "decisions.ndjson"),
+		Subject: getEnv("DECISION_LOG_SUBJECT", "rbac.decisions"),
+	}
+	switch cfg.DecisionLog.Sink {
+	case "", "postgres", "file", "nats":
+	default:
+		return Config{}, fmt.Errorf("invalid DECISION_LOG_SINK %q: expected postgres, file or nats", cfg.DecisionLog.Sink)
+	}
+	if cfg.DecisionLog.SampleRate, err = strconv.ParseFloat(getEnv("DECISION_LOG_SAMPLE_RATE", "1"), 64); err != nil {
+		return Config{}, fmt.Errorf("invalid DECISION_LOG_SAMPLE_RATE: %w", err)
+	}
+	if cfg.DecisionLog.BatchSize, err = strconv.Atoi(getEnv("DECISION_LOG_BATCH_SIZE", "100")); err != nil {
+		return Config{}, fmt.Errorf("invalid DECISION_LOG_BATCH_SIZE: %w", err)
+	}
+	if cfg.DecisionLog.BufferSize, err = strconv.Atoi(getEnv("DECISION_LOG_BUFFER_SIZE", "10000")); err != nil {
+		return Config{}, fmt.Errorf("invalid DECISION_LOG_BUFFER_SIZE: %w", err)
+	}
+	flushMS, err := strconv.Atoi(getEnv("DECISION_LOG_FLUSH_MS", "1000"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid DECISION_LOG_FLUSH_MS: %w", err)
+	}
+	cfg.DecisionLog.FlushInterval = time.Duration(flushMS) * time.Millisecond
+
+	cfg.GrantSweep.Subject = getEnv("GRANT_EXPIRED_SUBJECT", "rbac.grant-expired")
+	cfg.BreakGlass.Subject = getEnv("BREAK_GLASS_SUBJECT", "rbac.break-glass")
+	if cfg.GrantSweep.Interval, err = parseDurationSeconds(getEnv("GRANT_SWEEP_INTERVAL_SECONDS", "60")); err != nil {
+		return Config{}, fmt.Errorf("invalid GRANT_SWEEP_INTERVAL_SECONDS: %w", err)
+	}
+	if cfg.Elevation.MaxDuration, err = parseDurationSeconds(getEnv("ELEVATION_MAX_DURATION_SECONDS", "28800")); err != nil {
+		return Config{}, fmt.Errorf("invalid ELEVATION_MAX_DURATION_SECONDS: %w", err)
+	}
+	if cfg.Elevation.PendingTTL, err = parseDurationSeconds(getEnv("ELEVATION_PENDING_TTL_SECONDS", "86400")); err != nil {
+		return Config{}, fmt.Errorf("invalid ELEVATION_PENDING_TTL_SECONDS: %w", err)
+	}
+	if cfg.BreakGlass.MaxDuration, err = parseDurationSeconds(getEnv("BREAK_GLASS_MAX_DURATION_SECONDS", "14400")); err != nil {
+		return Config{}, fmt.Errorf("invalid BREAK_GLASS_MAX_DURATION_SECONDS: %w", err)
+	}
+	switch mode := getEnv("GRANT_SWEEP_MODE", "archive"); mode {
+	case "archive":
+		cfg.GrantSweep.Archive = true
+	case "delete":
+	default:
+		return Config{}, fmt.Errorf("invalid GRANT_SWEEP_MODE %q: expected archive or delete", mode)
+	}
+	cfg.PDP.Source = getEnv("PDP_SOURCE", "postgres")
+	switch cfg.PDP.Source {
+	case "postgres", "snapshot":
+	default:
+		return Config{}, fmt.Errorf("invalid PDP_SOURCE %q: expected postgres or snapshot", cfg.PDP.Source)
+	}
+	if cfg.PDP.ResyncInterval, err = parseDurationSeconds(getEnv("PDP_SNAPSHOT_RESYNC_SECONDS", "300")); err != nil {
+		return Config{}, fmt.Errorf("invalid PDP_SNAPSHOT_RESYNC_SECONDS: %w", err)
+	}
 	return cfg, nil
 }
 
@@ -0,0 +1,66 @@
+// Package audit describes who changed RBAC state and carries that identity through context.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	SourceHTTP   = "http"
+	SourceNATS   = "nats"
+	SourceSystem = "system"
+)
+
+// Actor identifies the caller responsible for a change.
+type Actor struct {
+	ID            string
+	Source        string
+	CorrelationID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx; changes made without one are
+// attributed to the system.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		if actor.Source == "" {
+			actor.Source = SourceSystem
+		}
+		return actor
+	}
+	return Actor{Source: SourceSystem}
+}
+
+// Entry is one row of the audit log.
+type Entry struct {
+	ID            int64           `json:"id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	ActorID       string          `json:"actor_id"`
+	Source        string          `json:"source"`
+	CorrelationID string          `json:"correlation_id"`
+	Action        string          `json:"action"`
+	Entity        string          `json:"entity"`
+	EntityID      string          `json:"entity_id"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+}
+
+// Filter narrows audit log queries; zero values match everything.
+type Filter struct {
+	ActorID       string
+	Source        string
+	CorrelationID string
+	Action        string
+	Entity        string
+	EntityID      string
+	From          *time.Time
+	To            *time.Time
+}
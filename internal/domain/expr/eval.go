@@ -0,0 +1,237 @@
+package expr
+
+import "fmt"
+
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literal struct {
+	value any
+}
+
+func (n literal) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type path struct {
+	parts []string
+}
+
+// eval looks the path up in vars; a missing attribute, or one below a value that is not
+// an object, is null.
+func (n path) eval(vars map[string]any) (any, error) {
+	var current any = vars
+	for _, part := range n.parts {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		current = object[part]
+	}
+	return normalize(current), nil
+}
+
+type list struct {
+	items []node
+}
+
+func (n list) eval(vars map[string]any) (any, error) {
+	values := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type not struct {
+	operand node
+}
+
+func (n not) eval(vars map[string]any) (any, error) {
+	v, err := evalBool(n.operand, vars, "!")
+	if err != nil {
+		return nil, err
+	}
+	return !v, nil
+}
+
+// logical is && or ||; the right operand is only evaluated when it decides the result.
+type logical struct {
+	or          bool
+	left, right node
+}
+
+func (n logical) eval(vars map[string]any) (any, error) {
+	op := "&&"
+	if n.or {
+		op = "||"
+	}
+	left, err := evalBool(n.left, vars, op)
+	if err != nil {
+		return nil, err
+	}
+	if left == n.or {
+		return left, nil
+	}
+	return evalBool(n.right, vars, op)
+}
+
+func evalBool(n node, vars map[string]any, op string) (bool, error) {
+	v, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s needs boolean operands, found %s", op, describe(v))
+	}
+	return b, nil
+}
+
+type comparison struct {
+	op          string
+	left, right node
+}
+
+func (n comparison) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+	cmp, err := order(left, right)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.op, err)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+type membership struct {
+	item, list node
+}
+
+// eval reports whether the item equals an element of the list; a null list has none.
+func (n membership) eval(vars map[string]any) (any, error) {
+	item, err := n.item.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.list.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return false, nil
+	}
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("in needs a list on its right, found %s", describe(v))
+	}
+	for _, value := range values {
+		if equal(item, value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case nil, bool, float64, string:
+		return a == b
+	}
+	return false
+}
+
+func order(a, b any) (int, error) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot order %s against %s", describe(a), describe(b))
+}
+
+// normalize converts attribute values supplied by Go callers to the types the language
+// works with: float64 numbers and []any lists.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		values := make([]any, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		return values
+	case []any:
+		values := make([]any, len(v))
+		for i, item := range v {
+			values[i] = normalize(item)
+		}
+		return values
+	}
+	return v
+}
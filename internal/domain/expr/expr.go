@@ -0,0 +1,126 @@
+// Package expr implements the condition language of grants and overrides: a small,
+// side-effect-free expression language evaluated against request attributes.
+//
+// An expression combines attribute paths (resource.owner), literals (strings in single
+// or double quotes, numbers, true, false, null and lists such as ["a", "b"]) with the
+// operators ==, !=, <, <=, >, >=, in, !, && and ||, grouped by parentheses:
+//
+//	resource.owner == principal.id && env.hour >= 9 && env.hour < 17
+//
+// A missing attribute is null. Ordering compares two numbers or two strings; anything
+// else is an evaluation error, as is a non-boolean result.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxLength bounds the source length of an expression.
+	MaxLength = 2048
+	// maxNesting bounds how deeply operators and parentheses may nest.
+	maxNesting = 32
+)
+
+// ErrSyntax is wrapped by every error Compile returns.
+var ErrSyntax = errors.New("invalid expression")
+
+// Program is a compiled expression. It is immutable and safe for concurrent use.
+type Program struct {
+	src  string
+	root node
+}
+
+// Compile parses and checks src. When roots is not empty every attribute path must
+// start with one of them. Compile rejects expressions that can never evaluate to a
+// boolean, such as 1 + 1 or "a" < 3.
+func Compile(src string, roots ...string) (*Program, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrSyntax, MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, roots: roots}
+	root, typ, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, syntaxError(tok.pos, "unexpected %s", tok)
+	}
+	if typ != typeAny && typ != typeBool {
+		return nil, fmt.Errorf("%w: expression evaluates to a %s, not a boolean", ErrSyntax, typ)
+	}
+	return &Program{src: src, root: root}, nil
+}
+
+// String returns the source of the program.
+func (p *Program) String() string {
+	return p.src
+}
+
+// Eval evaluates the program against vars, keyed by root name. Nested values are
+// map[string]any; numbers may be any Go integer or float type.
+func (p *Program) Eval(vars map[string]any) (bool, error) {
+	v, err := p.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %s, not a boolean", describe(v))
+	}
+	return b, nil
+}
+
+func syntaxError(pos int, format string, args ...any) error {
+	return fmt.Errorf("%w: position %d: %s", ErrSyntax, pos+1, fmt.Sprintf(format, args...))
+}
+
+// valueType is the static type of an expression; typeAny is an attribute whose type is
+// only known at evaluation.
+type valueType int
+
+const (
+	typeAny valueType = iota
+	typeBool
+	typeNumber
+	typeString
+	typeNull
+	typeList
+)
+
+func (t valueType) String() string {
+	return [...]string{"value", "boolean", "number", "string", "null", "list"}[t]
+}
+
+func typeOf(v any) valueType {
+	switch v.(type) {
+	case bool:
+		return typeBool
+	case float64:
+		return typeNumber
+	case string:
+		return typeString
+	case nil:
+		return typeNull
+	case []any:
+		return typeList
+	}
+	return typeAny
+}
+
+func describe(v any) string {
+	if t := typeOf(v); t != typeAny {
+		return "a " + t.String()
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func joinPath(parts []string) string {
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+var roots = []string{"principal", "resource", "env"}
+
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		"principal": map[string]any{"id": "u1", "level": 3},
+		"resource":  map[string]any{"owner": "u1", "tags": []string{"draft", "math"}, "meta": map[string]any{"public": true}},
+		"env":       map[string]any{"hour": 10, "weekday": "monday"},
+	}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`resource.owner == principal.id`, true},
+		{`resource.owner != principal.id`, false},
+		{`env.hour >= 9 && env.hour < 17`, true},
+		{`env.hour < 9 || env.hour >= 17`, false},
+		{`env.weekday in ["saturday", "sunday"]`, false},
+		{`"math" in resource.tags`, true},
+		{`!("archived" in resource.tags)`, true},
+		{`resource.meta.public`, true},
+		{`resource.missing == null`, true},
+		{`resource.missing.deeper == null`, true},
+		{`"x" in resource.missing`, false},
+		{`principal.level > 2.5 && principal.level <= 3`, true},
+		{`env.weekday < 'tuesday'`, true},
+		{`resource.tags == ["draft", "math"]`, true},
+		{`false && resource.missing > 1`, false},
+		{`true || resource.missing > 1`, true},
+		{`env.hour == -1`, false},
+	}
+	for _, tt := range tests {
+		program, err := Compile(tt.src, roots...)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+		got, err := program.Eval(vars)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	vars := map[string]any{"resource": map[string]any{"owner": "u1", "level": 2}}
+	for _, src := range []string{
+		`resource.missing > 1`,
+		`resource.owner > resource.level`,
+		`resource.owner && true`,
+		`resource.owner`,
+		`1 in resource.owner`,
+	} {
+		program, err := Compile(src, roots...)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", src, err)
+			continue
+		}
+		if got, err := program.Eval(vars); err == nil {
+			t.Errorf("Eval(%q) = %v, want an error", src, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`resource.owner ==`,
+		`resource.owner = principal.id`,
+		`(resource.owner == principal.id`,
+		`user.id == "u1"`,
+		`resource. == 1`,
+		`"unterminated`,
+		`"bad \q escape"`,
+		`1`,
+		`"a" < 3`,
+		`1 == "1"`,
+		`true < false`,
+		`1 && true`,
+		`!"a"`,
+		`resource.tags in "a"`,
+		`resource.a == 1 resource.b`,
+		`[1, 2`,
+		`resource.owner # 1`,
+		`in == 1`,
+	} {
+		if _, err := Compile(src, roots...); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Compile(%q) error = %v, want ErrSyntax", src, err)
+		}
+	}
+}
+
+func TestCompileLimitsNesting(t *testing.T) {
+	src := ""
+	for i := 0; i < maxNesting+1; i++ {
+		src += "("
+	}
+	src += "true"
+	for i := 0; i < maxNesting+1; i++ {
+		src += ")"
+	}
+	if _, err := Compile(src); !errors.Is(err, ErrSyntax) {
+		t.Fatalf("Compile error = %v, want ErrSyntax", err)
+	}
+}
@@ -0,0 +1,136 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+	num  float64
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (t token) is(op string) bool {
+	return t.kind == tokOp && t.text == op
+}
+
+// twoCharOps and oneCharOps are tried in this order, so <= is not read as < then =.
+var (
+	twoCharOps = []string{"&&", "||", "==", "!=", "<=", ">="}
+	oneCharOps = "!<>()[],."
+)
+
+func lex(src string) ([]token, error) {
+	tokens := make([]token, 0, 16)
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, pos: start, text: src[start:i]})
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			i++
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			num, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, syntaxError(start, "invalid number %q", src[start:i])
+			}
+			tokens = append(tokens, token{kind: tokNumber, pos: start, text: src[start:i], num: num})
+		case c == '"' || c == '\'':
+			text, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, pos: i, text: text})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range twoCharOps {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" && strings.IndexByte(oneCharOps, c) >= 0 {
+				op = string(c)
+			}
+			if op == "" {
+				return nil, syntaxError(i, "unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokOp, pos: i, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads the quoted string starting at src[start] and returns its value and
+// the offset just past the closing quote.
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\':
+			i++
+			if i == len(src) {
+				return "", 0, syntaxError(start, "unterminated string")
+			}
+			switch src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(src[i])
+			default:
+				return "", 0, syntaxError(i-1, "unknown escape \\%c", src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, syntaxError(start, "unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -0,0 +1,215 @@
+package expr
+
+import "slices"
+
+type parser struct {
+	tokens []token
+	pos    int
+	roots  []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	if tok := p.next(); !tok.is(op) {
+		return syntaxError(tok.pos, "expected %q, found %s", op, tok)
+	}
+	return nil
+}
+
+// parseExpr parses an || chain, the lowest precedence level.
+func (p *parser) parseExpr(depth int) (node, valueType, error) {
+	if depth > maxNesting {
+		return nil, 0, syntaxError(p.peek().pos, "nested deeper than %d levels", maxNesting)
+	}
+	left, lt, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, 0, err
+	}
+	for p.peek().is("||") {
+		tok := p.next()
+		right, rt, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := checkBool(tok, lt, rt); err != nil {
+			return nil, 0, err
+		}
+		left, lt = logical{or: true, left: left, right: right}, typeBool
+	}
+	return left, lt, nil
+}
+
+func (p *parser) parseAnd(depth int) (node, valueType, error) {
+	left, lt, err := p.parseNot(depth)
+	if err != nil {
+		return nil, 0, err
+	}
+	for p.peek().is("&&") {
+		tok := p.next()
+		right, rt, err := p.parseNot(depth)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := checkBool(tok, lt, rt); err != nil {
+			return nil, 0, err
+		}
+		left, lt = logical{left: left, right: right}, typeBool
+	}
+	return left, lt, nil
+}
+
+func (p *parser) parseNot(depth int) (node, valueType, error) {
+	if !p.peek().is("!") {
+		return p.parseComparison(depth)
+	}
+	tok := p.next()
+	if depth+1 > maxNesting {
+		return nil, 0, syntaxError(tok.pos, "nested deeper than %d levels", maxNesting)
+	}
+	operand, typ, err := p.parseNot(depth + 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := checkBool(tok, typ); err != nil {
+		return nil, 0, err
+	}
+	return not{operand: operand}, typeBool, nil
+}
+
+var comparisonOps = []string{"==", "!=", "<", "<=", ">", ">="}
+
+func (p *parser) parseComparison(depth int) (node, valueType, error) {
+	left, lt, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, 0, err
+	}
+	tok := p.peek()
+	isIn := tok.kind == tokIdent && tok.text == "in"
+	if !isIn && (tok.kind != tokOp || !slices.Contains(comparisonOps, tok.text)) {
+		return left, lt, nil
+	}
+	p.next()
+	right, rt, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, 0, err
+	}
+	if isIn {
+		if rt != typeAny && rt != typeList {
+			return nil, 0, syntaxError(tok.pos, "in needs a list on its right, found a %s", rt)
+		}
+		return membership{item: left, list: right}, typeBool, nil
+	}
+	if err := checkComparison(tok, lt, rt); err != nil {
+		return nil, 0, err
+	}
+	return comparison{op: tok.text, left: left, right: right}, typeBool, nil
+}
+
+func (p *parser) parseOperand(depth int) (node, valueType, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokNumber:
+		return literal{value: tok.num}, typeNumber, nil
+	case tok.kind == tokString:
+		return literal{value: tok.text}, typeString, nil
+	case tok.kind == tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return literal{value: tok.text == "true"}, typeBool, nil
+		case "null":
+			return literal{}, typeNull, nil
+		case "in":
+			return nil, 0, syntaxError(tok.pos, "unexpected %s", tok)
+		}
+		return p.parsePath(tok)
+	case tok.is("("):
+		inner, typ, err := p.parseExpr(depth + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, 0, err
+		}
+		return inner, typ, nil
+	case tok.is("["):
+		return p.parseList(depth)
+	}
+	return nil, 0, syntaxError(tok.pos, "unexpected %s", tok)
+}
+
+func (p *parser) parsePath(first token) (node, valueType, error) {
+	if len(p.roots) > 0 && !slices.Contains(p.roots, first.text) {
+		return nil, 0, syntaxError(first.pos, "unknown attribute %q, expected one of %v", first.text, p.roots)
+	}
+	parts := []string{first.text}
+	for p.peek().is(".") {
+		p.next()
+		tok := p.next()
+		if tok.kind != tokIdent {
+			return nil, 0, syntaxError(tok.pos, "expected an attribute name after %q, found %s", joinPath(parts), tok)
+		}
+		parts = append(parts, tok.text)
+	}
+	return path{parts: parts}, typeAny, nil
+}
+
+func (p *parser) parseList(depth int) (node, valueType, error) {
+	items := make([]node, 0)
+	if p.peek().is("]") {
+		p.next()
+		return list{items: items}, typeList, nil
+	}
+	for {
+		item, _, err := p.parseExpr(depth + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+		tok := p.next()
+		if tok.is("]") {
+			return list{items: items}, typeList, nil
+		}
+		if !tok.is(",") {
+			return nil, 0, syntaxError(tok.pos, "expected \",\" or \"]\", found %s", tok)
+		}
+	}
+}
+
+func checkBool(op token, types ...valueType) error {
+	for _, t := range types {
+		if t != typeAny && t != typeBool {
+			return syntaxError(op.pos, "%s needs boolean operands, found a %s", op.text, t)
+		}
+	}
+	return nil
+}
+
+func checkComparison(op token, left, right valueType) error {
+	known := left != typeAny && right != typeAny
+	if op.text == "==" || op.text == "!=" {
+		if known && left != right && left != typeNull && right != typeNull {
+			return syntaxError(op.pos, "comparing a %s with a %s is always false", left, right)
+		}
+		return nil
+	}
+	for _, t := range []valueType{left, right} {
+		if t != typeAny && t != typeNumber && t != typeString {
+			return syntaxError(op.pos, "%s compares numbers or strings, found a %s", op.text, t)
+		}
+	}
+	if known && left != right {
+		return syntaxError(op.pos, "cannot order a %s against a %s", left, right)
+	}
+	return nil
+}
@@ -50,6 +50,15 @@ const (
 	PrincipalKindGroup          PrincipalKind = "group"
 )
 
+// Valid reports whether k is one of the known principal kinds.
+func (k PrincipalKind) Valid() bool {
+	switch k {
+	case PrincipalKindUser, PrincipalKindServiceAccount, PrincipalKindGroup:
+		return true
+	}
+	return false
+}
+
 type PrincipalRole struct {
 	PrincipalID   string
 	PrincipalKind PrincipalKind
@@ -82,3 +91,23 @@ type SuperadminPrincipal struct {
 	PrincipalID   string
 	PrincipalKind PrincipalKind
 }
+
+// IsUUID reports whether s is a UUID in the canonical hyphenated form Postgres prints,
+// in either case. Ids of every entity are UUIDs.
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
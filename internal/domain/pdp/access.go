@@ -0,0 +1,34 @@
+package pdp
+
+import "github.com/example/ms-rbac-service/internal/domain/model"
+
+// Access path kinds, in the order the engine evaluates them.
+const (
+	AccessPathSuperadmin    = "superadmin"
+	AccessPathBreakGlass    = "break_glass"
+	AccessPathOverride      = "override"
+	AccessPathRole          = "role"
+	AccessPathInheritedRole = "inherited_role"
+)
+
+// AccessPath is one reason a principal is allowed a request.
+type AccessPath struct {
+	Kind string `json:"kind"`
+	// RoleKey is set for role and inherited_role paths.
+	RoleKey string `json:"role_key,omitempty"`
+	// GroupID is the group holding the role for inherited_role paths.
+	GroupID string `json:"group_id,omitempty"`
+	// PermissionID is the overridden permission for override paths.
+	PermissionID string `json:"permission_id,omitempty"`
+	// Conditional marks role paths that only allow the request through grants with a
+	// condition, which the access review cannot evaluate per principal.
+	Conditional bool `json:"conditional,omitempty"`
+}
+
+// PrincipalAccess is a principal allowed a request and every path that allows it, in
+// evaluation order: the first path is the one Check reports as the decision.
+type PrincipalAccess struct {
+	PrincipalID   string              `json:"principal_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	Paths         []AccessPath        `json:"paths"`
+}
@@ -0,0 +1,101 @@
+package pdp
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/expr"
+)
+
+// ConditionRoots are the attribute roots a condition may refer to.
+var ConditionRoots = []string{"principal", "resource", "env"}
+
+// Attributes are the request context conditions are evaluated against. Callers pass
+// whatever the policy needs, such as resource.owner; the engine adds the built-in
+// attributes listed on WithBuiltinAttributes.
+type Attributes struct {
+	Principal map[string]any `json:"principal,omitempty"`
+	Resource  map[string]any `json:"resource,omitempty"`
+	Env       map[string]any `json:"env,omitempty"`
+}
+
+// CompileCondition validates a condition before it is saved.
+func CompileCondition(condition string) (*expr.Program, error) {
+	return expr.Compile(condition, ConditionRoots...)
+}
+
+// compiled caches programs by source. Conditions are validated when saved, so the set
+// of distinct sources is bounded by the grants and overrides in the store.
+var compiled sync.Map
+
+// ConditionHolds evaluates condition against the request attributes. An empty condition
+// always holds.
+func (r CheckRequest) ConditionHolds(condition string) (bool, error) {
+	if strings.TrimSpace(condition) == "" {
+		return true, nil
+	}
+	program, ok := compiled.Load(condition)
+	if !ok {
+		p, err := CompileCondition(condition)
+		if err != nil {
+			return false, err
+		}
+		program, _ = compiled.LoadOrStore(condition, p)
+	}
+	holds, err := program.(*expr.Program).Eval(map[string]any{
+		"principal": r.Attributes.Principal,
+		"resource":  r.Attributes.Resource,
+		"env":       r.Attributes.Env,
+	})
+	if err != nil {
+		return false, fmt.Errorf("condition %q: %w", condition, err)
+	}
+	return holds, nil
+}
+
+// WithBuiltinAttributes returns r with attributes derived from the request itself,
+// which take precedence over caller supplied ones of the same name:
+//
+//   - principal.id, principal.kind
+//   - resource.kind and, when set, resource.id
+//   - env.action, env.tenant_id and env.service_id when set
+//   - env.time (RFC 3339), env.hour (0-23) and env.weekday ("monday"...) in UTC
+func (r CheckRequest) WithBuiltinAttributes(now time.Time) CheckRequest {
+	principal := maps.Clone(r.Attributes.Principal)
+	if principal == nil {
+		principal = map[string]any{}
+	}
+	principal["id"] = r.PrincipalID
+	principal["kind"] = string(r.PrincipalKind)
+
+	resource := maps.Clone(r.Attributes.Resource)
+	if resource == nil {
+		resource = map[string]any{}
+	}
+	resource["kind"] = r.ResourceKind
+	if r.ResourceID != nil {
+		resource["id"] = *r.ResourceID
+	}
+
+	env := maps.Clone(r.Attributes.Env)
+	if env == nil {
+		env = map[string]any{}
+	}
+	env["action"] = r.Action
+	if r.TenantID != nil {
+		env["tenant_id"] = *r.TenantID
+	}
+	if r.ServiceID != nil {
+		env["service_id"] = *r.ServiceID
+	}
+	now = now.UTC()
+	env["time"] = now.Format(time.RFC3339)
+	env["hour"] = now.Hour()
+	env["weekday"] = strings.ToLower(now.Weekday().String())
+
+	r.Attributes = Attributes{Principal: principal, Resource: resource, Env: env}
+	return r
+}
@@ -1,6 +1,10 @@
 package pdp
 
-import "github.com/example/ms-rbac-service/internal/domain/model"
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+)
 
 // CheckRequest represents a PDP input payload.
 type CheckRequest struct {
@@ -12,6 +16,11 @@ type CheckRequest struct {
 	ResourceKind  string
 	ResourceID    *string
 	CorrelationID string
+	// Ancestors are the registered ancestors of ResourceID, nearest first. The engine
+	// resolves them before evaluating the request.
+	Ancestors []ResourceNode
+	// Attributes are the context grant and override conditions are evaluated against.
+	Attributes Attributes
 }
 
 // CheckResult represents the decision returned by the PDP engine.
@@ -20,6 +29,9 @@ type CheckResult struct {
 	Decision      string   `json:"decision"`
 	RoleKeys      []string `json:"role_keys,omitempty"`
 	CorrelationID string   `json:"correlation_id,omitempty"`
+	// SoDConflicts lists dynamic separation-of-duties rules violated by RoleKeys. They
+	// are reported only and do not change the decision.
+	SoDConflicts []SoDConflict `json:"sod_conflicts,omitempty"`
 }
 
 // ExplainResult enriches the response with the matched artefacts.
@@ -27,20 +39,25 @@ type ExplainResult struct {
 	Allow    bool        `json:"allow"`
 	Decision string      `json:"decision"`
 	Matched  interface{} `json:"matched,omitempty"`
+	// SoDConflicts is CheckResult.SoDConflicts.
+	SoDConflicts []SoDConflict `json:"sod_conflicts,omitempty"`
 }
 
 // Repository is the contract required by the PDP engine for loading state.
 type Repository interface {
-	GetByPrincipal(principalID string, kind model.PrincipalKind) (bool, error)
-	GetByRequest(req CheckRequest) (*OverrideMatch, error)
-	List(req CheckRequest) ([]RoleWithScope, error)
-	ListByRoleIDs(roleIDs []string) ([]RolePermissionItem, error)
+	GetByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) (bool, error)
+	GetByRequest(ctx context.Context, req CheckRequest) (*OverrideMatch, error)
+	List(ctx context.Context, req CheckRequest) ([]RoleWithScope, error)
+	ListByRoleIDs(ctx context.Context, roleIDs []string) ([]RolePermissionItem, error)
+	ResourceRegistry
 }
 
 type OverrideMatch struct {
 	Effect       model.OverrideEffect
 	PermissionID string
 	Scope        OverrideScope
+	// Condition is the override's condition expression; empty means unconditional.
+	Condition string `json:",omitempty"`
 }
 
 type OverrideScope struct {
@@ -64,4 +81,6 @@ type RolePermissionItem struct {
 	Action       string
 	ResourceKind string
 	ResourceID   *string
+	// Condition is the grant's condition expression; empty means unconditional.
+	Condition string `json:",omitempty"`
 }
@@ -0,0 +1,34 @@
+package pdp
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+)
+
+// DecisionRecord is one logged authorization decision.
+type DecisionRecord struct {
+	OccurredAt    time.Time           `json:"occurred_at"`
+	PrincipalID   string              `json:"principal_id"`
+	PrincipalKind model.PrincipalKind `json:"principal_kind"`
+	TenantID      *string             `json:"tenant_id,omitempty"`
+	ServiceID     *string             `json:"service_id,omitempty"`
+	Action        string              `json:"action"`
+	ResourceKind  string              `json:"resource_kind"`
+	ResourceID    *string             `json:"resource_id,omitempty"`
+	CorrelationID string              `json:"correlation_id,omitempty"`
+	Allow         bool                `json:"allow"`
+	Decision      string              `json:"decision"`
+	RoleKeys      []string            `json:"role_keys,omitempty"`
+	Matched       interface{}         `json:"matched,omitempty"`
+	SoDConflicts  []SoDConflict       `json:"sod_conflicts,omitempty"`
+	LatencyMicros int64               `json:"latency_us"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// DecisionSink persists batches of decision records. Implementations are called from
+// a single background goroutine and never from the request path.
+type DecisionSink interface {
+	WriteDecisions(ctx context.Context, records []DecisionRecord) error
+}
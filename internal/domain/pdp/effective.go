@@ -0,0 +1,72 @@
+package pdp
+
+import "github.com/example/ms-rbac-service/internal/domain/model"
+
+// PermissionScope is where an effective permission applies; nil fields match any value.
+type PermissionScope struct {
+	TenantID     *string `json:"tenant_id"`
+	ServiceID    *string `json:"service_id"`
+	ResourceKind *string `json:"resource_kind"`
+	ResourceID   *string `json:"resource_id"`
+}
+
+// PermissionSource is where an effective permission comes from. Kind is one of the
+// role, inherited_role and override access path kinds.
+type PermissionSource struct {
+	Kind string `json:"kind"`
+	// RoleKey is the assigned role for role and inherited_role sources.
+	RoleKey string `json:"role_key,omitempty"`
+	// GrantedBy is the ancestor of RoleKey holding the grant when the permission is
+	// inherited through the role hierarchy.
+	GrantedBy string `json:"granted_by,omitempty"`
+	// GroupID is the group holding the role for inherited_role sources.
+	GroupID string `json:"group_id,omitempty"`
+}
+
+// EffectivePermission is one permission a principal holds, or is denied by an
+// override, in one scope.
+type EffectivePermission struct {
+	PermissionID string               `json:"permission_id"`
+	Action       string               `json:"action"`
+	ResourceKind string               `json:"resource_kind"`
+	Effect       model.OverrideEffect `json:"effect"`
+	Scope        PermissionScope      `json:"scope"`
+	// ServiceIDs limits a role grant to the services the role is bound to.
+	ServiceIDs []string         `json:"service_ids,omitempty"`
+	Source     PermissionSource `json:"source"`
+	// Condition must also hold for the entry to apply to a request.
+	Condition string `json:"condition,omitempty"`
+}
+
+// EffectivePermissions lists everything a principal may do. A superadmin, or a principal
+// with an active break-glass activation, is allowed every request regardless of
+// Permissions, which then only lists explicit grants.
+type EffectivePermissions struct {
+	PrincipalID   string                `json:"principal_id"`
+	PrincipalKind model.PrincipalKind   `json:"principal_kind"`
+	Superadmin    bool                  `json:"superadmin"`
+	Permissions   []EffectivePermission `json:"permissions"`
+}
+
+// Applies reports whether the permission can match a request in the tenant and
+// service; an empty argument matches every value.
+func (p EffectivePermission) Applies(tenantID, serviceID string) bool {
+	if tenantID != "" && p.Scope.TenantID != nil && *p.Scope.TenantID != tenantID {
+		return false
+	}
+	if serviceID == "" {
+		return true
+	}
+	if p.Scope.ServiceID != nil && *p.Scope.ServiceID != serviceID {
+		return false
+	}
+	if len(p.ServiceIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ServiceIDs {
+		if id == serviceID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package pdp
+
+import "github.com/example/ms-rbac-service/internal/domain/model"
+
+// OverrideCandidate is an active override whose scope matches the request being
+// decided. Action and ResourceKind are the overridden permission's, possibly patterns.
+// Depth is how far up the requested resource's ancestry the scope matched.
+type OverrideCandidate struct {
+	OverrideMatch
+	Action           string
+	ResourceKind     string
+	ScopeSpecificity int
+	Depth            int
+}
+
+// Match reports whether the scope, with nil fields meaning any, covers req and, when it
+// does, the depth in the requested resource's ancestry at which its resource fields
+// matched.
+func (s OverrideScope) Match(req CheckRequest) (int, bool) {
+	if s.TenantID != nil {
+		if req.TenantID == nil || *s.TenantID != *req.TenantID {
+			return 0, false
+		}
+	}
+	if s.ServiceID != nil {
+		if req.ServiceID == nil || *s.ServiceID != *req.ServiceID {
+			return 0, false
+		}
+	}
+	return MatchResourceScope(req.ResourcePath(), s.ResourceKind, s.ResourceID)
+}
+
+// Specificity ranks scopes for BestOverride: a tenant outweighs a service, which
+// outweighs a resource kind, which outweighs a resource id.
+func (s OverrideScope) Specificity() int {
+	score := 0
+	if s.TenantID != nil {
+		score += 1000
+	}
+	if s.ServiceID != nil {
+		score += 100
+	}
+	if s.ResourceKind != nil {
+		score += 10
+	}
+	if s.ResourceID != nil {
+		score += 1
+	}
+	return score
+}
+
+// BestOverride returns the override deciding req, or nil when no candidate's permission
+// matches. The most specific scope wins, then the scope on the nearest resource, so an
+// override on a lesson beats one on its course. Among those the most specific
+// permission pattern wins, so an exact permission beats a wildcard one; a deny wins a
+// remaining tie.
+//
+// Candidates whose condition does not hold for req are skipped. A condition that fails
+// to evaluate skips an allow but keeps a deny, so missing attributes never widen access.
+func BestOverride(candidates []OverrideCandidate, req CheckRequest) *OverrideMatch {
+	path := req.ResourcePath()
+	var best *OverrideCandidate
+	bestPermission := 0
+	for i := range candidates {
+		c := &candidates[i]
+		if !MatchPattern(c.Action, req.Action) || !MatchResourcePermission(path, c.ResourceKind, nil) {
+			continue
+		}
+		if holds, err := req.ConditionHolds(c.Condition); err != nil {
+			if c.Effect != model.OverrideEffectDeny {
+				continue
+			}
+		} else if !holds {
+			continue
+		}
+		permission := PermissionSpecificity(c.Action, c.ResourceKind)
+		if best == nil || overrideOutranks(c, permission, best, bestPermission) {
+			best, bestPermission = c, permission
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	match := best.OverrideMatch
+	return &match
+}
+
+func overrideOutranks(c *OverrideCandidate, permission int, best *OverrideCandidate, bestPermission int) bool {
+	if c.ScopeSpecificity != best.ScopeSpecificity {
+		return c.ScopeSpecificity > best.ScopeSpecificity
+	}
+	if c.Depth != best.Depth {
+		return c.Depth < best.Depth
+	}
+	if permission != bestPermission {
+		return permission > bestPermission
+	}
+	return c.Effect == model.OverrideEffectDeny && best.Effect != model.OverrideEffectDeny
+}
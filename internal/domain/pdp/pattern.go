@@ -0,0 +1,98 @@
+package pdp
+
+import "strings"
+
+// Permission actions and resource kinds may be patterns. "*" matches any value, and a
+// pattern ending in ".*" or ":*" matches every value starting with the text before the
+// "*", so "course.*" matches "course.lesson" and "course.lesson.quiz" but not "course".
+// Any other pattern, including one with a "*" elsewhere, matches only itself.
+
+// MatchPattern reports whether value matches pattern.
+func MatchPattern(pattern, value string) bool {
+	if pattern == value {
+		return true
+	}
+	prefix, ok := wildcardPrefix(pattern)
+	return ok && strings.HasPrefix(value, prefix)
+}
+
+// MatchingPatterns returns every pattern MatchPattern accepts for value: the value
+// itself, "*", and a ".*" or ":*" wildcard for each prefix ending in "." or ":". A
+// store can then look patterns up by equality instead of evaluating each one.
+func MatchingPatterns(value string) []string {
+	patterns := []string{value}
+	if value != "*" {
+		patterns = append(patterns, "*")
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] != '.' && value[i] != ':' {
+			continue
+		}
+		if pattern := value[:i+1] + "*"; pattern != value {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// PatternSpecificity ranks how narrowly pattern matches: an exact value outranks any
+// wildcard, and a longer wildcard prefix outranks a shorter one, "*" ranking lowest.
+func PatternSpecificity(pattern string) int {
+	prefix, ok := wildcardPrefix(pattern)
+	if !ok {
+		return exactSpecificity
+	}
+	return min(len(prefix), exactSpecificity-1)
+}
+
+// exactSpecificity is PatternSpecificity of a pattern without a wildcard.
+const exactSpecificity = 1 << 10
+
+// wildcardPrefix returns the text a wildcard pattern requires values to start with.
+func wildcardPrefix(pattern string) (string, bool) {
+	if pattern == "*" {
+		return "", true
+	}
+	if strings.HasSuffix(pattern, ".*") || strings.HasSuffix(pattern, ":*") {
+		return strings.TrimSuffix(pattern, "*"), true
+	}
+	return "", false
+}
+
+// MatchPermission reports whether a permission declared with the action and resource
+// kind patterns covers the requested action and resource kind.
+func MatchPermission(actionPattern, kindPattern, action, resourceKind string) bool {
+	return MatchPattern(actionPattern, action) && MatchPattern(kindPattern, resourceKind)
+}
+
+// PermissionSpecificity ranks a permission by its action pattern, then its resource kind
+// pattern, so an exact action with any kind outranks a wildcard action on an exact kind.
+func PermissionSpecificity(actionPattern, kindPattern string) int {
+	return PatternSpecificity(actionPattern)<<11 | PatternSpecificity(kindPattern)
+}
+
+// MatchIdentifier reports whether the permission identifier pattern covers identifier.
+// Both take the "action:resource_kind[:resource_id]" form used by the principal
+// permission API. The pattern's parts are matched as patterns; a pattern without a
+// resource id covers every resource, one with a resource id only that resource. A
+// pattern such as "read:*" is therefore any resource kind for the read action.
+func MatchIdentifier(pattern, identifier string) bool {
+	pAction, pKind, pResource := splitIdentifier(pattern)
+	action, kind, resource := splitIdentifier(identifier)
+	if !MatchPermission(pAction, pKind, action, kind) {
+		return false
+	}
+	return pResource == "" || pResource == resource
+}
+
+func splitIdentifier(identifier string) (action, kind, resource string) {
+	parts := strings.SplitN(identifier, ":", 3)
+	action = parts[0]
+	if len(parts) > 1 {
+		kind = parts[1]
+	}
+	if len(parts) > 2 {
+		resource = parts[2]
+	}
+	return action, kind, resource
+}
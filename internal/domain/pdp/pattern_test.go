@@ -0,0 +1,238 @@
+package pdp
+
+import (
+	"testing"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"course", "course", true},
+		{"course", "courses", false},
+		{"*", "course", true},
+		{"*", "", true},
+		{"course.*", "course.lesson", true},
+		{"course.*", "course.lesson.quiz", true},
+		{"course.*", "course", false},
+		{"course.*", "coursework", false},
+		{"read:*", "read:draft", true},
+		{"read:*", "read", false},
+		{"co*", "course", false},
+		{"co*", "co*", true},
+		{"course.*.quiz", "course.lesson.quiz", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPattern(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMatchingPatterns(t *testing.T) {
+	candidates := []string{"*", "course", "course.*", "course.lesson", "course.lesson.*", "course.lesson.quiz",
+		"course.lesson:*", "read:*", "read:draft", "read", "co*", "course.*.quiz", "", ".*"}
+	for _, value := range candidates {
+		patterns := MatchingPatterns(value)
+		seen := map[string]bool{}
+		for _, p := range patterns {
+			if seen[p] {
+				t.Errorf("MatchingPatterns(%q) repeats %q", value, p)
+			}
+			seen[p] = true
+		}
+		for _, pattern := range candidates {
+			if got, want := seen[pattern], MatchPattern(pattern, value); got != want {
+				t.Errorf("MatchingPatterns(%q) contains %q = %v, MatchPattern = %v", value, pattern, got, want)
+			}
+		}
+	}
+}
+
+func TestPatternSpecificityOrdersExactBeforeWildcards(t *testing.T) {
+	ordered := []string{"course.lesson", "course.lesson.*", "course.*", "*"}
+	for i := 1; i < len(ordered); i++ {
+		if PatternSpecificity(ordered[i-1]) <= PatternSpecificity(ordered[i]) {
+			t.Errorf("PatternSpecificity(%q) should outrank PatternSpecificity(%q)", ordered[i-1], ordered[i])
+		}
+	}
+}
+
+func TestPermissionSpecificityRanksActionFirst(t *testing.T) {
+	ordered := [][2]string{
+		{"read", "course"},
+		{"read", "course.*"},
+		{"read", "*"},
+		{"*", "course"},
+		{"*", "*"},
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, b := ordered[i-1], ordered[i]
+		if PermissionSpecificity(a[0], a[1]) <= PermissionSpecificity(b[0], b[1]) {
+			t.Errorf("permission %v should outrank %v", a, b)
+		}
+	}
+}
+
+func TestMatchIdentifier(t *testing.T) {
+	tests := []struct {
+		pattern, identifier string
+		want                bool
+	}{
+		{"read:course", "read:course", true},
+		{"read:course", "read:course:42", true},
+		{"read:course:42", "read:course:42", true},
+		{"read:course:42", "read:course:43", false},
+		{"read:course:42", "read:course", false},
+		{"read:*", "read:course", true},
+		{"read:*", "read:course:42", true},
+		{"read:*", "write:course", false},
+		{"*:course.*", "write:course.lesson", true},
+		{"*:course.*", "write:course", false},
+		{"*:*", "anything:at_all", true},
+	}
+	for _, tt := range tests {
+		if got := MatchIdentifier(tt.pattern, tt.identifier); got != tt.want {
+			t.Errorf("MatchIdentifier(%q, %q) = %v, want %v", tt.pattern, tt.identifier, got, tt.want)
+		}
+	}
+}
+
+func candidate(id string, effect model.OverrideEffect, action, kind string, scope int) OverrideCandidate {
+	return OverrideCandidate{
+		OverrideMatch:    OverrideMatch{Effect: effect, PermissionID: id},
+		Action:           action,
+		ResourceKind:     kind,
+		ScopeSpecificity: scope,
+	}
+}
+
+func TestBestOverridePrecedence(t *testing.T) {
+	allow, deny := model.OverrideEffectAllow, model.OverrideEffectDeny
+	tests := []struct {
+		name       string
+		candidates []OverrideCandidate
+		want       string
+	}{
+		{
+			name:       "no matching permission",
+			candidates: []OverrideCandidate{candidate("a", allow, "write", "course.lesson", 0)},
+		},
+		{
+			name: "exact permission beats wildcard in the same scope",
+			candidates: []OverrideCandidate{
+				candidate("wildcard", deny, "*", "*", 0),
+				candidate("exact", allow, "read", "course.lesson", 0),
+			},
+			want: "exact",
+		},
+		{
+			name: "narrower wildcard beats broader wildcard",
+			candidates: []OverrideCandidate{
+				candidate("any", allow, "read", "*", 0),
+				candidate("course", deny, "read", "course.*", 0),
+			},
+			want: "course",
+		},
+		{
+			name: "more specific scope beats more specific permission",
+			candidates: []OverrideCandidate{
+				candidate("exact-global", allow, "read", "course.lesson", 0),
+				candidate("wildcard-tenant", deny, "*", "*", 1000),
+			},
+			want: "wildcard-tenant",
+		},
+		{
+			name: "deny wins a full tie",
+			candidates: []OverrideCandidate{
+				candidate("allow", allow, "read", "*", 100),
+				candidate("deny", deny, "read", "*", 100),
+			},
+			want: "deny",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BestOverride(tt.candidates, CheckRequest{Action: "read", ResourceKind: "course.lesson"})
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("BestOverride = %+v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || got.PermissionID != tt.want {
+				t.Fatalf("BestOverride = %+v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestOverridePrefersNearestResource(t *testing.T) {
+	course := "course-1"
+	onLesson := candidate("lesson", model.OverrideEffectAllow, "read", "course.lesson", 100)
+	onCourse := candidate("course", model.OverrideEffectDeny, "read", "course.lesson", 100)
+	onCourse.Depth = 1
+	req := CheckRequest{Action: "read", ResourceKind: "course.lesson", Ancestors: []ResourceNode{{Kind: "course", ID: &course}}}
+	got := BestOverride([]OverrideCandidate{onCourse, onLesson}, req)
+	if got == nil || got.PermissionID != "lesson" {
+		t.Fatalf("BestOverride = %+v, want lesson", got)
+	}
+}
+
+func TestBestOverrideConditions(t *testing.T) {
+	allow, deny := model.OverrideEffectAllow, model.OverrideEffectDeny
+	req := CheckRequest{Action: "read", ResourceKind: "course.lesson", Attributes: Attributes{Resource: map[string]any{"owner": "p1"}}}
+	tests := []struct {
+		name       string
+		candidates []OverrideCandidate
+		want       string
+	}{
+		{
+			name: "false condition falls through to the next override",
+			candidates: []OverrideCandidate{
+				withCondition(candidate("exact", deny, "read", "course.lesson", 0), `resource.owner == "p2"`),
+				candidate("wildcard", allow, "*", "*", 0),
+			},
+			want: "wildcard",
+		},
+		{
+			name: "true condition applies",
+			candidates: []OverrideCandidate{
+				withCondition(candidate("exact", deny, "read", "course.lesson", 0), `resource.owner == "p1"`),
+				candidate("wildcard", allow, "*", "*", 0),
+			},
+			want: "exact",
+		},
+		{
+			name:       "allow whose condition fails to evaluate is skipped",
+			candidates: []OverrideCandidate{withCondition(candidate("allow", allow, "read", "*", 0), "resource.level > 1")},
+		},
+		{
+			name:       "deny whose condition fails to evaluate applies",
+			candidates: []OverrideCandidate{withCondition(candidate("deny", deny, "read", "*", 0), "resource.level > 1")},
+			want:       "deny",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BestOverride(tt.candidates, req)
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("BestOverride = %+v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || got.PermissionID != tt.want {
+				t.Fatalf("BestOverride = %+v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func withCondition(c OverrideCandidate, condition string) OverrideCandidate {
+	c.Condition = condition
+	return c
+}
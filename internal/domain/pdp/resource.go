@@ -0,0 +1,56 @@
+package pdp
+
+import "context"
+
+// ResourceNode is a resource in the resource registry, or the resource of a request.
+type ResourceNode struct {
+	Kind string
+	ID   *string
+}
+
+// ResourceRegistry resolves the registered ancestors of a resource.
+type ResourceRegistry interface {
+	// ResourceAncestors returns the ancestors of the resource, nearest first. An
+	// unregistered resource has none.
+	ResourceAncestors(ctx context.Context, resourceID string) ([]ResourceNode, error)
+}
+
+// ResourcePath returns the nodes the request's resource is matched against: the
+// requested resource itself, then its ancestors nearest first.
+func (r CheckRequest) ResourcePath() []ResourceNode {
+	path := make([]ResourceNode, 0, len(r.Ancestors)+1)
+	path = append(path, ResourceNode{Kind: r.ResourceKind, ID: r.ResourceID})
+	return append(path, r.Ancestors...)
+}
+
+// MatchResourceScope reports whether a resource scope, with nil meaning any kind or
+// any id, covers a node of path, and the depth of the nearest such node: 0 for the
+// requested resource, 1 for its parent and so on. A grant on a course therefore covers
+// requests on its lessons.
+func MatchResourceScope(path []ResourceNode, kind, id *string) (int, bool) {
+	for depth, node := range path {
+		if kind != nil && *kind != node.Kind {
+			continue
+		}
+		if id != nil && (node.ID == nil || *id != *node.ID) {
+			continue
+		}
+		return depth, true
+	}
+	return 0, false
+}
+
+// MatchResourcePermission reports whether a permission on kindPattern, optionally
+// granted on the single resource id, covers a node of path.
+func MatchResourcePermission(path []ResourceNode, kindPattern string, id *string) bool {
+	for _, node := range path {
+		if !MatchPattern(kindPattern, node.Kind) {
+			continue
+		}
+		if id != nil && (node.ID == nil || *id != *node.ID) {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package pdp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/domain/model"
+)
+
+// SnapshotSource loads policy snapshots and feeds them committed changes.
+type SnapshotSource interface {
+	// LoadSnapshot loads the rows named by change, or every row for a zero change.
+	LoadSnapshot(ctx context.Context, change SnapshotChange) (SnapshotState, error)
+	// ListenSnapshotChanges starts a feed of changes committed from now on.
+	ListenSnapshotChanges(ctx context.Context) (SnapshotFeed, error)
+}
+
+// SnapshotFeed delivers committed changes in commit order.
+type SnapshotFeed interface {
+	Next(ctx context.Context) (SnapshotChange, error)
+	Close()
+}
+
+// SnapshotState holds the rows of the tables the engine reads, as loaded into an
+// in-memory policy snapshot. A partial load for a SnapshotChange holds only the rows of
+// that table matching its key.
+type SnapshotState struct {
+	Superadmins             []SnapshotPrincipal
+	Activations             []SnapshotActivation
+	DisabledServiceAccounts []string
+	Overrides               []SnapshotOverride
+	Assignments             []SnapshotAssignment
+	Memberships             []SnapshotMembership
+	Roles                   []SnapshotRole
+	Grants                  []SnapshotGrant
+	Permissions             []SnapshotPermission
+	Resources               []SnapshotResource
+	SoDRules                []SnapshotSoDRule
+}
+
+// SnapshotPrincipal identifies a principal.
+type SnapshotPrincipal struct {
+	ID   string
+	Kind model.PrincipalKind
+}
+
+// SnapshotActivation is an open break-glass activation.
+type SnapshotActivation struct {
+	Principal SnapshotPrincipal
+	ExpiresAt time.Time
+}
+
+// SnapshotOverride is a principal override with its validity window.
+type SnapshotOverride struct {
+	Principal  SnapshotPrincipal
+	Override   OverrideMatch
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+}
+
+// SnapshotAssignment is a scoped role assignment with its validity window.
+type SnapshotAssignment struct {
+	Principal  SnapshotPrincipal
+	RoleID     string
+	Scope      OverrideScope
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+}
+
+// SnapshotMembership places a member principal in a group.
+type SnapshotMembership struct {
+	GroupID string
+	Member  SnapshotPrincipal
+}
+
+// SnapshotRole is a role with the services it is bound to and the roles it inherits
+// from directly through the role hierarchy.
+type SnapshotRole struct {
+	ID         string
+	Key        string
+	ServiceIDs []string
+	ParentIDs  []string
+}
+
+// SnapshotGrant grants a permission to a role, optionally on a single resource.
+type SnapshotGrant struct {
+	RoleID       string
+	PermissionID string
+	ResourceID   *string
+	Condition    string
+}
+
+// SnapshotPermission is a permission, whose action and kind may be patterns.
+type SnapshotPermission struct {
+	ID           string
+	Action       string
+	ResourceKind string
+}
+
+// SnapshotResource is a registered resource; ParentID is empty for a root.
+type SnapshotResource struct {
+	ID       string
+	Kind     string
+	ParentID string
+}
+
+// SnapshotSoDRule is a dynamic separation-of-duties rule between two roles.
+type SnapshotSoDRule struct {
+	Key     string
+	RoleAID string
+	RoleBID string
+}
+
+// SnapshotChange names the rows a snapshot reloads after a committed change: those of
+// Table whose key columns equal Key. A change without a table stands for every table.
+type SnapshotChange struct {
+	Table string
+	Key   []string
+}
+
+// ParseSnapshotChange parses a change notification of the form table[:key...].
+func ParseSnapshotChange(payload string) SnapshotChange {
+	parts := strings.Split(payload, ":")
+	if parts[0] == "" || parts[0] == "*" {
+		return SnapshotChange{}
+	}
+	return SnapshotChange{Table: parts[0], Key: parts[1:]}
+}
+
+// KeyPart returns the i-th key column, or "" when the change has fewer.
+func (c SnapshotChange) KeyPart(i int) string {
+	if i < len(c.Key) {
+		return c.Key[i]
+	}
+	return ""
+}
+
+// ActiveAt reports whether a validity window, with nil bounds meaning open, contains now.
+func ActiveAt(validFrom, validUntil *time.Time, now time.Time) bool {
+	return (validFrom == nil || !validFrom.After(now)) && (validUntil == nil || validUntil.After(now))
+}
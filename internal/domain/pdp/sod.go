@@ -0,0 +1,42 @@
+package pdp
+
+import "context"
+
+// SoDRule is a dynamic separation-of-duties rule: a principal may hold both roles, but a
+// check where both apply is flagged.
+type SoDRule struct {
+	Key   string
+	RoleA string
+	RoleB string
+}
+
+// SoDConflict names a dynamic rule violated by the roles a check resolved.
+type SoDConflict struct {
+	Rule  string   `json:"rule"`
+	Roles []string `json:"roles"`
+}
+
+// SoDRuleSource loads the dynamic separation-of-duties rules.
+type SoDRuleSource interface {
+	DynamicSoDRules(ctx context.Context) ([]SoDRule, error)
+}
+
+// DetectSoDConflicts returns the rules whose two roles are both among roleKeys, in rule order.
+func DetectSoDConflicts(rules []SoDRule, roleKeys []string) []SoDConflict {
+	if len(rules) == 0 || len(roleKeys) < 2 {
+		return nil
+	}
+	held := make(map[string]struct{}, len(roleKeys))
+	for _, key := range roleKeys {
+		held[key] = struct{}{}
+	}
+	var conflicts []SoDConflict
+	for _, rule := range rules {
+		_, a := held[rule.RoleA]
+		_, b := held[rule.RoleB]
+		if a && b {
+			conflicts = append(conflicts, SoDConflict{Rule: rule.Key, Roles: []string{rule.RoleA, rule.RoleB}})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,41 @@
+package pdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectSoDConflicts(t *testing.T) {
+	rules := []SoDRule{
+		{Key: "pay-approve", RoleA: "payer", RoleB: "approver"},
+		{Key: "author-review", RoleA: "author", RoleB: "reviewer"},
+		{Key: "payer-auditor", RoleA: "payer", RoleB: "auditor"},
+	}
+	tests := []struct {
+		name  string
+		rules []SoDRule
+		roles []string
+		want  []SoDConflict
+	}{
+		{"no rules", nil, []string{"payer", "approver"}, nil},
+		{"no roles", rules, nil, nil},
+		{"single role", rules, []string{"payer"}, nil},
+		{"unrelated roles", rules, []string{"payer", "reviewer"}, nil},
+		{"one conflict", rules, []string{"approver", "payer"}, []SoDConflict{
+			{Rule: "pay-approve", Roles: []string{"payer", "approver"}},
+		}},
+		{"conflicts in rule order", rules, []string{"auditor", "reviewer", "payer", "author", "approver"}, []SoDConflict{
+			{Rule: "pay-approve", Roles: []string{"payer", "approver"}},
+			{Rule: "author-review", Roles: []string{"author", "reviewer"}},
+			{Rule: "payer-auditor", Roles: []string{"payer", "auditor"}},
+		}},
+		{"repeated role", rules, []string{"payer", "payer"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectSoDConflicts(tt.rules, tt.roles); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectSoDConflicts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/example/ms-rbac-service/internal/domain/rebac"
+)
+
+// BackupVersion is the version written by export. Import also accepts
+// LegacyBackupVersion, written before the resource registry and ReBAC namespaces and
+// tuples were exported; restoring such a backup leaves those tables untouched.
+const (
+	BackupVersion       = 2
+	LegacyBackupVersion = 1
+)
+
+// Backup is a full, versioned copy of every RBAC table. Rows reference each other
+// by natural keys so a backup taken in one environment restores cleanly in another.
+type Backup struct {
+	Version            int                       `json:"version"`
+	ExportedAt         time.Time                 `json:"exported_at"`
+	Services           []BackupService           `json:"services"`
+	Roles              []BackupRole              `json:"roles"`
+	RoleHierarchy      []BackupRoleHierarchy     `json:"role_hierarchy"`
+	Permissions        []BackupPermission        `json:"permissions"`
+	RolePermissions    []BackupRolePermission    `json:"role_permissions"`
+	ServiceRoles       []BackupServiceRole       `json:"service_roles"`
+	ServicePermissions []BackupServicePermission `json:"service_permissions"`
+	PrincipalRoles     []BackupPrincipalRole     `json:"principal_roles"`
+	PrincipalOverrides []BackupPrincipalOverride `json:"principal_overrides"`
+	Superadmins        []BackupSuperadmin        `json:"superadmins"`
+	Groups             []BackupGroup             `json:"groups"`
+	GroupMembers       []BackupGroupMember       `json:"group_members"`
+	SoDRules           []BackupSoDRule           `json:"sod_rules"`
+	RoleCardinality    []BackupRoleCardinality   `json:"role_cardinality"`
+	Resources          []BackupResource          `json:"resources"`
+	RebacNamespaces    []BackupRebacNamespace    `json:"rebac_namespaces"`
+	RelationTuples     []rebac.Tuple             `json:"relation_tuples"`
+}
+
+type BackupService struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type BackupRole struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type BackupRoleHierarchy struct {
+	Role   string `json:"role"`
+	Parent string `json:"parent"`
+}
+
+type BackupPermission struct {
+	ID           string `json:"id"`
+	Action       string `json:"action"`
+	ResourceKind string `json:"resource_kind"`
+}
+
+type BackupRolePermission struct {
+	Role         string `json:"role"`
+	Action       string `json:"action"`
+	ResourceKind string `json:"resource_kind"`
+	ResourceID   string `json:"resource_id"`
+	Condition    string `json:"condition,omitempty"`
+}
+
+type BackupServiceRole struct {
+	Service string `json:"service"`
+	Role    string `json:"role"`
+}
+
+type BackupServicePermission struct {
+	Service      string `json:"service"`
+	Action       string `json:"action"`
+	ResourceKind string `json:"resource_kind"`
+}
+
+// BackupScope mirrors the scope columns shared by principal_role and principal_override.
+type BackupScope struct {
+	TenantID     string `json:"tenant_id"`
+	Service      string `json:"service"`
+	ResourceKind string `json:"resource_kind"`
+	ResourceID   string `json:"resource_id"`
+}
+
+type BackupPrincipalRole struct {
+	PrincipalID   string      `json:"principal_id"`
+	PrincipalKind string      `json:"principal_kind"`
+	Role          string      `json:"role"`
+	Scope         BackupScope `json:"scope"`
+	ValidFrom     *time.Time  `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time  `json:"valid_until,omitempty"`
+}
+
+type BackupPrincipalOverride struct {
+	PrincipalID   string      `json:"principal_id"`
+	PrincipalKind string      `json:"principal_kind"`
+	Action        string      `json:"action"`
+	ResourceKind  string      `json:"resource_kind"`
+	Effect        string      `json:"effect"`
+	Scope         BackupScope `json:"scope"`
+	ValidFrom     *time.Time  `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time  `json:"valid_until,omitempty"`
+	Condition     string      `json:"condition,omitempty"`
+}
+
+type BackupSuperadmin struct {
+	PrincipalID   string `json:"principal_id"`
+	PrincipalKind string `json:"principal_kind"`
+}
+
+// BackupGroup keeps its id: role assignments and memberships reference groups by id.
+type BackupGroup struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type BackupGroupMember struct {
+	GroupID    string `json:"group_id"`
+	MemberID   string `json:"member_id"`
+	MemberKind string `json:"member_kind"`
+}
+
+type BackupSoDRule struct {
+	Key         string `json:"key"`
+	RoleA       string `json:"role_a"`
+	RoleB       string `json:"role_b"`
+	Kind        string `json:"kind"`
+	SameScope   bool   `json:"same_scope"`
+	Description string `json:"description"`
+}
+
+type BackupRoleCardinality struct {
+	Role          string `json:"role"`
+	Scope         string `json:"scope"`
+	MaxPrincipals int    `json:"max_principals"`
+}
+
+// BackupResource keeps its id, which grants, overrides and checks use as resource_id.
+type BackupResource struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+type BackupRebacNamespace struct {
+	Name      string                   `json:"name"`
+	Relations map[string]rebac.Rewrite `json:"relations"`
+}
+
+// ImportOptions tune a restore.
+type ImportOptions struct {
+	// ExcludePrincipals keeps the target's principal assignments, overrides,
+	// superadmins, groups and relation tuples, and the resource registry their scopes
+	// refer to, untouched and ignores those sections of the backup.
+	ExcludePrincipals bool
+}
+
+// ImportReport counts the rows written per table; a table no row was written to is
+// left out.
+type ImportReport struct {
+	Restored map[string]int `json:"restored"`
+}
+
+// Validate checks the backup version and that every reference resolves inside the backup.
+func (b Backup) Validate() error {
+	if b.Version != BackupVersion && b.Version != LegacyBackupVersion {
+		return fmt.Errorf("%w: unsupported backup version %d", ErrInvalidDocument, b.Version)
+	}
+	services := make(map[string]struct{}, len(b.Services))
+	for _, s := range b.Services {
+		if s.Key == "" {
+			return fmt.Errorf("%w: service key is required", ErrInvalidDocument)
+		}
+		services[s.Key] = struct{}{}
+	}
+	roles := make(map[string]struct{}, len(b.Roles))
+	for _, r := range b.Roles {
+		if r.Key == "" {
+			return fmt.Errorf("%w: role key is required", ErrInvalidDocument)
+		}
+		roles[r.Key] = struct{}{}
+	}
+	permissions := make(map[string]struct{}, len(b.Permissions))
+	for _, p := range b.Permissions {
+		if p.Action == "" || p.ResourceKind == "" {
+			return fmt.Errorf("%w: permission action and resource_kind are required", ErrInvalidDocument)
+		}
+		permissions[PermissionKey(p.Action, p.ResourceKind)] = struct{}{}
+	}
+	check := func(set map[string]struct{}, kind, key string) error {
+		if _, ok := set[key]; !ok {
+			return fmt.Errorf("%w: unknown %s %q", ErrInvalidDocument, kind, key)
+		}
+		return nil
+	}
+	for _, h := range b.RoleHierarchy {
+		if err := check(roles, "role", h.Role); err != nil {
+			return err
+		}
+		if err := check(roles, "role", h.Parent); err != nil {
+			return err
+		}
+	}
+	for _, rp := range b.RolePermissions {
+		if err := check(roles, "role", rp.Role); err != nil {
+			return err
+		}
+		if err := check(permissions, "permission", PermissionKey(rp.Action, rp.ResourceKind)); err != nil {
+			return err
+		}
+		if err := checkCondition(rp.Condition); err != nil {
+			return err
+		}
+	}
+	for _, rule := range b.SoDRules {
+		if rule.Key == "" {
+			return fmt.Errorf("%w: sod rule key is required", ErrInvalidDocument)
+		}
+		if err := check(roles, "role", rule.RoleA); err != nil {
+			return err
+		}
+		if err := check(roles, "role", rule.RoleB); err != nil {
+			return err
+		}
+	}
+	for _, rc := range b.RoleCardinality {
+		if err := check(roles, "role", rc.Role); err != nil {
+			return err
+		}
+	}
+	for _, sr := range b.ServiceRoles {
+		if err := check(services, "service", sr.Service); err != nil {
+			return err
+		}
+		if err := check(roles, "role", sr.Role); err != nil {
+			return err
+		}
+	}
+	for _, sp := range b.ServicePermissions {
+		if err := check(services, "service", sp.Service); err != nil {
+			return err
+		}
+		if err := check(permissions, "permission", PermissionKey(sp.Action, sp.ResourceKind)); err != nil {
+			return err
+		}
+	}
+	for _, pr := range b.PrincipalRoles {
+		if err := check(roles, "role", pr.Role); err != nil {
+			return err
+		}
+		if err := check(services, "service", pr.Scope.Service); err != nil {
+			return err
+		}
+	}
+	for _, po := range b.PrincipalOverrides {
+		if err := check(permissions, "permission", PermissionKey(po.Action, po.ResourceKind)); err != nil {
+			return err
+		}
+		if err := check(services, "service", po.Scope.Service); err != nil {
+			return err
+		}
+		if err := checkCondition(po.Condition); err != nil {
+			return err
+		}
+	}
+	groups := make(map[string]struct{}, len(b.Groups))
+	for _, g := range b.Groups {
+		if g.ID == "" || g.Key == "" {
+			return fmt.Errorf("%w: group id and key are required", ErrInvalidDocument)
+		}
+		groups[g.ID] = struct{}{}
+	}
+	for _, m := range b.GroupMembers {
+		if err := check(groups, "group", m.GroupID); err != nil {
+			return err
+		}
+	}
+	if err := validateBackupResources(b.Resources); err != nil {
+		return err
+	}
+	return validateBackupRebac(b.RebacNamespaces, b.RelationTuples)
+}
+
+// validateBackupRebac checks every namespace config and that every tuple could be
+// written against the namespaces of the backup.
+func validateBackupRebac(configs []BackupRebacNamespace, tuples []rebac.Tuple) error {
+	namespaces := make(map[string]*rebac.Namespace, len(configs))
+	for _, c := range configs {
+		ns := &rebac.Namespace{Name: c.Name, Relations: c.Relations}
+		if err := ns.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+		}
+		namespaces[ns.Name] = ns
+	}
+	for _, t := range tuples {
+		if err := rebac.ValidateTuple(t, namespaces); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+		}
+	}
+	return nil
+}
+
+// validateBackupResources checks that every parent is in the backup and that parent
+// links do not form a cycle.
+func validateBackupResources(resources []BackupResource) error {
+	parents := make(map[string]string, len(resources))
+	for _, r := range resources {
+		if r.ID == "" || r.Kind == "" {
+			return fmt.Errorf("%w: resource id and kind are required", ErrInvalidDocument)
+		}
+		parents[r.ID] = r.ParentID
+	}
+	for _, r := range resources {
+		seen := map[string]bool{r.ID: true}
+		for id := r.ParentID; id != ""; id = parents[id] {
+			if _, ok := parents[id]; !ok {
+				return fmt.Errorf("%w: unknown parent resource %q", ErrInvalidDocument, id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: resource %q is nested under itself", ErrInvalidDocument, r.ID)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+func checkCondition(condition string) error {
+	if condition == "" {
+		return nil
+	}
+	if _, err := domainpdp.CompileCondition(condition); err != nil {
+		return fmt.Errorf("%w: condition: %v", ErrInvalidDocument, err)
+	}
+	return nil
+}
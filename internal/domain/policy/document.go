@@ -0,0 +1,205 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentVersion is the only policy document version understood by this service.
+const DocumentVersion = 1
+
+var ErrInvalidDocument = errors.New("invalid policy document")
+
+// Document is the declarative description of roles, permissions and their wiring.
+type Document struct {
+	Version     int          `json:"version" yaml:"version"`
+	Permissions []Permission `json:"permissions" yaml:"permissions"`
+	Roles       []Role       `json:"roles" yaml:"roles"`
+}
+
+// Permission declares an action on a resource kind and the services exposing it.
+type Permission struct {
+	Action       string   `json:"action" yaml:"action"`
+	ResourceKind string   `json:"resource_kind" yaml:"resource_kind"`
+	Services     []string `json:"services,omitempty" yaml:"services,omitempty"`
+}
+
+// Role declares a role, its parents, the services it is bound to and its grants.
+// Grants use the "action:resource_kind[:resource_id]" notation.
+type Role struct {
+	Key      string   `json:"key" yaml:"key"`
+	Title    string   `json:"title" yaml:"title"`
+	Inherits []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+	Services []string `json:"services,omitempty" yaml:"services,omitempty"`
+	Grants   []string `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// Grant is the parsed form of a role grant.
+type Grant struct {
+	Action       string
+	ResourceKind string
+	ResourceID   string
+}
+
+// String renders the grant in document notation.
+func (g Grant) String() string {
+	s := PermissionKey(g.Action, g.ResourceKind)
+	if g.ResourceID != "" {
+		s += ":" + g.ResourceID
+	}
+	return s
+}
+
+// PermissionKey is the natural key of a permission.
+func PermissionKey(action, resourceKind string) string {
+	return action + ":" + resourceKind
+}
+
+// ParseGrant parses "action:resource_kind[:resource_id]".
+func ParseGrant(v string) (Grant, error) {
+	parts := strings.SplitN(strings.TrimSpace(v), ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return Grant{}, fmt.Errorf("%w: grant %q must look like action:resource_kind[:resource_id]", ErrInvalidDocument, v)
+	}
+	g := Grant{Action: parts[0], ResourceKind: parts[1]}
+	if len(parts) == 3 {
+		g.ResourceID = parts[2]
+	}
+	return g, nil
+}
+
+// LoadFile reads a policy document from disk; ".yaml"/".yml" files are parsed as YAML,
+// everything else as JSON.
+func LoadFile(path string) (Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return Parse(data, FormatYAML)
+	default:
+		return Parse(data, FormatJSON)
+	}
+}
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// Parse decodes and validates a policy document.
+func Parse(data []byte, format Format) (Document, error) {
+	var doc Document
+	var err error
+	switch format {
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&doc)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&doc)
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if err := doc.Validate(); err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
+
+// Validate checks the document is self-consistent: keys are unique and every
+// grant and parent refers to something declared in the same document.
+func (d Document) Validate() error {
+	if d.Version != DocumentVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidDocument, d.Version)
+	}
+	permissions := make(map[string]struct{}, len(d.Permissions))
+	for _, p := range d.Permissions {
+		if p.Action == "" || p.ResourceKind == "" {
+			return fmt.Errorf("%w: permission action and resource_kind are required", ErrInvalidDocument)
+		}
+		key := PermissionKey(p.Action, p.ResourceKind)
+		if _, ok := permissions[key]; ok {
+			return fmt.Errorf("%w: duplicate permission %q", ErrInvalidDocument, key)
+		}
+		permissions[key] = struct{}{}
+	}
+	roles := make(map[string]struct{}, len(d.Roles))
+	for _, r := range d.Roles {
+		if r.Key == "" {
+			return fmt.Errorf("%w: role key is required", ErrInvalidDocument)
+		}
+		if _, ok := roles[r.Key]; ok {
+			return fmt.Errorf("%w: duplicate role %q", ErrInvalidDocument, r.Key)
+		}
+		roles[r.Key] = struct{}{}
+	}
+	for _, r := range d.Roles {
+		for _, parent := range r.Inherits {
+			if parent == r.Key {
+				return fmt.Errorf("%w: role %q cannot inherit from itself", ErrInvalidDocument, r.Key)
+			}
+			if _, ok := roles[parent]; !ok {
+				return fmt.Errorf("%w: role %q inherits undeclared role %q", ErrInvalidDocument, r.Key, parent)
+			}
+		}
+		for _, raw := range r.Grants {
+			g, err := ParseGrant(raw)
+			if err != nil {
+				return err
+			}
+			if _, ok := permissions[PermissionKey(g.Action, g.ResourceKind)]; !ok {
+				return fmt.Errorf("%w: role %q grants undeclared permission %q", ErrInvalidDocument, r.Key, raw)
+			}
+		}
+	}
+	return d.checkHierarchyCycles()
+}
+
+func (d Document) checkHierarchyCycles() error {
+	parents := make(map[string][]string, len(d.Roles))
+	for _, r := range d.Roles {
+		parents[r.Key] = r.Inherits
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(d.Roles))
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch state[key] {
+		case visiting:
+			return fmt.Errorf("%w: role hierarchy cycle through %q", ErrInvalidDocument, key)
+		case done:
+			return nil
+		}
+		state[key] = visiting
+		for _, parent := range parents[key] {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[key] = done
+		return nil
+	}
+	for _, r := range d.Roles {
+		if err := visit(r.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
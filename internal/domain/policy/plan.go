@@ -0,0 +1,176 @@
+package policy
+
+import "sort"
+
+type Op string
+
+const (
+	OpCreate Op = "create"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+type Kind string
+
+const (
+	KindPermission        Kind = "permission"
+	KindRole              Kind = "role"
+	KindRoleHierarchy     Kind = "role_hierarchy"
+	KindRolePermission    Kind = "role_permission"
+	KindServiceRole       Kind = "service_role"
+	KindServicePermission Kind = "service_permission"
+)
+
+// Change is a single step needed to converge the database onto a document.
+// Objects are referenced by natural keys (role key, action/resource kind, service key).
+type Change struct {
+	Op           Op     `json:"op"`
+	Kind         Kind   `json:"kind"`
+	Role         string `json:"role,omitempty"`
+	Parent       string `json:"parent,omitempty"`
+	Service      string `json:"service,omitempty"`
+	Action       string `json:"action,omitempty"`
+	ResourceKind string `json:"resource_kind,omitempty"`
+	ResourceID   string `json:"resource_id,omitempty"`
+	Title        string `json:"title,omitempty"`
+}
+
+// Plan is the ordered list of changes; Applied reports whether it was executed.
+type Plan struct {
+	Changes []Change `json:"changes"`
+	Applied bool     `json:"applied"`
+}
+
+// Options tune how a document is reconciled.
+type Options struct {
+	// Prune deletes roles, permissions and links that exist in the database but not in the document.
+	// Without it reconciliation is purely additive.
+	Prune bool
+}
+
+// Diff computes the changes turning current into desired. Creates come first in
+// dependency order, deletes last in reverse dependency order.
+func Diff(desired, current Document, opts Options) Plan {
+	want := index(desired)
+	have := index(current)
+
+	var changes []Change
+	for _, key := range sortedKeys(want.permissions) {
+		if _, ok := have.permissions[key]; !ok {
+			p := want.permissions[key]
+			changes = append(changes, Change{Op: OpCreate, Kind: KindPermission, Action: p.Action, ResourceKind: p.ResourceKind})
+		}
+	}
+	for _, key := range sortedKeys(want.roles) {
+		r := want.roles[key]
+		title := r.Title
+		if title == "" {
+			title = r.Key
+		}
+		cur, ok := have.roles[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Op: OpCreate, Kind: KindRole, Role: key, Title: title})
+		case r.Title != "" && r.Title != cur.Title:
+			changes = append(changes, Change{Op: OpUpdate, Kind: KindRole, Role: key, Title: r.Title})
+		}
+	}
+	changes = append(changes, linkChanges(OpCreate, want.links, have.links)...)
+
+	if opts.Prune {
+		deletes := linkChanges(OpDelete, have.links, want.links)
+		for i, j := 0, len(deletes)-1; i < j; i, j = i+1, j-1 {
+			deletes[i], deletes[j] = deletes[j], deletes[i]
+		}
+		changes = append(changes, deletes...)
+		for _, key := range sortedKeys(have.roles) {
+			if _, ok := want.roles[key]; !ok {
+				changes = append(changes, Change{Op: OpDelete, Kind: KindRole, Role: key})
+			}
+		}
+		for _, key := range sortedKeys(have.permissions) {
+			if _, ok := want.permissions[key]; !ok {
+				p := have.permissions[key]
+				changes = append(changes, Change{Op: OpDelete, Kind: KindPermission, Action: p.Action, ResourceKind: p.ResourceKind})
+			}
+		}
+	}
+	if changes == nil {
+		changes = []Change{}
+	}
+	return Plan{Changes: changes}
+}
+
+// linkKinds fixes the order links are created in; deletes walk it backwards.
+var linkKinds = []Kind{KindRoleHierarchy, KindServiceRole, KindServicePermission, KindRolePermission}
+
+func linkChanges(op Op, from, minus map[Kind]map[string]Change) []Change {
+	var out []Change
+	for _, kind := range linkKinds {
+		for _, key := range sortedKeys(from[kind]) {
+			if _, ok := minus[kind][key]; ok {
+				continue
+			}
+			c := from[kind][key]
+			c.Op = op
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+type documentIndex struct {
+	roles       map[string]Role
+	permissions map[string]Permission
+	links       map[Kind]map[string]Change
+}
+
+func index(doc Document) documentIndex {
+	idx := documentIndex{
+		roles:       make(map[string]Role, len(doc.Roles)),
+		permissions: make(map[string]Permission, len(doc.Permissions)),
+		links:       make(map[Kind]map[string]Change, len(linkKinds)),
+	}
+	for _, kind := range linkKinds {
+		idx.links[kind] = make(map[string]Change)
+	}
+	for _, p := range doc.Permissions {
+		key := PermissionKey(p.Action, p.ResourceKind)
+		idx.permissions[key] = p
+		for _, svc := range p.Services {
+			idx.links[KindServicePermission][svc+"|"+key] = Change{Kind: KindServicePermission, Service: svc, Action: p.Action, ResourceKind: p.ResourceKind}
+		}
+	}
+	for _, r := range doc.Roles {
+		idx.roles[r.Key] = r
+		for _, parent := range r.Inherits {
+			idx.links[KindRoleHierarchy][r.Key+"|"+parent] = Change{Kind: KindRoleHierarchy, Role: r.Key, Parent: parent}
+		}
+		for _, svc := range r.Services {
+			idx.links[KindServiceRole][svc+"|"+r.Key] = Change{Kind: KindServiceRole, Service: svc, Role: r.Key}
+		}
+		for _, raw := range r.Grants {
+			g, err := ParseGrant(raw)
+			if err != nil {
+				continue
+			}
+			idx.links[KindRolePermission][r.Key+"|"+g.String()] = Change{
+				Kind:         KindRolePermission,
+				Role:         r.Key,
+				Action:       g.Action,
+				ResourceKind: g.ResourceKind,
+				ResourceID:   g.ResourceID,
+			}
+		}
+	}
+	return idx
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	current := Document{
+		Version: DocumentVersion,
+		Permissions: []Permission{
+			{Action: "read", ResourceKind: "course", Services: []string{"course"}},
+			{Action: "delete", ResourceKind: "course"},
+		},
+		Roles: []Role{
+			{Key: "student", Title: "Student", Grants: []string{"read:course"}},
+			{Key: "intern", Title: "Intern", Inherits: []string{"student"}, Services: []string{"course"}},
+		},
+	}
+	tests := []struct {
+		name    string
+		desired Document
+		current Document
+		opts    Options
+		want    []Change
+	}{
+		{
+			name: "empty documents",
+			want: []Change{},
+		},
+		{
+			name:    "identical documents",
+			desired: current,
+			current: current,
+			opts:    Options{Prune: true},
+			want:    []Change{},
+		},
+		{
+			name: "creates in dependency order",
+			desired: Document{
+				Permissions: []Permission{
+					{Action: "write", ResourceKind: "course", Services: []string{"course"}},
+					{Action: "read", ResourceKind: "course"},
+				},
+				Roles: []Role{
+					{Key: "teacher", Inherits: []string{"student"}, Services: []string{"course"}, Grants: []string{"write:course", "read:course:c1"}},
+					{Key: "student", Title: "Student", Grants: []string{"read:course"}},
+				},
+			},
+			want: []Change{
+				{Op: OpCreate, Kind: KindPermission, Action: "read", ResourceKind: "course"},
+				{Op: OpCreate, Kind: KindPermission, Action: "write", ResourceKind: "course"},
+				{Op: OpCreate, Kind: KindRole, Role: "student", Title: "Student"},
+				{Op: OpCreate, Kind: KindRole, Role: "teacher", Title: "teacher"},
+				{Op: OpCreate, Kind: KindRoleHierarchy, Role: "teacher", Parent: "student"},
+				{Op: OpCreate, Kind: KindServiceRole, Service: "course", Role: "teacher"},
+				{Op: OpCreate, Kind: KindServicePermission, Service: "course", Action: "write", ResourceKind: "course"},
+				{Op: OpCreate, Kind: KindRolePermission, Role: "student", Action: "read", ResourceKind: "course"},
+				{Op: OpCreate, Kind: KindRolePermission, Role: "teacher", Action: "read", ResourceKind: "course", ResourceID: "c1"},
+				{Op: OpCreate, Kind: KindRolePermission, Role: "teacher", Action: "write", ResourceKind: "course"},
+			},
+		},
+		{
+			name: "updates titles but ignores empty ones",
+			desired: Document{Roles: []Role{
+				{Key: "student", Title: "Learner", Grants: []string{"read:course"}},
+				{Key: "intern", Inherits: []string{"student"}, Services: []string{"course"}},
+			}},
+			current: current,
+			want: []Change{
+				{Op: OpUpdate, Kind: KindRole, Role: "student", Title: "Learner"},
+			},
+		},
+		{
+			name:    "without prune extra objects are kept",
+			desired: Document{Roles: []Role{{Key: "student", Title: "Student"}}},
+			current: current,
+			want:    []Change{},
+		},
+		{
+			name:    "prune deletes links first in reverse order, then roles and permissions",
+			desired: Document{Permissions: []Permission{{Action: "read", ResourceKind: "course"}}, Roles: []Role{{Key: "student", Title: "Student"}}},
+			current: current,
+			opts:    Options{Prune: true},
+			want: []Change{
+				{Op: OpDelete, Kind: KindRolePermission, Role: "student", Action: "read", ResourceKind: "course"},
+				{Op: OpDelete, Kind: KindServicePermission, Service: "course", Action: "read", ResourceKind: "course"},
+				{Op: OpDelete, Kind: KindServiceRole, Service: "course", Role: "intern"},
+				{Op: OpDelete, Kind: KindRoleHierarchy, Role: "intern", Parent: "student"},
+				{Op: OpDelete, Kind: KindRole, Role: "intern"},
+				{Op: OpDelete, Kind: KindPermission, Action: "delete", ResourceKind: "course"},
+			},
+		},
+		{
+			name: "invalid grants are skipped",
+			desired: Document{
+				Permissions: []Permission{{Action: "read", ResourceKind: "course"}},
+				Roles:       []Role{{Key: "student", Title: "Student", Grants: []string{"read", "read:course"}}},
+			},
+			want: []Change{
+				{Op: OpCreate, Kind: KindPermission, Action: "read", ResourceKind: "course"},
+				{Op: OpCreate, Kind: KindRole, Role: "student", Title: "Student"},
+				{Op: OpCreate, Kind: KindRolePermission, Role: "student", Action: "read", ResourceKind: "course"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := Diff(tt.desired, tt.current, tt.opts)
+			if plan.Applied {
+				t.Error("Diff returned an applied plan")
+			}
+			if !reflect.DeepEqual(plan.Changes, tt.want) {
+				t.Errorf("Diff() changes:\n got %+v\nwant %+v", plan.Changes, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package rebac
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxRewriteNesting bounds how deeply unions may nest in a relation definition.
+const maxRewriteNesting = 8
+
+// Namespace configures the relations of one object namespace.
+type Namespace struct {
+	Name string `json:"name"`
+	// Relations maps each relation to the rewrite computing its subjects.
+	Relations map[string]Rewrite `json:"relations"`
+	UpdatedAt time.Time          `json:"updated_at,omitempty"`
+}
+
+// Rewrite computes the subjects of a relation. Exactly one field is set, except that an
+// empty rewrite stands for This, so a plain relation is configured as {}.
+type Rewrite struct {
+	// This is the subjects of the stored tuples for the relation itself.
+	This bool `json:"this,omitempty"`
+	// ComputedUserset is the subjects of another relation on the same object, e.g.
+	// every editor is a viewer.
+	ComputedUserset string `json:"computed_userset,omitempty"`
+	// TupleToUserset follows the tuples of a relation to other objects and takes the
+	// subjects of a relation on those, e.g. viewers of a document's parent folder.
+	TupleToUserset *TupleToUserset `json:"tuple_to_userset,omitempty"`
+	// Union is every subject of any child rewrite.
+	Union []Rewrite `json:"union,omitempty"`
+}
+
+// TupleToUserset reads the Tupleset relation of an object and, for every subject object
+// found, takes the subjects of ComputedUserset on it.
+type TupleToUserset struct {
+	Tupleset        string `json:"tupleset"`
+	ComputedUserset string `json:"computed_userset"`
+}
+
+// IsThis reports whether the rewrite reads stored tuples of the relation itself.
+func (r Rewrite) IsThis() bool {
+	return r.This || (r.ComputedUserset == "" && r.TupleToUserset == nil && r.Union == nil)
+}
+
+// AllowsTuples reports whether stored tuples of a relation defined by r are read, so
+// writing them has an effect.
+func (r Rewrite) AllowsTuples() bool {
+	if r.IsThis() {
+		return true
+	}
+	for _, child := range r.Union {
+		if child.AllowsTuples() {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the namespace name and that every rewrite is well formed and only
+// refers to relations of the namespace. The relation a tuple-to-userset computes on the
+// related objects belongs to their namespace and is resolved at check time.
+func (n Namespace) Validate() error {
+	if err := checkName("namespace", n.Name); err != nil {
+		return err
+	}
+	if len(n.Relations) == 0 {
+		return fmt.Errorf("%w: namespace %s defines no relations", ErrInvalid, n.Name)
+	}
+	for _, name := range n.RelationNames() {
+		if err := checkName("relation", name); err != nil {
+			return err
+		}
+		if err := n.validateRewrite(name, n.Relations[name], 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n Namespace) validateRewrite(relation string, r Rewrite, depth int) error {
+	if depth > maxRewriteNesting {
+		return fmt.Errorf("%w: %s#%s: unions nested deeper than %d levels", ErrInvalid, n.Name, relation, maxRewriteNesting)
+	}
+	set := 0
+	if r.This {
+		set++
+	}
+	if r.ComputedUserset != "" {
+		set++
+		if _, ok := n.Relations[r.ComputedUserset]; !ok {
+			return fmt.Errorf("%w: %s#%s: computed_userset refers to unknown relation %q", ErrInvalid, n.Name, relation, r.ComputedUserset)
+		}
+	}
+	if r.TupleToUserset != nil {
+		set++
+		ttu := r.TupleToUserset
+		tupleset, ok := n.Relations[ttu.Tupleset]
+		if !ok {
+			return fmt.Errorf("%w: %s#%s: tupleset refers to unknown relation %q", ErrInvalid, n.Name, relation, ttu.Tupleset)
+		}
+		if !tupleset.AllowsTuples() {
+			return fmt.Errorf("%w: %s#%s: tupleset relation %q stores no tuples", ErrInvalid, n.Name, relation, ttu.Tupleset)
+		}
+		if err := checkName("relation", ttu.ComputedUserset); err != nil {
+			return fmt.Errorf("%s#%s: tuple_to_userset computed_userset: %w", n.Name, relation, err)
+		}
+	}
+	if r.Union != nil {
+		set++
+		if len(r.Union) == 0 {
+			return fmt.Errorf("%w: %s#%s: union is empty", ErrInvalid, n.Name, relation)
+		}
+		for _, child := range r.Union {
+			if err := n.validateRewrite(relation, child, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("%w: %s#%s: a rewrite sets exactly one of this, computed_userset, tuple_to_userset and union", ErrInvalid, n.Name, relation)
+	}
+	if set == 0 && depth > 0 {
+		return fmt.Errorf("%w: %s#%s: empty rewrite inside a union, use {\"this\": true}", ErrInvalid, n.Name, relation)
+	}
+	return nil
+}
+
+// RelationNames returns the relation names in sorted order.
+func (n Namespace) RelationNames() []string {
+	names := make([]string, 0, len(n.Relations))
+	for name := range n.Relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateTuple checks that a tuple can be stored: its relation must be defined on the
+// object's namespace and read stored tuples, and a userset subject must name a relation
+// of a configured namespace. Plain subjects such as user:alice need no namespace.
+func ValidateTuple(t Tuple, namespaces map[string]*Namespace) error {
+	ns := namespaces[t.Object.Namespace]
+	if ns == nil {
+		return fmt.Errorf("%w: %s: namespace %q is not configured", ErrInvalid, t, t.Object.Namespace)
+	}
+	rewrite, ok := ns.Relations[t.Relation]
+	if !ok {
+		return fmt.Errorf("%w: %s: namespace %s has no relation %q", ErrInvalid, t, ns.Name, t.Relation)
+	}
+	if !rewrite.AllowsTuples() {
+		return fmt.Errorf("%w: %s: relation %s#%s is computed and stores no tuples", ErrInvalid, t, ns.Name, t.Relation)
+	}
+	if t.Subject.Relation == "" {
+		return nil
+	}
+	subjectNS := namespaces[t.Subject.Namespace]
+	if subjectNS == nil {
+		return fmt.Errorf("%w: %s: namespace %q is not configured", ErrInvalid, t, t.Subject.Namespace)
+	}
+	if _, ok := subjectNS.Relations[t.Subject.Relation]; !ok {
+		return fmt.Errorf("%w: %s: namespace %s has no relation %q", ErrInvalid, t, subjectNS.Name, t.Subject.Relation)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package rebac models relationship-based access control in the style of Zanzibar:
+// relation tuples of the form object#relation@subject, and per-namespace configs that
+// derive relations from other relations through userset rewrites.
+package rebac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalid is wrapped by every parse and validation error.
+	ErrInvalid = errors.New("invalid relation tuple")
+	// ErrUnknownRelation reports a check or expand on a relation no namespace defines.
+	ErrUnknownRelation = errors.New("unknown relation")
+	// ErrDepthExceeded reports a check or expand that followed more than MaxDepth
+	// rewrites or usersets.
+	ErrDepthExceeded = errors.New("relation depth exceeded")
+)
+
+// MaxDepth bounds how many rewrites and usersets a single check or expand follows.
+const MaxDepth = 25
+
+// maxIDLength bounds object ids, namespace and relation names.
+const maxIDLength = 256
+
+// Object is a namespaced object such as doc:readme.
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+// Subject is either a plain object such as user:alice or, when Relation is set, the
+// userset of every subject holding that relation on the object, such as group:eng#member.
+type Subject struct {
+	Object
+	Relation string
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Object.String()
+	}
+	return s.Object.String() + "#" + s.Relation
+}
+
+// Tuple states that Subject has Relation on Object.
+type Tuple struct {
+	Object   Object
+	Relation string
+	Subject  Subject
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// MarshalText encodes the tuple as object#relation@subject.
+func (t Tuple) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText parses object#relation@subject.
+func (t *Tuple) UnmarshalText(text []byte) error {
+	parsed, err := ParseTuple(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// ParseTuple parses object#relation@subject, e.g. doc:readme#viewer@group:eng#member.
+func ParseTuple(s string) (Tuple, error) {
+	objectRelation, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, fmt.Errorf("%w: %q: expected object#relation@subject", ErrInvalid, s)
+	}
+	objectPart, relation, ok := strings.Cut(objectRelation, "#")
+	if !ok {
+		return Tuple{}, fmt.Errorf("%w: %q: expected object#relation@subject", ErrInvalid, s)
+	}
+	object, err := ParseObject(objectPart)
+	if err != nil {
+		return Tuple{}, err
+	}
+	if err := checkName("relation", relation); err != nil {
+		return Tuple{}, err
+	}
+	sub, err := ParseSubject(subject)
+	if err != nil {
+		return Tuple{}, err
+	}
+	return Tuple{Object: object, Relation: relation, Subject: sub}, nil
+}
+
+// ParseObject parses namespace:id.
+func ParseObject(s string) (Object, error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok {
+		return Object{}, fmt.Errorf("%w: object %q: expected namespace:id", ErrInvalid, s)
+	}
+	if err := checkName("namespace", namespace); err != nil {
+		return Object{}, err
+	}
+	if id == "" || len(id) > maxIDLength || strings.ContainsAny(id, "#@ \t\r\n") {
+		return Object{}, fmt.Errorf("%w: object id %q", ErrInvalid, id)
+	}
+	return Object{Namespace: namespace, ID: id}, nil
+}
+
+// ParseSubject parses namespace:id or namespace:id#relation.
+func ParseSubject(s string) (Subject, error) {
+	objectPart, relation, hasRelation := strings.Cut(s, "#")
+	object, err := ParseObject(objectPart)
+	if err != nil {
+		return Subject{}, err
+	}
+	if hasRelation {
+		if err := checkName("relation", relation); err != nil {
+			return Subject{}, err
+		}
+	}
+	return Subject{Object: object, Relation: relation}, nil
+}
+
+// checkName accepts lower-case identifiers: a letter, then letters, digits and '_'.
+func checkName(kind, name string) error {
+	if name == "" || len(name) > maxIDLength {
+		return fmt.Errorf("%w: %s %q", ErrInvalid, kind, name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if (c >= 'a' && c <= 'z') || (i > 0 && ((c >= '0' && c <= '9') || c == '_')) {
+			continue
+		}
+		return fmt.Errorf("%w: %s %q must be lower-case letters, digits and '_'", ErrInvalid, kind, name)
+	}
+	return nil
+}
+
+// Store is the contract the ReBAC checker needs for loading state.
+type Store interface {
+	// Namespace returns the config of a namespace, or nil when none is configured.
+	Namespace(ctx context.Context, name string) (*Namespace, error)
+	// Subjects returns the subjects of the stored tuples object#relation@...
+	Subjects(ctx context.Context, object Object, relation string) ([]Subject, error)
+}
+
+// ExpandNode is a node of the userset tree Expand returns for object#relation. Kind is
+// the rewrite that produced it: "this" nodes list the subjects of stored tuples, which
+// may themselves be usersets to expand further; the other kinds combine their Children.
+type ExpandNode struct {
+	Kind     string       `json:"kind"`
+	Userset  string       `json:"userset"`
+	Subjects []string     `json:"subjects,omitempty"`
+	Children []ExpandNode `json:"children,omitempty"`
+}
@@ -0,0 +1,94 @@
+package rebac
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTuple(t *testing.T) {
+	for _, s := range []string{
+		"doc:readme#viewer@user:alice",
+		"doc:readme#viewer@group:eng#member",
+		"folder:2f1c-9a#parent@folder:root",
+	} {
+		tuple, err := ParseTuple(s)
+		if err != nil {
+			t.Errorf("ParseTuple(%q): %v", s, err)
+			continue
+		}
+		if got := tuple.String(); got != s {
+			t.Errorf("ParseTuple(%q).String() = %q", s, got)
+		}
+	}
+	for _, s := range []string{
+		"",
+		"doc:readme#viewer",
+		"doc:readme@user:alice",
+		"doc#viewer@user:alice",
+		"doc:#viewer@user:alice",
+		"Doc:readme#viewer@user:alice",
+		"doc:readme#Viewer@user:alice",
+		"doc:read me#viewer@user:alice",
+		"doc:readme#viewer@user:alice#",
+		"doc:readme#viewer@user",
+	} {
+		if _, err := ParseTuple(s); !errors.Is(err, ErrInvalid) {
+			t.Errorf("ParseTuple(%q) error = %v, want ErrInvalid", s, err)
+		}
+	}
+}
+
+func TestNamespaceValidate(t *testing.T) {
+	valid := Namespace{Name: "doc", Relations: map[string]Rewrite{
+		"parent": {},
+		"editor": {},
+		"viewer": {Union: []Rewrite{
+			{This: true},
+			{ComputedUserset: "editor"},
+			{TupleToUserset: &TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}},
+		}},
+	}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	tests := map[string]map[string]Rewrite{
+		"no relations":           {},
+		"unknown computed":       {"viewer": {ComputedUserset: "owner"}},
+		"unknown tupleset":       {"viewer": {TupleToUserset: &TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}}},
+		"computed tupleset":      {"editor": {}, "parent": {ComputedUserset: "editor"}, "viewer": {TupleToUserset: &TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}}},
+		"two kinds":              {"editor": {}, "viewer": {This: true, ComputedUserset: "editor"}},
+		"empty union":            {"viewer": {Union: []Rewrite{}}},
+		"empty rewrite in union": {"viewer": {Union: []Rewrite{{}}}},
+		"bad relation name":      {"Viewer": {}},
+	}
+	for name, relations := range tests {
+		ns := Namespace{Name: "doc", Relations: relations}
+		if err := ns.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Validate error = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestValidateTuple(t *testing.T) {
+	namespaces := map[string]*Namespace{
+		"doc":   {Name: "doc", Relations: map[string]Rewrite{"owner": {}, "viewer": {ComputedUserset: "owner"}}},
+		"group": {Name: "group", Relations: map[string]Rewrite{"member": {}}},
+	}
+	for s, ok := range map[string]bool{
+		"doc:a#owner@user:alice":       true,
+		"doc:a#owner@group:eng#member": true,
+		"doc:a#viewer@user:alice":      false,
+		"doc:a#editor@user:alice":      false,
+		"folder:a#owner@user:alice":    false,
+		"doc:a#owner@group:eng#admin":  false,
+		"doc:a#owner@team:eng#member":  false,
+	} {
+		tuple, err := ParseTuple(s)
+		if err != nil {
+			t.Fatalf("ParseTuple(%q): %v", s, err)
+		}
+		if err := ValidateTuple(tuple, namespaces); (err == nil) != ok {
+			t.Errorf("ValidateTuple(%s) = %v, want ok=%v", s, err, ok)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+)
+
+// AccessUsecase answers reverse lookups: which principals are allowed a request.
+type AccessUsecase struct {
+	repo *repo.PDPRepository
+}
+
+func NewAccessUsecase(r *repo.PDPRepository) *AccessUsecase {
+	return &AccessUsecase{repo: r}
+}
+
+// ListPrincipals returns every principal Check would allow for req and why. The
+// principal fields of req are ignored.
+func (uc *AccessUsecase) ListPrincipals(ctx context.Context, req domainpdp.CheckRequest) ([]domainpdp.PrincipalAccess, error) {
+	if req.Action == "" || req.ResourceKind == "" {
+		return nil, fmt.Errorf("%w: action and resource_kind are required", ErrValidation)
+	}
+	return uc.repo.ListAccess(ctx, req)
+}
@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// AuditUsecase queries the audit log.
+type AuditUsecase struct {
+	repo *repo.AuditRepository
+}
+
+// NewAuditUsecase constructs a new AuditUsecase instance.
+func NewAuditUsecase(r *repo.AuditRepository) *AuditUsecase {
+	return &AuditUsecase{repo: r}
+}
+
+// List returns audit entries matching filter, newest first.
+func (uc *AuditUsecase) List(ctx context.Context, filter audit.Filter, params pagination.Params) ([]audit.Entry, int64, error) {
+	return uc.repo.List(ctx, filter, params.Offset(), params.PageSize)
+}
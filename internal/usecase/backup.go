@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/policy"
+)
+
+// BackupUsecase exports and restores the complete RBAC state.
+type BackupUsecase struct {
+	repo *repo.BackupRepository
+}
+
+// NewBackupUsecase constructs a new BackupUsecase instance.
+func NewBackupUsecase(r *repo.BackupRepository) *BackupUsecase {
+	return &BackupUsecase{repo: r}
+}
+
+// Export returns a versioned copy of every RBAC table.
+func (uc *BackupUsecase) Export(ctx context.Context) (policy.Backup, error) {
+	return uc.repo.Export(ctx)
+}
+
+// Import validates and restores a backup in a single transaction.
+func (uc *BackupUsecase) Import(ctx context.Context, backup policy.Backup, opts policy.ImportOptions) (policy.ImportReport, error) {
+	if err := backup.Validate(); err != nil {
+		return policy.ImportReport{}, err
+	}
+	return uc.repo.Import(ctx, backup, opts)
+}
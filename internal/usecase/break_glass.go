@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+const (
+	BreakGlassActivated   = "activated"
+	BreakGlassDenied      = "denied"
+	BreakGlassDeactivated = "deactivated"
+	BreakGlassExpired     = "expired"
+	BreakGlassReviewed    = "reviewed"
+)
+
+// BreakGlassEvent is published for every break-glass state change and failed attempt.
+type BreakGlassEvent struct {
+	Type          string                     `json:"type"`
+	OccurredAt    time.Time                  `json:"occurred_at"`
+	PrincipalID   string                     `json:"principal_id"`
+	PrincipalKind model.PrincipalKind        `json:"principal_kind"`
+	Reason        string                     `json:"reason,omitempty"`
+	Activation    *repo.BreakGlassActivation `json:"activation,omitempty"`
+}
+
+// BreakGlassPublisher announces break-glass events.
+type BreakGlassPublisher interface {
+	PublishBreakGlass(ctx context.Context, event BreakGlassEvent) error
+}
+
+// BreakGlassUsecase lets pre-registered principals activate temporary superadmin with a
+// sealed secret when normal access paths are unavailable.
+type BreakGlassUsecase struct {
+	repo        *repo.BreakGlassRepository
+	publisher   BreakGlassPublisher
+	maxDuration time.Duration
+}
+
+// NewBreakGlassUsecase constructs a new BreakGlassUsecase instance. maxDuration caps the
+// duration any registration may allow; publisher may be nil.
+func NewBreakGlassUsecase(r *repo.BreakGlassRepository, publisher BreakGlassPublisher, maxDuration time.Duration) *BreakGlassUsecase {
+	return &BreakGlassUsecase{repo: r, publisher: publisher, maxDuration: maxDuration}
+}
+
+// Register enrols the principal and returns its new secret. The secret is shown only
+// once; only its hash is stored. Registering again rotates the secret.
+func (uc *BreakGlassUsecase) Register(ctx context.Context, principalID string, kind model.PrincipalKind, maxDuration time.Duration) (string, error) {
+	if maxDuration <= 0 {
+		maxDuration = uc.maxDuration
+	}
+	if maxDuration < time.Minute || maxDuration > uc.maxDuration {
+		return "", fmt.Errorf("%w: max duration must be between 1m and %s", ErrValidation, uc.maxDuration)
+	}
+	secret, err := newSecret("bg_")
+	if err != nil {
+		return "", err
+	}
+	p := repo.BreakGlassPrincipal{PrincipalID: principalID, PrincipalKind: kind, MaxDurationSeconds: int(maxDuration / time.Second)}
+	if err := uc.repo.Register(ctx, p, hashSecret(secret)); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (uc *BreakGlassUsecase) Unregister(ctx context.Context, principalID string, kind model.PrincipalKind) error {
+	return uc.repo.Unregister(ctx, principalID, kind)
+}
+
+func (uc *BreakGlassUsecase) ListPrincipals(ctx context.Context) ([]repo.BreakGlassPrincipal, error) {
+	return uc.repo.ListPrincipals(ctx)
+}
+
+// Activate verifies the secret and grants superadmin for duration, or for the
+// registration's maximum when duration is zero. Failed attempts are audited and
+// published but report only ErrForbidden, whatever the cause.
+func (uc *BreakGlassUsecase) Activate(ctx context.Context, principalID string, kind model.PrincipalKind, secret, reason string, duration time.Duration) (repo.BreakGlassActivation, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return repo.BreakGlassActivation{}, fmt.Errorf("%w: reason is required", ErrValidation)
+	}
+	p, hash, err := uc.repo.Credential(ctx, principalID, kind)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return repo.BreakGlassActivation{}, err
+	}
+	if err != nil || !secretMatches(hash, secret) {
+		if err := uc.repo.RecordDenied(ctx, principalID, kind, reason); err != nil {
+			return repo.BreakGlassActivation{}, err
+		}
+		uc.publish(ctx, BreakGlassEvent{Type: BreakGlassDenied, PrincipalID: principalID, PrincipalKind: kind, Reason: reason})
+		return repo.BreakGlassActivation{}, fmt.Errorf("%w: break-glass activation denied", ErrForbidden)
+	}
+	maxDuration := time.Duration(p.MaxDurationSeconds) * time.Second
+	if duration <= 0 {
+		duration = maxDuration
+	}
+	if duration > maxDuration {
+		return repo.BreakGlassActivation{}, fmt.Errorf("%w: duration must not exceed %s", ErrValidation, maxDuration)
+	}
+	activation, err := uc.repo.Activate(ctx, principalID, kind, reason, duration)
+	if err != nil {
+		return repo.BreakGlassActivation{}, err
+	}
+	uc.publish(ctx, BreakGlassEvent{Type: BreakGlassActivated, PrincipalID: principalID, PrincipalKind: kind, Reason: reason, Activation: &activation})
+	return activation, nil
+}
+
+// Deactivate ends an activation before it expires.
+func (uc *BreakGlassUsecase) Deactivate(ctx context.Context, id string) (repo.BreakGlassActivation, error) {
+	activation, err := uc.repo.Deactivate(ctx, id)
+	if err != nil {
+		return repo.BreakGlassActivation{}, err
+	}
+	uc.publish(ctx, BreakGlassEvent{Type: BreakGlassDeactivated, PrincipalID: activation.PrincipalID, PrincipalKind: activation.PrincipalKind, Activation: &activation})
+	return activation, nil
+}
+
+// EndExpired closes activations past their expiry and publishes an event for each.
+func (uc *BreakGlassUsecase) EndExpired(ctx context.Context) (int, error) {
+	ended, err := uc.repo.EndExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range ended {
+		a := ended[i]
+		uc.publish(ctx, BreakGlassEvent{Type: BreakGlassExpired, PrincipalID: a.PrincipalID, PrincipalKind: a.PrincipalKind, Activation: &a})
+	}
+	return len(ended), nil
+}
+
+func (uc *BreakGlassUsecase) ListActivations(ctx context.Context, filter repo.BreakGlassFilter, params pagination.Params) ([]repo.BreakGlassActivation, int64, error) {
+	return uc.repo.ListActivations(ctx, filter, params.Offset(), params.PageSize)
+}
+
+func (uc *BreakGlassUsecase) GetActivation(ctx context.Context, id string) (repo.BreakGlassActivation, error) {
+	return uc.repo.GetActivation(ctx, id)
+}
+
+// Review records the mandatory after-the-fact review of an ended activation in the name
+// of the calling user, as set by the authenticating gateway in X-Actor-ID. The principal
+// who used break-glass cannot review their own activation.
+func (uc *BreakGlassUsecase) Review(ctx context.Context, id, notes string) (repo.BreakGlassActivation, error) {
+	reviewer := audit.ActorFromContext(ctx).ID
+	if reviewer == "" {
+		return repo.BreakGlassActivation{}, fmt.Errorf("%w: the caller must be identified to review break-glass activations", ErrUnauthenticated)
+	}
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return repo.BreakGlassActivation{}, fmt.Errorf("%w: notes are required", ErrValidation)
+	}
+	current, err := uc.repo.GetActivation(ctx, id)
+	if err != nil {
+		return repo.BreakGlassActivation{}, err
+	}
+	if current.PrincipalID == reviewer && current.PrincipalKind == model.PrincipalKindUser {
+		return repo.BreakGlassActivation{}, fmt.Errorf("%w: principals cannot review their own break-glass activation", ErrForbidden)
+	}
+	activation, err := uc.repo.Review(ctx, id, reviewer, notes)
+	if err != nil {
+		return repo.BreakGlassActivation{}, err
+	}
+	uc.publish(ctx, BreakGlassEvent{Type: BreakGlassReviewed, PrincipalID: activation.PrincipalID, PrincipalKind: activation.PrincipalKind, Activation: &activation})
+	return activation, nil
+}
+
+// publish is best effort: break-glass has to keep working while NATS is down, and the
+// audit log already holds the durable record.
+func (uc *BreakGlassUsecase) publish(ctx context.Context, event BreakGlassEvent) {
+	if uc.publisher == nil {
+		return
+	}
+	event.OccurredAt = time.Now().UTC()
+	_ = uc.publisher.PublishBreakGlass(ctx, event)
+}
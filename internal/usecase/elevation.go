@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/audit"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// Approvers of an elevation request need this permission on the requested role,
+// either globally or narrowed to the role id (approve:role:<role-id>).
+const (
+	ElevationApproveAction       = "approve"
+	ElevationApproveResourceKind = "role"
+)
+
+// Authorizer evaluates PDP decisions; *pdp.Engine satisfies it.
+type Authorizer interface {
+	Check(ctx context.Context, req domainpdp.CheckRequest) (domainpdp.CheckResult, error)
+}
+
+// ElevationUsecase runs the just-in-time elevation workflow: principals request a role
+// for a limited time, approvers holding approve:role decide, and approved requests become
+// time-bound role assignments.
+type ElevationUsecase struct {
+	repo        *repo.ElevationRepository
+	authz       Authorizer
+	maxDuration time.Duration
+	pendingTTL  time.Duration
+}
+
+// NewElevationUsecase constructs a new ElevationUsecase instance. maxDuration caps how
+// long a role can be granted for and pendingTTL how long a request waits for a decision.
+func NewElevationUsecase(r *repo.ElevationRepository, authz Authorizer, maxDuration, pendingTTL time.Duration) *ElevationUsecase {
+	return &ElevationUsecase{repo: r, authz: authz, maxDuration: maxDuration, pendingTTL: pendingTTL}
+}
+
+// Request files a pending elevation request.
+func (uc *ElevationUsecase) Request(ctx context.Context, input repo.ElevationRequestInput) (repo.ElevationRequest, error) {
+	input.Justification = strings.TrimSpace(input.Justification)
+	if input.Justification == "" {
+		return repo.ElevationRequest{}, fmt.Errorf("%w: justification is required", ErrValidation)
+	}
+	if input.Duration < time.Minute {
+		return repo.ElevationRequest{}, fmt.Errorf("%w: duration must be at least one minute", ErrValidation)
+	}
+	if uc.maxDuration > 0 && input.Duration > uc.maxDuration {
+		return repo.ElevationRequest{}, fmt.Errorf("%w: duration must not exceed %s", ErrValidation, uc.maxDuration)
+	}
+	input.PendingTTL = uc.pendingTTL
+	return uc.repo.Create(ctx, input)
+}
+
+func (uc *ElevationUsecase) Get(ctx context.Context, id string) (repo.ElevationRequest, error) {
+	return uc.repo.Get(ctx, id)
+}
+
+func (uc *ElevationUsecase) List(ctx context.Context, filter repo.ElevationFilter, params pagination.Params) ([]repo.ElevationRequest, int64, error) {
+	return uc.repo.List(ctx, filter, params.Offset(), params.PageSize)
+}
+
+// Approve grants the requested role once the caller is authorised to approve it.
+func (uc *ElevationUsecase) Approve(ctx context.Context, id, note string) (repo.ElevationRequest, error) {
+	decision, err := uc.authorize(ctx, id, note)
+	if err != nil {
+		return repo.ElevationRequest{}, err
+	}
+	return uc.repo.Approve(ctx, id, decision)
+}
+
+// Reject closes the request without granting anything.
+func (uc *ElevationUsecase) Reject(ctx context.Context, id, note string) (repo.ElevationRequest, error) {
+	decision, err := uc.authorize(ctx, id, note)
+	if err != nil {
+		return repo.ElevationRequest{}, err
+	}
+	return uc.repo.Reject(ctx, id, decision)
+}
+
+// ExpirePending closes requests nobody decided on in time.
+func (uc *ElevationUsecase) ExpirePending(ctx context.Context) (int, error) {
+	return uc.repo.ExpirePending(ctx)
+}
+
+// authorize checks that the caller holds approve:role for the requested role in the
+// request's tenant and service and returns the decision made in their name. Principals
+// may not decide their own requests.
+func (uc *ElevationUsecase) authorize(ctx context.Context, id, note string) (repo.ElevationDecision, error) {
+	decision, err := approverFromContext(ctx)
+	if err != nil {
+		return repo.ElevationDecision{}, err
+	}
+	decision.Note = note
+	req, err := uc.repo.Get(ctx, id)
+	if err != nil {
+		return repo.ElevationDecision{}, err
+	}
+	if req.PrincipalID == decision.ApproverID && req.PrincipalKind == decision.ApproverKind {
+		return repo.ElevationDecision{}, fmt.Errorf("%w: principals cannot decide their own elevation requests", ErrForbidden)
+	}
+	result, err := uc.authz.Check(ctx, domainpdp.CheckRequest{
+		PrincipalID:   decision.ApproverID,
+		PrincipalKind: decision.ApproverKind,
+		TenantID:      req.TenantID,
+		ServiceID:     req.ServiceID,
+		Action:        ElevationApproveAction,
+		ResourceKind:  ElevationApproveResourceKind,
+		ResourceID:    &req.RoleID,
+	})
+	if err != nil {
+		return repo.ElevationDecision{}, err
+	}
+	if !result.Allow {
+		return repo.ElevationDecision{}, fmt.Errorf("%w: %s:%s required to decide requests for role %s", ErrForbidden, ElevationApproveAction, ElevationApproveResourceKind, req.RoleKey)
+	}
+	return decision, nil
+}
+
+// approverFromContext identifies who decides a request: the user the admin API
+// attributes the call to, as set by the authenticating gateway in X-Actor-ID, never a
+// value taken from the request body.
+func approverFromContext(ctx context.Context) (repo.ElevationDecision, error) {
+	actor := audit.ActorFromContext(ctx)
+	if actor.ID == "" {
+		return repo.ElevationDecision{}, fmt.Errorf("%w: the caller must be identified to decide elevation requests", ErrUnauthenticated)
+	}
+	return repo.ElevationDecision{ApproverID: actor.ID, ApproverKind: model.PrincipalKindUser}, nil
+}
@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrValidation = errors.New("validation error")
+	ErrForbidden  = errors.New("forbidden")
+	// ErrUnauthenticated reports missing, unknown or wrong credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
 )
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+)
+
+// GrantExpiryPublisher announces grants removed by the sweeper.
+type GrantExpiryPublisher interface {
+	PublishExpired(ctx context.Context, grants []repo.ExpiredGrant) error
+}
+
+// GrantExpiryUsecase removes role assignments and overrides whose validity window ended.
+type GrantExpiryUsecase struct {
+	repo      *repo.GrantExpiryRepository
+	publisher GrantExpiryPublisher
+	archive   bool
+}
+
+// NewGrantExpiryUsecase constructs a new GrantExpiryUsecase instance. When archive is set
+// expired grants are copied to the archive tables instead of being discarded; publisher
+// may be nil.
+func NewGrantExpiryUsecase(r *repo.GrantExpiryRepository, publisher GrantExpiryPublisher, archive bool) *GrantExpiryUsecase {
+	return &GrantExpiryUsecase{repo: r, publisher: publisher, archive: archive}
+}
+
+// Sweep removes expired grants and publishes an event for each one. Events are sent
+// after the removal commits, so a publish failure never resurrects a grant.
+func (uc *GrantExpiryUsecase) Sweep(ctx context.Context) ([]repo.ExpiredGrant, error) {
+	expired, err := uc.repo.SweepExpired(ctx, uc.archive)
+	if err != nil || len(expired) == 0 || uc.publisher == nil {
+		return expired, err
+	}
+	return expired, uc.publisher.PublishExpired(ctx, expired)
+}
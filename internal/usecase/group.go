@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// GroupUsecase manages groups and their membership. Members inherit the roles assigned
+// to the group, including through nested groups.
+type GroupUsecase struct {
+	repo *repo.GroupRepository
+}
+
+func NewGroupUsecase(r *repo.GroupRepository) *GroupUsecase {
+	return &GroupUsecase{repo: r}
+}
+
+func (uc *GroupUsecase) Create(ctx context.Context, key, title string) (*repo.Group, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("%w: key is required", ErrValidation)
+	}
+	group := &repo.Group{Key: key, Title: title}
+	if err := uc.repo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (uc *GroupUsecase) Update(ctx context.Context, id, title string) error {
+	return uc.repo.Update(ctx, id, title)
+}
+
+func (uc *GroupUsecase) Get(ctx context.Context, id string) (*repo.Group, error) {
+	return uc.repo.Get(ctx, id)
+}
+
+func (uc *GroupUsecase) List(ctx context.Context, params pagination.Params) ([]repo.Group, int64, error) {
+	return uc.repo.List(ctx, params.Offset(), params.PageSize)
+}
+
+func (uc *GroupUsecase) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
+
+func (uc *GroupUsecase) AddMember(ctx context.Context, member repo.GroupMember) error {
+	if err := validateMember(member); err != nil {
+		return err
+	}
+	return uc.repo.AddMember(ctx, member)
+}
+
+func (uc *GroupUsecase) RemoveMember(ctx context.Context, member repo.GroupMember) error {
+	if err := validateMember(member); err != nil {
+		return err
+	}
+	return uc.repo.RemoveMember(ctx, member)
+}
+
+func (uc *GroupUsecase) ListMembers(ctx context.Context, groupID string, params pagination.Params) ([]repo.GroupMember, int64, error) {
+	return uc.repo.ListMembers(ctx, groupID, params.Offset(), params.PageSize)
+}
+
+// ListByPrincipal returns the groups the principal belongs to, directly or transitively.
+func (uc *GroupUsecase) ListByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) ([]repo.Group, error) {
+	return uc.repo.ListByPrincipal(ctx, principalID, kind)
+}
+
+func validateMember(member repo.GroupMember) error {
+	if member.GroupID == "" || member.MemberID == "" {
+		return fmt.Errorf("%w: group_id and member_id are required", ErrValidation)
+	}
+	if member.MemberKind != "" && !member.MemberKind.Valid() {
+		return fmt.Errorf("%w: unknown member_kind %q", ErrValidation, member.MemberKind)
+	}
+	return nil
+}
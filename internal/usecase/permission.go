@@ -31,6 +31,10 @@ func (uc *PermissionUsecase) Get(ctx context.Context, id string) (*repo.Permissi
 	return uc.repo.Get(ctx, id)
 }
 
-func (uc *PermissionUsecase) List(ctx context.Context, params pagination.Params) ([]repo.Permission, int64, error) {
-	return uc.repo.List(ctx, params.Offset(), params.PageSize)
+func (uc *PermissionUsecase) List(ctx context.Context, filter repo.PermissionListFilter, params pagination.Params) ([]repo.Permission, int64, error) {
+	return uc.repo.List(ctx, filter, params.Offset(), params.PageSize)
+}
+
+func (uc *PermissionUsecase) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
 }
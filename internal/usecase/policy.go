@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/policy"
+)
+
+// PolicyUsecase reconciles declarative policy documents with the stored state.
+type PolicyUsecase struct {
+	repo *repo.PolicyRepository
+}
+
+// NewPolicyUsecase constructs a new PolicyUsecase instance.
+func NewPolicyUsecase(r *repo.PolicyRepository) *PolicyUsecase {
+	return &PolicyUsecase{repo: r}
+}
+
+// Get returns the current state as a policy document.
+func (uc *PolicyUsecase) Get(ctx context.Context) (policy.Document, error) {
+	return uc.repo.Current(ctx)
+}
+
+// Plan computes the changes needed to converge onto doc without applying them.
+func (uc *PolicyUsecase) Plan(ctx context.Context, doc policy.Document, opts policy.Options) (policy.Plan, error) {
+	if err := doc.Validate(); err != nil {
+		return policy.Plan{}, err
+	}
+	return uc.repo.Sync(ctx, doc, opts, false)
+}
+
+// Apply converges the database onto doc in a single transaction.
+func (uc *PolicyUsecase) Apply(ctx context.Context, doc policy.Document, opts policy.Options) (policy.Plan, error) {
+	if err := doc.Validate(); err != nil {
+		return policy.Plan{}, err
+	}
+	return uc.repo.Sync(ctx, doc, opts, true)
+}
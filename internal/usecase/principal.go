@@ -2,9 +2,14 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/example/ms-rbac-service/pkg/pagination"
 )
 
 // PrincipalRoleUsecase handles principal role assignments.
@@ -18,26 +23,26 @@ func NewPrincipalRoleUsecase(r *repo.PrincipalRoleRepository) *PrincipalRoleUsec
 }
 
 // Update updates the principal's role assignment.
-func (uc *PrincipalRoleUsecase) Update(ctx context.Context, principalID string, input repo.PrincipalRoleUpdate) error {
+func (uc *PrincipalRoleUsecase) Update(ctx context.Context, principalID string, kind model.PrincipalKind, input repo.PrincipalRoleUpdate) error {
 	input.RoleKey = strings.TrimSpace(input.RoleKey)
-	current, err := uc.repo.Get(ctx, principalID)
+	current, err := uc.repo.Get(ctx, principalID, kind)
 	if err != nil {
 		return err
 	}
 	if current == input.RoleKey && current != "" {
 		return nil
 	}
-	return uc.repo.Update(ctx, principalID, input)
+	return uc.repo.Update(ctx, principalID, kind, input)
 }
 
 // Get returns the role key associated with the principal.
-func (uc *PrincipalRoleUsecase) Get(ctx context.Context, principalID string) (string, error) {
-	return uc.repo.Get(ctx, principalID)
+func (uc *PrincipalRoleUsecase) Get(ctx context.Context, principalID string, kind model.PrincipalKind) (string, error) {
+	return uc.repo.Get(ctx, principalID, kind)
 }
 
 // GetByRole checks whether the principal has the provided role.
-func (uc *PrincipalRoleUsecase) GetByRole(ctx context.Context, principalID, role string) (bool, error) {
-	current, err := uc.Get(ctx, principalID)
+func (uc *PrincipalRoleUsecase) GetByRole(ctx context.Context, principalID string, kind model.PrincipalKind, role string) (bool, error) {
+	current, err := uc.Get(ctx, principalID, kind)
 	if err != nil {
 		return false, err
 	}
@@ -45,60 +50,138 @@ func (uc *PrincipalRoleUsecase) GetByRole(ctx context.Context, principalID, role
 	return current == role && current != "", nil
 }
 
+// Assign adds a scoped role assignment, optionally bounded by a validity window.
+func (uc *PrincipalRoleUsecase) Assign(ctx context.Context, input repo.PrincipalRoleAssignment) error {
+	if err := validateWindow(input.ValidFrom, input.ValidUntil); err != nil {
+		return err
+	}
+	return uc.repo.Assign(ctx, input)
+}
+
+// Revoke removes a scoped role assignment.
+func (uc *PrincipalRoleUsecase) Revoke(ctx context.Context, input repo.PrincipalRoleAssignment) error {
+	return uc.repo.Revoke(ctx, input)
+}
+
+// ListByPrincipal returns every role assignment of the principal.
+func (uc *PrincipalRoleUsecase) ListByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) ([]repo.PrincipalRoleAssignment, error) {
+	return uc.repo.ListByPrincipal(ctx, principalID, kind)
+}
+
+// ListByRole returns the role's direct assignments matching filter.
+func (uc *PrincipalRoleUsecase) ListByRole(ctx context.Context, roleID string, filter repo.RoleMemberFilter, params pagination.Params) ([]repo.PrincipalRoleAssignment, int64, error) {
+	return uc.repo.ListByRole(ctx, roleID, filter, params.Offset(), params.PageSize)
+}
+
+// RemoveMembers revokes the role from principals in bulk. Without principals the scope
+// filter alone selects the assignments, so at least one of them must be given to
+// avoid clearing the role by accident.
+func (uc *PrincipalRoleUsecase) RemoveMembers(ctx context.Context, roleID string, filter repo.RoleMemberFilter, principals []repo.RoleMember) (int64, error) {
+	for i, p := range principals {
+		principals[i].PrincipalID = strings.TrimSpace(p.PrincipalID)
+		if principals[i].PrincipalID == "" {
+			return 0, fmt.Errorf("%w: principal_id is required for every principal", ErrValidation)
+		}
+		if p.PrincipalKind != "" && !p.PrincipalKind.Valid() {
+			return 0, fmt.Errorf("%w: unknown principal_kind %q", ErrValidation, p.PrincipalKind)
+		}
+	}
+	if len(principals) == 0 && filter == (repo.RoleMemberFilter{}) {
+		return 0, fmt.Errorf("%w: principals or a scope filter is required", ErrValidation)
+	}
+	return uc.repo.RemoveMembers(ctx, roleID, filter, principals)
+}
+
 // PrincipalPermissionUsecase resolves permissions for principals.
 type PrincipalPermissionUsecase struct {
 	roleRepo       *repo.PrincipalRoleRepository
 	permissionRepo *repo.RolePermissionRepository
+	pdpRepo        *repo.PDPRepository
 }
 
 // NewPrincipalPermissionUsecase constructs a new PrincipalPermissionUsecase instance.
-func NewPrincipalPermissionUsecase(roleRepo *repo.PrincipalRoleRepository, permissionRepo *repo.RolePermissionRepository) *PrincipalPermissionUsecase {
-	return &PrincipalPermissionUsecase{roleRepo: roleRepo, permissionRepo: permissionRepo}
+func NewPrincipalPermissionUsecase(roleRepo *repo.PrincipalRoleRepository, permissionRepo *repo.RolePermissionRepository, pdpRepo *repo.PDPRepository) *PrincipalPermissionUsecase {
+	return &PrincipalPermissionUsecase{roleRepo: roleRepo, permissionRepo: permissionRepo, pdpRepo: pdpRepo}
 }
 
-// List returns the permission identifiers for the principal's current role.
-func (uc *PrincipalPermissionUsecase) List(ctx context.Context, principalID string) ([]string, error) {
-	role, err := uc.roleRepo.Get(ctx, principalID)
+// Effective returns every permission the principal holds with its scope and source.
+// Non-empty tenantID and serviceID drop permissions that cannot apply there.
+func (uc *PrincipalPermissionUsecase) Effective(ctx context.Context, principalID string, kind model.PrincipalKind, tenantID, serviceID string) (domainpdp.EffectivePermissions, error) {
+	result, err := uc.pdpRepo.EffectivePermissions(ctx, principalID, kind)
+	if err != nil || (tenantID == "" && serviceID == "") {
+		return result, err
+	}
+	perms := result.Permissions[:0]
+	for _, perm := range result.Permissions {
+		if perm.Applies(tenantID, serviceID) {
+			perms = append(perms, perm)
+		}
+	}
+	result.Permissions = perms
+	return result, nil
+}
+
+// List returns the permission identifiers for the principal's current role and the
+// roles it inherits from its groups. Grants narrowed to a resource instance are
+// suffixed with ":<resource_id>".
+func (uc *PrincipalPermissionUsecase) List(ctx context.Context, principalID string, kind model.PrincipalKind) ([]string, error) {
+	role, err := uc.roleRepo.Get(ctx, principalID, kind)
 	if err != nil {
 		return nil, err
 	}
-	role = strings.TrimSpace(role)
-	if role == "" {
-		return []string{}, nil
-	}
-	perms, err := uc.permissionRepo.ListByRoleKey(ctx, role)
+	roles, err := uc.roleRepo.InheritedRoleKeys(ctx, principalID, kind)
 	if err != nil {
 		return nil, err
 	}
-	seen := make(map[string]struct{}, len(perms))
-	result := make([]string, 0, len(perms))
-	for _, perm := range perms {
-		if identifier := permissionIdentifier(perm); identifier != "" {
-			if _, ok := seen[identifier]; ok {
-				continue
+	if role = strings.TrimSpace(role); role != "" {
+		roles = append([]string{role}, roles...)
+	}
+	seen := make(map[string]struct{})
+	result := make([]string, 0)
+	for _, key := range roles {
+		perms, err := uc.permissionRepo.ListByRoleKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, perm := range perms {
+			if identifier := permissionIdentifier(perm); identifier != "" {
+				if _, ok := seen[identifier]; ok {
+					continue
+				}
+				seen[identifier] = struct{}{}
+				result = append(result, identifier)
 			}
-			seen[identifier] = struct{}{}
-			result = append(result, identifier)
 		}
 	}
 	return result, nil
 }
 
 // GetByPermission checks whether the principal has the requested permission.
-func (uc *PrincipalPermissionUsecase) GetByPermission(ctx context.Context, principalID, permission string) (bool, error) {
-	perms, err := uc.List(ctx, principalID)
+// A permission qualified with a resource id ("read:course:<id>") is also
+// satisfied by the unscoped grant ("read:course"), and wildcard grants such as
+// "read:*" or "*:course.*" cover the permissions they match.
+func (uc *PrincipalPermissionUsecase) GetByPermission(ctx context.Context, principalID string, kind model.PrincipalKind, permission string) (bool, error) {
+	perms, err := uc.List(ctx, principalID, kind)
 	if err != nil {
 		return false, err
 	}
 	for _, p := range perms {
-		if p == permission {
+		if domainpdp.MatchIdentifier(p, permission) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-func permissionIdentifier(perm repo.Permission) string {
+func permissionIdentifier(grant repo.RolePermissionGrant) string {
+	identifier := baseIdentifier(grant.Permission)
+	if identifier != "" && grant.ResourceID != nil {
+		identifier += ":" + *grant.ResourceID
+	}
+	return identifier
+}
+
+func baseIdentifier(perm repo.Permission) string {
 	switch {
 	case perm.Action == "" && perm.ResourceKind == "":
 		return ""
@@ -110,3 +193,11 @@ func permissionIdentifier(perm repo.Permission) string {
 		return perm.ResourceKind
 	}
 }
+
+// validateWindow rejects validity windows that end before they start.
+func validateWindow(from, until *time.Time) error {
+	if from != nil && until != nil && !until.After(*from) {
+		return fmt.Errorf("%w: valid_until must be after valid_from", ErrValidation)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+)
+
+// PrincipalOverrideUsecase manages per-principal allow/deny overrides.
+type PrincipalOverrideUsecase struct {
+	repo *repo.PrincipalOverrideRepository
+}
+
+// NewPrincipalOverrideUsecase constructs a new PrincipalOverrideUsecase instance.
+func NewPrincipalOverrideUsecase(r *repo.PrincipalOverrideRepository) *PrincipalOverrideUsecase {
+	return &PrincipalOverrideUsecase{repo: r}
+}
+
+// Set creates or replaces an override.
+func (uc *PrincipalOverrideUsecase) Set(ctx context.Context, input repo.PrincipalOverrideInput) error {
+	if input.Effect != model.OverrideEffectAllow && input.Effect != model.OverrideEffectDeny {
+		return fmt.Errorf("%w: effect must be allow or deny", ErrValidation)
+	}
+	if err := validateWindow(input.ValidFrom, input.ValidUntil); err != nil {
+		return err
+	}
+	condition, err := validateCondition(input.Condition)
+	if err != nil {
+		return err
+	}
+	input.Condition = condition
+	return uc.repo.Set(ctx, input)
+}
+
+// Delete removes an override.
+func (uc *PrincipalOverrideUsecase) Delete(ctx context.Context, input repo.PrincipalOverrideInput) error {
+	return uc.repo.Delete(ctx, input)
+}
+
+// ListByPrincipal returns every override of the principal.
+func (uc *PrincipalOverrideUsecase) ListByPrincipal(ctx context.Context, principalID string, kind model.PrincipalKind) ([]repo.PrincipalOverrideInput, error) {
+	return uc.repo.ListByPrincipal(ctx, principalID, kind)
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	adapterrebac "github.com/example/ms-rbac-service/internal/adapters/rebac"
+	domainrebac "github.com/example/ms-rbac-service/internal/domain/rebac"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// maxTupleBatch bounds how many tuples one write or delete may carry.
+const maxTupleBatch = 1000
+
+// RebacUsecase manages ReBAC namespace configs and relation tuples, and answers
+// Check and Expand requests over them.
+type RebacUsecase struct {
+	repo    *repo.RebacRepository
+	checker *adapterrebac.Checker
+}
+
+func NewRebacUsecase(r *repo.RebacRepository) *RebacUsecase {
+	return &RebacUsecase{repo: r, checker: adapterrebac.NewChecker(r)}
+}
+
+// SetNamespace validates and stores a namespace config.
+func (uc *RebacUsecase) SetNamespace(ctx context.Context, ns *domainrebac.Namespace) error {
+	if err := ns.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return uc.repo.SetNamespace(ctx, ns)
+}
+
+func (uc *RebacUsecase) GetNamespace(ctx context.Context, name string) (*domainrebac.Namespace, error) {
+	return uc.repo.GetNamespace(ctx, name)
+}
+
+func (uc *RebacUsecase) ListNamespaces(ctx context.Context) ([]domainrebac.Namespace, error) {
+	return uc.repo.ListNamespaces(ctx)
+}
+
+func (uc *RebacUsecase) DeleteNamespace(ctx context.Context, name string) error {
+	return uc.repo.DeleteNamespace(ctx, name)
+}
+
+// WriteTuples checks every tuple against the namespace configs and stores them all or
+// none; it returns how many were new.
+func (uc *RebacUsecase) WriteTuples(ctx context.Context, tuples []domainrebac.Tuple) (int64, error) {
+	if err := checkTupleBatch(tuples); err != nil {
+		return 0, err
+	}
+	namespaces := map[string]*domainrebac.Namespace{}
+	for _, t := range tuples {
+		for _, name := range []string{t.Object.Namespace, t.Subject.Namespace} {
+			if _, ok := namespaces[name]; ok {
+				continue
+			}
+			ns, err := uc.repo.Namespace(ctx, name)
+			if err != nil {
+				return 0, err
+			}
+			namespaces[name] = ns
+		}
+		if err := domainrebac.ValidateTuple(t, namespaces); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+	}
+	return uc.repo.WriteTuples(ctx, tuples)
+}
+
+// DeleteTuples removes the tuples that are stored and returns how many were.
+func (uc *RebacUsecase) DeleteTuples(ctx context.Context, tuples []domainrebac.Tuple) (int64, error) {
+	if err := checkTupleBatch(tuples); err != nil {
+		return 0, err
+	}
+	return uc.repo.DeleteTuples(ctx, tuples)
+}
+
+func (uc *RebacUsecase) ListTuples(ctx context.Context, filter repo.RelationTupleFilter, params pagination.Params) ([]domainrebac.Tuple, int64, error) {
+	return uc.repo.ListTuples(ctx, filter, params.Offset(), params.PageSize)
+}
+
+// Check reports whether subject has relation on object.
+func (uc *RebacUsecase) Check(ctx context.Context, object domainrebac.Object, relation string, subject domainrebac.Subject) (bool, error) {
+	allowed, err := uc.checker.Check(ctx, object, relation, subject)
+	return allowed, rebacError(err)
+}
+
+// Expand returns the userset tree of object#relation.
+func (uc *RebacUsecase) Expand(ctx context.Context, object domainrebac.Object, relation string) (domainrebac.ExpandNode, error) {
+	tree, err := uc.checker.Expand(ctx, object, relation)
+	return tree, rebacError(err)
+}
+
+func checkTupleBatch(tuples []domainrebac.Tuple) error {
+	if len(tuples) == 0 {
+		return fmt.Errorf("%w: tuples are required", ErrValidation)
+	}
+	if len(tuples) > maxTupleBatch {
+		return fmt.Errorf("%w: at most %d tuples per request", ErrValidation, maxTupleBatch)
+	}
+	return nil
+}
+
+// rebacError reports an unknown relation as a validation error; an exceeded depth is a
+// property of the stored data and is passed through.
+func rebacError(err error) error {
+	if errors.Is(err, domainrebac.ErrUnknownRelation) {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return err
+}
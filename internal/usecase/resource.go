@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// ResourceUsecase manages the resource registry. A grant or override on a registered
+// resource also covers its descendants.
+type ResourceUsecase struct {
+	repo *repo.ResourceRepository
+}
+
+func NewResourceUsecase(r *repo.ResourceRepository) *ResourceUsecase {
+	return &ResourceUsecase{repo: r}
+}
+
+func (uc *ResourceUsecase) Create(ctx context.Context, id, kind, parentID string) (*repo.Resource, error) {
+	item, err := newResource(id, kind, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Update changes the kind and parent of a resource; an empty parentID makes it a root.
+func (uc *ResourceUsecase) Update(ctx context.Context, id, kind, parentID string) (*repo.Resource, error) {
+	item, err := newResource(id, kind, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Update(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (uc *ResourceUsecase) Get(ctx context.Context, id string) (*repo.Resource, error) {
+	return uc.repo.Get(ctx, id)
+}
+
+func (uc *ResourceUsecase) List(ctx context.Context, filter repo.ResourceListFilter, params pagination.Params) ([]repo.Resource, int64, error) {
+	return uc.repo.List(ctx, filter, params.Offset(), params.PageSize)
+}
+
+func (uc *ResourceUsecase) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
+
+func newResource(id, kind, parentID string) (*repo.Resource, error) {
+	item := &repo.Resource{
+		ID:       strings.TrimSpace(id),
+		Kind:     strings.TrimSpace(kind),
+		ParentID: strings.TrimSpace(parentID),
+	}
+	if item.ID == "" || item.Kind == "" {
+		return nil, fmt.Errorf("%w: id and kind are required", ErrValidation)
+	}
+	if item.ParentID == item.ID {
+		return nil, fmt.Errorf("%w: a resource cannot be its own parent", ErrValidation)
+	}
+	return item, nil
+}
@@ -31,6 +31,10 @@ func (uc *RoleUsecase) Get(ctx context.Context, id string) (*repo.Role, error) {
 	return uc.repo.Get(ctx, id)
 }
 
-func (uc *RoleUsecase) List(ctx context.Context, params pagination.Params) ([]repo.Role, int64, error) {
-	return uc.repo.List(ctx, params.Offset(), params.PageSize)
+func (uc *RoleUsecase) List(ctx context.Context, filter repo.RoleListFilter, params pagination.Params) ([]repo.Role, int64, error) {
+	return uc.repo.List(ctx, filter, params.Offset(), params.PageSize)
+}
+
+func (uc *RoleUsecase) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
 }
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+)
+
+// RoleCardinalityUsecase manages limits on how many principals may hold a role per scope.
+type RoleCardinalityUsecase struct {
+	repo *repo.RoleCardinalityRepository
+}
+
+func NewRoleCardinalityUsecase(r *repo.RoleCardinalityRepository) *RoleCardinalityUsecase {
+	return &RoleCardinalityUsecase{repo: r}
+}
+
+func (uc *RoleCardinalityUsecase) Set(ctx context.Context, limit repo.RoleCardinality) (repo.RoleCardinality, error) {
+	if err := validateCardinalityKey(&limit.RoleKey, &limit.Scope); err != nil {
+		return repo.RoleCardinality{}, err
+	}
+	if limit.MaxPrincipals < 1 {
+		return repo.RoleCardinality{}, fmt.Errorf("%w: max_principals must be at least 1", ErrValidation)
+	}
+	if err := uc.repo.Set(ctx, &limit); err != nil {
+		return repo.RoleCardinality{}, err
+	}
+	return limit, nil
+}
+
+func (uc *RoleCardinalityUsecase) Delete(ctx context.Context, roleKey, scope string) error {
+	if err := validateCardinalityKey(&roleKey, &scope); err != nil {
+		return err
+	}
+	return uc.repo.Delete(ctx, roleKey, scope)
+}
+
+func (uc *RoleCardinalityUsecase) List(ctx context.Context, roleKey string) ([]repo.RoleCardinality, error) {
+	return uc.repo.List(ctx, strings.TrimSpace(roleKey))
+}
+
+func validateCardinalityKey(roleKey, scope *string) error {
+	*roleKey = strings.TrimSpace(*roleKey)
+	*scope = strings.TrimSpace(*scope)
+	if *roleKey == "" {
+		return fmt.Errorf("%w: role_key is required", ErrValidation)
+	}
+	switch *scope {
+	case repo.CardinalityScopeGlobal, repo.CardinalityScopeTenant, repo.CardinalityScopeService, repo.CardinalityScopeResource:
+		return nil
+	default:
+		return fmt.Errorf("%w: scope must be global, tenant, service or resource", ErrValidation)
+	}
+}
@@ -2,8 +2,12 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
 )
 
 // RolePermissionFilter defines filters for listing role permissions.
@@ -19,10 +23,48 @@ func NewRolePermissionUsecase(r *repo.RolePermissionRepository) *RolePermissionU
 	return &RolePermissionUsecase{repo: r}
 }
 
+// Create grants a permission to a role. Granting it again replaces its condition.
 func (uc *RolePermissionUsecase) Create(ctx context.Context, input repo.RolePermissionCreate) error {
+	if err := validateGrantResourceID(input.ResourceID); err != nil {
+		return err
+	}
+	condition, err := validateCondition(input.Condition)
+	if err != nil {
+		return err
+	}
+	input.Condition = condition
 	return uc.repo.Create(ctx, input)
 }
 
-func (uc *RolePermissionUsecase) List(ctx context.Context, filter RolePermissionFilter) ([]repo.Permission, error) {
+func (uc *RolePermissionUsecase) Delete(ctx context.Context, input repo.RolePermissionCreate) error {
+	if err := validateGrantResourceID(input.ResourceID); err != nil {
+		return err
+	}
+	return uc.repo.Delete(ctx, input)
+}
+
+func (uc *RolePermissionUsecase) List(ctx context.Context, filter RolePermissionFilter) ([]repo.RolePermissionGrant, error) {
 	return uc.repo.ListByRoleKey(ctx, filter.RoleKey)
 }
+
+// validateCondition trims a grant or override condition and rejects one that does not
+// compile, so the engine only ever evaluates valid expressions.
+func validateCondition(condition string) (string, error) {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return "", nil
+	}
+	if _, err := domainpdp.CompileCondition(condition); err != nil {
+		return "", fmt.Errorf("%w: condition: %v", ErrValidation, err)
+	}
+	return condition, nil
+}
+
+// validateGrantResourceID rejects a resource id that is not a UUID, which the
+// role_permission.resource_id column would otherwise refuse with a database error.
+func validateGrantResourceID(resourceID string) error {
+	if resourceID != "" && !model.IsUUID(resourceID) {
+		return fmt.Errorf("%w: resource_id must be a UUID", ErrValidation)
+	}
+	return nil
+}
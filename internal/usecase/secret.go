@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// newSecret returns a random URL-safe secret with the given prefix.
+func newSecret(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecret hashes a generated secret for storage. The secrets carry 256 bits of
+// entropy, so a plain SHA-256 is sufficient; they are never user-chosen passwords.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// secretMatches compares secret against a stored hash in constant time.
+func secretMatches(hash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashSecret(secret))) == 1
+}
@@ -34,3 +34,7 @@ func (uc *ServiceUsecase) Get(ctx context.Context, id string) (*repo.Service, er
 func (uc *ServiceUsecase) List(ctx context.Context, params pagination.Params) ([]repo.Service, int64, error) {
 	return uc.repo.List(ctx, params.Offset(), params.PageSize)
 }
+
+func (uc *ServiceUsecase) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
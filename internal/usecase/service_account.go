@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// ServiceAccountUsecase manages service accounts and authenticates them.
+type ServiceAccountUsecase struct {
+	repo *repo.ServiceAccountRepository
+}
+
+func NewServiceAccountUsecase(r *repo.ServiceAccountRepository) *ServiceAccountUsecase {
+	return &ServiceAccountUsecase{repo: r}
+}
+
+// Create registers a service account and returns it with its secret. The secret is
+// shown only once; only its hash is stored.
+func (uc *ServiceAccountUsecase) Create(ctx context.Context, key, title string) (repo.ServiceAccount, string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return repo.ServiceAccount{}, "", fmt.Errorf("%w: key is required", ErrValidation)
+	}
+	secret, err := newSecret("sa_")
+	if err != nil {
+		return repo.ServiceAccount{}, "", err
+	}
+	sa := repo.ServiceAccount{Key: key, Title: title}
+	if err := uc.repo.Create(ctx, &sa, hashSecret(secret)); err != nil {
+		return repo.ServiceAccount{}, "", err
+	}
+	return sa, secret, nil
+}
+
+func (uc *ServiceAccountUsecase) Get(ctx context.Context, id string) (repo.ServiceAccount, error) {
+	return uc.repo.Get(ctx, id)
+}
+
+func (uc *ServiceAccountUsecase) List(ctx context.Context, params pagination.Params) ([]repo.ServiceAccount, int64, error) {
+	return uc.repo.List(ctx, params.Offset(), params.PageSize)
+}
+
+// RotateSecret issues a new secret and invalidates the previous one.
+func (uc *ServiceAccountUsecase) RotateSecret(ctx context.Context, id string) (repo.ServiceAccount, string, error) {
+	secret, err := newSecret("sa_")
+	if err != nil {
+		return repo.ServiceAccount{}, "", err
+	}
+	sa, err := uc.repo.Rotate(ctx, id, hashSecret(secret))
+	if err != nil {
+		return repo.ServiceAccount{}, "", err
+	}
+	return sa, secret, nil
+}
+
+func (uc *ServiceAccountUsecase) Disable(ctx context.Context, id string) (repo.ServiceAccount, error) {
+	return uc.repo.SetDisabled(ctx, id, true)
+}
+
+func (uc *ServiceAccountUsecase) Enable(ctx context.Context, id string) (repo.ServiceAccount, error) {
+	return uc.repo.SetDisabled(ctx, id, false)
+}
+
+// Authenticate verifies the account's credentials. Unknown accounts, wrong secrets and
+// disabled accounts all report ErrUnauthenticated.
+func (uc *ServiceAccountUsecase) Authenticate(ctx context.Context, id, secret string) (repo.ServiceAccount, error) {
+	sa, hash, err := uc.repo.Credential(ctx, id)
+	if errors.Is(err, repo.ErrNotFound) {
+		return repo.ServiceAccount{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return repo.ServiceAccount{}, err
+	}
+	if !secretMatches(hash, secret) || sa.DisabledAt != nil {
+		return repo.ServiceAccount{}, ErrUnauthenticated
+	}
+	return sa, nil
+}
+
+type serviceAccountKey struct{}
+
+// WithServiceAccount returns a context carrying the service account the caller
+// authenticated as.
+func WithServiceAccount(ctx context.Context, sa repo.ServiceAccount) context.Context {
+	return context.WithValue(ctx, serviceAccountKey{}, sa)
+}
+
+// ServiceAccountFromContext returns the service account stored in ctx; ok is false for
+// calls that presented no credentials.
+func ServiceAccountFromContext(ctx context.Context) (repo.ServiceAccount, bool) {
+	sa, ok := ctx.Value(serviceAccountKey{}).(repo.ServiceAccount)
+	return sa, ok
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+)
+
+// ServiceManifestUsecase registers permission catalogs published by services.
+type ServiceManifestUsecase struct {
+	repo         *repo.ServiceManifestRepository
+	defaultRoles []string
+}
+
+// NewServiceManifestUsecase constructs a new ServiceManifestUsecase instance. Manifests
+// may only grant their permissions by default to the roles in defaultRoles.
+func NewServiceManifestUsecase(r *repo.ServiceManifestRepository, defaultRoles []string) *ServiceManifestUsecase {
+	return &ServiceManifestUsecase{repo: r, defaultRoles: defaultRoles}
+}
+
+// Register validates and normalises the manifest, then upserts it. Only the service
+// account whose key is the manifest key may register it.
+func (uc *ServiceManifestUsecase) Register(ctx context.Context, manifest repo.ServiceManifest) (*repo.ServiceManifestReport, error) {
+	normalized, err := normalizeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	sa, ok := ServiceAccountFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: service account credentials are required to register a manifest", ErrUnauthenticated)
+	}
+	if sa.Key != normalized.Key {
+		return nil, fmt.Errorf("%w: service account %s cannot register the manifest of service %s", ErrForbidden, sa.Key, normalized.Key)
+	}
+	for _, perm := range normalized.Permissions {
+		for _, role := range perm.DefaultRoles {
+			if !containsString(uc.defaultRoles, role) {
+				return nil, fmt.Errorf("%w: role %s is not allowed as a manifest default role", ErrForbidden, role)
+			}
+		}
+	}
+	return uc.repo.Apply(ctx, normalized)
+}
+
+func normalizeManifest(manifest repo.ServiceManifest) (repo.ServiceManifest, error) {
+	out := repo.ServiceManifest{
+		Key:   strings.TrimSpace(manifest.Key),
+		Title: strings.TrimSpace(manifest.Title),
+	}
+	if out.Key == "" {
+		return repo.ServiceManifest{}, fmt.Errorf("%w: key is required", ErrValidation)
+	}
+	if out.Title == "" {
+		out.Title = out.Key
+	}
+	index := make(map[string]int, len(manifest.Permissions))
+	for _, perm := range manifest.Permissions {
+		action := strings.TrimSpace(perm.Action)
+		resourceKind := strings.TrimSpace(perm.ResourceKind)
+		if action == "" || resourceKind == "" {
+			return repo.ServiceManifest{}, fmt.Errorf("%w: permission action and resource_kind are required", ErrValidation)
+		}
+		if strings.Contains(action, "*") || strings.Contains(resourceKind, "*") {
+			return repo.ServiceManifest{}, fmt.Errorf("%w: permission %s:%s must not use wildcards", ErrValidation, action, resourceKind)
+		}
+		key := action + ":" + resourceKind
+		i, ok := index[key]
+		if !ok {
+			i = len(out.Permissions)
+			index[key] = i
+			out.Permissions = append(out.Permissions, repo.ManifestPermission{Action: action, ResourceKind: resourceKind})
+		}
+		for _, role := range perm.DefaultRoles {
+			role = strings.TrimSpace(role)
+			if role == "" || containsString(out.Permissions[i].DefaultRoles, role) {
+				continue
+			}
+			out.Permissions[i].DefaultRoles = append(out.Permissions[i].DefaultRoles, role)
+		}
+	}
+	return out, nil
+}
+
+func containsString(items []string, v string) bool {
+	for _, item := range items {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+)
+
+type ServicePermissionUsecase struct {
+	repo *repo.ServicePermissionRepository
+}
+
+func NewServicePermissionUsecase(r *repo.ServicePermissionRepository) *ServicePermissionUsecase {
+	return &ServicePermissionUsecase{repo: r}
+}
+
+func (uc *ServicePermissionUsecase) Create(ctx context.Context, input repo.ServicePermissionBinding) error {
+	return uc.repo.Create(ctx, input)
+}
+
+func (uc *ServicePermissionUsecase) Delete(ctx context.Context, input repo.ServicePermissionBinding) error {
+	return uc.repo.Delete(ctx, input)
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+)
+
+type ServiceRoleUsecase struct {
+	repo *repo.ServiceRoleRepository
+}
+
+func NewServiceRoleUsecase(r *repo.ServiceRoleRepository) *ServiceRoleUsecase {
+	return &ServiceRoleUsecase{repo: r}
+}
+
+func (uc *ServiceRoleUsecase) Create(ctx context.Context, input repo.ServiceRoleBinding) error {
+	return uc.repo.Create(ctx, input)
+}
+
+func (uc *ServiceRoleUsecase) Delete(ctx context.Context, input repo.ServiceRoleBinding) error {
+	return uc.repo.Delete(ctx, input)
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/pkg/pagination"
+)
+
+// SoDUsecase manages separation-of-duties rules and reports principals violating them.
+type SoDUsecase struct {
+	repo *repo.SoDRepository
+}
+
+func NewSoDUsecase(r *repo.SoDRepository) *SoDUsecase {
+	return &SoDUsecase{repo: r}
+}
+
+func (uc *SoDUsecase) Create(ctx context.Context, rule repo.SoDRule) (repo.SoDRule, error) {
+	rule.Key = strings.TrimSpace(rule.Key)
+	rule.RoleA = strings.TrimSpace(rule.RoleA)
+	rule.RoleB = strings.TrimSpace(rule.RoleB)
+	if rule.Key == "" || rule.RoleA == "" || rule.RoleB == "" {
+		return repo.SoDRule{}, fmt.Errorf("%w: key, role_a and role_b are required", ErrValidation)
+	}
+	if rule.RoleA == rule.RoleB {
+		return repo.SoDRule{}, fmt.Errorf("%w: role_a and role_b must differ", ErrValidation)
+	}
+	if rule.Kind == "" {
+		rule.Kind = repo.SoDKindStatic
+	}
+	if rule.Kind != repo.SoDKindStatic && rule.Kind != repo.SoDKindDynamic {
+		return repo.SoDRule{}, fmt.Errorf("%w: kind must be static or dynamic", ErrValidation)
+	}
+	if err := uc.repo.Create(ctx, &rule); err != nil {
+		return repo.SoDRule{}, err
+	}
+	return rule, nil
+}
+
+func (uc *SoDUsecase) Get(ctx context.Context, id string) (repo.SoDRule, error) {
+	return uc.repo.Get(ctx, id)
+}
+
+func (uc *SoDUsecase) List(ctx context.Context, params pagination.Params) ([]repo.SoDRule, int64, error) {
+	return uc.repo.List(ctx, params.Offset(), params.PageSize)
+}
+
+func (uc *SoDUsecase) Delete(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
+
+// ListViolations reports principals holding both roles of a rule.
+func (uc *SoDUsecase) ListViolations(ctx context.Context, filter repo.SoDViolationFilter, params pagination.Params) ([]repo.SoDViolation, int64, error) {
+	if filter.Kind != "" && filter.Kind != repo.SoDKindStatic && filter.Kind != repo.SoDKindDynamic {
+		return nil, 0, fmt.Errorf("%w: kind must be static or dynamic", ErrValidation)
+	}
+	return uc.repo.ListViolations(ctx, filter, params.Offset(), params.PageSize)
+}
@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+)
+
+// SuperadminUsecase manages superadmin principals.
+type SuperadminUsecase struct {
+	repo *repo.SuperadminRepository
+}
+
+// NewSuperadminUsecase constructs a new SuperadminUsecase instance.
+func NewSuperadminUsecase(r *repo.SuperadminRepository) *SuperadminUsecase {
+	return &SuperadminUsecase{repo: r}
+}
+
+func (uc *SuperadminUsecase) Grant(ctx context.Context, principalID string, kind model.PrincipalKind) error {
+	return uc.repo.Grant(ctx, principalID, kind)
+}
+
+func (uc *SuperadminUsecase) Revoke(ctx context.Context, principalID string) error {
+	return uc.repo.Revoke(ctx, principalID)
+}
+
+func (uc *SuperadminUsecase) List(ctx context.Context) ([]model.SuperadminPrincipal, error) {
+	return uc.repo.List(ctx)
+}
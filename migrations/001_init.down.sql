@@ -0,0 +1,12 @@
+drop table if exists superadmin_principal;
+drop table if exists principal_override;
+drop type if exists override_effect;
+drop table if exists principal_role;
+drop type if exists principal_kind;
+drop table if exists service_permission;
+drop table if exists service_role;
+drop table if exists role_permission;
+drop table if exists permission;
+drop table if exists role_hierarchy;
+drop table if exists role;
+drop table if exists service;
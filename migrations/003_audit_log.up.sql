@@ -0,0 +1,17 @@
+create table audit_log (
+  id bigserial primary key,
+  occurred_at timestamptz not null default now(),
+  actor_id text not null default '',
+  source text not null,
+  correlation_id text not null default '',
+  action text not null,
+  entity text not null,
+  entity_id text not null default '',
+  before jsonb,
+  after jsonb
+);
+
+create index audit_log_occurred_at_idx on audit_log (occurred_at desc, id desc);
+create index audit_log_entity_idx on audit_log (entity, entity_id);
+create index audit_log_actor_idx on audit_log (actor_id);
+create index audit_log_correlation_idx on audit_log (correlation_id);
@@ -0,0 +1 @@
+drop table if exists decision_log;
@@ -0,0 +1,22 @@
+create table decision_log (
+  id bigserial primary key,
+  occurred_at timestamptz not null,
+  principal_id text not null,
+  principal_kind text not null,
+  tenant_id text,
+  service_id text,
+  action text not null,
+  resource_kind text not null,
+  resource_id text,
+  correlation_id text not null default '',
+  allow boolean not null,
+  decision text not null,
+  role_keys text[] not null default '{}',
+  matched jsonb,
+  latency_us bigint not null,
+  error text not null default ''
+);
+
+create index decision_log_occurred_at_idx on decision_log (occurred_at desc);
+create index decision_log_principal_idx on decision_log (principal_id, occurred_at desc);
+create index decision_log_correlation_idx on decision_log (correlation_id);
@@ -0,0 +1,12 @@
+drop table if exists principal_override_archive;
+drop table if exists principal_role_archive;
+
+alter table principal_override
+  drop constraint if exists principal_override_validity_chk,
+  drop column if exists valid_until,
+  drop column if exists valid_from;
+
+alter table principal_role
+  drop constraint if exists principal_role_validity_chk,
+  drop column if exists valid_until,
+  drop column if exists valid_from;
@@ -0,0 +1,43 @@
+alter table principal_role
+  add column valid_from timestamptz,
+  add column valid_until timestamptz,
+  add constraint principal_role_validity_chk check (valid_until is null or valid_from is null or valid_until > valid_from);
+
+alter table principal_override
+  add column valid_from timestamptz,
+  add column valid_until timestamptz,
+  add constraint principal_override_validity_chk check (valid_until is null or valid_from is null or valid_until > valid_from);
+
+create index principal_role_valid_until_idx on principal_role (valid_until) where valid_until is not null;
+create index principal_override_valid_until_idx on principal_override (valid_until) where valid_until is not null;
+
+-- Expired grants moved aside by the sweeper when GRANT_SWEEP_MODE=archive.
+create table principal_role_archive (
+  principal_id uuid not null,
+  principal_kind principal_kind not null,
+  role_id uuid not null,
+  tenant_id uuid,
+  service_id uuid,
+  resource_kind text,
+  resource_id uuid,
+  valid_from timestamptz,
+  valid_until timestamptz,
+  archived_at timestamptz not null default now()
+);
+
+create table principal_override_archive (
+  principal_id uuid not null,
+  principal_kind principal_kind not null,
+  permission_id uuid not null,
+  effect override_effect not null,
+  tenant_id uuid,
+  service_id uuid,
+  resource_kind text,
+  resource_id uuid,
+  valid_from timestamptz,
+  valid_until timestamptz,
+  archived_at timestamptz not null default now()
+);
+
+create index principal_role_archive_principal_idx on principal_role_archive (principal_id, principal_kind);
+create index principal_override_archive_principal_idx on principal_override_archive (principal_id, principal_kind);
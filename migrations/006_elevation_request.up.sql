@@ -0,0 +1,25 @@
+create table elevation_request (
+  id uuid primary key default gen_random_uuid(),
+  principal_id uuid not null,
+  principal_kind principal_kind not null,
+  role_id uuid not null references role(id) on delete cascade,
+
+  tenant_id uuid not null,
+  service_id uuid not null references service(id) on delete cascade,
+  resource_kind text not null,
+  resource_id uuid not null,
+
+  justification text not null,
+  duration_seconds integer not null check (duration_seconds > 0),
+  status text not null default 'pending' check (status in ('pending', 'approved', 'rejected', 'expired')),
+  requested_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  decided_at timestamptz,
+  decided_by text not null default '',
+  decision_note text not null default '',
+  granted_until timestamptz
+);
+
+create index elevation_request_status_idx on elevation_request (status, requested_at desc);
+create index elevation_request_principal_idx on elevation_request (principal_id, principal_kind, requested_at desc);
+create index elevation_request_pending_expiry_idx on elevation_request (expires_at) where status = 'pending';
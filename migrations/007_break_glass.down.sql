@@ -0,0 +1,2 @@
+drop table if exists break_glass_activation;
+drop table if exists break_glass_principal;
@@ -0,0 +1,26 @@
+-- Principals allowed to activate temporary superadmin with a sealed secret.
+create table break_glass_principal (
+  principal_id uuid not null,
+  principal_kind principal_kind not null,
+  secret_hash text not null,
+  max_duration_seconds integer not null check (max_duration_seconds > 0),
+  registered_at timestamptz not null default now(),
+  primary key (principal_id, principal_kind)
+);
+
+create table break_glass_activation (
+  id uuid primary key default gen_random_uuid(),
+  principal_id uuid not null,
+  principal_kind principal_kind not null,
+  reason text not null,
+  activated_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  ended_at timestamptz,
+  review_status text not null default 'pending' check (review_status in ('pending', 'reviewed')),
+  reviewed_at timestamptz,
+  reviewed_by text not null default '',
+  review_notes text not null default ''
+);
+
+create index break_glass_activation_active_idx on break_glass_activation (principal_id, principal_kind) where ended_at is null;
+create index break_glass_activation_review_idx on break_glass_activation (review_status, activated_at desc);
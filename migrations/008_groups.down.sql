@@ -0,0 +1,2 @@
+drop table if exists group_member;
+drop table if exists principal_group;
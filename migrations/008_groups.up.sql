@@ -0,0 +1,20 @@
+-- Groups are principals (principal_kind 'group') whose role assignments are inherited
+-- by their members. Members may be users, service accounts or other groups.
+create table principal_group (
+  id uuid primary key default gen_random_uuid(),
+  key text unique not null,
+  title text not null,
+  created_at timestamptz not null default now()
+);
+
+create table group_member (
+  group_id uuid not null references principal_group(id) on delete cascade,
+  member_id uuid not null,
+  member_kind principal_kind not null,
+  added_at timestamptz not null default now(),
+  primary key (group_id, member_id, member_kind),
+  check (member_kind <> 'group' or member_id <> group_id)
+);
+
+-- Membership is resolved from the member upwards.
+create index group_member_member_idx on group_member (member_id, member_kind);
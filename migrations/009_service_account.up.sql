@@ -0,0 +1,11 @@
+-- Service accounts are principals of kind 'service_account' that authenticate to the
+-- public API with their id and a generated secret. Only the secret's hash is stored.
+create table service_account (
+  id uuid primary key default gen_random_uuid(),
+  key text unique not null,
+  title text not null,
+  secret_hash text not null,
+  created_at timestamptz not null default now(),
+  secret_rotated_at timestamptz not null default now(),
+  disabled_at timestamptz
+);
@@ -0,0 +1,18 @@
+-- Separation-of-duties rules pair two roles. Static rules reject assignments that would
+-- give a principal both roles; dynamic rules allow holding both but flag checks where
+-- both apply. same_scope limits a rule to assignments whose scopes overlap, e.g. the
+-- same course, instead of anywhere.
+create table sod_rule (
+  id uuid primary key default gen_random_uuid(),
+  key text unique not null,
+  role_a_id uuid not null references role(id) on delete cascade,
+  role_b_id uuid not null references role(id) on delete cascade,
+  kind text not null check (kind in ('static', 'dynamic')),
+  same_scope boolean not null default false,
+  description text not null default '',
+  created_at timestamptz not null default now(),
+  check (role_a_id <> role_b_id)
+);
+
+create index sod_rule_role_a_idx on sod_rule (role_a_id);
+create index sod_rule_role_b_idx on sod_rule (role_b_id);
@@ -0,0 +1,11 @@
+-- Caps how many distinct principals may hold a role within a scope. scope_level picks
+-- the scope columns that partition the count: global counts every assignment of the
+-- role, tenant counts per tenant_id, service per (tenant_id, service_id) and resource
+-- per full scope, e.g. one owner per course.
+create table role_cardinality (
+  role_id uuid not null references role(id) on delete cascade,
+  scope_level text not null check (scope_level in ('global', 'tenant', 'service', 'resource')),
+  max_principals integer not null check (max_principals > 0),
+  updated_at timestamptz not null default now(),
+  primary key (role_id, scope_level)
+);
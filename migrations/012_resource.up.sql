@@ -0,0 +1,14 @@
+-- Registry of resources that nest, e.g. school -> course -> lesson. A resource is
+-- identified by the same id its grants and overrides use in resource_id; the PDP
+-- matches a request on a resource against the resource and all of its ancestors.
+-- A resource with children cannot be deleted.
+create table resource (
+  id uuid primary key,
+  kind text not null,
+  parent_id uuid references resource(id) on delete restrict,
+  created_at timestamptz not null default now(),
+  check (parent_id <> id)
+);
+
+create index resource_parent_idx on resource (parent_id);
+create index resource_kind_idx on resource (kind);
@@ -0,0 +1,3 @@
+alter table principal_override_archive drop column if exists condition;
+alter table principal_override drop column if exists condition;
+alter table role_permission drop column if exists condition;
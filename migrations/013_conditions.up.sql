@@ -0,0 +1,5 @@
+-- Optional condition expressions on grants and overrides, evaluated by the PDP against
+-- the attributes of a request (see internal/domain/expr). Null means unconditional.
+alter table role_permission add column condition text;
+alter table principal_override add column condition text;
+alter table principal_override_archive add column condition text;
@@ -0,0 +1,2 @@
+drop table if exists relation_tuple;
+drop table if exists rebac_namespace;
@@ -0,0 +1,22 @@
+-- Relationship-based access control alongside the role-based PDP. A namespace config
+-- lists the relations of an object namespace and the rewrites deriving them; relation
+-- tuples state object#relation@subject. A plain subject such as user:alice has an
+-- empty subject_relation, a userset subject such as group:eng#member sets it.
+create table rebac_namespace (
+  name text primary key,
+  config jsonb not null,
+  updated_at timestamptz not null default now()
+);
+
+create table relation_tuple (
+  namespace text not null references rebac_namespace(name) on delete restrict,
+  object_id text not null,
+  relation text not null,
+  subject_namespace text not null,
+  subject_id text not null,
+  subject_relation text not null default '',
+  created_at timestamptz not null default now(),
+  primary key (namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+);
+
+create index relation_tuple_subject_idx on relation_tuple (subject_namespace, subject_id, subject_relation);
@@ -0,0 +1,25 @@
+drop trigger if exists superadmin_principal_pdp_snapshot_truncate on superadmin_principal;
+drop trigger if exists superadmin_principal_pdp_snapshot on superadmin_principal;
+drop trigger if exists break_glass_activation_pdp_snapshot_truncate on break_glass_activation;
+drop trigger if exists break_glass_activation_pdp_snapshot on break_glass_activation;
+drop trigger if exists service_account_pdp_snapshot_truncate on service_account;
+drop trigger if exists service_account_pdp_snapshot on service_account;
+drop trigger if exists principal_override_pdp_snapshot_truncate on principal_override;
+drop trigger if exists principal_override_pdp_snapshot on principal_override;
+drop trigger if exists principal_role_pdp_snapshot_truncate on principal_role;
+drop trigger if exists principal_role_pdp_snapshot on principal_role;
+drop trigger if exists group_member_pdp_snapshot_truncate on group_member;
+drop trigger if exists group_member_pdp_snapshot on group_member;
+drop trigger if exists role_pdp_snapshot_truncate on role;
+drop trigger if exists role_pdp_snapshot on role;
+drop trigger if exists service_role_pdp_snapshot_truncate on service_role;
+drop trigger if exists service_role_pdp_snapshot on service_role;
+drop trigger if exists role_permission_pdp_snapshot_truncate on role_permission;
+drop trigger if exists role_permission_pdp_snapshot on role_permission;
+drop trigger if exists permission_pdp_snapshot_truncate on permission;
+drop trigger if exists permission_pdp_snapshot on permission;
+drop trigger if exists resource_pdp_snapshot_truncate on resource;
+drop trigger if exists resource_pdp_snapshot on resource;
+drop trigger if exists sod_rule_pdp_snapshot_truncate on sod_rule;
+drop trigger if exists sod_rule_pdp_snapshot on sod_rule;
+drop function if exists notify_pdp_snapshot();
@@ -0,0 +1,76 @@
+-- Change feed for in-memory PDP snapshots. Every committed change to a table the PDP
+-- reads is announced on the pdp_snapshot channel as "table:key[:key...]", the key
+-- columns being the trigger arguments; a snapshot reloads the rows with that key.
+-- TRUNCATE announces "*", asking for a full reload. Postgres delivers notifications on
+-- commit and folds duplicates within a transaction.
+create function notify_pdp_snapshot() returns trigger language plpgsql as $$
+declare
+  rec jsonb;
+  col text;
+  payload text;
+begin
+  if TG_OP = 'TRUNCATE' then
+    perform pg_notify('pdp_snapshot', '*');
+    return null;
+  end if;
+  foreach rec in array array[to_jsonb(OLD), to_jsonb(NEW)] loop
+    continue when rec is null;
+    payload := TG_TABLE_NAME;
+    foreach col in array TG_ARGV loop
+      payload := payload || ':' || coalesce(rec ->> col, '');
+    end loop;
+    perform pg_notify('pdp_snapshot', payload);
+  end loop;
+  return null;
+end
+$$;
+
+create trigger superadmin_principal_pdp_snapshot after insert or update or delete on superadmin_principal
+  for each row execute function notify_pdp_snapshot('principal_id', 'principal_kind');
+create trigger break_glass_activation_pdp_snapshot after insert or update or delete on break_glass_activation
+  for each row execute function notify_pdp_snapshot('principal_id', 'principal_kind');
+create trigger service_account_pdp_snapshot after insert or update or delete on service_account
+  for each row execute function notify_pdp_snapshot('id');
+create trigger principal_override_pdp_snapshot after insert or update or delete on principal_override
+  for each row execute function notify_pdp_snapshot('principal_id', 'principal_kind');
+create trigger principal_role_pdp_snapshot after insert or update or delete on principal_role
+  for each row execute function notify_pdp_snapshot('principal_id', 'principal_kind');
+create trigger group_member_pdp_snapshot after insert or update or delete on group_member
+  for each row execute function notify_pdp_snapshot('member_id', 'member_kind');
+create trigger role_pdp_snapshot after insert or update or delete on role
+  for each row execute function notify_pdp_snapshot('id');
+create trigger service_role_pdp_snapshot after insert or update or delete on service_role
+  for each row execute function notify_pdp_snapshot('role_id');
+create trigger role_permission_pdp_snapshot after insert or update or delete on role_permission
+  for each row execute function notify_pdp_snapshot('role_id');
+create trigger permission_pdp_snapshot after insert or update or delete on permission
+  for each row execute function notify_pdp_snapshot('id');
+create trigger resource_pdp_snapshot after insert or update or delete on resource
+  for each row execute function notify_pdp_snapshot('id');
+create trigger sod_rule_pdp_snapshot after insert or update or delete on sod_rule
+  for each row execute function notify_pdp_snapshot('id');
+
+create trigger superadmin_principal_pdp_snapshot_truncate after truncate on superadmin_principal
+  for each statement execute function notify_pdp_snapshot();
+create trigger break_glass_activation_pdp_snapshot_truncate after truncate on break_glass_activation
+  for each statement execute function notify_pdp_snapshot();
+create trigger service_account_pdp_snapshot_truncate after truncate on service_account
+  for each statement execute function notify_pdp_snapshot();
+create trigger principal_override_pdp_snapshot_truncate after truncate on principal_override
+  for each statement execute function notify_pdp_snapshot();
+create trigger principal_role_pdp_snapshot_truncate after truncate on principal_role
+  for each statement execute function notify_pdp_snapshot();
+create trigger group_member_pdp_snapshot_truncate after truncate on group_member
+  for each statement execute function notify_pdp_snapshot();
+create trigger role_pdp_snapshot_truncate after truncate on role
+  for each statement execute function notify_pdp_snapshot();
+create trigger service_role_pdp_snapshot_truncate after truncate on service_role
+  for each statement execute function notify_pdp_snapshot();
+create trigger role_permission_pdp_snapshot_truncate after truncate on role_permission
+  for each statement execute function notify_pdp_snapshot();
+create trigger permission_pdp_snapshot_truncate after truncate on permission
+  for each statement execute function notify_pdp_snapshot();
+create trigger resource_pdp_snapshot_truncate after truncate on resource
+  for each statement execute function notify_pdp_snapshot();
+create trigger sod_rule_pdp_snapshot_truncate after truncate on sod_rule
+  for each statement execute function notify_pdp_snapshot();
@@ -0,0 +1,3 @@
+drop index if exists role_permission_permission_idx;
+drop index if exists principal_override_permission_idx;
+drop index if exists principal_role_role_idx;
@@ -0,0 +1,9 @@
+-- The check path is served by the primary keys of 001 and its principal indexes:
+-- overrides are probed by (principal_id, principal_kind, permission_id, ...) and role
+-- assignments by (principal_id, principal_kind, tenant_id, ...), so it needs no new
+-- index. Lookups starting from a role or a permission had none: access review, role
+-- member listing, cardinality counts on every assignment and the cascades of role and
+-- permission deletes scanned whole tables.
+create index principal_role_role_idx on principal_role (role_id, tenant_id, service_id, resource_kind, resource_id);
+create index principal_override_permission_idx on principal_override (permission_id);
+create index role_permission_permission_idx on role_permission (permission_id);
@@ -0,0 +1,8 @@
+-- The manifest a service last registered. Orphans are what the previous manifest
+-- declared and the new one drops, so permissions and grants added by admins or other
+-- services are never reported as belonging to the service.
+create table service_manifest (
+  service_id uuid primary key references service(id) on delete cascade,
+  permissions jsonb not null,
+  applied_at timestamptz not null default now()
+);
@@ -0,0 +1,2 @@
+drop trigger if exists role_hierarchy_pdp_snapshot_truncate on role_hierarchy;
+drop trigger if exists role_hierarchy_pdp_snapshot on role_hierarchy;
@@ -0,0 +1,6 @@
+-- The PDP evaluates roles inherited through role_hierarchy, so snapshots reload a role
+-- when its parents change.
+create trigger role_hierarchy_pdp_snapshot after insert or update or delete on role_hierarchy
+  for each row execute function notify_pdp_snapshot('role_id');
+create trigger role_hierarchy_pdp_snapshot_truncate after truncate on role_hierarchy
+  for each statement execute function notify_pdp_snapshot();
@@ -0,0 +1 @@
+alter table decision_log drop column if exists sod_conflicts;
@@ -0,0 +1,3 @@
+-- The dynamic separation-of-duties conflicts flagged on a decision. Databases that took
+-- this column from an earlier revision of 010_sod_rule already have it.
+alter table decision_log add column if not exists sod_conflicts jsonb;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations so the service binary can apply them itself.
+package migrations
+
+import "embed"
+
+// FS holds every NNN_name.up.sql / NNN_name.down.sql file of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
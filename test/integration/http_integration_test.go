@@ -42,6 +42,23 @@ func TestAssignsUserRoleForNewPrincipal(t *testing.T) {
 	}
 }
 
+func TestResourceScopedRolePermission(t *testing.T) {
+	ts := newTestServer(t)
+
+	permID := createPermission(t, ts, "edit", "lesson")
+	resourceID := "33333333-3333-3333-3333-333333333333"
+	assignResourcePermissionToRole(t, ts, "teacher", permID, resourceID)
+
+	userID := "44444444-4444-4444-4444-444444444444"
+	assignRole(t, ts, userID, "teacher")
+
+	assertPermissionsList(t, ts, userID, []string{"edit:lesson:" + resourceID})
+	assertCheckPermission(t, ts, userID, "edit:lesson:"+resourceID, true)
+	assertCheckPermission(t, ts, userID, "edit:lesson", false)
+	assertCheck(t, ts, userID, "edit", "lesson", resourceID, true)
+	assertCheck(t, ts, userID, "edit", "lesson", "55555555-5555-5555-5555-555555555555", false)
+}
+
 type testServer struct {
 	handler http.Handler
 }
@@ -96,6 +113,17 @@ func assignPermissionToRole(t *testing.T, ts testServer, roleKey, permissionID s
 	}
 }
 
+func assignResourcePermissionToRole(t *testing.T, ts testServer, roleKey, permissionID, resourceID string) {
+	t.Helper()
+	body := fmt.Sprintf(`{"role_key":"%s","permission_id":"%s","resource_id":"%s"}`, roleKey, permissionID, resourceID)
+	req := httptest.NewRequest(http.MethodPost, "/admin/v1/role-permission", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := ts.do(req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+}
+
 func assignRole(t *testing.T, ts testServer, userID, role string) {
 	t.Helper()
 	body := fmt.Sprintf(`{"value":{"user_id":"%s","role":"%s"}}`, userID, role)
@@ -166,6 +194,26 @@ func assertCheckPermission(t *testing.T, ts testServer, userID, permission strin
 	}
 }
 
+func assertCheck(t *testing.T, ts testServer, userID, action, resourceKind, resourceID string, expected bool) {
+	t.Helper()
+	body := fmt.Sprintf(`{"principal_id":"%s","action":"%s","resource_kind":"%s","resource_id":"%s"}`, userID, action, resourceKind, resourceID)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/check", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := ts.do(req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var payload struct {
+		Allow bool `json:"allow"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode check: %v", err)
+	}
+	if payload.Allow != expected {
+		t.Fatalf("expected allow=%v, got %v", expected, payload.Allow)
+	}
+}
+
 func getRole(t *testing.T, ts testServer, userID string) string {
 	t.Helper()
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/principal-role/get?user_id="+userID, nil)
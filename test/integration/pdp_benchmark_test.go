@@ -0,0 +1,252 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/example/ms-rbac-service/internal/adapters/postgres"
+	"github.com/example/ms-rbac-service/internal/domain/model"
+	domainpdp "github.com/example/ms-rbac-service/internal/domain/pdp"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The PDP benchmarks seed one principal with n overrides and n role assignments, all
+// but one in tenants the request is not for, and compare the repository queries with
+// a baseline that loads every row of the principal and filters it in Go, as the
+// repository did before filtering moved into Postgres:
+//
+//	DB_DSN=... go test -tags integration -run '^$' -bench PDP ./test/integration
+
+const (
+	benchPrincipal = "bbbbbbbb-0000-4000-8000-000000000001"
+	benchRole      = "bench-pdp-role"
+
+	benchDefaultTenant   = "00000000-0000-0000-0000-000000000000"
+	benchDefaultService  = "00000000-0000-0000-0000-000000000100"
+	benchDefaultKind     = "global"
+	benchDefaultResource = "00000000-0000-0000-0000-000000000000"
+)
+
+var benchSizes = []int{100, 1000, 5000}
+
+func benchPool(b *testing.B) *pgxpool.Pool {
+	b.Helper()
+	if os.Getenv("DB_DSN") == "" {
+		b.Skip("DB_DSN is required for PDP benchmarks")
+	}
+	pool, err := pgxpool.New(context.Background(), os.Getenv("DB_DSN"))
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	b.Cleanup(pool.Close)
+	return pool
+}
+
+// seedBenchPrincipal gives benchPrincipal n overrides, each on its own permission, and n
+// assignments of benchRole. Only the override on bench.action.1 and one assignment
+// are in the default tenant; the rest are in random tenants.
+func seedBenchPrincipal(b *testing.B, pool *pgxpool.Pool, n int) {
+	b.Helper()
+	ctx := context.Background()
+	cleanupBenchPrincipal(b, pool)
+	b.Cleanup(func() { cleanupBenchPrincipal(b, pool) })
+	for _, stmt := range []struct {
+		sql  string
+		args []any
+	}{
+		{`INSERT INTO permission (action, resource_kind)
+			SELECT 'bench.action.' || g, 'bench_doc' FROM generate_series(1, $1::int) g`, []any{n}},
+		{`INSERT INTO principal_override
+			(principal_id, principal_kind, permission_id, effect, tenant_id, service_id, resource_kind, resource_id)
+			SELECT $1::uuid, 'user', p.id, 'allow',
+				CASE WHEN p.action = 'bench.action.1' THEN $2::uuid ELSE gen_random_uuid() END, $3::uuid, $4::text, $5::uuid
+			FROM permission p WHERE p.resource_kind = 'bench_doc'`,
+			[]any{benchPrincipal, benchDefaultTenant, benchDefaultService, benchDefaultKind, benchDefaultResource}},
+		{`INSERT INTO role (key, title) VALUES ($1, 'PDP benchmark')`, []any{benchRole}},
+		{`INSERT INTO principal_role (principal_id, principal_kind, role_id, tenant_id, service_id, resource_kind, resource_id)
+			SELECT $1::uuid, 'user', r.id, CASE WHEN g = 1 THEN $2::uuid ELSE gen_random_uuid() END, $3::uuid, $4::text, $5::uuid
+			FROM role r, generate_series(1, $6::int) g WHERE r.key = $7`,
+			[]any{benchPrincipal, benchDefaultTenant, benchDefaultService, benchDefaultKind, benchDefaultResource, n, benchRole}},
+	} {
+		if _, err := pool.Exec(ctx, stmt.sql, stmt.args...); err != nil {
+			b.Fatalf("seed: %v", err)
+		}
+	}
+	if _, err := pool.Exec(ctx, `ANALYZE permission, principal_override, principal_role`); err != nil {
+		b.Fatalf("analyze: %v", err)
+	}
+}
+
+func cleanupBenchPrincipal(b *testing.B, pool *pgxpool.Pool) {
+	b.Helper()
+	ctx := context.Background()
+	for _, stmt := range []struct {
+		sql string
+		arg string
+	}{
+		{`DELETE FROM principal_override WHERE principal_id = $1::uuid`, benchPrincipal},
+		{`DELETE FROM principal_role WHERE principal_id = $1::uuid`, benchPrincipal},
+		{`DELETE FROM permission WHERE resource_kind = $1`, "bench_doc"},
+		{`DELETE FROM role WHERE key = $1`, benchRole},
+	} {
+		if _, err := pool.Exec(ctx, stmt.sql, stmt.arg); err != nil {
+			b.Fatalf("cleanup: %v", err)
+		}
+	}
+}
+
+func benchRequest() domainpdp.CheckRequest {
+	return domainpdp.CheckRequest{
+		PrincipalID:   benchPrincipal,
+		PrincipalKind: model.PrincipalKindUser,
+		Action:        "bench.action.1",
+		ResourceKind:  "bench_doc",
+	}
+}
+
+func BenchmarkPDPGetByRequest(b *testing.B) {
+	pool := benchPool(b)
+	pdpRepo := repo.NewPDPRepository(pool)
+	ctx := context.Background()
+	req := benchRequest()
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("overrides=%d", n), func(b *testing.B) {
+			seedBenchPrincipal(b, pool, n)
+			b.Run("sql-filtered", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					match, err := pdpRepo.GetByRequest(ctx, req)
+					if err != nil || match == nil {
+						b.Fatalf("GetByRequest = %v, %v", match, err)
+					}
+				}
+			})
+			b.Run("go-filtered", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					match, err := unfilteredGetByRequest(ctx, pool, req)
+					if err != nil || match == nil {
+						b.Fatalf("baseline = %v, %v", match, err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkPDPList(b *testing.B) {
+	pool := benchPool(b)
+	pdpRepo := repo.NewPDPRepository(pool)
+	ctx := context.Background()
+	req := benchRequest()
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("assignments=%d", n), func(b *testing.B) {
+			seedBenchPrincipal(b, pool, n)
+			b.Run("sql-filtered", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					roles, err := pdpRepo.List(ctx, req)
+					if err != nil || len(roles) != 1 {
+						b.Fatalf("List = %v, %v", roles, err)
+					}
+				}
+			})
+			b.Run("go-filtered", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					roles, err := unfilteredList(ctx, pool, req)
+					if err != nil || len(roles) != 1 {
+						b.Fatalf("baseline = %v, %v", roles, err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// unfilteredGetByRequest loads every active override of the principal and leaves
+// matching to Go.
+func unfilteredGetByRequest(ctx context.Context, pool *pgxpool.Pool, req domainpdp.CheckRequest) (*domainpdp.OverrideMatch, error) {
+	rows, err := pool.Query(ctx, `SELECT po.permission_id::text, po.effect::text,
+		po.tenant_id::text, po.service_id::text, po.resource_kind, po.resource_id::text,
+		p.action, p.resource_kind, coalesce(po.condition, '')
+		FROM principal_override po
+		JOIN permission p ON p.id = po.permission_id
+		WHERE po.principal_id = $1 AND po.principal_kind = $2
+			AND (po.valid_from IS NULL OR po.valid_from <= now()) AND (po.valid_until IS NULL OR po.valid_until > now())`,
+		req.PrincipalID, string(req.PrincipalKind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	candidates := make([]domainpdp.OverrideCandidate, 0)
+	for rows.Next() {
+		var permissionID, effect, tenantID, serviceID, resourceKind, resourceID, action, permResourceKind, condition string
+		if err := rows.Scan(&permissionID, &effect, &tenantID, &serviceID, &resourceKind, &resourceID, &action, &permResourceKind, &condition); err != nil {
+			return nil, err
+		}
+		scope := benchScope(tenantID, serviceID, resourceKind, resourceID)
+		depth, ok := scope.Match(req)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, domainpdp.OverrideCandidate{
+			OverrideMatch:    domainpdp.OverrideMatch{Effect: model.OverrideEffect(effect), PermissionID: permissionID, Scope: scope, Condition: condition},
+			Action:           action,
+			ResourceKind:     permResourceKind,
+			ScopeSpecificity: scope.Specificity(),
+			Depth:            depth,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return domainpdp.BestOverride(candidates, req), nil
+}
+
+// unfilteredList loads every active role assignment of the principal and leaves scope
+// matching to Go.
+func unfilteredList(ctx context.Context, pool *pgxpool.Pool, req domainpdp.CheckRequest) ([]domainpdp.RoleWithScope, error) {
+	rows, err := pool.Query(ctx, `SELECT DISTINCT r.id::text, r.key,
+		pr.tenant_id::text, pr.service_id::text, pr.resource_kind, pr.resource_id::text,
+		ARRAY(SELECT sr.service_id::text FROM service_role sr WHERE sr.role_id = r.id)
+		FROM principal_role pr
+		JOIN role r ON r.id = pr.role_id
+		WHERE pr.principal_id = $1 AND pr.principal_kind = $2
+			AND (pr.valid_from IS NULL OR pr.valid_from <= now()) AND (pr.valid_until IS NULL OR pr.valid_until > now())`,
+		req.PrincipalID, string(req.PrincipalKind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	roles := make([]domainpdp.RoleWithScope, 0)
+	for rows.Next() {
+		var roleID, roleKey, tenantID, serviceID, resourceKind, resourceID string
+		var serviceIDs []string
+		if err := rows.Scan(&roleID, &roleKey, &tenantID, &serviceID, &resourceKind, &resourceID, &serviceIDs); err != nil {
+			return nil, err
+		}
+		scope := benchScope(tenantID, serviceID, resourceKind, resourceID)
+		if _, ok := scope.Match(req); !ok {
+			continue
+		}
+		roles = append(roles, domainpdp.RoleWithScope{RoleID: roleID, RoleKey: roleKey, Scope: scope, ServiceIDs: serviceIDs})
+	}
+	return roles, rows.Err()
+}
+
+func benchScope(tenantID, serviceID, resourceKind, resourceID string) domainpdp.OverrideScope {
+	value := func(v, def string) *string {
+		if v == def {
+			return nil
+		}
+		return &v
+	}
+	return domainpdp.OverrideScope{
+		TenantID:     value(tenantID, benchDefaultTenant),
+		ServiceID:    value(serviceID, benchDefaultService),
+		ResourceKind: value(resourceKind, benchDefaultKind),
+		ResourceID:   value(resourceID, benchDefaultResource),
+	}
+}